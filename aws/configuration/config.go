@@ -24,6 +24,13 @@ func NewConfig(dir string, profile string) *Config {
 
 // Save to ~/.aws/config
 func (c *Config) Save(region string) error {
+	return c.SaveOptions(map[string]string{
+		"region": region,
+	})
+}
+
+// SaveOptions saves options to the profile in ~/.aws/config
+func (c *Config) SaveOptions(options map[string]string) error {
 	configIni, err := ini.Load(c.file)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -32,14 +39,16 @@ func (c *Config) Save(region string) error {
 		configIni = ini.Empty()
 	}
 	section := configIni.Section(fmt.Sprintf("profile %s", c.profile))
-	k, err := section.GetKey("region")
-	if err != nil {
-		_, err := section.NewKey("region", region)
+	for key, value := range options {
+		k, err := section.GetKey(key)
 		if err != nil {
-			return err
+			_, err := section.NewKey(key, value)
+			if err != nil {
+				return err
+			}
+		} else {
+			k.SetValue(value)
 		}
-	} else {
-		k.SetValue(region)
 	}
-	return configIni.SaveTo(c.file)
+	return save(configIni, c.file)
 }
@@ -126,3 +126,64 @@ region = ap-northeast-1
 		})
 	}
 }
+
+func TestConfig_SaveOptions(t *testing.T) {
+	type fields struct {
+		file    string
+		profile string
+	}
+	type args struct {
+		options map[string]string
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		args        args
+		wantErr     bool
+		wantContent string
+	}{
+		{
+			name: "chained profile",
+			fields: fields{
+				file:    "/tmp/testconfig",
+				profile: "chained",
+			},
+			args: args{
+				options: map[string]string{
+					"role_arn": "arn:aws:iam::123456789012:role/admin",
+				},
+			},
+			wantErr: false,
+			wantContent: `[profile chained]
+role_arn = arn:aws:iam::123456789012:role/admin
+
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.fields.file
+			os.Remove(file)
+			c := &Config{
+				file:    tt.fields.file,
+				profile: tt.fields.profile,
+			}
+			err := c.SaveOptions(tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.SaveOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				data, err := ioutil.ReadFile(file)
+				if err != nil {
+					t.Errorf("%#v", err)
+				}
+				actual := string(data)
+				expected := tt.wantContent
+				if actual != expected {
+					t.Errorf("'%v' is not equal '%v'", actual, expected)
+				}
+				os.Remove(tt.fields.file)
+			}
+		})
+	}
+}
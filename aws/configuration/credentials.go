@@ -42,5 +42,21 @@ func (c *Credentials) Save(options map[string]string) error {
 			k.SetValue(value)
 		}
 	}
-	return credsIni.SaveTo(c.file)
+	return save(credsIni, c.file)
+}
+
+// Delete removes the profile from ~/.aws/credentials
+func (c *Credentials) Delete() error {
+	credsIni, err := ini.Load(c.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if _, err := credsIni.GetSection(c.profile); err != nil {
+		return nil
+	}
+	credsIni.DeleteSection(c.profile)
+	return save(credsIni, c.file)
 }
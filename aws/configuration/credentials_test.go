@@ -126,3 +126,43 @@ aws_access_key = 12345678
 		})
 	}
 }
+
+func TestCredentials_Delete(t *testing.T) {
+	file := "/tmp/testcredentials"
+	defer os.Remove(file)
+	content := `[default]
+aws_access_key_id = 12345678
+
+[other]
+aws_access_key_id = 87654321
+
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c := &Credentials{
+		file:    file,
+		profile: "default",
+	}
+	if err := c.Delete(); err != nil {
+		t.Errorf("Credentials.Delete() error = %v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `[other]
+aws_access_key_id = 87654321
+
+`
+	if string(data) != expected {
+		t.Errorf("'%v' is not equal '%v'", string(data), expected)
+	}
+	c = &Credentials{
+		file:    "/tmp/notexists",
+		profile: "default",
+	}
+	if err := c.Delete(); err != nil {
+		t.Errorf("Credentials.Delete() error = %v", err)
+	}
+}
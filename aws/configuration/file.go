@@ -0,0 +1,18 @@
+package configuration
+
+import (
+	"bytes"
+
+	"github.com/go-ini/ini"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// save writes the ini file atomically, which is only accessible by the user
+func save(f *ini.File, file string) error {
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return err
+	}
+	return secret.WriteFile(file, buf.Bytes())
+}
@@ -0,0 +1,110 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+)
+
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_providers_enable-console-custom-url.html
+
+const (
+	// DefaultEndpoint is AWS federation endpoint
+	DefaultEndpoint = "https://signin.aws.amazon.com/federation"
+	// DefaultDestination is AWS console URL
+	DefaultDestination = "https://console.aws.amazon.com/"
+)
+
+// Console represents AWS federation endpoint handler
+type Console struct {
+	Endpoint string
+	// Destination is the AWS console URL opened when LoginURL is given no destination
+	Destination string
+	HTTPClient  *http.Client
+}
+
+type session struct {
+	SessionID    string `json:"sessionId"`
+	SessionKey   string `json:"sessionKey"`
+	SessionToken string `json:"sessionToken"`
+}
+
+type signinTokenResponse struct {
+	SigninToken string `json:"SigninToken"`
+}
+
+// New creates a Console
+func New() *Console {
+	return &Console{
+		Endpoint:   DefaultEndpoint,
+		HTTPClient: &http.Client{},
+	}
+}
+
+// ForPartition creates a Console of the federation endpoint and the console of the partition, e.g. aws-us-gov
+func ForPartition(p partition.Partition) *Console {
+	return &Console{
+		Endpoint:    p.SigninEndpoint,
+		Destination: p.ConsoleURL,
+		HTTPClient:  &http.Client{},
+	}
+}
+
+// SigninToken retrieves a sign-in token with the credentials
+func (c *Console) SigninToken(creds *sts.Credentials) (string, error) {
+	s, err := json.Marshal(&session{
+		SessionID:    *creds.AccessKeyId,
+		SessionKey:   *creds.SecretAccessKey,
+		SessionToken: *creds.SessionToken,
+	})
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(s))
+	res, err := c.HTTPClient.Get(fmt.Sprintf("%s?%s", c.Endpoint, query.Encode()))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("[%d] failed to get signin token", res.StatusCode)
+	}
+	var output signinTokenResponse
+	if err := json.Unmarshal(body, &output); err != nil {
+		return "", err
+	}
+	return output.SigninToken, nil
+}
+
+// LoginURL returns the URL to sign in to AWS console with the credentials
+func (c *Console) LoginURL(creds *sts.Credentials, destination string, issuer string) (string, error) {
+	token, err := c.SigninToken(creds)
+	if err != nil {
+		return "", err
+	}
+	if destination == "" {
+		destination = c.Destination
+	}
+	if destination == "" {
+		destination = DefaultDestination
+	}
+	query := url.Values{}
+	query.Set("Action", "login")
+	query.Set("Issuer", issuer)
+	query.Set("Destination", destination)
+	query.Set("SigninToken", token)
+	return fmt.Sprintf("%s?%s", c.Endpoint, query.Encode()), nil
+}
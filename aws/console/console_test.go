@@ -0,0 +1,95 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+)
+
+func TestConsole_LoginURL(t *testing.T) {
+	creds := &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+	}
+	tests := []struct {
+		name        string
+		code        int
+		destination string
+		partition   string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "default destination",
+			code: 200,
+			want: "?Action=login&Destination=https%3A%2F%2Fconsole.aws.amazon.com%2F&Issuer=issuer&SigninToken=signin-token",
+		},
+		{
+			name:        "destination",
+			code:        200,
+			destination: "https://console.aws.amazon.com/s3/",
+			want:        "?Action=login&Destination=https%3A%2F%2Fconsole.aws.amazon.com%2Fs3%2F&Issuer=issuer&SigninToken=signin-token",
+		},
+		{
+			name:      "partition destination",
+			code:      200,
+			partition: "https://console.amazonaws-us-gov.com/",
+			want:      "?Action=login&Destination=https%3A%2F%2Fconsole.amazonaws-us-gov.com%2F&Issuer=issuer&SigninToken=signin-token",
+		},
+		{
+			name:    "error",
+			code:    400,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("Action") != "getSigninToken" {
+					t.Errorf("%s is not equal %s", r.URL.Query().Get("Action"), "getSigninToken")
+				}
+				var s session
+				if err := json.Unmarshal([]byte(r.URL.Query().Get("Session")), &s); err != nil {
+					t.Errorf("%#v", err)
+				}
+				if s.SessionID != "access-key-id" || s.SessionKey != "secret-access-key" || s.SessionToken != "session-token" {
+					t.Errorf("%#v is invalid session", s)
+				}
+				w.WriteHeader(tt.code)
+				fmt.Fprint(w, `{"SigninToken": "signin-token"}`)
+			}))
+			defer server.Close()
+			c := &Console{
+				Endpoint:    server.URL,
+				Destination: tt.partition,
+				HTTPClient:  server.Client(),
+			}
+			got, err := c.LoginURL(creds, tt.destination, "issuer")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Console.LoginURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != server.URL+tt.want {
+				t.Errorf("Console.LoginURL() = %v, want %v", got, server.URL+tt.want)
+			}
+		})
+	}
+}
+
+func TestForPartition(t *testing.T) {
+	p, err := partition.Lookup(partition.AWSCN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ForPartition(p)
+	if c.Endpoint != "https://signin.amazonaws.cn/federation" || c.Destination != "https://console.amazonaws.cn/" {
+		t.Errorf("%#v is not the console of %s", c, partition.AWSCN)
+	}
+}
@@ -0,0 +1,121 @@
+package partition
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IDs of AWS partitions
+const (
+	AWS      = "aws"
+	AWSUSGov = "aws-us-gov"
+	AWSCN    = "aws-cn"
+)
+
+// Partition represents the endpoints of an AWS partition
+type Partition struct {
+	ID string
+	// Region is the region of STS used when no region is given, STS of the partition is not global
+	Region string
+	// SigninEndpoint is the federation endpoint issuing sign-in tokens of the console
+	SigninEndpoint string
+	// ConsoleURL is the default destination after sign in
+	ConsoleURL string
+	// DNSSuffix is the domain of regional endpoints
+	DNSSuffix string
+	// RegionPrefix is the prefix of the region names in the partition, empty for aws
+	RegionPrefix string
+}
+
+// Partitions are the AWS partitions supported by login and console
+var Partitions = []Partition{
+	{
+		ID:             AWS,
+		Region:         "us-east-1",
+		SigninEndpoint: "https://signin.aws.amazon.com/federation",
+		ConsoleURL:     "https://console.aws.amazon.com/",
+		DNSSuffix:      "amazonaws.com",
+	},
+	{
+		ID:             AWSUSGov,
+		Region:         "us-gov-west-1",
+		SigninEndpoint: "https://signin.amazonaws-us-gov.com/federation",
+		ConsoleURL:     "https://console.amazonaws-us-gov.com/",
+		DNSSuffix:      "amazonaws.com",
+		RegionPrefix:   "us-gov-",
+	},
+	{
+		ID:             AWSCN,
+		Region:         "cn-north-1",
+		SigninEndpoint: "https://signin.amazonaws.cn/federation",
+		ConsoleURL:     "https://console.amazonaws.cn/",
+		DNSSuffix:      "amazonaws.com.cn",
+		RegionPrefix:   "cn-",
+	},
+}
+
+// Lookup returns the partition of the ID
+func Lookup(id string) (Partition, error) {
+	for _, p := range Partitions {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	ids := make([]string, len(Partitions))
+	for i, p := range Partitions {
+		ids[i] = p.ID
+	}
+	return Partition{}, errors.Errorf("%s is not AWS partition, it must be one of %s", id, strings.Join(ids, ", "))
+}
+
+// FromArn returns the partition of the ARN like arn:aws-us-gov:iam::123456789012:role/Role,
+// and false if the ARN is not valid or its partition is unknown
+func FromArn(arn string) (Partition, bool) {
+	fields := strings.SplitN(arn, ":", 3)
+	if len(fields) < 3 || fields[0] != "arn" {
+		return Partition{}, false
+	}
+	p, err := Lookup(fields[1])
+	return p, err == nil
+}
+
+// Resolve returns the configured partition, or the one of the role ARN, or the aws partition
+func Resolve(id string, roleArn string) (Partition, error) {
+	if id != "" {
+		return Lookup(id)
+	}
+	if p, ok := FromArn(roleArn); ok {
+		return p, nil
+	}
+	return Partitions[0], nil
+}
+
+// regionPattern matches region names like ap-northeast-1 and us-gov-west-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// ValidateRegion returns an error if the region is not a name of AWS region
+func ValidateRegion(region string) error {
+	if !regionPattern.MatchString(region) {
+		return errors.Errorf("%s is not AWS region, it must be a name like ap-northeast-1", region)
+	}
+	return nil
+}
+
+// ForRegion returns the partition which the region belongs to
+func ForRegion(region string) Partition {
+	for _, p := range Partitions {
+		if p.RegionPrefix != "" && strings.HasPrefix(region, p.RegionPrefix) {
+			return p
+		}
+	}
+	return Partitions[0]
+}
+
+// STSEndpoint returns the regional STS endpoint like https://sts.ap-northeast-1.amazonaws.com,
+// which is used instead of the global https://sts.amazonaws.com
+func STSEndpoint(region string) string {
+	return fmt.Sprintf("https://sts.%s.%s", region, ForRegion(region).DNSSuffix)
+}
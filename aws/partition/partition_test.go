@@ -0,0 +1,55 @@
+package partition
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		roleArn string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: AWS},
+		{name: "aws role", roleArn: "arn:aws:iam::123456789012:role/Role", want: AWS},
+		{name: "gov cloud role", roleArn: "arn:aws-us-gov:iam::123456789012:role/Role", want: AWSUSGov},
+		{name: "china role", roleArn: "arn:aws-cn:iam::123456789012:role/Role", want: AWSCN},
+		{name: "unknown role partition", roleArn: "arn:aws-iso:iam::123456789012:role/Role", want: AWS},
+		{name: "invalid role", roleArn: "role/Role", want: AWS},
+		{name: "configured", id: AWSCN, roleArn: "arn:aws:iam::123456789012:role/Role", want: AWSCN},
+		{name: "unknown", id: "aws-iso", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.id, tt.roleArn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.ID != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSTSEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":      "https://sts.us-east-1.amazonaws.com",
+		"ap-northeast-1": "https://sts.ap-northeast-1.amazonaws.com",
+		"us-gov-east-1":  "https://sts.us-gov-east-1.amazonaws.com",
+		"cn-northwest-1": "https://sts.cn-northwest-1.amazonaws.com.cn",
+	}
+	for region, want := range tests {
+		if err := ValidateRegion(region); err != nil {
+			t.Errorf("%v", err)
+		}
+		if got := STSEndpoint(region); got != want {
+			t.Errorf("STSEndpoint(%s) = %v, want %v", region, got, want)
+		}
+	}
+	for _, region := range []string{"", "tokyo", "https://sts.amazonaws.com", "ap-northeast"} {
+		if err := ValidateRegion(region); err == nil {
+			t.Errorf("%s is valid region", region)
+		}
+	}
+}
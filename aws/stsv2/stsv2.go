@@ -0,0 +1,139 @@
+package stsv2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	stsv1 "github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// DefaultRegion is used when neither Region nor AWS_REGION is given, whose endpoint is the global one
+const DefaultRegion = "us-east-1"
+
+// STS implements AssumeRoleWithSAML of stsiface.STSAPI with AWS SDK for Go v2,
+// so it can be given to login.Login instead of the client of AWS SDK for Go v1
+type STS struct {
+	// STSAPI is nil, other methods than AssumeRoleWithSAML are not supported and panic
+	stsiface.STSAPI
+	// Client is the STS client of AWS SDK for Go v2
+	Client *sts.Client
+	// PolicyArns are the managed session policies, which are not supported by the version of AWS SDK for Go v1
+	PolicyArns []string
+}
+
+// New creates the STS with the HTTP client and the region, AWS_REGION or DefaultRegion is used if the region is empty
+func New(client *http.Client, region string, optFns ...func(*sts.Options)) *STS {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+	options := sts.Options{
+		Region: region,
+		// AssumeRoleWithSAML is not signed
+		Credentials: aws.AnonymousCredentials{},
+		APIOptions:  []func(*middleware.Stack) error{awsmiddleware.AddUserAgentKey(useragent.String())},
+	}
+	if client != nil {
+		options.HTTPClient = client
+	}
+	return &STS{Client: sts.New(options, optFns...)}
+}
+
+// WithEndpoint returns the option of New using the custom STS endpoint like https://sts.us-gov-east-1.amazonaws.com
+func WithEndpoint(url string) func(*sts.Options) {
+	return func(o *sts.Options) {
+		o.EndpointResolver = sts.EndpointResolverFromURL(url)
+	}
+}
+
+// WithoutRetries returns the option of New disabling the retries of AWS SDK, for callers retrying by themselves
+func WithoutRetries() func(*sts.Options) {
+	return func(o *sts.Options) {
+		o.Retryer = aws.NopRetryer{}
+	}
+}
+
+// AssumeRoleWithSAML assumes the role with AWS SDK for Go v2
+func (s *STS) AssumeRoleWithSAML(input *stsv1.AssumeRoleWithSAMLInput) (*stsv1.AssumeRoleWithSAMLOutput, error) {
+	return s.AssumeRoleWithSAMLWithContext(context.Background(), input)
+}
+
+// AssumeRoleWithSAMLWithContext is the same as AssumeRoleWithSAML with the context, the request options of v1 are ignored
+func (s *STS) AssumeRoleWithSAMLWithContext(ctx awsv1.Context, input *stsv1.AssumeRoleWithSAMLInput, _ ...request.Option) (*stsv1.AssumeRoleWithSAMLOutput, error) {
+	params := &sts.AssumeRoleWithSAMLInput{
+		PrincipalArn:  input.PrincipalArn,
+		RoleArn:       input.RoleArn,
+		SAMLAssertion: input.SAMLAssertion,
+		Policy:        input.Policy,
+	}
+	if input.DurationSeconds != nil {
+		params.DurationSeconds = aws.Int32(int32(*input.DurationSeconds))
+	}
+	for _, arn := range s.PolicyArns {
+		params.PolicyArns = append(params.PolicyArns, types.PolicyDescriptorType{Arn: aws.String(arn)})
+	}
+	output, err := s.Client.AssumeRoleWithSAML(ctx, params)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	converted := &stsv1.AssumeRoleWithSAMLOutput{
+		Audience:      output.Audience,
+		Issuer:        output.Issuer,
+		NameQualifier: output.NameQualifier,
+		Subject:       output.Subject,
+		SubjectType:   output.SubjectType,
+	}
+	if output.PackedPolicySize != nil {
+		converted.PackedPolicySize = awsv1.Int64(int64(*output.PackedPolicySize))
+	}
+	if user := output.AssumedRoleUser; user != nil {
+		converted.AssumedRoleUser = &stsv1.AssumedRoleUser{Arn: user.Arn, AssumedRoleId: user.AssumedRoleId}
+	}
+	if creds := output.Credentials; creds != nil {
+		converted.Credentials = &stsv1.Credentials{
+			AccessKeyId:     creds.AccessKeyId,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Expiration:      creds.Expiration,
+		}
+	}
+	return converted, nil
+}
+
+// convertError converts API errors to awserr.Error, so callers handle error codes as the ones of AWS SDK for Go v1.
+// Errors of responses are awserr.RequestFailure with the status code and the request ID, so 5xx can be retried
+func convertError(err error) error {
+	var converted awserr.Error
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		converted = awserr.New(apiErr.ErrorCode(), apiErr.ErrorMessage(), err)
+	}
+	var resErr *awshttp.ResponseError
+	if errors.As(err, &resErr) {
+		if converted == nil {
+			converted = awserr.New(request.ErrCodeSerialization, resErr.Error(), err)
+		}
+		return awserr.NewRequestFailure(converted, resErr.HTTPStatusCode(), resErr.ServiceRequestID())
+	}
+	if converted != nil {
+		return converted
+	}
+	return err
+}
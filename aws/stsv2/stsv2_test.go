@@ -0,0 +1,101 @@
+package stsv2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	stsv1 "github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+var _ stsiface.STSAPI = &STS{}
+
+func TestAssumeRoleWithSAML(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !strings.Contains(r.UserAgent(), useragent.String()) {
+			t.Errorf("%s does not have User-Agent of the connector", r.UserAgent())
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("the request is signed")
+		}
+		if r.Form.Get("SAMLAssertion") != "saml" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>InvalidIdentityToken</Code><Message>invalid assertion</Message></Error><RequestId>request-id</RequestId></ErrorResponse>`)
+			return
+		}
+		if r.Form.Get("Action") != "AssumeRoleWithSAML" || r.Form.Get("DurationSeconds") != "3600" || r.Form.Get("PolicyArns.member.1.arn") != "arn:aws:iam::aws:policy/ReadOnlyAccess" {
+			t.Errorf("%v is unexpected", r.Form)
+		}
+		fmt.Fprint(w, `<AssumeRoleWithSAMLResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithSAMLResult>
+    <Credentials>
+      <AccessKeyId>access-key-id</AccessKeyId>
+      <SecretAccessKey>secret-access-key</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2026-10-14T10:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/admin/user</Arn>
+      <AssumedRoleId>ARO123EXAMPLE123:user</AssumedRoleId>
+    </AssumedRoleUser>
+    <PackedPolicySize>6</PackedPolicySize>
+    <Subject>user</Subject>
+  </AssumeRoleWithSAMLResult>
+  <ResponseMetadata><RequestId>request-id</RequestId></ResponseMetadata>
+</AssumeRoleWithSAMLResponse>`)
+	}))
+	defer ts.Close()
+	s := New(ts.Client(), "ap-northeast-1", WithEndpoint(ts.URL))
+	s.PolicyArns = []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}
+	input := &stsv1.AssumeRoleWithSAMLInput{
+		PrincipalArn:    awsv1.String("arn:aws:iam::123456789012:saml-provider/onelogin"),
+		RoleArn:         awsv1.String("arn:aws:iam::123456789012:role/admin"),
+		SAMLAssertion:   awsv1.String("saml"),
+		DurationSeconds: awsv1.Int64(3600),
+	}
+	output, err := s.AssumeRoleWithSAML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := output.Credentials
+	if *creds.AccessKeyId != "access-key-id" || *creds.SecretAccessKey != "secret-access-key" || *creds.SessionToken != "session-token" || creds.Expiration.Hour() != 10 {
+		t.Errorf("%v is unexpected", creds)
+	}
+	if *output.AssumedRoleUser.Arn != "arn:aws:sts::123456789012:assumed-role/admin/user" || *output.PackedPolicySize != 6 || *output.Subject != "user" {
+		t.Errorf("%v is unexpected", output)
+	}
+
+	input.SAMLAssertion = awsv1.String("invalid")
+	_, err = s.AssumeRoleWithSAML(input)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "InvalidIdentityToken" || aerr.Message() != "invalid assertion" {
+		t.Errorf("%#v is not converted", err)
+	}
+	if failure, ok := err.(awserr.RequestFailure); !ok || failure.StatusCode() != http.StatusBadRequest || failure.RequestID() != "request-id" {
+		t.Errorf("%#v does not have the status code and the request ID", err)
+	}
+}
+
+func TestAssumeRoleWithSAMLServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Receiver</Type><Code>ServiceUnavailable</Code><Message>unavailable</Message></Error><RequestId>request-id</RequestId></ErrorResponse>`)
+	}))
+	defer ts.Close()
+	s := New(ts.Client(), "ap-northeast-1", WithEndpoint(ts.URL), WithoutRetries())
+	_, err := s.AssumeRoleWithSAML(&stsv1.AssumeRoleWithSAMLInput{
+		PrincipalArn:  awsv1.String("arn:aws:iam::123456789012:saml-provider/onelogin"),
+		RoleArn:       awsv1.String("arn:aws:iam::123456789012:role/admin"),
+		SAMLAssertion: awsv1.String("saml"),
+	})
+	if failure, ok := err.(awserr.RequestFailure); !ok || failure.StatusCode() != http.StatusServiceUnavailable || failure.Code() != "ServiceUnavailable" {
+		t.Errorf("%#v is not a request failure of 503", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// accountAliasCacheTTL is how long the alias resolved by iam:ListAccountAliases is cached,
+// failures are cached too not to call IAM on every login without the permission
+const accountAliasCacheTTL = 7 * 24 * time.Hour
+
+type accountAliasCache struct {
+	Alias      string    `json:"alias"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// newIAM creates IAM client with the credentials of the assumed role
+var newIAM = func(creds *sts.Credentials, client *http.Client, roleArn string) (iamiface.IAMAPI, error) {
+	p, _ := partition.Resolve("", roleArn)
+	awsConfig := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(*creds.AccessKeyId, *creds.SecretAccessKey, *creds.SessionToken)).
+		WithRegion(p.Region)
+	if client != nil {
+		awsConfig.HTTPClient = client
+	}
+	s, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.Handlers.Build.PushBackNamed(useragent.Handler)
+	return iam.New(s), nil
+}
+
+func accountAliasCacheFile(dir string) string {
+	return path.Join(dir, "account-aliases.cache")
+}
+
+func loadAccountAliasCache(dir string) map[string]accountAliasCache {
+	cache := map[string]accountAliasCache{}
+	data, err := ioutil.ReadFile(accountAliasCacheFile(dir))
+	if err != nil {
+		return cache
+	}
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+func saveAccountAliasCache(dir string, cache map[string]accountAliasCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return secret.WriteFile(accountAliasCacheFile(dir), data)
+}
+
+// accountID returns the AWS account ID of the ARN, or empty string
+func accountID(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
+
+// accountAlias returns the alias of the account configured in [account], or resolved on login and loaded by loadAccountAliasCache
+func accountAlias(c *config.Config, cache map[string]accountAliasCache, arn string) string {
+	if alias := c.AccountAlias(arn); alias != "" {
+		return alias
+	}
+	id := accountID(arn)
+	if id == "" {
+		return ""
+	}
+	return cache[id].Alias
+}
+
+// resolveAccountAlias caches the alias of the account of the role by iam:ListAccountAliases with its credentials,
+// unless the alias is configured or cached.
+// IAM is called without mu, which is held only while the cache file is written, so logins of other profiles are not blocked
+func resolveAccountAlias(c *config.Config, dir string, roleArn string, creds *sts.Credentials, client *http.Client, mu sync.Locker) error {
+	id := accountID(roleArn)
+	if id == "" || c.AccountAlias(roleArn) != "" {
+		return nil
+	}
+	if cached, ok := loadAccountAliasCache(dir)[id]; ok && time.Since(cached.ResolvedAt) < accountAliasCacheTTL {
+		return nil
+	}
+	api, err := newIAM(creds, client, roleArn)
+	if err != nil {
+		return err
+	}
+	entry := accountAliasCache{ResolvedAt: time.Now()}
+	output, err := api.ListAccountAliases(&iam.ListAccountAliasesInput{})
+	if err == nil && len(output.AccountAliases) > 0 {
+		entry.Alias = aws.StringValue(output.AccountAliases[0])
+	}
+	if err != nil && debug {
+		log.Printf("failed to resolve the account alias of %s: %v\n", id, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// the cache is loaded again, other profiles may have written their accounts meanwhile
+	cache := loadAccountAliasCache(dir)
+	cache[id] = entry
+	return saveAccountAliasCache(dir, cache)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+type iamMock struct {
+	iamiface.IAMAPI
+	aliases []string
+	err     error
+	calls   int
+}
+
+func (m *iamMock) ListAccountAliases(*iam.ListAccountAliasesInput) (*iam.ListAccountAliasesOutput, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &iam.ListAccountAliasesOutput{AccountAliases: aws.StringSlice(m.aliases)}, nil
+}
+
+func TestResolveAccountAlias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mock := &iamMock{aliases: []string{"production"}}
+	defer func(f func(*sts.Credentials, *http.Client, string) (iamiface.IAMAPI, error)) { newIAM = f }(newIAM)
+	newIAM = func(*sts.Credentials, *http.Client, string) (iamiface.IAMAPI, error) {
+		return mock, nil
+	}
+	c := &config.Config{Account: map[string]string{"210987654321": "configured"}}
+	creds := &sts.Credentials{}
+	mu := &sync.Mutex{}
+
+	role := "arn:aws:iam::123456789012:role/Role"
+	if err := resolveAccountAlias(c, dir, role, creds, nil, mu); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolveAccountAlias(c, dir, role, creds, nil, mu); err != nil {
+		t.Fatal(err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("IAM is called %d times, the alias is not cached", mock.calls)
+	}
+	if alias := accountAlias(c, loadAccountAliasCache(dir), role); alias != "production" {
+		t.Errorf("%s is not the resolved alias", alias)
+	}
+
+	configured := "arn:aws:iam::210987654321:role/Role"
+	if err := resolveAccountAlias(c, dir, configured, creds, nil, mu); err != nil {
+		t.Fatal(err)
+	}
+	if mock.calls != 1 {
+		t.Error("IAM is called for the configured alias")
+	}
+	if alias := accountAlias(c, loadAccountAliasCache(dir), configured); alias != "configured" {
+		t.Errorf("%s is not the configured alias", alias)
+	}
+
+	mock.err = errors.New("AccessDenied")
+	denied := "arn:aws:iam::111111111111:role/Role"
+	if err := resolveAccountAlias(c, dir, denied, creds, nil, mu); err != nil {
+		t.Fatal(err)
+	}
+	if err := resolveAccountAlias(c, dir, denied, creds, nil, mu); err != nil {
+		t.Fatal(err)
+	}
+	if mock.calls != 2 {
+		t.Errorf("IAM is called %d times, the failure is not cached", mock.calls)
+	}
+	if alias := accountAlias(c, loadAccountAliasCache(dir), denied); alias != "" {
+		t.Errorf("%s is resolved without the permission", alias)
+	}
+}
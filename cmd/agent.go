@@ -0,0 +1,201 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/agent"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/biometric"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+var agentSocket string
+var agentTimeout time.Duration
+var agentTOTP bool
+var agentBiometric bool
+var agentStartBiometric bool
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Hold the OneLogin password in memory like ssh-agent",
+	Long: `Agent holds the OneLogin password and the TOTP seed in locked memory after unlocked,
+so login command reads them from the agent instead of prompting.`,
+}
+
+// agentStartCmd represents the agent start command
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent in the foreground",
+	Run: func(cmd *cobra.Command, args []string) {
+		path := agentSocketPath()
+		l, err := agent.Listen(path)
+		if err != nil {
+			errorExit(err)
+		}
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			l.Close()
+		}()
+		info(i18n.T("agent is listening on %s\n"), path)
+		fmt.Printf("%s=%s; export %s;\n", agent.SocketEnv, path, agent.SocketEnv)
+		a := agent.New()
+		a.Verify = biometric.Verify
+		a.Biometric = agentStartBiometric
+		a.Serve(l)
+		os.Remove(path)
+	},
+}
+
+// agentUnlockCmd represents the agent unlock command
+var agentUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Send the password and the TOTP seed to the agent",
+	Run: func(cmd *cobra.Command, args []string) {
+		s := newLoginSession(nil)
+		defer s.Wipe()
+		password, err := passwordFromSource(s.reader)
+		if err == nil && len(password) == 0 {
+			password, err = s.promptPassword()
+		}
+		if err != nil {
+			errorExit(err)
+		}
+		defer password.Wipe()
+		var seed secret.Bytes
+		if agentTOTP {
+			if interactiveInput {
+				fmt.Print(i18n.T("Enter your TOTP seed: "))
+			}
+			seed, err = readMasked(s.reader)
+			if err != nil {
+				errorExit(err)
+			}
+			defer seed.Wipe()
+		}
+		if err := agentError(agentClient().Unlock(password, seed, agentTimeout, agentBiometric)); err != nil {
+			errorExit(err)
+		}
+		info(i18n.T("Unlocked the agent\n"))
+	},
+}
+
+// agentLockCmd represents the agent lock command
+var agentLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Make the agent forget the password and the TOTP seed",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := agentError(agentClient().Lock()); err != nil {
+			errorExit(err)
+		}
+		info(i18n.T("Locked the agent\n"))
+	},
+}
+
+// agentStatusCmd represents the agent status command
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print whether the agent is unlocked",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := agentClient().Status()
+		if err != nil {
+			errorExit(agentError(err))
+		}
+		if status.Locked {
+			fmt.Print(i18n.T("agent is locked\n"))
+			return
+		}
+		fmt.Print(i18n.T("agent is unlocked\n"))
+		if status.Seed {
+			fmt.Print(i18n.T("TOTP seed is held\n"))
+		}
+		if status.Biometric {
+			fmt.Print(i18n.T("Touch ID or Windows Hello is required to use them\n"))
+		}
+		if status.Expires != nil {
+			fmt.Print(i18n.Sprintf("it is locked at %s\n", status.Expires.Local().Format(time.RFC3339)))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentStartCmd)
+	agentCmd.AddCommand(agentUnlockCmd)
+	agentCmd.AddCommand(agentLockCmd)
+	agentCmd.AddCommand(agentStatusCmd)
+	agentStartCmd.Flags().BoolVarP(&agentStartBiometric, "biometric", "", false, "Require Touch ID or Windows Hello every time the password or TOTP token is used, whatever unlock asks")
+	agentUnlockCmd.Flags().DurationVarP(&agentTimeout, "timeout", "", 0, "Forget the password after the duration (e.g. 8h), 0 keeps it until locked")
+	agentUnlockCmd.Flags().BoolVarP(&agentTOTP, "totp", "", false, "Also send the base32 encoded TOTP seed to generate MFA tokens")
+	agentUnlockCmd.Flags().BoolVarP(&agentBiometric, "biometric", "", false, "Require Touch ID or Windows Hello every time the password or TOTP token is used")
+}
+
+// agentSocketPath returns ONELOGIN_AWS_AGENT_SOCK, or the socket in the config directory
+func agentSocketPath() string {
+	if path := os.Getenv(agent.SocketEnv); path != "" {
+		return path
+	}
+	return agentSocket
+}
+
+// agentClient returns the client to the agent
+func agentClient() *agent.Client {
+	return agent.NewClient(agentSocketPath())
+}
+
+// agentError tells how to start the agent if it is not running
+func agentError(err error) error {
+	if _, ok := err.(*net.OpError); ok {
+		return errors.Wrapf(err, i18n.T("agent is not running on %s, please run `onelogin-aws-connector agent start`"), agentSocketPath())
+	}
+	return err
+}
+
+// agentPassword returns the password held by the agent, or nil if the agent is not running or locked
+func agentPassword() secret.Bytes {
+	password, err := agentClient().Password()
+	if err != nil {
+		if debug {
+			log.Printf("password is not read from the agent: %v\n", err)
+		}
+		return nil
+	}
+	return password
+}
+
+// agentToken returns the TOTP token generated by the agent, or nil if the agent has no TOTP seed
+func agentToken() secret.Bytes {
+	token, err := agentClient().TOTP()
+	if err != nil {
+		if debug {
+			log.Printf("MFA token is not generated by the agent: %v\n", err)
+		}
+		return nil
+	}
+	return token
+}
@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/ipc"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// SocketEnv is the environment variable of the agent socket path
+const SocketEnv = "ONELOGIN_AWS_AGENT_SOCK"
+
+// operations of the agent protocol
+const (
+	opUnlock   = "unlock"
+	opLock     = "lock"
+	opPassword = "password"
+	opTOTP     = "totp"
+	opStatus   = "status"
+)
+
+// ErrLocked is returned when the agent holds no password or TOTP seed
+var ErrLocked = errors.New("agent is locked")
+
+// request is a line of JSON sent to the agent
+type request struct {
+	Op        string       `json:"op"`
+	Password  secret.Bytes `json:"password,omitempty"`
+	Seed      secret.Bytes `json:"seed,omitempty"`
+	Timeout   int64        `json:"timeout,omitempty"`
+	Biometric bool         `json:"biometric,omitempty"`
+}
+
+// response is a line of JSON returned from the agent
+type response struct {
+	Error    string       `json:"error,omitempty"`
+	Locked   bool         `json:"locked,omitempty"`
+	Password secret.Bytes `json:"password,omitempty"`
+	Token    string       `json:"token,omitempty"`
+	Seed     bool         `json:"seed,omitempty"`
+	Expires  *time.Time   `json:"expires,omitempty"`
+	// Biometric tells the secrets are released only after the biometric verification
+	Biometric bool `json:"biometric,omitempty"`
+}
+
+// Status represents the state of the agent
+type Status struct {
+	Locked    bool
+	Seed      bool
+	Expires   *time.Time
+	Biometric bool
+}
+
+// Agent holds the password and the TOTP seed in locked memory
+type Agent struct {
+	mu       sync.Mutex
+	password secret.Bytes
+	seed     secret.Bytes
+	expires  *time.Time
+	timer    *time.Timer
+	// biometric requires Verify before releasing the secrets
+	biometric bool
+	// generation is incremented whenever the secrets are cleared
+	generation uint64
+	// Biometric requires Verify for any secrets unlocked, whatever the unlock request asks
+	Biometric bool
+	// Now returns the current time, it is replaced in tests
+	Now func() time.Time
+	// Verify verifies the user with Touch ID or Windows Hello
+	Verify func(reason string) error
+}
+
+// New creates an Agent
+func New() *Agent {
+	return &Agent{Now: time.Now}
+}
+
+// Listen creates the endpoint accessible only by the user, a unix domain socket or a named pipe on Windows
+func Listen(path string) (net.Listener, error) {
+	l, err := ipc.Listen(path)
+	if err == ipc.ErrInUse {
+		return nil, errors.Errorf("agent is already running on %s", path)
+	}
+	return l, err
+}
+
+// Serve accepts connections until the listener is closed
+func (a *Agent) Serve(l net.Listener) error {
+	defer a.lock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go a.handle(conn)
+	}
+}
+
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	defer secret.Wipe(line)
+	if err != nil {
+		return
+	}
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		writeResponse(conn, &response{Error: err.Error()})
+		return
+	}
+	defer req.Password.Wipe()
+	defer req.Seed.Wipe()
+	res := a.do(&req)
+	writeResponse(conn, res)
+	res.Password.Wipe()
+}
+
+func (a *Agent) do(req *request) *response {
+	switch req.Op {
+	case opUnlock:
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		biometric := a.Biometric || req.Biometric
+		if biometric && a.Verify == nil {
+			return &response{Error: "biometric verification is not supported by the agent"}
+		}
+		a.unlock(req.Password.Copy(), req.Seed.Copy(), time.Duration(req.Timeout)*time.Second)
+		a.biometric = biometric
+		return &response{}
+	case opLock:
+		a.lock()
+		return &response{}
+	case opPassword:
+		password, err := a.release(func() secret.Bytes { return a.password }, "use the OneLogin password held by the agent")
+		if err != nil {
+			return errorResponse(err)
+		}
+		return &response{Password: password}
+	case opTOTP:
+		seed, err := a.release(func() secret.Bytes { return a.seed }, "generate the MFA token with the TOTP seed held by the agent")
+		if err != nil {
+			return errorResponse(err)
+		}
+		defer seed.Wipe()
+		token, err := TOTP(seed, a.Now())
+		if err != nil {
+			return &response{Error: err.Error()}
+		}
+		return &response{Token: token}
+	case opStatus:
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return &response{Locked: len(a.password) == 0, Seed: len(a.seed) > 0, Expires: a.expires, Biometric: a.biometric}
+	}
+	return &response{Error: "unknown operation " + req.Op}
+}
+
+// release returns a copy of the secret after the verification if it is required.
+// The lock is released while verifying, and the copy is discarded if the agent is locked meanwhile.
+func (a *Agent) release(held func() secret.Bytes, reason string) (secret.Bytes, error) {
+	a.mu.Lock()
+	if len(held()) == 0 {
+		a.mu.Unlock()
+		return nil, ErrLocked
+	}
+	value := held().Copy()
+	biometric := a.biometric
+	generation := a.generation
+	a.mu.Unlock()
+	if !biometric {
+		return value, nil
+	}
+	if err := a.Verify(reason); err != nil {
+		value.Wipe()
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.generation != generation {
+		value.Wipe()
+		return nil, ErrLocked
+	}
+	return value, nil
+}
+
+func errorResponse(err error) *response {
+	return &response{Error: err.Error(), Locked: err == ErrLocked}
+}
+
+// unlock keeps the secrets, they are wiped after the timeout if it is not zero
+func (a *Agent) unlock(password, seed secret.Bytes, timeout time.Duration) {
+	a.clear()
+	lockMemory(password)
+	lockMemory(seed)
+	a.password = password
+	a.seed = seed
+	if timeout > 0 {
+		expires := a.Now().Add(timeout)
+		a.expires = &expires
+		a.timer = time.AfterFunc(timeout, func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			// the secrets may be replaced by another unlock while waiting for the lock
+			if a.expires == &expires {
+				a.clear()
+			}
+		})
+	}
+}
+
+func (a *Agent) lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clear()
+}
+
+func (a *Agent) clear() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.password.Wipe()
+	a.seed.Wipe()
+	unlockMemory(a.password)
+	unlockMemory(a.seed)
+	a.password = nil
+	a.seed = nil
+	a.expires = nil
+	a.biometric = false
+	a.generation++
+}
+
+func writeResponse(conn net.Conn, res *response) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		data, _ = json.Marshal(&response{Error: err.Error()})
+	}
+	defer secret.Wipe(data)
+	conn.Write(append(data, '\n'))
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+func TestTOTP(t *testing.T) {
+	// test vectors of RFC 6238 for the seed "12345678901234567890"
+	tests := []struct {
+		seed     string
+		time     int64
+		expected string
+	}{
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 59, "287082"},
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 1111111109, "081804"},
+		{"gezd gnbv gy3t qojq gezd gnbv gy3t qojq", 1234567890, "005924"},
+	}
+	for _, tt := range tests {
+		actual, err := TOTP(secret.Bytes(tt.seed), time.Unix(tt.time, 0))
+		if err != nil || actual != tt.expected {
+			t.Errorf("%s, %v is not equal %s", actual, err, tt.expected)
+		}
+	}
+	if _, err := TOTP(secret.Bytes("not base32!"), time.Now()); err == nil {
+		t.Error("invalid seed needs to return error")
+	}
+}
+
+func TestAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	a := New()
+	a.Now = func() time.Time { return time.Unix(59, 0) }
+	go a.Serve(l)
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("%v, %v is unexpected", info, err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Error("listening twice needs to return error")
+	}
+	c := NewClient(path)
+	if _, err := c.Password(); err != ErrLocked {
+		t.Errorf("%v is not ErrLocked", err)
+	}
+	if err := c.Unlock(secret.Bytes("password"), secret.Bytes("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"), time.Hour, false); err != nil {
+		t.Fatal(err)
+	}
+	password, err := c.Password()
+	if err != nil || string(password) != "password" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	token, err := c.TOTP()
+	if err != nil || string(token) != "287082" {
+		t.Errorf("%q, %v is unexpected", string(token), err)
+	}
+	status, err := c.Status()
+	if err != nil || status.Locked || !status.Seed || status.Expires == nil || !status.Expires.Equal(time.Unix(3659, 0)) {
+		t.Errorf("%+v, %v is unexpected", status, err)
+	}
+	if err := c.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.TOTP(); err != ErrLocked {
+		t.Errorf("%v is not ErrLocked", err)
+	}
+}
+
+func TestAgentTimeout(t *testing.T) {
+	a := New()
+	a.do(&request{Op: opUnlock, Password: secret.Bytes("password"), Timeout: 1})
+	a.mu.Lock()
+	timer := a.timer
+	a.mu.Unlock()
+	if timer == nil {
+		t.Fatal("timer is not started")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if res := a.do(&request{Op: opStatus}); !res.Locked {
+		t.Errorf("%+v is not locked after the timeout", res)
+	}
+}
+
+func TestAgentBiometric(t *testing.T) {
+	a := New()
+	if res := a.do(&request{Op: opUnlock, Password: secret.Bytes("password"), Biometric: true}); res.Error == "" {
+		t.Errorf("%+v needs to be an error without Verify", res)
+	}
+	reasons := []string{}
+	verified := false
+	a.Verify = func(reason string) error {
+		reasons = append(reasons, reason)
+		if !verified {
+			return errors.New("canceled")
+		}
+		return nil
+	}
+	a.do(&request{Op: opUnlock, Password: secret.Bytes("password"), Biometric: true})
+	if res := a.do(&request{Op: opPassword}); res.Error != "canceled" || res.Password != nil {
+		t.Errorf("%+v is released without the verification", res)
+	}
+	verified = true
+	if res := a.do(&request{Op: opPassword}); res.Error != "" || string(res.Password) != "password" {
+		t.Errorf("%+v is unexpected", res)
+	}
+	if len(reasons) != 2 {
+		t.Errorf("%v is unexpected", reasons)
+	}
+	if res := a.do(&request{Op: opStatus}); !res.Biometric {
+		t.Errorf("%+v is unexpected", res)
+	}
+}
+
+func TestAgentBiometricEnforced(t *testing.T) {
+	a := New()
+	a.Biometric = true
+	if res := a.do(&request{Op: opUnlock, Password: secret.Bytes("password")}); res.Error == "" {
+		t.Errorf("%+v needs to be an error without Verify", res)
+	}
+	verified := 0
+	a.Verify = func(reason string) error {
+		verified++
+		return nil
+	}
+	a.do(&request{Op: opUnlock, Password: secret.Bytes("password")})
+	if res := a.do(&request{Op: opPassword}); res.Error != "" || string(res.Password) != "password" || verified != 1 {
+		t.Errorf("%+v is released without the verification", res)
+	}
+	if res := a.do(&request{Op: opStatus}); !res.Biometric {
+		t.Errorf("%+v is unexpected", res)
+	}
+}
+
+func TestAgentLockedWhileVerifying(t *testing.T) {
+	a := New()
+	a.Verify = func(reason string) error {
+		// the lock is not held while verifying, so it does not block
+		a.do(&request{Op: opLock})
+		return nil
+	}
+	a.do(&request{Op: opUnlock, Password: secret.Bytes("password"), Biometric: true})
+	if res := a.do(&request{Op: opPassword}); !res.Locked || res.Password != nil {
+		t.Errorf("%+v is released after locked", res)
+	}
+}
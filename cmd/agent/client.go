@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/ipc"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// Client talks to the agent over the unix domain socket
+type Client struct {
+	Path string
+}
+
+// NewClient creates a Client of the agent listening on the path
+func NewClient(path string) *Client {
+	return &Client{Path: path}
+}
+
+// Unlock sends the password and the TOTP seed to the agent, the agent forgets them after the timeout if it is not zero.
+// If biometric is true, the agent verifies the user with Touch ID or Windows Hello before releasing them
+func (c *Client) Unlock(password, seed secret.Bytes, timeout time.Duration, biometric bool) error {
+	_, err := c.call(&request{Op: opUnlock, Password: password, Seed: seed, Timeout: int64(timeout.Seconds()), Biometric: biometric})
+	return err
+}
+
+// Lock makes the agent forget the password and the TOTP seed
+func (c *Client) Lock() error {
+	_, err := c.call(&request{Op: opLock})
+	return err
+}
+
+// Password returns the password held by the agent, or ErrLocked
+func (c *Client) Password() (secret.Bytes, error) {
+	res, err := c.call(&request{Op: opPassword})
+	if err != nil {
+		return nil, err
+	}
+	return res.Password, nil
+}
+
+// TOTP returns the current TOTP token generated by the agent, the seed never leaves the agent
+func (c *Client) TOTP() (secret.Bytes, error) {
+	res, err := c.call(&request{Op: opTOTP})
+	if err != nil {
+		return nil, err
+	}
+	return secret.Bytes(res.Token), nil
+}
+
+// Status returns the state of the agent
+func (c *Client) Status() (*Status, error) {
+	res, err := c.call(&request{Op: opStatus})
+	if err != nil {
+		return nil, err
+	}
+	return &Status{Locked: res.Locked, Seed: res.Seed, Expires: res.Expires, Biometric: res.Biometric}, nil
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	conn, err := ipc.Dial(c.Path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write(append(data, '\n'))
+	secret.Wipe(data)
+	if err != nil {
+		return nil, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	defer secret.Wipe(line)
+	if err != nil {
+		return nil, err
+	}
+	var res response
+	if err := json.Unmarshal(line, &res); err != nil {
+		return nil, err
+	}
+	if res.Locked && res.Error != "" {
+		return nil, ErrLocked
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	return &res, nil
+}
@@ -0,0 +1,9 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package agent
+
+// lockMemory does nothing where the memory cannot be locked, the secret is still wiped after use
+func lockMemory(b []byte) {}
+
+func unlockMemory(b []byte) {}
@@ -0,0 +1,19 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package agent
+
+import "golang.org/x/sys/unix"
+
+// lockMemory prevents the secret from being swapped out, errors are ignored as RLIMIT_MEMLOCK may be small
+func lockMemory(b []byte) {
+	if len(b) > 0 {
+		unix.Mlock(b)
+	}
+}
+
+func unlockMemory(b []byte) {
+	if len(b) > 0 {
+		unix.Munlock(b)
+	}
+}
@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procVirtualLock   = kernel32.NewProc("VirtualLock")
+	procVirtualUnlock = kernel32.NewProc("VirtualUnlock")
+)
+
+// lockMemory prevents the secret from being paged out
+func lockMemory(b []byte) {
+	if len(b) > 0 {
+		procVirtualLock.Call(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+	}
+}
+
+func unlockMemory(b []byte) {
+	if len(b) > 0 {
+		procVirtualUnlock.Call(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+	}
+}
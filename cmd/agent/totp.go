@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+const (
+	totpPeriod = 30
+	totpDigits = 6
+)
+
+// TOTP generates the RFC 6238 token of the base32 encoded seed, which authenticator apps show
+func TOTP(seed secret.Bytes, t time.Time) (string, error) {
+	// normalize the seed without converting it to string, e.g. "jbsw y3dp ehpk 3pxp" or with padding
+	encoded := make(secret.Bytes, 0, len(seed))
+	defer encoded.Wipe()
+	for _, c := range seed {
+		switch {
+		case c == ' ' || c == '=':
+		case c >= 'a' && c <= 'z':
+			encoded = append(encoded, c-'a'+'A')
+		default:
+			encoded = append(encoded, c)
+		}
+	}
+	key := make([]byte, base32.StdEncoding.WithPadding(base32.NoPadding).DecodedLen(len(encoded)))
+	defer secret.Wipe(key)
+	n, err := base32.StdEncoding.WithPadding(base32.NoPadding).Decode(key, encoded)
+	if err != nil {
+		return "", fmt.Errorf("TOTP seed is not base32 encoded")
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/totpPeriod))
+	mac := hmac.New(sha1.New, key[:n])
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000), nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/agent"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+func TestAgentPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv(agent.SocketEnv, filepath.Join(dir, "agent.sock"))
+	defer os.Unsetenv(agent.SocketEnv)
+
+	if password := agentPassword(); password != nil {
+		t.Errorf("%q is returned without the agent", string(password))
+	}
+	if err := agentError(agentClient().Lock()); err == nil || !strings.HasPrefix(err.Error(), "agent is not running") {
+		t.Errorf("%v is unexpected", err)
+	}
+
+	l, err := agent.Listen(agentSocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go agent.New().Serve(l)
+	if password := agentPassword(); password != nil {
+		t.Errorf("%q is returned from the locked agent", string(password))
+	}
+	if err := agentClient().Unlock(secret.Bytes("agent-secret"), nil, time.Minute, false); err != nil {
+		t.Fatal(err)
+	}
+	s := &loginSession{}
+	password, err := s.Password()
+	if err != nil || string(password) != "agent-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+var aliasName string
+var aliasRoleArn string
+var aliasDelete bool
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Assign a friendly name to AWS Role ARN",
+	Long:  `Alias is assigning a friendly name to AWS Role ARN, which is accepted everywhere a role can be specified.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := initAlias(configFile, aliasName); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(aliasCmd)
+	aliasCmd.Flags().StringVarP(&aliasName, "name", "", "", "Alias Name")
+	aliasCmd.Flags().StringVarP(&aliasRoleArn, "role-arn", "", "", "AWS Role ARN")
+	aliasCmd.Flags().BoolVarP(&aliasDelete, "delete", "", false, "Delete the alias")
+}
+
+func initAlias(file string, name string) error {
+	if name == "" {
+		return errors.Errorf("Alias name is required")
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+	if aliasDelete {
+		delete(c.Alias, name)
+	} else {
+		if aliasRoleArn == "" {
+			return errors.Errorf("Role ARN is required")
+		}
+		c.Alias[name] = aliasRoleArn
+	}
+	if err := c.Save(); err != nil {
+		return err
+	}
+	if debug {
+		log.Printf("Alias: %#v\n", c.Alias)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAliasCmd(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetAliasFlags()
+	aliasRoleArn = "arn:aws:iam::123456789012:role/admin"
+	if err := initAlias(file, "prod-admin"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+
+[alias]
+  prod-admin = "arn:aws:iam::123456789012:role/admin"
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+
+	resetAliasFlags()
+	aliasDelete = true
+	if err := initAlias(file, "prod-admin"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err = ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual = string(data)
+	expected = `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestAliasCmdWithoutRoleArn(t *testing.T) {
+	file := "fixtures/serviceconfig.toml"
+	resetAliasFlags()
+	err := initAlias(file, "prod-admin")
+	if err == nil || err.Error() != "Role ARN is required" {
+		t.Errorf("%v is not equal %v", err, "Role ARN is required")
+	}
+}
+
+func resetAliasFlags() {
+	aliasRoleArn = ""
+	aliasDelete = false
+}
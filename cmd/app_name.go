@@ -0,0 +1,131 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+// appIDCacheTTL is how long the AppID looked up by the app name is cached
+const appIDCacheTTL = 24 * time.Hour
+
+// newConfigUsersAPI creates OneLogin Users API client with the OneLogin config used to log in
+var newConfigUsersAPI = func(c *onelogin.Config) usersAPI {
+	return users.NewUsers(c)
+}
+
+type appIDCache struct {
+	AppID      string    `json:"app_id"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+func appIDCacheFile(dir string, service config.ServiceConfig) string {
+	return path.Join(dir, fmt.Sprintf("apps.%s.cache", service.ClientToken))
+}
+
+func loadAppIDCache(dir string, service config.ServiceConfig) map[string]appIDCache {
+	cache := map[string]appIDCache{}
+	data, err := ioutil.ReadFile(appIDCacheFile(dir, service))
+	if err != nil {
+		return cache
+	}
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+func saveAppIDCache(dir string, service config.ServiceConfig, cache map[string]appIDCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return secret.WriteFile(appIDCacheFile(dir, service), data)
+}
+
+// appIDCacheKey distinguishes the same app name of other subdomains and users
+func appIDCacheKey(service config.ServiceConfig, name string) string {
+	return fmt.Sprintf("%s/%s/%s", service.Subdomain, service.UsernameOrEmail, strings.ToLower(name))
+}
+
+// resolveAppID looks up the AppID of the app assigned to the user by the name, which is compared case-insensitively
+func resolveAppID(api usersAPI, dir string, service config.ServiceConfig, name string) (string, error) {
+	cache := loadAppIDCache(dir, service)
+	key := appIDCacheKey(service, name)
+	if cached, ok := cache[key]; ok && time.Since(cached.ResolvedAt) < appIDCacheTTL {
+		if debug {
+			log.Printf("use cached AppID %s of %s\n", cached.AppID, name)
+		}
+		return cached.AppID, nil
+	}
+	ctx := context.Background()
+	user, err := api.Find(ctx, service.UsernameOrEmail)
+	if err != nil {
+		return "", err
+	}
+	apps, err := api.Apps(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	for _, app := range apps {
+		if !strings.EqualFold(app.Name, name) {
+			continue
+		}
+		if id != "" {
+			return "", errors.Errorf(i18n.Sprintf("%s matches more than one app, please configure --app-id instead", name))
+		}
+		id = strconv.Itoa(app.ID)
+	}
+	if id == "" {
+		return "", errors.Errorf(i18n.Sprintf("%s is not found in apps assigned to %s", name, service.UsernameOrEmail))
+	}
+	if debug {
+		log.Printf("AppID of %s is %s\n", name, id)
+	}
+	cache[key] = appIDCache{AppID: id, ResolvedAt: time.Now()}
+	return id, saveAppIDCache(dir, service, cache)
+}
+
+// staleAppID returns true if the error of the app tells the cached AppID may be stale,
+// the app is not found or not accessible because it is recreated with another AppID
+func staleAppID(err error) bool {
+	return errors.Is(err, apiresponse.ErrNotFound) || errors.Is(err, apiresponse.ErrForbidden)
+}
+
+// forgetAppID removes the cached AppID of the app name
+func forgetAppID(dir string, service config.ServiceConfig, name string) {
+	cache := loadAppIDCache(dir, service)
+	key := appIDCacheKey(service, name)
+	if _, ok := cache[key]; ok {
+		delete(cache, key)
+		saveAppIDCache(dir, service, cache)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+type countingUsersAPI struct {
+	usersAPIMock
+	count int
+}
+
+func (m *countingUsersAPI) Apps(ctx context.Context, userID int) ([]users.App, error) {
+	m.count++
+	return m.usersAPIMock.Apps(ctx, userID)
+}
+
+func TestResolveAppID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	service := config.ServiceConfig{ClientToken: "client-token", Subdomain: "subdomain", UsernameOrEmail: "user"}
+	api := &countingUsersAPI{usersAPIMock: usersAPIMock{
+		user: &users.User{ID: 1},
+		apps: []users.App{
+			{ID: 1, Name: "AWS Production"},
+			{ID: 2, Name: "AWS Staging"},
+			{ID: 3, Name: "aws staging"},
+		},
+	}}
+	for i := 0; i < 2; i++ {
+		id, err := resolveAppID(api, dir, service, "aws production")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "1" {
+			t.Errorf("%s is not 1", id)
+		}
+	}
+	if api.count != 1 {
+		t.Errorf("apps are fetched %d times, the cache is not used", api.count)
+	}
+	forgetAppID(dir, service, "AWS Production")
+	if _, err := resolveAppID(api, dir, service, "AWS Production"); err != nil || api.count != 2 {
+		t.Errorf("%v: apps are fetched %d times after the cache is removed", err, api.count)
+	}
+	if _, err := resolveAppID(api, dir, service, "AWS Staging"); err == nil {
+		t.Error("ambiguous name must be an error")
+	}
+	if _, err := resolveAppID(api, dir, service, "AWS Development"); err == nil {
+		t.Error("unknown name must be an error")
+	}
+}
+
+func TestStaleAppID(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.Wrap(apiresponse.ErrNotFound, "[404] Not Found"), true},
+		{errors.Wrap(apiresponse.ErrForbidden, "[403] Forbidden"), true},
+		{errors.Wrap(samlassertion.ErrInvalidCredentials, "[401] Unauthorized"), false},
+		{errors.Wrap(apiresponse.ErrServerError, "[503] Service Unavailable"), false},
+		{errors.New("connection reset by peer"), false},
+	}
+	for _, tt := range tests {
+		if actual := staleAppID(tt.err); actual != tt.expected {
+			t.Errorf("%v: %v is not %v", tt.err, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package biometric
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exitUnavailable is the exit status of the verification scripts when no biometric device is set up,
+// other non-zero status means the verification failed
+const exitUnavailable = 2
+
+var (
+	// ErrFailed is returned when the user is not verified or cancels the verification
+	ErrFailed = errors.New("biometric verification failed")
+	// ErrUnavailable is returned when no biometric device is set up
+	ErrUnavailable = errors.New("biometric verification is not available")
+)
+
+// Command runs an external command with environment variables, and returns its exit status
+var Command = func(name string, env []string, args ...string) (int, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return 0, nil
+}
+
+// Verify asks the user to verify with Touch ID on macOS or Windows Hello on Windows, showing the reason
+func Verify(reason string) error {
+	return verified(verify(reason))
+}
+
+// verified converts the exit status of the verification scripts to an error
+func verified(status int, err error) error {
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 0:
+		return nil
+	case exitUnavailable:
+		return ErrUnavailable
+	}
+	return ErrFailed
+}
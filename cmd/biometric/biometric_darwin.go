@@ -0,0 +1,29 @@
+package biometric
+
+import "os"
+
+// touchID evaluates LAPolicyDeviceOwnerAuthenticationWithBiometrics of LocalAuthentication framework
+const touchID = `ObjC.import('LocalAuthentication');
+ObjC.import('stdlib');
+function run(argv) {
+  var policy = 1;
+  var context = $.LAContext.alloc.init;
+  if (!context.canEvaluatePolicyError(policy, null)) {
+    $.exit(2);
+  }
+  var done = false;
+  var verified = false;
+  context.evaluatePolicyLocalizedReasonReply(policy, argv[0], function (success, error) {
+    verified = success;
+    done = true;
+  });
+  while (!done) {
+    $.NSRunLoop.currentRunLoop.runUntilDate($.NSDate.dateWithTimeIntervalSinceNow(0.1));
+  }
+  $.exit(verified ? 0 : 1);
+}`
+
+// verify runs the JavaScript for Automation with osascript, which needs no compiled helper
+func verify(reason string) (int, error) {
+	return Command("osascript", os.Environ(), "-l", "JavaScript", "-e", touchID, reason)
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package biometric
+
+func verify(reason string) (int, error) {
+	return exitUnavailable, nil
+}
@@ -0,0 +1,19 @@
+package biometric
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestVerified(t *testing.T) {
+	for status, expected := range map[int]error{0: nil, 1: ErrFailed, 255: ErrFailed, exitUnavailable: ErrUnavailable} {
+		if err := verified(status, nil); err != expected {
+			t.Errorf("%d: %v is not %v", status, err, expected)
+		}
+	}
+	failure := errors.New("osascript is not found")
+	if err := verified(0, failure); err != failure {
+		t.Errorf("%v is not %v", err, failure)
+	}
+}
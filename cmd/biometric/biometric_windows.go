@@ -0,0 +1,33 @@
+package biometric
+
+import "os"
+
+// reasonEnv passes the reason to the script without quoting
+const reasonEnv = "ONELOGIN_AWS_BIOMETRIC_REASON"
+
+// windowsHello requests the verification with UserConsentVerifier of Windows Runtime
+const windowsHello = `Add-Type -AssemblyName System.Runtime.WindowsRuntime
+$null = [Windows.Security.Credentials.UI.UserConsentVerifier, Windows.Security.Credentials.UI, ContentType = WindowsRuntime]
+$asTask = [System.WindowsRuntimeSystemExtensions].GetMethods() | Where-Object {
+    $_.Name -eq 'AsTask' -and $_.GetParameters().Count -eq 1 -and $_.GetParameters()[0].ParameterType.Name -eq 'IAsyncOperation` + "`" + `1'
+} | Select-Object -First 1
+function Await($operation, $type) {
+    $task = $asTask.MakeGenericMethod($type).Invoke($null, @($operation))
+    $null = $task.Wait(-1)
+    $task.Result
+}
+$availability = Await ([Windows.Security.Credentials.UI.UserConsentVerifier]::CheckAvailabilityAsync()) ([Windows.Security.Credentials.UI.UserConsentVerifierAvailability])
+if ($availability -ne [Windows.Security.Credentials.UI.UserConsentVerifierAvailability]::Available) {
+    exit 2
+}
+$result = Await ([Windows.Security.Credentials.UI.UserConsentVerifier]::RequestVerificationAsync($env:` + reasonEnv + `)) ([Windows.Security.Credentials.UI.UserConsentVerificationResult])
+if ($result -ne [Windows.Security.Credentials.UI.UserConsentVerificationResult]::Verified) {
+    exit 1
+}
+exit 0`
+
+// verify runs the PowerShell script, which needs no compiled helper
+func verify(reason string) (int, error) {
+	env := append(os.Environ(), reasonEnv+"="+reason)
+	return Command("powershell.exe", env, "-NoProfile", "-NonInteractive", "-Command", windowsHello)
+}
@@ -0,0 +1,89 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/configuration"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+var chainSourceProfile string
+var chainRoleArn string
+var chainRegion string
+
+// chainCmd represents the chain command
+var chainCmd = &cobra.Command{
+	Use:   "chain",
+	Short: "Setup aws profile to assume role from the SAML-assumed role",
+	Long: `Chain is writing role_arn and source_profile of --profile to ~/.aws/config,
+so AWS CLI and SDK can assume the role via the SAML-assumed role.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// the chained profile is never the default one, which may be the SAML-assumed role
+		profile := ""
+		if cmd.Flags().Changed("profile") || cmd.Flags().Changed("aws-profile") {
+			profile = awsProfile
+		}
+		if err := initChain(configFile, awsDir, profile); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(chainCmd)
+	chainCmd.Flags().StringVarP(&chainSourceProfile, "source-profile", "", "", "aws profile name of the SAML-assumed role")
+	chainCmd.Flags().StringVarP(&chainRoleArn, "role-arn", "", "", "Chained AWS Role ARN or alias")
+	chainCmd.Flags().StringVarP(&chainRegion, "aws-region", "", "", "AWS Region")
+}
+
+func initChain(file string, dir string, profile string) error {
+	if profile == "" {
+		return errors.New(i18n.T("aws profile is required, give it by --profile"))
+	}
+	if profile == chainSourceProfile {
+		return errors.Errorf(i18n.T("%s is the source profile, the chained role needs another profile"), profile)
+	}
+	if chainRoleArn == "" {
+		return errors.Errorf("Role ARN is required")
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.App[chainSourceProfile]; !ok {
+		return errors.Errorf(i18n.T("%s profile is not exists"), chainSourceProfile)
+	}
+	options := map[string]string{
+		"role_arn":       c.ResolveRole(chainRoleArn),
+		"source_profile": chainSourceProfile,
+	}
+	if chainRegion != "" {
+		options["region"] = chainRegion
+	}
+	awsConfig := configuration.NewConfig(dir, profile)
+	if err := awsConfig.SaveOptions(options); err != nil {
+		return err
+	}
+	if debug {
+		log.Printf("Chain: %#v\n", options)
+	}
+	return nil
+}
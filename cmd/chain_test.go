@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestChainCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	resetChainFlags()
+	chainSourceProfile = "default"
+	chainRoleArn = "arn:aws:iam::123456789012:role/admin"
+	if err := initChain("fixtures/fullfilled.toml", dir, "chained"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, "config"))
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	for _, expected := range []string{
+		"[profile chained]\n",
+		"role_arn       = arn:aws:iam::123456789012:role/admin\n",
+		"source_profile = default\n",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("'%v' does not contain '%v'", actual, expected)
+		}
+	}
+}
+
+func TestChainCmdWithUnknownSourceProfile(t *testing.T) {
+	resetChainFlags()
+	chainSourceProfile = "none"
+	chainRoleArn = "arn:aws:iam::123456789012:role/admin"
+	err := initChain("fixtures/fullfilled.toml", os.TempDir(), "chained")
+	if err == nil || err.Error() != "none profile is not exists" {
+		t.Errorf("%v is not equal %v", err, "none profile is not exists")
+	}
+}
+
+func TestChainCmdWithSourceProfile(t *testing.T) {
+	resetChainFlags()
+	chainSourceProfile = "default"
+	chainRoleArn = "arn:aws:iam::123456789012:role/admin"
+	if err := initChain("fixtures/fullfilled.toml", os.TempDir(), "default"); err == nil {
+		t.Error("the source profile is overwritten by the chained role")
+	}
+	if err := initChain("fixtures/fullfilled.toml", os.TempDir(), ""); err == nil {
+		t.Error("the chained profile is not required")
+	}
+}
+
+func resetChainFlags() {
+	chainSourceProfile = ""
+	chainRoleArn = ""
+	chainRegion = ""
+}
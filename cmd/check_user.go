@@ -0,0 +1,54 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+// userStatuses are messages of the user statuses which cannot log in
+var userStatuses = map[int]string{
+	users.StatusUnactivated:     "%s is not activated in subdomain %s",
+	users.StatusSuspended:       "%s is suspended in subdomain %s",
+	users.StatusLocked:          "%s is locked in subdomain %s",
+	users.StatusPasswordExpired: "the password of %s is expired in subdomain %s",
+	users.StatusAwaitingReset:   "%s is awaiting the password reset in subdomain %s",
+}
+
+// lookupUser looks up the user before asking the password,
+// to tell the user is not found or cannot log in instead of the authentication failure
+func lookupUser(api usersAPI, service config.ServiceConfig) error {
+	user, err := api.Find(context.Background(), service.UsernameOrEmail)
+	if errors.Is(err, users.ErrNotFound) {
+		return errors.Errorf(i18n.Sprintf("user %s is not found in subdomain %s", service.UsernameOrEmail, service.Subdomain))
+	}
+	if err != nil {
+		return err
+	}
+	if debug {
+		log.Printf("OneLogin user: id=%d status=%d\n", user.ID, user.Status)
+	}
+	if message, ok := userStatuses[user.Status]; ok {
+		return errors.Errorf(i18n.Sprintf(message, service.UsernameOrEmail, service.Subdomain))
+	}
+	return nil
+}
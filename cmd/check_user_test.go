@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+func TestLookupUser(t *testing.T) {
+	service := config.ServiceConfig{Subdomain: "example", UsernameOrEmail: "user@example.com"}
+	tests := []struct {
+		name     string
+		api      *usersAPIMock
+		expected string
+	}{
+		{"active", &usersAPIMock{user: &users.User{ID: 1, Status: users.StatusActive}}, ""},
+		{"not found", &usersAPIMock{err: errors.WithMessage(users.ErrNotFound, "user@example.com")}, "user user@example.com is not found in subdomain example"},
+		{"locked", &usersAPIMock{user: &users.User{ID: 1, Status: users.StatusLocked}}, "user@example.com is locked in subdomain example"},
+		{"api error", &usersAPIMock{err: errors.New("[401] Unauthorized: Authorization Information is incorrect")}, "[401] Unauthorized: Authorization Information is incorrect"},
+	}
+	for _, tt := range tests {
+		err := lookupUser(tt.api, service)
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != tt.expected {
+			t.Errorf("%s: '%s' is not '%s'", tt.name, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	colorRed    = 31
+	colorGreen  = 32
+	colorYellow = 33
+)
+
+// useColor represents whether output is colored, it is disabled by NO_COLOR env or --no-color
+var useColor = terminal.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == ""
+
+var noColor bool
+var quiet bool
+
+func colorize(color int, s string) string {
+	if !useColor || noColor {
+		return s
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", color, s)
+}
+
+// infoOut returns the writer of informational messages, which are discarded with --quiet
+func infoOut() io.Writer {
+	if quiet {
+		return ioutil.Discard
+	}
+	return os.Stdout
+}
+
+// info prints an informational message
+func info(format string, a ...interface{}) {
+	fmt.Fprintf(infoOut(), format, a...)
+}
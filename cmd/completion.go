@@ -0,0 +1,285 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+const (
+	completeProfiles = "profiles"
+	completeRoles    = "roles"
+	completeGroups   = "groups"
+)
+
+// completionFlags maps flag names to the kind of configured names they are completed with
+var completionFlags = map[string]string{
+	"aws-profile":    completeProfiles,
+	"aws-profiles":   completeProfiles,
+	"source-profile": completeProfiles,
+	"export-profile": completeProfiles,
+	"role":           completeRoles,
+	"role-arn":       completeRoles,
+	"group":          completeGroups,
+	"alias/name":     completeRoles,
+	"group/name":     completeGroups,
+}
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:       "completion bash|zsh|fish|powershell",
+	Short:     "Generate shell completion script",
+	Long:      `Completion is printing a completion script for bash, zsh, fish or powershell.`,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			errorExit(errors.Errorf("completion requires one of bash, zsh, fish or powershell"))
+		}
+		if err := genCompletion(os.Stdout, RootCmd, args[0]); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+// completeCmd prints configured names used by completion scripts
+var completeCmd = &cobra.Command{
+	Use:    "__complete profiles|roles|groups",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			return
+		}
+		c, err := config.Load(configFile)
+		if err != nil {
+			return
+		}
+		for _, name := range completionNames(c, args[0]) {
+			fmt.Println(name)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(completeCmd)
+}
+
+func completionNames(c *config.Config, kind string) []string {
+	names := []string{}
+	switch kind {
+	case completeProfiles:
+		for name := range c.App {
+			names = append(names, name)
+		}
+	case completeRoles:
+		for name := range c.Alias {
+			names = append(names, name)
+		}
+	case completeGroups:
+		for name := range c.Group {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completionKind(cmd *cobra.Command, flag *pflag.Flag) string {
+	if kind, ok := completionFlags[cmd.Name()+"/"+flag.Name]; ok {
+		return kind
+	}
+	return completionFlags[flag.Name]
+}
+
+type completionFlag struct {
+	Name       string
+	Usage      string
+	Kind       string
+	TakesValue bool
+}
+
+type completionCommand struct {
+	Name  string
+	Short string
+	Flags []completionFlag
+}
+
+func completionCommands(root *cobra.Command) []completionCommand {
+	commands := []completionCommand{}
+	for _, c := range root.Commands() {
+		if !c.IsAvailableCommand() || c.Name() == "help" {
+			continue
+		}
+		command := completionCommand{Name: c.Name(), Short: c.Short}
+		add := func(flag *pflag.Flag) {
+			if flag.Name == "help" {
+				return
+			}
+			command.Flags = append(command.Flags, completionFlag{
+				Name:       flag.Name,
+				Usage:      flag.Usage,
+				Kind:       completionKind(c, flag),
+				TakesValue: flag.Value.Type() != "bool",
+			})
+		}
+		c.NonInheritedFlags().VisitAll(add)
+		c.InheritedFlags().VisitAll(add)
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+func markCompletionFlags(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		c.Flags().VisitAll(func(flag *pflag.Flag) {
+			if kind := completionKind(c, flag); kind != "" {
+				c.MarkFlagCustom(flag.Name, "__onelogin-aws-connector_complete "+kind)
+			}
+		})
+	}
+}
+
+func genCompletion(out io.Writer, root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		markCompletionFlags(root)
+		root.BashCompletionFunction = bashCompletionFunction
+		return root.GenBashCompletion(out)
+	case "zsh":
+		return zshCompletion.Execute(out, completionCommands(root))
+	case "fish":
+		return fishCompletion.Execute(out, completionCommands(root))
+	case "powershell":
+		return powershellCompletion.Execute(out, completionCommands(root))
+	}
+	return errors.Errorf("%s is not supported shell", shell)
+}
+
+const bashCompletionFunction = `__onelogin-aws-connector_complete()
+{
+    local names
+    names=$(onelogin-aws-connector __complete "$1" 2>/dev/null)
+    COMPREPLY=( $(compgen -W "${names}" -- "$cur") )
+}
+`
+
+var completionFuncs = template.FuncMap{
+	"quote": func(s string) string {
+		return strings.Replace(s, "'", "''", -1)
+	},
+	"zsh": func(s string) string {
+		return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+	},
+	"fish": func(s string) string {
+		return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s)
+	},
+}
+
+var zshCompletion = template.Must(template.New("zsh").Funcs(completionFuncs).Parse(`#compdef onelogin-aws-connector
+
+__onelogin-aws-connector_complete() {
+    local -a names
+    names=(${(f)"$(onelogin-aws-connector __complete $1 2>/dev/null)"})
+    compadd -a names
+}
+
+_onelogin-aws-connector() {
+    local -a commands
+    commands=(
+{{- range .}}
+        '{{zsh .Name}}:{{zsh .Short}}'
+{{- end}}
+    )
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+    case $words[2] in
+{{- range .}}
+        {{.Name}})
+            _arguments \
+{{- range .Flags}}
+                '--{{.Name}}[{{zsh .Usage}}]{{if .Kind}}:{{.Kind}}:__onelogin-aws-connector_complete {{.Kind}}{{else if .TakesValue}}:{{.Name}}: {{end}}' \
+{{- end}}
+                '*: :_files'
+            ;;
+{{- end}}
+    esac
+}
+
+compdef _onelogin-aws-connector onelogin-aws-connector
+`))
+
+var fishCompletion = template.Must(template.New("fish").Funcs(completionFuncs).Parse(`complete -c onelogin-aws-connector -f
+{{- range .}}
+complete -c onelogin-aws-connector -n '__fish_use_subcommand' -a '{{fish .Name}}' -d '{{fish .Short}}'
+{{- $command := .Name}}
+{{- range .Flags}}
+complete -c onelogin-aws-connector -n '__fish_seen_subcommand_from {{$command}}' -l '{{.Name}}'{{if .Kind}} -r -a '(onelogin-aws-connector __complete {{.Kind}} 2>/dev/null)'{{else if .TakesValue}} -r{{end}} -d '{{fish .Usage}}'
+{{- end}}
+{{- end}}
+`))
+
+var powershellCompletion = template.Must(template.New("powershell").Funcs(completionFuncs).Parse(`Register-ArgumentCompleter -Native -CommandName 'onelogin-aws-connector' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $commands = @{
+{{- range .}}
+        '{{quote .Name}}' = @({{range $i, $f := .Flags}}{{if $i}}, {{end}}'--{{$f.Name}}'{{end}})
+{{- end}}
+    }
+    $dynamic = @{
+{{- range .}}
+{{- $command := .Name}}
+{{- range .Flags}}{{if .Kind}}
+        '{{$command}} --{{.Name}}' = '{{.Kind}}'
+{{- end}}{{end}}
+{{- end}}
+    }
+    $elements = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+    if ($wordToComplete) {
+        $elements = $elements[0..($elements.Count - 2)]
+    }
+    if ($elements.Count -le 1) {
+        $commands.Keys | Sort-Object | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+            [System.Management.Automation.CompletionResult]::new($_, $_, 'Command', $_)
+        }
+        return
+    }
+    $command = $elements[1]
+    $key = "$command $($elements[-1])"
+    if ($dynamic.ContainsKey($key)) {
+        & onelogin-aws-connector __complete $dynamic[$key] 2>$null | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+            [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+        }
+        return
+    }
+    if ($commands.ContainsKey($command)) {
+        $commands[$command] | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+            [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterName', $_)
+        }
+    }
+}
+`))
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestCompletionNames(t *testing.T) {
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	c.Alias["admin"] = "role-arn"
+	c.Group["all"] = []string{"default", "other"}
+	tests := []struct {
+		kind     string
+		expected []string
+	}{
+		{completeProfiles, []string{"default", "other"}},
+		{completeRoles, []string{"admin"}},
+		{completeGroups, []string{"all"}},
+		{"unknown", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if got := completionNames(c, tt.kind); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("%v is not equal %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenCompletion(t *testing.T) {
+	root := &cobra.Command{Use: "onelogin-aws-connector"}
+	sub := &cobra.Command{Use: "login", Short: "Login to AWS with OneLogin", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.Flags().StringP("aws-profile", "", "", "aws profile name")
+	sub.Flags().BoolP("force", "", false, "force login")
+	root.AddCommand(sub)
+	tests := []struct {
+		shell    string
+		expected string
+	}{
+		{"bash", `flags_completion+=("__onelogin-aws-connector_complete profiles")`},
+		{"zsh", `'--aws-profile[aws profile name]:profiles:__onelogin-aws-connector_complete profiles'`},
+		{"fish", `complete -c onelogin-aws-connector -n '__fish_seen_subcommand_from login' -l 'aws-profile' -r -a '(onelogin-aws-connector __complete profiles 2>/dev/null)' -d 'aws profile name'`},
+		{"powershell", `'login --aws-profile' = 'profiles'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := genCompletion(&buf, root, tt.shell); err != nil {
+				t.Errorf("%#v", err)
+			}
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("'%v' does not contain '%v'", buf.String(), tt.expected)
+			}
+		})
+	}
+	t.Run("unsupported", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := genCompletion(&buf, root, "tcsh"); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+}
@@ -1,61 +1,279 @@
 package config
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
 )
 
 // Config stores config
 type Config struct {
-	Service map[string]*ServiceConfig `toml:"service"`
-	App     map[string]*AppConfig     `toml:"app"`
-	file    string                    `toml:"-"`
+	Version      int                       `toml:"version"`
+	ConfigSource string                    `toml:"config_source,omitempty"`
+	Language     string                    `toml:"language,omitempty"`
+	UserAgent    string                    `toml:"user_agent,omitempty"`
+	Service      map[string]*ServiceConfig `toml:"service"`
+	App          map[string]*AppConfig     `toml:"app"`
+	Alias        map[string]string         `toml:"alias,omitempty"`
+	Group        map[string][]string       `toml:"group,omitempty"`
+	Account      map[string]string         `toml:"account,omitempty"`
+	file         string                    `toml:"-"`
+	shared       *Config                   `toml:"-"`
+	encrypted    bool                      `toml:"-"`
+	// original is the file of an older version, which is backed up when the migrated config is saved
+	original        []byte `toml:"-"`
+	originalVersion int    `toml:"-"`
 }
 
 // ServiceConfig stores initialized data
 type ServiceConfig struct {
 	Endpoint        string `toml:"endpoint"`
+	Region          string `toml:"region,omitempty"`
 	ClientToken     string `toml:"client_token"`
 	ClientSecret    string `toml:"client_secret"`
 	Subdomain       string `toml:"subdomain"`
 	UsernameOrEmail string `toml:"username_or_email"`
+	Vault           string `toml:"vault,omitempty"`
+	CheckUser       bool   `toml:"check_user,omitempty"`
+	MFADevice       string `toml:"mfa_device,omitempty"`
+	Proxy           string `toml:"proxy,omitempty"`
+	NoProxy         string `toml:"no_proxy,omitempty"`
+	ProxyUser       string `toml:"proxy_user,omitempty"`
+	// ProxyPassword is read from the environment variable or the keychain at runtime, and never saved
+	ProxyPassword string `toml:"-"`
+	// CABundle is the PEM file of CA certificates trusted in addition to the system ones
+	CABundle string `toml:"ca_bundle,omitempty"`
+	// ClientCert and ClientKey are the PEM files for mutual TLS required by some network security appliances
+	ClientCert string `toml:"client_cert,omitempty"`
+	ClientKey  string `toml:"client_key,omitempty"`
+	// Pins are SPKI hashes of the certificate chain of the endpoint like sha256/AAAA=
+	Pins               []string `toml:"pins,omitempty"`
+	InsecureSkipVerify bool     `toml:"insecure_skip_verify,omitempty"`
+	// timeouts are seconds or duration strings parsed by Timeouts
+	Timeout             string `toml:"timeout,omitempty"`
+	ConnectTimeout      string `toml:"connect_timeout,omitempty"`
+	TLSHandshakeTimeout string `toml:"tls_handshake_timeout,omitempty"`
+	VerifyFactorTimeout string `toml:"verify_factor_timeout,omitempty"`
+	IdleConnTimeout     string `toml:"idle_conn_timeout,omitempty"`
+	// HTTP2 is "force" or "off" for proxies breaking HTTP/2, empty means auto
+	HTTP2               string `toml:"http2,omitempty"`
+	MaxIdleConns        int    `toml:"max_idle_conns,omitzero"`
+	MaxIdleConnsPerHost int    `toml:"max_idle_conns_per_host,omitzero"`
+	PreferIPv4          bool   `toml:"prefer_ipv4,omitempty"`
+	DNSResolver         string `toml:"dns_resolver,omitempty"`
+	DNSCache            bool   `toml:"dns_cache,omitempty"`
+	// Credentials has client credentials of other tenants by their subdomains
+	Credentials map[string]*CredentialConfig `toml:"credentials,omitempty"`
+
+	sealedClientToken  *sealedValue
+	sealedClientSecret *sealedValue
+}
+
+// CredentialConfig stores OneLogin API client credentials of a subdomain
+type CredentialConfig struct {
+	ClientToken  string `toml:"client_token"`
+	ClientSecret string `toml:"client_secret"`
+
+	sealedClientToken  *sealedValue
+	sealedClientSecret *sealedValue
 }
 
 // AppConfig stores configured data
 type AppConfig struct {
 	AppID           string `toml:"app_id"`
+	AppName         string `toml:"app_name,omitempty"`
+	Service         string `toml:"service,omitempty"`
+	Subdomain       string `toml:"subdomain,omitempty"`
+	Proxy           string `toml:"proxy,omitempty"`
+	NoProxy         string `toml:"no_proxy,omitempty"`
+	ProxyUser       string `toml:"proxy_user,omitempty"`
 	RoleArn         string `toml:"role_arn"`
 	PrincipalArn    string `toml:"principal_arn"`
 	DurationSeconds int64  `toml:"duration_seconds"`
+	Duration        string `toml:"duration,omitempty"`
+	Region          string `toml:"region,omitempty"`
+	PasswordPrompt  bool   `toml:"password_prompt,omitempty"`
+	PasswordCommand string `toml:"password_command,omitempty"`
+	Keychain        bool   `toml:"keychain,omitempty"`
+	// Biometric asks Touch ID or Windows Hello before login reads the keychain, the keychain does not enforce it
+	Biometric  bool   `toml:"biometric,omitempty"`
+	APIVersion string `toml:"api_version,omitempty"`
+	AWSSDK     string `toml:"aws_sdk,omitempty"`
+	// Partition is aws, aws-us-gov or aws-cn, empty means the partition of the role ARN
+	Partition   string `toml:"partition,omitempty"`
+	STSEndpoint string `toml:"sts_endpoint,omitempty"`
+	// STSRegion is the region whose STS endpoint assumes the role instead of the global one
+	STSRegion string `toml:"sts_region,omitempty"`
+	// Policy is JSON of the session policy, and PolicyArns are the managed session policies
+	Policy     string   `toml:"policy,omitempty"`
+	PolicyArns []string `toml:"policy_arns,omitempty"`
+	// ChainRoleArn is assumed with the credentials of the SAML-assumed role on login
+	ChainRoleArn     string `toml:"chain_role_arn,omitempty"`
+	ChainExternalID  string `toml:"chain_external_id,omitempty"`
+	ChainSessionName string `toml:"chain_session_name,omitempty"`
+	// ResolveAccountAlias calls iam:ListAccountAliases on login for accounts without [account] alias
+	ResolveAccountAlias bool `toml:"resolve_account_alias,omitempty"`
+	// RefreshWithin and QuietHours are the refresh schedule of the daemon parsed by Schedule
+	RefreshWithin string `toml:"refresh_within,omitempty"`
+	QuietHours    string `toml:"quiet_hours,omitempty"`
+}
+
+// DefaultService is the name of the service used by apps which have no service
+const DefaultService = "default"
+
+// ServiceName returns the name of the service the app logs in with, e.g. a sandbox tenant
+func (a AppConfig) ServiceName() string {
+	if a.Service == "" {
+		return DefaultService
+	}
+	return a.Service
+}
+
+// LoadLanguage returns the language of messages in the config file,
+// it does not decrypt the file nor fetch the shared config
+func LoadLanguage(file string) string {
+	return loadPlain(file).Language
+}
+
+// LoadUserAgent returns the suffix of User-Agent in the config file,
+// it does not decrypt the file nor fetch the shared config
+func LoadUserAgent(file string) string {
+	return loadPlain(file).UserAgent
+}
+
+func loadPlain(file string) (c struct {
+	Language  string `toml:"language"`
+	UserAgent string `toml:"user_agent"`
+}) {
+	if _, err := toml.DecodeFile(file, &c); err != nil {
+		c.Language = ""
+		c.UserAgent = ""
+	}
+	return c
 }
 
 // Load creates a Loaded Config
 func Load(file string) (*Config, error) {
 	var config Config
-	if _, err := toml.DecodeFile(file, &config); err != nil {
+	version := CurrentVersion
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
 		}
-		config = Config{
-			Service: map[string]*ServiceConfig{},
-			App:     map[string]*AppConfig{},
+	} else {
+		plain, encrypted, err := decryptFile(file, data)
+		if err != nil {
+			return nil, err
+		}
+		version, err = decode(plain, &config)
+		if err != nil {
+			return nil, err
 		}
+		config.encrypted = encrypted
 	}
-	if config.App == nil {
-		config.App = map[string]*AppConfig{}
+	config.initialize()
+	if err := config.decryptFields(); err != nil {
+		return nil, err
 	}
 	config.file = file
+	if version != CurrentVersion {
+		config.original = data
+		config.originalVersion = version
+	}
+	if config.ConfigSource != "" {
+		shared, err := loadShared(sharedCacheFile(file))
+		if err != nil {
+			return nil, err
+		}
+		if shared != nil {
+			config.merge(shared)
+		}
+	}
 	return &config, nil
 }
 
+func (c *Config) initialize() {
+	c.Version = CurrentVersion
+	if c.Service == nil {
+		c.Service = map[string]*ServiceConfig{}
+	}
+	if c.App == nil {
+		c.App = map[string]*AppConfig{}
+	}
+	if c.Alias == nil {
+		c.Alias = map[string]string{}
+	}
+	if c.Group == nil {
+		c.Group = map[string][]string{}
+	}
+	if c.Account == nil {
+		c.Account = map[string]string{}
+	}
+}
+
+// ResolveRole returns the role ARN for the alias, or name itself if no alias matches
+func (c Config) ResolveRole(name string) string {
+	if arn, ok := c.Alias[name]; ok {
+		return arn
+	}
+	return name
+}
+
+// RoleAlias returns the alias assigned to the role ARN, or empty string
+func (c Config) RoleAlias(arn string) string {
+	alias := ""
+	for name, v := range c.Alias {
+		if v == arn && (alias == "" || name < alias) {
+			alias = name
+		}
+	}
+	return alias
+}
+
+// AccountAlias returns the alias of AWS account which the ARN belongs to, or empty string
+func (c Config) AccountAlias(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 5 {
+		return ""
+	}
+	return c.Account[parts[4]]
+}
+
+// GroupProfiles returns profiles in the group
+func (c Config) GroupProfiles(name string) ([]string, error) {
+	profiles, ok := c.Group[name]
+	if !ok {
+		return nil, errors.Errorf(i18n.T("%s group is not exists"), name)
+	}
+	for _, profile := range profiles {
+		if _, ok := c.App[profile]; !ok {
+			return nil, errors.Errorf(i18n.T("%s profile in %s group is not exists"), profile, name)
+		}
+	}
+	return profiles, nil
+}
+
 // Save to persistent store
 func (c Config) Save() error {
-	fd, err := os.Create(c.file)
-	if err != nil {
+	if c.encrypted {
+		return errors.Errorf("%s is encrypted. Please edit it with sops or gpg", c.file)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c.local().sealed()); err != nil {
 		return err
 	}
-	defer fd.Close()
-	encoder := toml.NewEncoder(fd)
-	return encoder.Encode(c)
+	if c.original != nil {
+		if err := backup(c.file, c.original, c.originalVersion); err != nil {
+			return err
+		}
+	}
+	return secret.WriteFile(c.file, buf.Bytes())
 }
@@ -31,7 +31,9 @@ func TestLoadNoFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
 
 [app]
 `
@@ -76,7 +78,9 @@ func TestLoadServiceOnlyFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "api-server"
     client_token = "client-token"
@@ -107,7 +111,9 @@ func TestLoadServiceOnlyFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual = string(data)
-	expected = `[service]
+	expected = `version = 1
+
+[service]
   [service.default]
     endpoint = "new-api-server"
     client_token = "new-client-token"
@@ -159,7 +165,9 @@ func TestLoadNormalFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "api-server"
     client_token = "client-token"
@@ -206,7 +214,9 @@ func TestLoadNormalFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual = string(data)
-	expected = `[service]
+	expected = `version = 1
+
+[service]
   [service.default]
     endpoint = "new-api-server"
     client_token = "new-client-token"
@@ -230,3 +240,49 @@ func TestLoadNormalFile(t *testing.T) {
 		t.Errorf("%v is not equal %v", actual, expected)
 	}
 }
+
+func TestResolveRole(t *testing.T) {
+	c := Config{
+		Alias: map[string]string{
+			"prod-admin": "arn:aws:iam::123456789012:role/admin",
+		},
+	}
+	if arn := c.ResolveRole("prod-admin"); arn != "arn:aws:iam::123456789012:role/admin" {
+		t.Errorf("%s is not equal %s", arn, "arn:aws:iam::123456789012:role/admin")
+	}
+	if arn := c.ResolveRole("role-arn"); arn != "role-arn" {
+		t.Errorf("%s is not equal %s", arn, "role-arn")
+	}
+	if alias := c.RoleAlias("arn:aws:iam::123456789012:role/admin"); alias != "prod-admin" {
+		t.Errorf("%s is not equal %s", alias, "prod-admin")
+	}
+	if alias := c.RoleAlias("role-arn"); alias != "" {
+		t.Errorf("'%s' is not empty", alias)
+	}
+}
+
+func TestGroupProfiles(t *testing.T) {
+	c := Config{
+		App: map[string]*AppConfig{
+			"default": {},
+			"other":   {},
+		},
+		Group: map[string][]string{
+			"all":     {"default", "other"},
+			"invalid": {"default", "none"},
+		},
+	}
+	profiles, err := c.GroupProfiles("all")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if len(profiles) != 2 || profiles[0] != "default" || profiles[1] != "other" {
+		t.Errorf("%v is not equal %v", profiles, []string{"default", "other"})
+	}
+	if _, err := c.GroupProfiles("none"); err == nil || err.Error() != "none group is not exists" {
+		t.Errorf("%v is not equal %v", err, "none group is not exists")
+	}
+	if _, err := c.GroupProfiles("invalid"); err == nil || err.Error() != "none profile in invalid group is not exists" {
+		t.Errorf("%v is not equal %v", err, "none profile in invalid group is not exists")
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MinDurationSeconds is the minimum session duration of AssumeRoleWithSAML
+	MinDurationSeconds int64 = 900
+	// MaxDurationSeconds is the maximum session duration of AssumeRoleWithSAML
+	MaxDurationSeconds int64 = 43200
+	// DefaultDurationSeconds is used when no duration is configured
+	DefaultDurationSeconds int64 = 3600
+)
+
+// ParseDuration converts seconds or duration string like "1h", "45m" to seconds
+func ParseDuration(s string) (int64, error) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, errors.Errorf("%s is invalid duration", s)
+		}
+		if d%time.Second != 0 {
+			return 0, errors.Errorf("%s is not whole seconds", s)
+		}
+		seconds = int64(d / time.Second)
+	}
+	if seconds < MinDurationSeconds || seconds > MaxDurationSeconds {
+		return 0, errors.Errorf("%s is out of range from %ds to %ds", s, MinDurationSeconds, MaxDurationSeconds)
+	}
+	return seconds, nil
+}
+
+// SessionDuration returns the session duration seconds to assume the role
+func (a AppConfig) SessionDuration() (int64, error) {
+	if a.Duration != "" {
+		return ParseDuration(a.Duration)
+	}
+	if a.DurationSeconds == 0 {
+		return DefaultDurationSeconds, nil
+	}
+	return ParseDuration(strconv.FormatInt(a.DurationSeconds, 10))
+}
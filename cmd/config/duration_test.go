@@ -0,0 +1,55 @@
+package config
+
+import "testing"
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{s: "3600", want: 3600},
+		{s: "1h", want: 3600},
+		{s: "45m", want: 2700},
+		{s: "1h30m", want: 5400},
+		{s: "12h", want: 43200},
+		{s: "14m", wantErr: true},
+		{s: "13h", wantErr: true},
+		{s: "900.5s", wantErr: true},
+		{s: "an hour", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDuration(%s) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%s) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSessionDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     AppConfig
+		want    int64
+		wantErr bool
+	}{
+		{name: "not configured", app: AppConfig{}, want: 3600},
+		{name: "duration_seconds", app: AppConfig{DurationSeconds: 7200}, want: 7200},
+		{name: "duration", app: AppConfig{DurationSeconds: 7200, Duration: "8h"}, want: 28800},
+		{name: "out of range", app: AppConfig{DurationSeconds: 60}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.app.SessionDuration()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SessionDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SessionDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
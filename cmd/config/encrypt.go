@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const pgpMessageHeader = "-----BEGIN PGP MESSAGE-----"
+
+// Command runs an external command with input, and returns its output
+var Command = func(name string, input []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// decryptFile returns decrypted data if the whole file is encrypted with SOPS or GPG
+func decryptFile(file string, data []byte) ([]byte, bool, error) {
+	switch {
+	case isSOPS(data):
+		plain, err := Command("sops", nil, "--decrypt", "--input-type", "binary", "--output-type", "binary", file)
+		return plain, true, err
+	case isGPG(data):
+		plain, err := Command("gpg", data, "--quiet", "--batch", "--decrypt")
+		return plain, true, err
+	}
+	return data, false, nil
+}
+
+func isSOPS(data []byte) bool {
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return false
+	}
+	_, ok := v["sops"]
+	return ok
+}
+
+// OpenPGP packet tags starting an encrypted message, RFC 4880 section 4.3
+const (
+	pgpTagPublicKeyEncryptedSessionKey    = 1
+	pgpTagSymmetricKeyEncryptedSessionKey = 3
+)
+
+func isGPG(data []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(pgpMessageHeader)) {
+		return true
+	}
+	tag, body, ok := pgpPacket(data)
+	if !ok || len(body) == 0 {
+		return false
+	}
+	// the version of the session key packet, so a text file starting with a multibyte character is not taken as GPG
+	switch tag {
+	case pgpTagPublicKeyEncryptedSessionKey:
+		return body[0] == 3 || body[0] == 6
+	case pgpTagSymmetricKeyEncryptedSessionKey:
+		return body[0] >= 4 && body[0] <= 6
+	}
+	return false
+}
+
+// pgpPacket parses the header of the first OpenPGP packet, and returns its tag and the rest of data after the header
+func pgpPacket(data []byte) (int, []byte, bool) {
+	if len(data) < 2 || data[0]&0x80 == 0 {
+		return 0, nil, false
+	}
+	if data[0]&0x40 != 0 {
+		// new format: the tag is the low 6 bits, and the length is 1, 2 or 5 octets
+		tag := int(data[0] & 0x3f)
+		switch first := data[1]; {
+		case first < 192:
+			return tag, data[2:], true
+		case first < 224 && len(data) >= 3:
+			return tag, data[3:], true
+		case first == 255 && len(data) >= 6:
+			return tag, data[6:], true
+		}
+		return 0, nil, false
+	}
+	// old format: the tag is the bits 5-2, and the length type is the low 2 bits
+	tag := int(data[0]&0x3c) >> 2
+	header := map[byte]int{0: 2, 1: 3, 2: 5, 3: 1}[data[0]&0x03]
+	if len(data) < header {
+		return 0, nil, false
+	}
+	return tag, data[header:], true
+}
+
+// sealedValue keeps an encrypted field value with its plain text
+type sealedValue struct {
+	encrypted string
+	plain     string
+}
+
+// restore returns the encrypted value if value is not changed after decrypted
+func (v *sealedValue) restore(value string) string {
+	if v != nil && v.plain == value {
+		return v.encrypted
+	}
+	return value
+}
+
+// secretField is a secret field which may be encrypted with GPG
+type secretField struct {
+	value  *string
+	sealed **sealedValue
+}
+
+// decryptFields decrypts GPG encrypted secret fields
+func (c *Config) decryptFields() error {
+	for _, service := range c.Service {
+		fields := []secretField{
+			{&service.ClientToken, &service.sealedClientToken},
+			{&service.ClientSecret, &service.sealedClientSecret},
+		}
+		for _, pair := range service.Credentials {
+			fields = append(fields,
+				secretField{&pair.ClientToken, &pair.sealedClientToken},
+				secretField{&pair.ClientSecret, &pair.sealedClientSecret},
+			)
+		}
+		for _, field := range fields {
+			if !strings.HasPrefix(*field.value, pgpMessageHeader) {
+				continue
+			}
+			plain, err := Command("gpg", []byte(*field.value), "--quiet", "--batch", "--decrypt")
+			if err != nil {
+				return err
+			}
+			*field.sealed = &sealedValue{
+				encrypted: *field.value,
+				plain:     strings.TrimSpace(string(plain)),
+			}
+			*field.value = (*field.sealed).plain
+		}
+	}
+	return nil
+}
+
+// sealed returns config which secret fields are restored to encrypted values
+func (c Config) sealed() Config {
+	s := c
+	s.Service = map[string]*ServiceConfig{}
+	for name, service := range c.Service {
+		v := *service
+		v.ClientToken = service.sealedClientToken.restore(service.ClientToken)
+		v.ClientSecret = service.sealedClientSecret.restore(service.ClientSecret)
+		if service.Credentials != nil {
+			v.Credentials = map[string]*CredentialConfig{}
+			for subdomain, pair := range service.Credentials {
+				p := *pair
+				p.ClientToken = pair.sealedClientToken.restore(pair.ClientToken)
+				p.ClientSecret = pair.sealedClientSecret.restore(pair.ClientSecret)
+				v.Credentials[subdomain] = &p
+			}
+		}
+		s.Service[name] = &v
+	}
+	return s
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func mockCommand(outputs map[string]string) func() {
+	original := Command
+	Command = func(name string, input []byte, args ...string) ([]byte, error) {
+		key := name
+		if name == "gpg" && strings.HasPrefix(string(input), pgpMessageHeader) {
+			key = string(input)
+		}
+		output, ok := outputs[key]
+		if !ok {
+			return nil, errors.Errorf("unexpected command %s %v", name, args)
+		}
+		return []byte(output), nil
+	}
+	return func() {
+		Command = original
+	}
+}
+
+func TestLoadSOPSEncryptedFile(t *testing.T) {
+	defer mockCommand(map[string]string{
+		"sops": `[service]
+  [service.default]
+    client_secret = "client-secret"
+`,
+	})()
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	if _, err := dist.WriteString(`{"data": "ENC[AES256_GCM,data:xxx]", "sops": {"version": "3.7.3"}}`); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if c.Service["default"].ClientSecret != "client-secret" {
+		t.Errorf("%s is not equal %s", c.Service["default"].ClientSecret, "client-secret")
+	}
+	if err := c.Save(); err == nil {
+		t.Error("It need to return encrypted error.")
+	}
+}
+
+func TestLoadGPGEncryptedField(t *testing.T) {
+	encrypted := pgpMessageHeader + "\nhQEMA\n-----END PGP MESSAGE-----\n"
+	defer mockCommand(map[string]string{
+		encrypted: "client-secret\n",
+	})()
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	content := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "-----BEGIN PGP MESSAGE-----\nhQEMA\n-----END PGP MESSAGE-----\n"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+`
+	if _, err := dist.WriteString(content); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if c.Service["default"].ClientSecret != "client-secret" {
+		t.Errorf("%s is not equal %s", c.Service["default"].ClientSecret, "client-secret")
+	}
+	if err := c.Save(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != content {
+		t.Errorf("'%s' is not equal '%s'", string(data), content)
+	}
+}
+
+func TestLoadGPGEncryptedSubdomainCredentials(t *testing.T) {
+	encrypted := pgpMessageHeader + "\nhQEMB\n-----END PGP MESSAGE-----\n"
+	defer mockCommand(map[string]string{
+		encrypted: "tenant-secret\n",
+	})()
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	content := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+    [service.default.credentials]
+      [service.default.credentials.tenant]
+        client_token = "tenant-token"
+        client_secret = "-----BEGIN PGP MESSAGE-----\nhQEMB\n-----END PGP MESSAGE-----\n"
+
+[app]
+`
+	if _, err := dist.WriteString(content); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair := c.Service["default"].Credentials["tenant"]; pair.ClientToken != "tenant-token" || pair.ClientSecret != "tenant-secret" {
+		t.Errorf("%#v is unexpected", pair)
+	}
+	if err := c.Save(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != content {
+		t.Errorf("'%s' is not equal '%s'", string(data), content)
+	}
+}
+
+func TestIsGPG(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{"armored", []byte("\n" + pgpMessageHeader + "\n"), true},
+		{"public key session key in new format", []byte{0xc1, 0x0c, 0x03, 0x01}, true},
+		{"public key session key in old format", []byte{0x85, 0x01, 0x0c, 0x03, 0x01}, true},
+		{"symmetric key session key", []byte{0x8c, 0x0d, 0x04, 0x09}, true},
+		{"unknown session key version", []byte{0xc1, 0x0c, 0x01, 0x01}, false},
+		{"literal data packet", []byte{0xcb, 0x0c, 0x62, 0x00}, false},
+		{"text starting with a multibyte character", []byte("日本語 = \"value\"\n"), false},
+		{"text with BOM", []byte("\xef\xbb\xbfversion = 1\n"), false},
+		{"toml", []byte("version = 1\n"), false},
+	} {
+		if actual := isGPG(tt.data); actual != tt.expected {
+			t.Errorf("%s is detected as GPG: %v", tt.name, actual)
+		}
+	}
+}
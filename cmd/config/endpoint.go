@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+)
+
+// Regions are the OneLogin API regions which can be given as `region`
+var Regions = []string{"us", "eu"}
+
+// RegionEndpoint returns the OneLogin API endpoint of the region like "api.us.onelogin.com"
+func RegionEndpoint(region string) (string, error) {
+	region = strings.ToLower(region)
+	for _, r := range Regions {
+		if r == region {
+			return fmt.Sprintf("api.%s.onelogin.com", region), nil
+		}
+	}
+	return "", errors.Errorf("%s is not OneLogin region, it must be one of %s", region, strings.Join(Regions, ", "))
+}
+
+// APIEndpoint returns the endpoint, or the endpoint of the region if no custom endpoint is configured
+func (s ServiceConfig) APIEndpoint() (string, error) {
+	if s.Endpoint != "" || s.Region == "" {
+		return s.Endpoint, nil
+	}
+	return RegionEndpoint(s.Region)
+}
+
+// SAML assertion API versions of `api_version`
+const (
+	APIVersion1    = "1"
+	APIVersion2    = "2"
+	APIVersionAuto = "auto"
+)
+
+// ParseAPIVersion returns the SAML assertion API version and whether to fall back to version 1 if version 2 is not found.
+// Empty string means "auto"
+func ParseAPIVersion(version string) (int, bool, error) {
+	switch version {
+	case APIVersion1:
+		return 1, false, nil
+	case APIVersion2:
+		return 2, false, nil
+	case APIVersionAuto, "":
+		return 2, true, nil
+	}
+	return 0, false, errors.Errorf("%s is not SAML assertion API version, it must be one of 1, 2 or auto", version)
+}
+
+// AWS SDK for Go versions of `aws_sdk`
+const (
+	AWSSDK1 = "1"
+	AWSSDK2 = "2"
+)
+
+// ParseAWSSDK returns the major version of AWS SDK for Go assuming the role. Empty string means 1
+func ParseAWSSDK(version string) (int, error) {
+	switch version {
+	case AWSSDK1, "":
+		return 1, nil
+	case AWSSDK2:
+		return 2, nil
+	}
+	return 0, errors.Errorf("%s is not AWS SDK version, it must be 1 or 2", version)
+}
+
+// AssumeRoleRegion returns the region whose STS assumes the role of the app: sts_region, the given AWS region,
+// or the default region of the configured partition if it is not aws. Empty means the global endpoint
+func (a AppConfig) AssumeRoleRegion(region string) (string, error) {
+	if a.STSRegion != "" {
+		return a.STSRegion, nil
+	}
+	if region != "" {
+		return region, nil
+	}
+	if a.Partition == "" {
+		return "", nil
+	}
+	p, err := partition.Lookup(a.Partition)
+	if err != nil {
+		return "", err
+	}
+	if p.ID != partition.AWS {
+		return p.Region, nil
+	}
+	return "", nil
+}
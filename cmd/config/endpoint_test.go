@@ -0,0 +1,93 @@
+package config
+
+import "testing"
+
+func TestAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		service ServiceConfig
+		want    string
+		wantErr bool
+	}{
+		{service: ServiceConfig{Region: "us"}, want: "api.us.onelogin.com"},
+		{service: ServiceConfig{Region: "EU"}, want: "api.eu.onelogin.com"},
+		{service: ServiceConfig{Region: "eu", Endpoint: "localhost:8443"}, want: "localhost:8443"},
+		{service: ServiceConfig{Endpoint: "api.us.onelogin.com"}, want: "api.us.onelogin.com"},
+		{service: ServiceConfig{}, want: ""},
+		{service: ServiceConfig{Region: "jp"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.service.APIEndpoint()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%#v: error = %v, wantErr %v", tt.service, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("%#v: %s is not %s", tt.service, got, tt.want)
+		}
+	}
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		want      int
+		negotiate bool
+		wantErr   bool
+	}{
+		{version: "1", want: 1},
+		{version: "2", want: 2},
+		{version: "auto", want: 2, negotiate: true},
+		{version: "", want: 2, negotiate: true},
+		{version: "3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, negotiate, err := ParseAPIVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+		if got != tt.want || negotiate != tt.negotiate {
+			t.Errorf("%s: %d, %v is not %d, %v", tt.version, got, negotiate, tt.want, tt.negotiate)
+		}
+	}
+}
+
+func TestParseAWSSDK(t *testing.T) {
+	tests := []struct {
+		version string
+		want    int
+		wantErr bool
+	}{
+		{version: "", want: 1},
+		{version: "1", want: 1},
+		{version: "2", want: 2},
+		{version: "v2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAWSSDK(tt.version)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: %d, %v is unexpected", tt.version, got, err)
+		}
+	}
+}
+
+func TestAssumeRoleRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     AppConfig
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{name: "global", app: AppConfig{}, want: ""},
+		{name: "aws partition", app: AppConfig{Partition: "aws"}, want: ""},
+		{name: "partition", app: AppConfig{Partition: "aws-us-gov"}, want: "us-gov-west-1"},
+		{name: "region", app: AppConfig{Partition: "aws-us-gov"}, region: "us-gov-east-1", want: "us-gov-east-1"},
+		{name: "sts_region", app: AppConfig{STSRegion: "eu-west-1"}, region: "ap-northeast-1", want: "eu-west-1"},
+		{name: "unknown partition", app: AppConfig{Partition: "aws-mars"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.app.AssumeRoleRegion(tt.region)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: %s, %v is unexpected", tt.name, got, err)
+		}
+	}
+}
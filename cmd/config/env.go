@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// PasswordEnv is the environment variable of the password read instead of the prompt.
+// PasswordEnvAlias is the alias of PasswordEnv, which is read only if PasswordEnv is not set
+const (
+	PasswordEnv      = "ONELOGIN_AWS_PASSWORD"
+	PasswordEnvAlias = "ONELOGIN_PASSWORD"
+)
+
+// ClientIDEnv and ClientSecretEnv are the environment variables of OneLogin API client credentials used when the config file omits them
+const (
+	ClientIDEnv     = "ONELOGIN_CLIENT_ID"
+	ClientSecretEnv = "ONELOGIN_CLIENT_SECRET"
+)
+
+// ProxyUserEnv and ProxyPasswordEnv are the environment variables of the proxy credentials
+const (
+	ProxyUserEnv     = "ONELOGIN_PROXY_USER"
+	ProxyPasswordEnv = "ONELOGIN_PROXY_PASSWORD"
+)
+
+// EnvCredentials fills the client token and the client secret omitted in the service with ClientIDEnv and ClientSecretEnv,
+// and returns the variables which are read. The client secret is registered to be redacted
+func EnvCredentials(service *ServiceConfig) []string {
+	var read []string
+	if service.ClientToken == "" {
+		if value := os.Getenv(ClientIDEnv); value != "" {
+			service.ClientToken = value
+			read = append(read, ClientIDEnv)
+		}
+	}
+	if service.ClientSecret == "" {
+		if value := os.Getenv(ClientSecretEnv); value != "" {
+			service.ClientSecret = value
+			secret.Register(value)
+			read = append(read, ClientSecretEnv)
+		}
+	}
+	return read
+}
+
+// EnvPassword returns the password of PasswordEnv or PasswordEnvAlias, or empty string
+func EnvPassword() string {
+	if password := os.Getenv(PasswordEnv); password != "" {
+		return password
+	}
+	return os.Getenv(PasswordEnvAlias)
+}
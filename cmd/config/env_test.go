@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEnvPassword(t *testing.T) {
+	defer os.Unsetenv(PasswordEnv)
+	defer os.Unsetenv(PasswordEnvAlias)
+	os.Setenv(PasswordEnvAlias, "alias-secret")
+	if password := EnvPassword(); password != "alias-secret" {
+		t.Errorf("%s is not the alias", password)
+	}
+	os.Setenv(PasswordEnv, "secret")
+	if password := EnvPassword(); password != "secret" {
+		t.Errorf("%s does not take precedence over the alias", password)
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	defer os.Unsetenv(ClientIDEnv)
+	defer os.Unsetenv(ClientSecretEnv)
+	os.Setenv(ClientIDEnv, "env-client-id")
+	os.Setenv(ClientSecretEnv, "env-client-secret")
+	service := &ServiceConfig{ClientToken: "local-token"}
+	if read := EnvCredentials(service); !reflect.DeepEqual(read, []string{ClientSecretEnv}) {
+		t.Errorf("%v are read", read)
+	}
+	if service.ClientToken != "local-token" || service.ClientSecret != "env-client-secret" {
+		t.Errorf("%#v is unexpected", service)
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n/i18ntest"
+)
+
+func TestMain(m *testing.M) {
+	i18ntest.Main(m)
+}
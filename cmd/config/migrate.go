@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// CurrentVersion is the version of config format
+const CurrentVersion = 1
+
+// migrations converts raw config of the version to the next version
+var migrations = map[int]func(raw map[string]interface{}) error{
+	// version 0 is unversioned config, which has the same format with version 1
+	0: func(raw map[string]interface{}) error {
+		return nil
+	},
+}
+
+// decode decodes config data with migrating it to the current version, and returns the original version
+func decode(data []byte, config *Config) (int, error) {
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return 0, err
+	}
+	original := 0
+	if v, ok := raw["version"].(int64); ok {
+		original = int(v)
+	}
+	if original > CurrentVersion {
+		return original, errors.Errorf("config version %d is not supported. Please update onelogin-aws-connector", original)
+	}
+	if original == CurrentVersion {
+		_, err := toml.Decode(string(data), config)
+		return original, err
+	}
+	for version := original; version < CurrentVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return original, errors.Errorf("config version %d can not be migrated", version)
+		}
+		if err := migrate(raw); err != nil {
+			return original, errors.Wrapf(err, "failed to migrate config version %d", version)
+		}
+	}
+	raw["version"] = CurrentVersion
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return original, err
+	}
+	_, err := toml.Decode(buf.String(), config)
+	return original, err
+}
+
+// backup copies data of the config file before migration
+func backup(file string, data []byte, version int) error {
+	return secret.WriteFile(fmt.Sprintf("%s.v%d.bak", file, version), data)
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoadUnversionedFile(t *testing.T) {
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	backupFile := fmt.Sprintf("%s.v0.bak", file)
+	defer os.Remove(file)
+	defer os.Remove(backupFile)
+	content := `[service]
+  [service.default]
+    endpoint = "api-server"
+
+[app]
+  [app.default]
+    app_id = "app-id"
+`
+	if _, err := dist.WriteString(content); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if c.Version != CurrentVersion {
+		t.Errorf("%d is not equal %d", c.Version, CurrentVersion)
+	}
+	if c.App["default"].AppID != "app-id" {
+		t.Errorf("%s is not equal %s", c.App["default"].AppID, "app-id")
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != content {
+		t.Errorf("the file is rewritten on load: '%s'", string(data))
+	}
+	if _, err := os.Stat(backupFile); !os.IsNotExist(err) {
+		t.Errorf("the backup is written on load: %v", err)
+	}
+
+	if err := c.Save(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err = ioutil.ReadFile(backupFile)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != content {
+		t.Errorf("'%s' is not equal '%s'", string(data), content)
+	}
+	data, err = ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = ""
+    client_secret = ""
+    subdomain = ""
+    username_or_email = ""
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = ""
+    principal_arn = ""
+    duration_seconds = 0
+`
+	if string(data) != expected {
+		t.Errorf("'%s' is not equal '%s'", string(data), expected)
+	}
+}
+
+func TestLoadNewerVersionFile(t *testing.T) {
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	if _, err := dist.WriteString(fmt.Sprintf("version = %d\n", CurrentVersion+1)); err != nil {
+		t.Errorf("%#v", err)
+	}
+	_, err = Load(file)
+	expected := fmt.Sprintf("config version %d is not supported. Please update onelogin-aws-connector", CurrentVersion+1)
+	if err == nil || err.Error() != expected {
+		t.Errorf("%v is not equal %v", err, expected)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// policyFilePrefix loads the session policy from the file like AWS CLI
+const policyFilePrefix = "file://"
+
+// MaxPolicyLength is the maximum length of the session policy of AssumeRoleWithSAML
+const MaxPolicyLength = 2048
+
+// MaxPolicyArns is the maximum number of the managed session policies of AssumeRoleWithSAML
+const MaxPolicyArns = 10
+
+// policyArnPattern matches ARNs of managed policies like arn:aws:iam::aws:policy/ReadOnlyAccess
+var policyArnPattern = regexp.MustCompile(`^arn:[a-z-]+:iam::(aws|[0-9]{12}):policy/.+$`)
+
+// ParsePolicy returns the compacted JSON of the session policy given as JSON or file://path
+func ParsePolicy(s string) (string, error) {
+	data := []byte(s)
+	if strings.HasPrefix(s, policyFilePrefix) {
+		var err error
+		if data, err = ioutil.ReadFile(strings.TrimPrefix(s, policyFilePrefix)); err != nil {
+			return "", err
+		}
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, data); err != nil {
+		return "", errors.Wrap(err, "session policy is not valid JSON")
+	}
+	if b.Len() > MaxPolicyLength {
+		return "", errors.Errorf("session policy is %d characters, it must be up to %d", b.Len(), MaxPolicyLength)
+	}
+	return b.String(), nil
+}
+
+// ValidatePolicyArns returns an error if the ARNs are not managed policies or too many
+func ValidatePolicyArns(arns []string) error {
+	if len(arns) > MaxPolicyArns {
+		return errors.Errorf("%d managed session policies are given, it must be up to %d", len(arns), MaxPolicyArns)
+	}
+	for _, arn := range arns {
+		if !policyArnPattern.MatchString(arn) {
+			return errors.Errorf("%s is not ARN of managed policy like arn:aws:iam::aws:policy/ReadOnlyAccess", arn)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	file, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString("{\n  \"Version\": \"2012-10-17\"\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	tests := []struct {
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{s: `{ "Version": "2012-10-17" }`, want: `{"Version":"2012-10-17"}`},
+		{s: "file://" + file.Name(), want: `{"Version":"2012-10-17"}`},
+		{s: "file:///not/found", wantErr: true},
+		{s: `{"Version":`, wantErr: true},
+		{s: `"` + strings.Repeat("a", MaxPolicyLength) + `"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePolicy(%s) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%s) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePolicyArns(t *testing.T) {
+	tests := []struct {
+		arns    []string
+		wantErr bool
+	}{
+		{arns: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws-us-gov:iam::123456789012:policy/path/Policy"}},
+		{arns: []string{"arn:aws:iam::123456789012:role/Role"}, wantErr: true},
+		{arns: []string{"ReadOnlyAccess"}, wantErr: true},
+		{arns: make([]string, MaxPolicyArns+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePolicyArns(tt.arns); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePolicyArns(%v) error = %v, wantErr %v", tt.arns, err, tt.wantErr)
+		}
+	}
+}
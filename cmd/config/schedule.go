@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule is the refresh schedule of a profile in the daemon
+type Schedule struct {
+	// Within refreshes the credentials expiring within it, zero means --within of the daemon
+	Within time.Duration
+	// QuietHours is the daily window without scheduled refreshes, nil means no window
+	QuietHours *QuietHours
+}
+
+// QuietHours is a daily window of the local time like "22:00-07:00", which may pass midnight
+type QuietHours struct {
+	// Start and End are the offsets from midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseQuietHours converts the window like "22:00-07:00" to QuietHours
+func ParseQuietHours(s string) (*QuietHours, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, errors.Errorf("%s is invalid quiet hours, it must be like 22:00-07:00", s)
+	}
+	var offsets [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.Errorf("%s is invalid quiet hours, it must be like 22:00-07:00", s)
+		}
+		offsets[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if offsets[0] == offsets[1] {
+		return nil, errors.Errorf("%s is empty quiet hours", s)
+	}
+	return &QuietHours{Start: offsets[0], End: offsets[1]}, nil
+}
+
+// at returns the time of the offset from midnight on the day of t by the wall clock, so it follows DST changes
+func at(t time.Time, offset time.Duration) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// Contains tells whether t is in the quiet hours
+func (q QuietHours) Contains(t time.Time) bool {
+	hour, min, sec := t.Clock()
+	offset := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	if q.Start < q.End {
+		return q.Start <= offset && offset < q.End
+	}
+	return q.Start <= offset || offset < q.End
+}
+
+// Until returns the end of the quiet hours containing t, or t if it is not in them
+func (q QuietHours) Until(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+	end := at(t, q.End)
+	if !end.After(t) {
+		end = at(t.AddDate(0, 0, 1), q.End)
+	}
+	return end
+}
+
+// Since returns the start of the quiet hours containing t, or t if it is not in them
+func (q QuietHours) Since(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+	start := at(t, q.Start)
+	if start.After(t) {
+		start = at(t.AddDate(0, 0, -1), q.Start)
+	}
+	return start
+}
+
+func (q QuietHours) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", d/time.Hour, d%time.Hour/time.Minute)
+	}
+	return format(q.Start) + "-" + format(q.End)
+}
+
+// Schedule returns the parsed refresh schedule of the app.
+// refresh_within must be shorter than the session duration, or the credentials would be refreshed at every check
+func (a AppConfig) Schedule() (Schedule, error) {
+	var s Schedule
+	if a.RefreshWithin != "" {
+		within, err := ParseTimeout(a.RefreshWithin)
+		if err != nil || within == 0 {
+			return Schedule{}, errors.Errorf("%s is invalid refresh_within", a.RefreshWithin)
+		}
+		if seconds, err := a.SessionDuration(); err == nil && within >= time.Duration(seconds)*time.Second {
+			return Schedule{}, errors.Errorf("refresh_within %s is not shorter than the session duration %s", a.RefreshWithin, time.Duration(seconds)*time.Second)
+		}
+		s.Within = within
+	}
+	if a.QuietHours != "" {
+		quiet, err := ParseQuietHours(a.QuietHours)
+		if err != nil {
+			return Schedule{}, err
+		}
+		s.QuietHours = quiet
+	}
+	return s, nil
+}
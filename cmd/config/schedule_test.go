@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected *QuietHours
+	}{
+		{"22:00-07:00", &QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour}},
+		{"12:30 - 13:15", &QuietHours{Start: 12*time.Hour + 30*time.Minute, End: 13*time.Hour + 15*time.Minute}},
+		{"22:00", nil},
+		{"25:00-07:00", nil},
+		{"07:00-07:00", nil},
+	} {
+		actual, err := ParseQuietHours(tt.value)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("%s is accepted", tt.value)
+			}
+			continue
+		}
+		if err != nil || *actual != *tt.expected {
+			t.Errorf("%v, %v is unexpected for %s", actual, err, tt.value)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	night := QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour}
+	lunch := QuietHours{Start: 12 * time.Hour, End: 13 * time.Hour}
+	day := func(hour, min int) time.Time {
+		return time.Date(2026, 1, 2, hour, min, 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		quiet    QuietHours
+		t        time.Time
+		contains bool
+		since    time.Time
+		until    time.Time
+	}{
+		{night, day(21, 59), false, day(21, 59), day(21, 59)},
+		{night, day(22, 0), true, day(22, 0), time.Date(2026, 1, 3, 7, 0, 0, 0, time.UTC)},
+		{night, day(3, 0), true, time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC), day(7, 0)},
+		{night, day(7, 0), false, day(7, 0), day(7, 0)},
+		{lunch, day(12, 30), true, day(12, 0), day(13, 0)},
+		{lunch, day(23, 0), false, day(23, 0), day(23, 0)},
+	} {
+		if actual := tt.quiet.Contains(tt.t); actual != tt.contains {
+			t.Errorf("%s contains %v is %v", tt.quiet, tt.t, actual)
+		}
+		if actual := tt.quiet.Since(tt.t); !actual.Equal(tt.since) {
+			t.Errorf("%s since %v is %v", tt.quiet, tt.t, actual)
+		}
+		if actual := tt.quiet.Until(tt.t); !actual.Equal(tt.until) {
+			t.Errorf("%s until %v is %v", tt.quiet, tt.t, actual)
+		}
+	}
+}
+
+func TestAppConfigSchedule(t *testing.T) {
+	s, err := AppConfig{RefreshWithin: "30m", QuietHours: "22:00-07:00"}.Schedule()
+	if err != nil || s.Within != 30*time.Minute || s.QuietHours == nil || s.QuietHours.String() != "22:00-07:00" {
+		t.Errorf("%+v, %v is unexpected", s, err)
+	}
+	if s, err := (AppConfig{}).Schedule(); err != nil || s.Within != 0 || s.QuietHours != nil {
+		t.Errorf("%+v, %v is not the default", s, err)
+	}
+	if _, err := (AppConfig{RefreshWithin: "0"}).Schedule(); err == nil {
+		t.Error("zero refresh_within is accepted")
+	}
+	if _, err := (AppConfig{RefreshWithin: "1h"}).Schedule(); err == nil || err.Error() != "refresh_within 1h is not shorter than the session duration 1h0m0s" {
+		t.Errorf("%v does not reject refresh_within of the default session duration", err)
+	}
+	if s, err := (AppConfig{RefreshWithin: "1h", Duration: "2h"}).Schedule(); err != nil || s.Within != time.Hour {
+		t.Errorf("%+v, %v is not accepted in the session duration", s, err)
+	}
+	if _, err := (AppConfig{QuietHours: "night"}).Schedule(); err == nil {
+		t.Error("invalid quiet_hours is accepted")
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// HTTPClient is used to fetch the shared config from HTTPS URL
+var HTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// loadShared reads the shared config fetched by Sync, or returns nil if it is not fetched yet
+func loadShared(cache string) (*Config, error) {
+	data, err := ioutil.ReadFile(cache)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeShared(cache, data)
+}
+
+func decodeShared(name string, data []byte) (*Config, error) {
+	var shared Config
+	if _, err := decode(data, &shared); err != nil {
+		return nil, errors.Wrapf(err, "%s is invalid config", name)
+	}
+	shared.initialize()
+	return &shared, nil
+}
+
+// Sync fetches the shared config of config_source and saves it as the cache, which Load merges without fetching.
+// The cache is kept if fetching fails
+func (c *Config) Sync() error {
+	if c.ConfigSource == "" {
+		return errors.New(i18n.T("config_source is not configured, please run `onelogin-aws-connector init --config-source`"))
+	}
+	data, err := fetchSource(c.ConfigSource)
+	if err != nil {
+		return err
+	}
+	shared, err := decodeShared(c.ConfigSource, data)
+	if err != nil {
+		return err
+	}
+	if err := secret.WriteFile(sharedCacheFile(c.file), data); err != nil {
+		return err
+	}
+	// the definitions of the last shared config are replaced, so they are not saved as the local ones
+	local := c.local()
+	local.merge(shared)
+	*c = local
+	return nil
+}
+
+func fetchSource(source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "https":
+		res, err := HTTPClient.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("[%d] failed to fetch %s", res.StatusCode, source)
+		}
+		return ioutil.ReadAll(res.Body)
+	case "s3":
+		s, err := session.NewSession()
+		if err != nil {
+			return nil, err
+		}
+		s.Handlers.Build.PushBackNamed(useragent.Handler)
+		output, err := s3.New(s).GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(u.Host),
+			Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer output.Body.Close()
+		return ioutil.ReadAll(output.Body)
+	}
+	return nil, errors.Errorf("%s is not supported config source", source)
+}
+
+func sharedCacheFile(file string) string {
+	return path.Join(path.Dir(file), fmt.Sprintf("shared.%s", path.Base(file)))
+}
+
+// merge adds shared definitions which are not defined in local
+func (c *Config) merge(shared *Config) {
+	c.shared = shared
+	for name, app := range shared.App {
+		if _, ok := c.App[name]; !ok {
+			v := *app
+			c.App[name] = &v
+		}
+	}
+	for name, arn := range shared.Alias {
+		if _, ok := c.Alias[name]; !ok {
+			c.Alias[name] = arn
+		}
+	}
+	for name, profiles := range shared.Group {
+		if _, ok := c.Group[name]; !ok {
+			c.Group[name] = append([]string{}, profiles...)
+		}
+	}
+	for id, alias := range shared.Account {
+		if _, ok := c.Account[id]; !ok {
+			c.Account[id] = alias
+		}
+	}
+}
+
+// local returns config without unchanged shared definitions
+func (c Config) local() Config {
+	if c.shared == nil {
+		return c
+	}
+	l := c
+	l.App = map[string]*AppConfig{}
+	for name, app := range c.App {
+		if shared, ok := c.shared.App[name]; !ok || !reflect.DeepEqual(app, shared) {
+			l.App[name] = app
+		}
+	}
+	l.Alias = map[string]string{}
+	for name, arn := range c.Alias {
+		if shared, ok := c.shared.Alias[name]; !ok || arn != shared {
+			l.Alias[name] = arn
+		}
+	}
+	l.Group = map[string][]string{}
+	for name, profiles := range c.Group {
+		if shared, ok := c.shared.Group[name]; !ok || !reflect.DeepEqual(profiles, shared) {
+			l.Group[name] = profiles
+		}
+	}
+	l.Account = map[string]string{}
+	for id, alias := range c.Account {
+		if shared, ok := c.shared.Account[id]; !ok || alias != shared {
+			l.Account[id] = alias
+		}
+	}
+	return l
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+const sharedConfig = `[app]
+  [app.default]
+    app_id = "shared-app-id"
+    role_arn = "shared-role-arn"
+    principal_arn = "shared-provider-arn"
+  [app.shared]
+    app_id = "shared-app-id"
+    role_arn = "prod-admin"
+    principal_arn = "shared-provider-arn"
+
+[alias]
+  prod-admin = "arn:aws:iam::123456789012:role/admin"
+
+[account]
+  123456789012 = "production"
+`
+
+func TestLoadWithConfigSource(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, sharedConfig)
+	}))
+	defer server.Close()
+	HTTPClient = server.Client()
+
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "config.toml")
+	local := fmt.Sprintf(`version = 1
+config_source = "%s/shared.toml"
+
+[service]
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = "role-arn"
+    principal_arn = "provider-arn"
+    duration_seconds = 0
+`, server.URL)
+	if err := ioutil.WriteFile(file, []byte(local), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if _, ok := c.App["shared"]; ok {
+		t.Error("shared profile is loaded before it is fetched")
+	}
+	if err := c.Sync(); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if c, err = Load(file); err != nil {
+		t.Errorf("%#v", err)
+	}
+	if c.App["default"].AppID != "app-id" {
+		t.Errorf("%s is not equal %s", c.App["default"].AppID, "app-id")
+	}
+	if c.App["shared"].AppID != "shared-app-id" {
+		t.Errorf("%s is not equal %s", c.App["shared"].AppID, "shared-app-id")
+	}
+	if arn := c.ResolveRole(c.App["shared"].RoleArn); arn != "arn:aws:iam::123456789012:role/admin" {
+		t.Errorf("%s is not equal %s", arn, "arn:aws:iam::123456789012:role/admin")
+	}
+	if alias := c.AccountAlias("arn:aws:iam::123456789012:role/admin"); alias != "production" {
+		t.Errorf("%s is not equal %s", alias, "production")
+	}
+
+	if err := c.Save(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != local {
+		t.Errorf("'%s' is not equal '%s'", string(data), local)
+	}
+
+	status = http.StatusInternalServerError
+	if err := c.Sync(); err == nil {
+		t.Error("failed fetch is not returned")
+	}
+	c, err = Load(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if _, ok := c.App["shared"]; !ok {
+		t.Error("shared profile is not loaded from cache")
+	}
+}
+
+func TestSyncReplacesShared(t *testing.T) {
+	shared := sharedConfig
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, shared)
+	}))
+	defer server.Close()
+	HTTPClient = server.Client()
+
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "config.toml")
+	local := fmt.Sprintf("version = 1\nconfig_source = \"%s/shared.toml\"\n\n[service]\n\n[app]\n", server.URL)
+	if err := ioutil.WriteFile(file, []byte(local), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if err := c.Sync(); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	shared = strings.Replace(sharedConfig, `role_arn = "prod-admin"`, `role_arn = "prod-readonly"`, 1)
+	if err := c.Sync(); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if c.App["shared"].RoleArn != "prod-readonly" {
+		t.Errorf("%s is not the updated shared role", c.App["shared"].RoleArn)
+	}
+	if err := c.Save(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != local {
+		t.Errorf("'%s' is not equal '%s'", string(data), local)
+	}
+}
+
+func TestSyncWithoutConfigSource(t *testing.T) {
+	if err := (&Config{}).Sync(); err == nil {
+		t.Error("sync without config_source is accepted")
+	}
+}
+
+func TestFetchSourceUnsupported(t *testing.T) {
+	_, err := fetchSource("ftp://example.com/shared.toml")
+	if err == nil || err.Error() != "ftp://example.com/shared.toml is not supported config source" {
+		t.Errorf("%v is not equal %v", err, "ftp://example.com/shared.toml is not supported config source")
+	}
+}
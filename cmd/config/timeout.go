@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Timeouts are the HTTP timeouts of a service, zero means the default
+type Timeouts struct {
+	// Request is the overall timeout of each request including reading the response
+	Request time.Duration
+	// Connect is the timeout of establishing TCP connections
+	Connect time.Duration
+	// TLSHandshake is the timeout of TLS handshakes
+	TLSHandshake time.Duration
+	// VerifyFactor is the budget of polling VerifyFactor while waiting for the push approval
+	VerifyFactor time.Duration
+	// IdleConn is the time to keep idle connections
+	IdleConn time.Duration
+}
+
+// ParseTimeout converts seconds or duration string like "30s", "2m" to the duration
+func ParseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		s = strconv.FormatInt(seconds, 10) + "s"
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errors.Errorf("%s is invalid timeout", s)
+	}
+	return d, nil
+}
+
+// Timeouts returns the parsed timeouts of the service
+func (s ServiceConfig) Timeouts() (Timeouts, error) {
+	var t Timeouts
+	var err error
+	for _, field := range []struct {
+		value  string
+		parsed *time.Duration
+	}{
+		{s.Timeout, &t.Request},
+		{s.ConnectTimeout, &t.Connect},
+		{s.TLSHandshakeTimeout, &t.TLSHandshake},
+		{s.VerifyFactorTimeout, &t.VerifyFactor},
+		{s.IdleConnTimeout, &t.IdleConn},
+	} {
+		if *field.parsed, err = ParseTimeout(field.value); err != nil {
+			return Timeouts{}, err
+		}
+	}
+	return t, nil
+}
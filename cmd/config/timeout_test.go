@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, true},
+		{"30", 30 * time.Second, true},
+		{"1m30s", 90 * time.Second, true},
+		{"500ms", 500 * time.Millisecond, true},
+		{"-1s", 0, false},
+		{"forever", 0, false},
+	} {
+		actual, err := ParseTimeout(tt.value)
+		if (err == nil) != tt.ok || actual != tt.expected {
+			t.Errorf("%v, %v is unexpected for %s", actual, err, tt.value)
+		}
+	}
+}
+
+func TestServiceConfigTimeouts(t *testing.T) {
+	timeouts, err := ServiceConfig{Timeout: "20s", ConnectTimeout: "5", VerifyFactorTimeout: "3m"}.Timeouts()
+	expected := Timeouts{Request: 20 * time.Second, Connect: 5 * time.Second, VerifyFactor: 3 * time.Minute}
+	if err != nil || timeouts != expected {
+		t.Errorf("%#v, %v is unexpected", timeouts, err)
+	}
+	if _, err := (ServiceConfig{TLSHandshakeTimeout: "soon"}).Timeouts(); err == nil {
+		t.Error("invalid timeout is accepted")
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+)
+
+// TransportOptions returns the transport options of the service, the endpoint must be resolved for the pins.
+// Invalid timeouts are ignored, they are validated by Timeouts
+func (s ServiceConfig) TransportOptions() transport.Options {
+	timeouts, _ := s.Timeouts()
+	return transport.Options{
+		Proxy:               s.Proxy,
+		NoProxy:             s.NoProxy,
+		ProxyUser:           s.ProxyUser,
+		ProxyPassword:       s.ProxyPassword,
+		CABundle:            s.CABundle,
+		ClientCert:          s.ClientCert,
+		ClientKey:           s.ClientKey,
+		Pins:                s.Pins,
+		PinnedHost:          s.Endpoint,
+		InsecureSkipVerify:  s.InsecureSkipVerify,
+		Timeout:             timeouts.Request,
+		DialTimeout:         timeouts.Connect,
+		TLSHandshakeTimeout: timeouts.TLSHandshake,
+		HTTP2:               s.HTTP2,
+		IdleConnTimeout:     timeouts.IdleConn,
+		MaxIdleConns:        s.MaxIdleConns,
+		MaxIdleConnsPerHost: s.MaxIdleConnsPerHost,
+		PreferIPv4:          s.PreferIPv4,
+		Resolver:            s.DNSResolver,
+		DNSCache:            s.DNSCache,
+	}
+}
+
+// AppService returns the service of the app with the subdomain and the proxy of the app,
+// the secrets from Vault, keychains and environment variables are not applied
+func (c Config) AppService(app AppConfig) (ServiceConfig, error) {
+	service := ServiceConfig{}
+	name := app.ServiceName()
+	if s, ok := c.Service[name]; ok {
+		service = *s
+	} else if name != DefaultService {
+		return ServiceConfig{}, errors.Errorf(i18n.Sprintf("%s service is not exists", name))
+	}
+	if app.Subdomain != "" {
+		service.Subdomain = app.Subdomain
+	}
+	if app.Proxy != "" {
+		service.Proxy = app.Proxy
+	}
+	if app.NoProxy != "" {
+		service.NoProxy = app.NoProxy
+	}
+	if app.ProxyUser != "" {
+		service.ProxyUser = app.ProxyUser
+	}
+	return service, nil
+}
@@ -16,17 +16,43 @@ package cmd
 
 import (
 	"log"
+	"net/url"
+	"regexp"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
 	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
 )
 
 var appID string
+var appName string
 var roleArn string
 var principalArn string
-var duration int64
+var duration string
+var appRegion string
+var passwordPrompt bool
+var passwordPromptChanged bool
+var resolveAlias bool
+var resolveAliasChanged bool
+var passwordCommand string
+var apiVersion string
+var awsSDK string
+var awsPartition string
+var stsEndpoint string
+var appSTSRegion string
+var policy string
+var policyArns []string
+var appChainRoleArn string
+var appChainExternalID string
+var appChainSessionName string
+var appService string
+var appSubdomain string
+var appProxy string
+var appNoProxy string
+var appProxyUser string
 
 // configureCmd represents the configure command
 var configureCmd = &cobra.Command{
@@ -34,9 +60,8 @@ var configureCmd = &cobra.Command{
 	Short: "Add config to login to onelogin api",
 	Long:  `Configure is add config to login to onelogin api.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if awsProfile == "" {
-			awsProfile = "default"
-		}
+		passwordPromptChanged = cmd.Flags().Changed("password-prompt")
+		resolveAliasChanged = cmd.Flags().Changed("resolve-account-alias")
 		if err := initAppConfig(configFile, awsProfile); err != nil {
 			errorExit(err)
 		}
@@ -46,10 +71,29 @@ var configureCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(configureCmd)
 	configureCmd.Flags().StringVarP(&appID, "app-id", "", "", "OneLogin AppID")
+	configureCmd.Flags().StringVarP(&appName, "app-name", "", "", "OneLogin app name, the AppID is looked up on login")
 	configureCmd.Flags().StringVarP(&roleArn, "role-arn", "", "", "Login Target AWS Role ARN")
 	configureCmd.Flags().StringVarP(&principalArn, "principal-arn", "", "", "AWS Provider ARN connected to OneLogin AppID")
-	configureCmd.Flags().Int64VarP(&duration, "duration", "", 3600, "The session duration to assuming the role")
-	configureCmd.Flags().StringVarP(&awsProfile, "aws-profile", "", awsProfile, "aws profile name")
+	configureCmd.Flags().StringVarP(&duration, "duration", "", "1h", "The session duration to assuming the role (e.g. 3600, 1h, 45m)")
+	configureCmd.Flags().StringVarP(&appRegion, "aws-region", "", "", "AWS Region written to ~/.aws/config on login")
+	configureCmd.Flags().StringVarP(&passwordCommand, "password-command", "", "", "Command printing the password on login (e.g. \"pass show onelogin\")")
+	configureCmd.Flags().BoolVarP(&passwordPrompt, "password-prompt", "", false, "Always prompt the password on login instead of reading it from other sources")
+	configureCmd.Flags().StringVarP(&appService, "service", "", "", "Name of the service initialized by init --service to login with, e.g. sandbox")
+	configureCmd.Flags().StringVarP(&appSubdomain, "subdomain", "", "", "OneLogin subdomain of the profile instead of the service one, which uses the credentials saved by init --credentials-subdomain")
+	configureCmd.Flags().StringVarP(&appProxy, "proxy", "", "", "Proxy URL of the profile instead of the service one, or direct to connect without proxies")
+	configureCmd.Flags().StringVarP(&appNoProxy, "no-proxy", "", "", "Comma separated hosts connected without the proxy of the profile")
+	configureCmd.Flags().StringVarP(&appProxyUser, "proxy-user", "", "", "User of the proxy of the profile requiring basic auth")
+	configureCmd.Flags().StringVarP(&apiVersion, "api-version", "", "", "OneLogin SAML assertion API version (1, 2 or auto)")
+	configureCmd.Flags().StringVarP(&awsSDK, "aws-sdk", "", "", "Major version of AWS SDK for Go assuming the role (1 or 2)")
+	configureCmd.Flags().StringVarP(&awsPartition, "partition", "", "", "AWS partition of the role (aws, aws-us-gov or aws-cn), the one of --role-arn by default")
+	configureCmd.Flags().StringVarP(&stsEndpoint, "sts-endpoint", "", "", "STS endpoint URL assuming the role instead of the one of the partition")
+	configureCmd.Flags().StringVarP(&policy, "policy", "", "", "JSON or file://path of the session policy scoping down the role, or none to remove it")
+	configureCmd.Flags().StringSliceVarP(&policyArns, "policy-arn", "", nil, "ARN of managed session policy scoping down the role like arn:aws:iam::aws:policy/ReadOnlyAccess, repeatable. none removes them")
+	configureCmd.Flags().StringVarP(&appChainRoleArn, "chain-role-arn", "", "", "AWS Role ARN or alias assumed with the SAML-assumed role on login, or none to remove it")
+	configureCmd.Flags().StringVarP(&appChainExternalID, "chain-external-id", "", "", "External ID to assume the chained role")
+	configureCmd.Flags().StringVarP(&appChainSessionName, "chain-session-name", "", "", "Session name of the chained role, the username by default")
+	configureCmd.Flags().BoolVarP(&resolveAlias, "resolve-account-alias", "", false, "Resolve the AWS account alias by iam:ListAccountAliases on login to show it in pickers and status")
+	configureCmd.Flags().StringVarP(&appSTSRegion, "sts-region", "", "", "AWS Region of the STS endpoint assuming the role instead of the global one (e.g. ap-northeast-1)")
 }
 
 func initAppConfig(file string, profile string) error {
@@ -61,6 +105,11 @@ func initAppConfig(file string, profile string) error {
 	if !ok {
 		appConfig = &config.AppConfig{}
 	}
+	if appName != "" {
+		appConfig.AppName = appName
+		// the app name is used to look up the AppID, which is taken precedence over it
+		appConfig.AppID = ""
+	}
 	if appID != "" {
 		appConfig.AppID = appID
 	}
@@ -70,11 +119,87 @@ func initAppConfig(file string, profile string) error {
 	if principalArn != "" {
 		appConfig.PrincipalArn = principalArn
 	}
-	if duration != 0 {
-		appConfig.DurationSeconds = duration
+	if duration != "" {
+		seconds, err := config.ParseDuration(duration)
+		if err != nil {
+			return err
+		}
+		appConfig.DurationSeconds = seconds
+		appConfig.Duration = ""
+	}
+	if appRegion != "" {
+		appConfig.Region = appRegion
+	}
+	if passwordCommand != "" {
+		appConfig.PasswordCommand = passwordCommand
+	}
+	if apiVersion != "" {
+		if _, _, err := config.ParseAPIVersion(apiVersion); err != nil {
+			return err
+		}
+		appConfig.APIVersion = apiVersion
+	}
+	if awsSDK != "" {
+		if _, err := config.ParseAWSSDK(awsSDK); err != nil {
+			return err
+		}
+		appConfig.AWSSDK = awsSDK
+	}
+	if awsPartition != "" {
+		if _, err := partition.Lookup(awsPartition); err != nil {
+			return err
+		}
+		appConfig.Partition = awsPartition
+	}
+	if stsEndpoint != "" {
+		if err := validateSTSEndpoint(stsEndpoint); err != nil {
+			return err
+		}
+		appConfig.STSEndpoint = stsEndpoint
+	}
+	if appSTSRegion != "" {
+		if err := partition.ValidateRegion(appSTSRegion); err != nil {
+			return err
+		}
+		appConfig.STSRegion = appSTSRegion
+	}
+	if err := initPolicy(appConfig); err != nil {
+		return err
+	}
+	if err := initChainRole(appConfig); err != nil {
+		return err
+	}
+	if appSubdomain != "" {
+		appConfig.Subdomain = appSubdomain
+	}
+	if appProxy != "" {
+		if _, err := transport.ProxyFunc(transport.Options{Proxy: appProxy}); err != nil {
+			return err
+		}
+		appConfig.Proxy = appProxy
+	}
+	if appNoProxy != "" {
+		appConfig.NoProxy = appNoProxy
+	}
+	if appProxyUser != "" {
+		appConfig.ProxyUser = appProxyUser
+	}
+	if passwordPromptChanged {
+		appConfig.PasswordPrompt = passwordPrompt
 	}
-	serviceProfile := "default"
+	if resolveAliasChanged {
+		appConfig.ResolveAccountAlias = resolveAlias
+	}
+	if appService == config.DefaultService {
+		appConfig.Service = ""
+	} else if appService != "" {
+		appConfig.Service = appService
+	}
+	serviceProfile := appConfig.ServiceName()
 	if _, ok := c.Service[serviceProfile]; !ok {
+		if serviceProfile != config.DefaultService {
+			return errors.Errorf("There is no initialized %s service. Please run `onelogin-aws-connector init --service %s`", serviceProfile, serviceProfile)
+		}
 		return errors.Errorf("There is no initialized service. Please run `onelogin-aws-connector init`")
 	}
 	c.App[profile] = appConfig
@@ -86,3 +211,66 @@ func initAppConfig(file string, profile string) error {
 	}
 	return nil
 }
+
+// noPolicy is the --policy, --policy-arn and --chain-role-arn value removing the setting
+const noPolicy = "none"
+
+// initPolicy saves --policy and --policy-arn after validating them
+func initPolicy(app *config.AppConfig) error {
+	if policy == noPolicy {
+		app.Policy = ""
+	} else if policy != "" {
+		p, err := config.ParsePolicy(policy)
+		if err != nil {
+			return err
+		}
+		app.Policy = p
+	}
+	if len(policyArns) == 1 && policyArns[0] == noPolicy {
+		app.PolicyArns = nil
+	} else if len(policyArns) > 0 {
+		if err := config.ValidatePolicyArns(policyArns); err != nil {
+			return err
+		}
+		app.PolicyArns = policyArns
+	}
+	return nil
+}
+
+// chainSessionNamePattern is the one of RoleSessionName of sts:AssumeRole
+var chainSessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+
+// initChainRole saves the chained role, none removes it with the external ID and the session name
+func initChainRole(app *config.AppConfig) error {
+	if appChainRoleArn == noPolicy {
+		app.ChainRoleArn = ""
+		app.ChainExternalID = ""
+		app.ChainSessionName = ""
+		return nil
+	}
+	if appChainRoleArn != "" {
+		app.ChainRoleArn = appChainRoleArn
+	}
+	if appChainExternalID != "" {
+		app.ChainExternalID = appChainExternalID
+	}
+	if appChainSessionName != "" {
+		if !chainSessionNamePattern.MatchString(appChainSessionName) {
+			return errors.Errorf("%s is not valid session name, it must be 2 to 64 characters of alphanumerics and +=,.@-", appChainSessionName)
+		}
+		app.ChainSessionName = appChainSessionName
+	}
+	if app.ChainRoleArn == "" && (app.ChainExternalID != "" || app.ChainSessionName != "") {
+		return errors.Errorf("--chain-role-arn is required with --chain-external-id and --chain-session-name")
+	}
+	return nil
+}
+
+// validateSTSEndpoint requires the scheme, AWS SDK takes the endpoint without it as https but it is rather mistyped
+func validateSTSEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.Errorf("%s is not STS endpoint, it must be URL like https://sts.us-gov-east-1.amazonaws.com", endpoint)
+	}
+	return nil
+}
@@ -5,7 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
 )
 
 func TestConfigureCmdWithoutInit(t *testing.T) {
@@ -55,7 +58,9 @@ func TestConfigureCmdWithService(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "api-server"
     client_token = "client-token"
@@ -104,7 +109,9 @@ func TestConfigureCmdWithDefault(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "api-server"
     client_token = "client-token"
@@ -126,6 +133,370 @@ func TestConfigureCmdWithDefault(t *testing.T) {
 
 func resetConfigureFlags() {
 	appID = ""
+	appName = ""
 	roleArn = ""
 	principalArn = ""
+	appRegion = ""
+	passwordPrompt = false
+	passwordPromptChanged = false
+	resolveAlias = false
+	resolveAliasChanged = false
+	passwordCommand = ""
+	apiVersion = ""
+	awsSDK = ""
+	awsPartition = ""
+	stsEndpoint = ""
+	appSTSRegion = ""
+	policy = ""
+	policyArns = nil
+	appChainRoleArn = ""
+	appChainExternalID = ""
+	appChainSessionName = ""
+	appService = ""
+	appSubdomain = ""
+	appProxy = ""
+	appNoProxy = ""
+	appProxyUser = ""
+}
+
+func TestConfigureCmdWithRegion(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	appID = "app-id"
+	roleArn = "role-arn"
+	principalArn = "provider-arn"
+	appRegion = "ap-northeast-1"
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = "role-arn"
+    principal_arn = "provider-arn"
+    duration_seconds = 3600
+    region = "ap-northeast-1"
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestConfigureCmdWithPartition(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	defer resetConfigureFlags()
+	appID = "app-id"
+	roleArn = "arn:aws-us-gov:iam::123456789012:role/Role"
+	principalArn = "arn:aws-us-gov:iam::123456789012:saml-provider/onelogin"
+	awsPartition = "aws-iso"
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("unknown partition is saved")
+	}
+	awsPartition = "aws-us-gov"
+	stsEndpoint = "sts.us-gov-east-1.amazonaws.com"
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("STS endpoint without scheme is saved")
+	}
+	stsEndpoint = "https://sts.us-gov-east-1.amazonaws.com"
+	appSTSRegion = "gov-east"
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("invalid STS region is saved")
+	}
+	appSTSRegion = "us-gov-east-1"
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = "arn:aws-us-gov:iam::123456789012:role/Role"
+    principal_arn = "arn:aws-us-gov:iam::123456789012:saml-provider/onelogin"
+    duration_seconds = 3600
+    partition = "aws-us-gov"
+    sts_endpoint = "https://sts.us-gov-east-1.amazonaws.com"
+    sts_region = "us-gov-east-1"
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestConfigureCmdWithPolicy(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	defer resetConfigureFlags()
+	appID = "app-id"
+	roleArn = "role-arn"
+	principalArn = "provider-arn"
+	policy = `{"Version":`
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("invalid session policy is saved")
+	}
+	policy = `{ "Version": "2012-10-17" }`
+	policyArns = []string{"ReadOnlyAccess"}
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("invalid policy ARN is saved")
+	}
+	policyArns = []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := c.App["default"]
+	if app.Policy != `{"Version":"2012-10-17"}` || !reflect.DeepEqual(app.PolicyArns, policyArns) {
+		t.Errorf("%#v does not have the session policies", app)
+	}
+
+	resetConfigureFlags()
+	policy = noPolicy
+	policyArns = []string{noPolicy}
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err = config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app := c.App["default"]; app.Policy != "" || app.PolicyArns != nil {
+		t.Errorf("%#v has the session policies", app)
+	}
+}
+
+func TestConfigureCmdWithChainRole(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	defer resetConfigureFlags()
+	appID = "app-id"
+	roleArn = "role-arn"
+	principalArn = "provider-arn"
+	appChainExternalID = "external-id"
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("external ID is saved without the chained role")
+	}
+	appChainRoleArn = "arn:aws:iam::210987654321:role/Member"
+	appChainSessionName = "a"
+	if err := initAppConfig(file, "default"); err == nil {
+		t.Error("invalid session name is saved")
+	}
+	appChainSessionName = "user@example.com"
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app := c.App["default"]; app.ChainRoleArn != appChainRoleArn || app.ChainExternalID != "external-id" || app.ChainSessionName != "user@example.com" {
+		t.Errorf("%#v does not have the chained role", app)
+	}
+
+	resetConfigureFlags()
+	appChainRoleArn = noPolicy
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err = config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app := c.App["default"]; app.ChainRoleArn != "" || app.ChainExternalID != "" || app.ChainSessionName != "" {
+		t.Errorf("%#v has the chained role", app)
+	}
+}
+
+func TestConfigureCmdWithPasswordPrompt(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	appID = "app-id"
+	passwordPrompt = true
+	passwordPromptChanged = true
+	if err := initAppConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = ""
+    principal_arn = ""
+    duration_seconds = 3600
+    password_prompt = true
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestConfigureCmdWithSandboxService(t *testing.T) {
+	source, err := os.Open("fixtures/serviceconfig.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetConfigureFlags()
+	defer resetConfigureFlags()
+	appID = "app-id"
+	appService = "sandbox"
+	err = initAppConfig(file, "sandbox")
+	errorMessage := "There is no initialized sandbox service. Please run `onelogin-aws-connector init --service sandbox`"
+	if err == nil || err.Error() != errorMessage {
+		t.Errorf("%v is not equal to %s", err, errorMessage)
+	}
+
+	resetInitFlags()
+	defer resetInitFlags()
+	clientToken = "sandbox-client-token"
+	if err := initServiceConfig(file, "sandbox"); err != nil {
+		t.Fatal(err)
+	}
+	if err := initAppConfig(file, "sandbox"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.App["sandbox"].Service != "sandbox" || c.Service["sandbox"].ClientToken != "sandbox-client-token" || c.Service["default"].ClientToken != "client-token" {
+		t.Errorf("%#v and %#v are unexpected", c.App["sandbox"], c.Service)
+	}
+
+	// the default service is not written to the profile
+	appService = config.DefaultService
+	if err := initAppConfig(file, "sandbox"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ = config.Load(file); c.App["sandbox"].Service != "" {
+		t.Errorf("%s is not empty", c.App["sandbox"].Service)
+	}
 }
@@ -0,0 +1,61 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/console"
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+var destination string
+
+// consoleCmd represents the console command
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Print the URL to sign in to AWS console",
+	Long:  `Console is printing the URL to sign in to AWS console with the credentials created by login.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		creds, err := loginProfile(s, awsProfile)
+		s.Wipe()
+		if err != nil {
+			errorExit(err)
+		}
+		app := profileApp(c)
+		p, err := partition.Resolve(app.Partition, app.RoleArn)
+		if err != nil {
+			errorExit(err)
+		}
+		url, err := console.ForPartition(p).LoginURL(creds, destination, "onelogin-aws-connector")
+		if err != nil {
+			errorExit(err)
+		}
+		fmt.Println(url)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(consoleCmd)
+	consoleCmd.Flags().StringVarP(&destination, "destination", "", "", "AWS console URL to open after sign in, the console of the partition of the profile by default")
+}
@@ -0,0 +1,30 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+// applyEnvCredentials fills the client token and the client secret omitted in the config file with environment variables
+func applyEnvCredentials(service *config.ServiceConfig) {
+	for _, name := range config.EnvCredentials(service) {
+		if debug {
+			log.Printf("OneLogin API client credentials are read from %s\n", name)
+		}
+	}
+}
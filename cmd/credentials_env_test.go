@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestApplyEnvCredentials(t *testing.T) {
+	os.Setenv(config.ClientIDEnv, "env-client-id")
+	os.Setenv(config.ClientSecretEnv, "env-client-secret")
+	defer os.Unsetenv(config.ClientIDEnv)
+	defer os.Unsetenv(config.ClientSecretEnv)
+
+	service := &config.ServiceConfig{ClientToken: "local-token"}
+	applyEnvCredentials(service)
+	if service.ClientToken != "local-token" || service.ClientSecret != "env-client-secret" {
+		t.Errorf("%#v is unexpected", service)
+	}
+
+	c := &config.Config{Service: map[string]*config.ServiceConfig{
+		"default": {Endpoint: "api-server", Subdomain: "subdomain"},
+	}}
+	fetched, err := fetchService(c, config.AppConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ClientToken != "env-client-id" || fetched.ClientSecret != "env-client-secret" {
+		t.Errorf("%#v is unexpected", fetched)
+	}
+	if c.Service["default"].ClientSecret != "" {
+		t.Error("the secret is written to the config")
+	}
+}
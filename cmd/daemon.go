@@ -0,0 +1,706 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/daemon"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/ecs"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/notify"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/service"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+var daemonInterval time.Duration
+var daemonWithin time.Duration
+var daemonListen string
+var daemonSocket string
+var daemonNoNotify bool
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon [profiles...]",
+	Short: "Keep credentials of AWS profiles fresh in the background",
+	Long: `Daemon logs in to the profiles again before their credentials expire, until it is stopped by Ctrl-C or SIGTERM.
+The profiles are the logged in ones if they are not given.
+The passwords are asked once at the start and held in memory, and MFA is approved by the push notification of OneLogin Protect
+or the TOTP token of the agent, so the daemon never prompts after the start.
+With --listen, containers and tools get the credentials of the profiles from http://<listen>/credentials/<profile>
+by AWS_CONTAINER_CREDENTIALS_FULL_URI and AWS_CONTAINER_AUTHORIZATION_TOKEN printed at the start.
+With --metrics, the refreshes, the latency and the rate limit of OneLogin API and the validity of the credentials are served for Prometheus.
+A failed refresh is shown by a desktop notification with the reason and the command to fix it, unless --no-notify.
+refresh_within and quiet_hours of a profile in the config file change how early it is refreshed before the expiration,
+and the daily window like "22:00-07:00" when it is not refreshed, so MFA is not pushed at night.
+Other tools query the credentials, the profiles and the status, or refresh a profile by the JSON API on the unix domain socket,
+whose path is ONELOGIN_AWS_DAEMON_SOCK or ~/.onelogin-aws-connector/daemon.sock.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		profiles, err := daemonProfiles(c, cacheDir, args)
+		if err != nil {
+			errorExit(err)
+		}
+		schedules, err := daemonSchedules(c, profiles)
+		if err != nil {
+			errorExit(err)
+		}
+		// the socket is listened before the passwords are asked, so the second daemon stops without prompting
+		path := daemonSocketPath()
+		l, err := daemon.Listen(path)
+		if err != nil {
+			errorExit(err)
+		}
+		defer os.Remove(path)
+		s := newLoginSession(c)
+		defer s.Wipe()
+		s.refresh = true
+		if err := s.holdPasswords(profiles); err != nil {
+			l.Close()
+			errorExit(err)
+		}
+		daemonMode = true
+		ctx, cancel := context.WithCancel(context.Background())
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			cancel()
+			l.Close()
+		}()
+		login := loginProfile
+		if metricsListen != "" {
+			registry, stop, err := serveMetrics(metricsListen, profiles)
+			if err != nil {
+				l.Close()
+				errorExit(err)
+			}
+			defer stop()
+			login = countRefreshes(registry, login)
+		}
+		r := newRefresher(os.Stderr, s, profiles, login)
+		r.schedules = schedules
+		if !daemonNoNotify {
+			r.notify = notifyFailure
+		}
+		go daemon.New(r).Serve(l)
+		if daemonListen != "" {
+			if err := serveContainerCredentials(ctx, daemonListen, profiles); err != nil {
+				l.Close()
+				errorExit(err)
+			}
+		}
+		info(i18n.T("daemon is refreshing %d profiles\n"), len(profiles))
+		r.run(ctx)
+	},
+}
+
+// daemonStatusCmd represents the daemon status command
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the expiration, the next refresh and the last error of the profiles refreshed by the daemon",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := daemon.NewClient(daemonSocketPath()).Status()
+		if err != nil {
+			errorExit(daemonError(err))
+		}
+		if err := printDaemonStatus(os.Stdout, status, time.Now()); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+var daemonInstallPrint bool
+
+// daemonInstallCmd represents the daemon install command
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install [profiles...]",
+	Short: "Register the daemon to systemd, launchd or Task Scheduler of the user",
+	Long: `Install registers the daemon with --interval, --within and --listen to the service manager of the user,
+a systemd user unit on Linux, a launchd agent on macOS or a scheduled task on Windows.
+It is started at once and at every login, and started again 30 seconds after it fails.
+The output is appended to ~/.onelogin-aws-connector/daemon.log.
+The daemon runs with --no-prompt, so the profiles need password_command, the keychain or the agent for the password.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		profiles, err := daemonProfiles(c, cacheDir, args)
+		if err != nil {
+			errorExit(err)
+		}
+		for _, profile := range profiles {
+			if c.App[profile] != nil && c.App[profile].PasswordPrompt {
+				errorExit(errors.Errorf(i18n.T("%s has password_prompt, which cannot be refreshed by the daemon"), profile))
+			}
+		}
+		executable, err := os.Executable()
+		if err == nil {
+			executable, err = filepath.EvalSymlinks(executable)
+		}
+		if err != nil {
+			errorExit(err)
+		}
+		home, err := homedir.Dir()
+		if err != nil {
+			errorExit(err)
+		}
+		d := service.Definition{
+			Args: daemonServiceArgs(executable, args),
+			Log:  filepath.Join(filepath.Dir(cacheDir), "daemon.log"),
+		}
+		if daemonInstallPrint {
+			_, content, err := service.Render(runtime.GOOS, home, d)
+			if err != nil {
+				errorExit(err)
+			}
+			os.Stdout.Write(content)
+			return
+		}
+		path, err := service.Install(runtime.GOOS, home, d)
+		if err != nil {
+			errorExit(err)
+		}
+		info(i18n.T("Installed the daemon to %s, the log is %s\n"), path, d.Log)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonInstallCmd.Flags().BoolVarP(&daemonInstallPrint, "print", "", false, "Print the definition instead of writing and registering it")
+	daemonCmd.PersistentFlags().DurationVarP(&daemonInterval, "interval", "", time.Minute, "Check the expiration of the credentials at the interval")
+	daemonCmd.PersistentFlags().DurationVarP(&daemonWithin, "within", "", 15*time.Minute, "Refresh credentials which expire within the duration, unless refresh_within of the profile is configured")
+	daemonCmd.PersistentFlags().BoolVarP(&daemonNoNotify, "no-notify", "", false, "Do not show desktop notifications of failed refreshes")
+	daemonCmd.PersistentFlags().StringVarP(&metricsListen, "metrics", "", "", "Serve the metrics of Prometheus on the address and port, e.g. 127.0.0.1:9913")
+	daemonCmd.PersistentFlags().StringVarP(&daemonListen, "listen", "", "", "Serve the credentials by the ECS container credentials protocol on the loopback address and port, e.g. 127.0.0.1:9912")
+}
+
+// daemonProfiles returns the given profiles, or the logged in profiles if none is given
+func daemonProfiles(c *config.Config, cache string, args []string) ([]string, error) {
+	if len(args) == 0 {
+		profiles, err := expiringProfiles(c, cache, time.Now().Add(100*365*24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if len(profiles) == 0 {
+			return nil, errors.New(i18n.T("no profile is logged in, please login or give the profiles to refresh"))
+		}
+		return profiles, nil
+	}
+	for _, profile := range args {
+		if _, ok := c.App[profile]; !ok {
+			return nil, errors.Errorf(i18n.T("%s profile is not exists"), profile)
+		}
+	}
+	profiles := append([]string{}, args...)
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// holdPasswords gets the passwords of the profiles before the daemon stops prompting
+func (s *loginSession) holdPasswords(profiles []string) error {
+	for _, profile := range profiles {
+		app := s.conf.App[profile]
+		if app.PasswordPrompt {
+			return errors.Errorf(i18n.T("%s has password_prompt, which cannot be refreshed by the daemon"), profile)
+		}
+		password, err := s.profilePassword(profile, *app)
+		if err != nil {
+			return err
+		}
+		password.Wipe()
+	}
+	return nil
+}
+
+// daemonSocketPath returns ONELOGIN_AWS_DAEMON_SOCK, or the socket in the config directory
+func daemonSocketPath() string {
+	if path := os.Getenv(daemon.SocketEnv); path != "" {
+		return path
+	}
+	return daemonSocket
+}
+
+// daemonServiceArgs returns the command line of the daemon run by the service manager, which never prompts.
+// The profiles are not fixed if they are not given, so the daemon refreshes the logged in profiles at the start
+func daemonServiceArgs(executable string, profiles []string) []string {
+	args := []string{executable, "daemon", "--no-prompt", "--config", configFile,
+		"--interval", daemonInterval.String(), "--within", daemonWithin.String()}
+	if daemonListen != "" {
+		args = append(args, "--listen", daemonListen)
+	}
+	if daemonNoNotify {
+		args = append(args, "--no-notify")
+	}
+	if metricsListen != "" {
+		args = append(args, "--metrics", metricsListen)
+	}
+	if len(profiles) > 0 {
+		args = append(append(args, "--"), profiles...)
+	}
+	return args
+}
+
+// daemonError tells how to start the daemon if it is not running
+func daemonError(err error) error {
+	if _, ok := err.(*net.OpError); ok {
+		return errors.Wrapf(err, i18n.T("daemon is not running on %s, please run `onelogin-aws-connector daemon`"), daemonSocketPath())
+	}
+	return err
+}
+
+// printDaemonStatus prints the state of the profiles, the expiration is colored like the status command
+func printDaemonStatus(out io.Writer, status *daemon.Status, now time.Time) error {
+	if output == outputJSON {
+		return writeJSON(out, status)
+	}
+	fmt.Fprint(out, i18n.Sprintf("daemon %d is running since %s\n", status.PID, status.Started.Local().Format(time.RFC3339)))
+	format := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Local().Format(time.RFC3339)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tEXPIRATION\tNEXT REFRESH\tONELOGIN TOKEN\tLAST ERROR")
+	for _, p := range status.Profiles {
+		expiration := colorize(colorRed, "not logged in")
+		if p.Expiration != nil {
+			expiration = colorize(colorGreen, format(p.Expiration))
+			switch remaining := p.Expiration.Sub(now); {
+			case remaining <= 0:
+				expiration = colorize(colorRed, "expired")
+			case remaining < expiringThreshold:
+				expiration = colorize(colorYellow, format(p.Expiration))
+			}
+		}
+		token := format(p.TokenExpiration)
+		if p.TokenExpiration != nil && !now.Before(*p.TokenExpiration) {
+			token = colorize(colorYellow, "expired")
+		}
+		lastError := "-"
+		if p.Error != "" {
+			lastError = colorize(colorRed, p.Error)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Profile, expiration, format(p.NextRefresh), token, lastError)
+	}
+	return w.Flush()
+}
+
+// refresher refreshes the profiles at daemonInterval and by the requests to the API of the daemon
+type refresher struct {
+	out      io.Writer
+	session  *loginSession
+	profiles []string
+	login    func(*loginSession, string) (*sts.Credentials, error)
+	started  time.Time
+	// mu serializes refreshes, it is held during the logins
+	mu sync.Mutex
+	// state guards the results of the refreshes below, it is never held during a login so Status answers at once
+	state     sync.Mutex
+	refreshed map[string]time.Time
+	failures  map[string]error
+	// next is the next check of the expirations
+	next time.Time
+	// notify shows the failure of a refresh on the desktop if it is not nil
+	notify func(message string)
+	// schedules are the refresh schedules of the profiles, a missing one refreshes at --within at any time
+	schedules map[string]config.Schedule
+	// attempts are the numbers of the consecutive failures of the profiles, and retries are the times
+	// before which the failed profiles are not refreshed by the schedule
+	attempts map[string]int
+	retries  map[string]time.Time
+	// halted are the profiles failed by the password or the user, they are refreshed only by Refresh until the restart
+	halted map[string]bool
+}
+
+// maxDaemonBackoff is the longest delay of the retries of a failed profile
+const maxDaemonBackoff = 30 * time.Minute
+
+func newRefresher(out io.Writer, s *loginSession, profiles []string, login func(*loginSession, string) (*sts.Credentials, error)) *refresher {
+	return &refresher{
+		out:       out,
+		session:   s,
+		profiles:  profiles,
+		login:     login,
+		started:   time.Now(),
+		refreshed: map[string]time.Time{},
+		failures:  map[string]error{},
+		attempts:  map[string]int{},
+		retries:   map[string]time.Time{},
+		halted:    map[string]bool{},
+	}
+}
+
+// run refreshes the expiring profiles at daemonInterval until the context is done.
+// A failed profile is retried with backoff, and the failure is written to out
+func (r *refresher) run(ctx context.Context) {
+	ticker := time.NewTicker(daemonInterval)
+	defer ticker.Stop()
+	for {
+		r.refreshDue(time.Now())
+		r.state.Lock()
+		r.next = time.Now().Add(daemonInterval)
+		r.state.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshDue logs in to the profiles whose credentials expire before their deadlines or are not cached,
+// except the profiles in their quiet hours, backing off after failures or halted
+func (r *refresher) refreshDue(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, profile := range r.profiles {
+		if r.backingOff(profile, now) {
+			continue
+		}
+		deadline, ok := r.deadline(profile, now)
+		if !ok {
+			continue
+		}
+		creds, err := loadCachedCredentials(cacheDir, profile)
+		if err == nil && creds != nil && creds.Expiration != nil && creds.Expiration.After(deadline) {
+			continue
+		}
+		r.refresh(profile, now)
+	}
+	// SAML assertions expire within minutes, so they are generated again at the next refresh
+	r.session.forgetAssertions()
+}
+
+// backingOff reports whether the profile is halted or waiting for the retry at now
+func (r *refresher) backingOff(profile string, now time.Time) bool {
+	r.state.Lock()
+	defer r.state.Unlock()
+	return r.halted[profile] || now.Before(r.retries[profile])
+}
+
+// within returns how early the credentials of the profile are refreshed before they expire
+func (r *refresher) within(profile string) time.Duration {
+	if within := r.schedules[profile].Within; within > 0 {
+		return within
+	}
+	return daemonWithin
+}
+
+// deadline returns the time before which the credentials of the profile are refreshed at now, or false in its quiet hours.
+// The last check before the quiet hours refreshes the credentials expiring in them, so they last as long as possible
+func (r *refresher) deadline(profile string, now time.Time) (time.Time, bool) {
+	within := r.within(profile)
+	if quiet := r.schedules[profile].QuietHours; quiet != nil {
+		if quiet.Contains(now) {
+			return time.Time{}, false
+		}
+		if next := now.Add(daemonInterval); quiet.Contains(next) {
+			return quiet.Until(next).Add(within), true
+		}
+	}
+	return now.Add(within), true
+}
+
+// nextRefresh returns the next scheduled refresh of the profile after the next check and the retry of the failure,
+// r.state must be locked
+func (r *refresher) nextRefresh(profile string, expiration *time.Time) time.Time {
+	next := r.next
+	if expiration != nil && expiration.Add(-r.within(profile)).After(next) {
+		next = expiration.Add(-r.within(profile))
+	}
+	quiet := r.schedules[profile].QuietHours
+	if quiet != nil && quiet.Contains(next) && !quiet.Until(r.next).Equal(quiet.Until(next)) {
+		// the credentials expiring in later quiet hours are refreshed by the last check before them like deadline
+		next = quiet.Since(next).Add(-daemonInterval)
+		if next.Before(r.next) {
+			next = r.next
+		}
+	}
+	if retry := r.retries[profile]; retry.After(next) {
+		next = retry
+	}
+	if quiet != nil {
+		next = quiet.Until(next)
+	}
+	return next
+}
+
+// daemonSchedules returns the refresh schedules of the profiles configured by refresh_within and quiet_hours
+func daemonSchedules(c *config.Config, profiles []string) (map[string]config.Schedule, error) {
+	schedules := map[string]config.Schedule{}
+	for _, profile := range profiles {
+		app, ok := c.App[profile]
+		if !ok {
+			continue
+		}
+		schedule, err := app.Schedule()
+		if err != nil {
+			return nil, errors.Wrapf(err, i18n.T("%s has invalid refresh schedule"), profile)
+		}
+		schedules[profile] = schedule
+	}
+	return schedules, nil
+}
+
+// failureNotification tells the reason of the failed refresh and the command to fix it
+func failureNotification(profile string, err error) string {
+	var prompt *promptRequiredError
+	var netErr net.Error
+	var fix string
+	switch {
+	case errors.As(err, &prompt):
+		fix = i18n.Sprintf("run `onelogin-aws-connector login --aws-profile %s`", profile)
+	case errors.Is(err, samlassertion.ErrInvalidCredentials), errors.Is(err, samlassertion.ErrPasswordExpired):
+		fix = i18n.T("update the password and restart `onelogin-aws-connector daemon`")
+	case errors.Is(err, samlassertion.ErrUserLocked):
+		fix = i18n.T("ask the administrator of OneLogin to unlock the user and restart `onelogin-aws-connector daemon`")
+	case errors.Is(err, samlassertion.ErrRateLimited), errors.Is(err, apiresponse.ErrServerError), errors.As(err, &netErr):
+		fix = i18n.T("the daemon retries later, run `onelogin-aws-connector daemon status` to check the next refresh")
+	default:
+		fix = i18n.Sprintf("run `onelogin-aws-connector login --aws-profile %s` to see the details", profile)
+	}
+	return i18n.Sprintf("%s failed to refresh: %v\n%s", profile, err, fix)
+}
+
+// notifyFailure shows the notification, the failure to show it is logged only with --verbose
+func notifyFailure(message string) {
+	if err := notify.Send(message); err != nil && debug {
+		log.Println(err)
+	}
+}
+
+// haltsRefresh reports whether the failure repeats until the password or the user is fixed,
+// retrying it would only lock the user or flood it with MFA
+func haltsRefresh(err error) bool {
+	return errors.Is(err, samlassertion.ErrInvalidCredentials) || errors.Is(err, samlassertion.ErrUserLocked) ||
+		errors.Is(err, samlassertion.ErrPasswordExpired)
+}
+
+// daemonBackoff returns the delay of the retry after the consecutive failures, doubling daemonInterval up to maxDaemonBackoff
+func daemonBackoff(attempts int) time.Duration {
+	delay := daemonInterval
+	for i := 1; i < attempts && delay < maxDaemonBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxDaemonBackoff {
+		return maxDaemonBackoff
+	}
+	return delay
+}
+
+// refresh logs in to the profile at now and records the result, r.mu must be locked
+func (r *refresher) refresh(profile string, now time.Time) error {
+	_, err := r.login(r.session, profile)
+	r.state.Lock()
+	defer r.state.Unlock()
+	if err != nil {
+		// the notification is shown once until the failure changes or the profile is refreshed
+		if last, ok := r.failures[profile]; r.notify != nil && (!ok || last.Error() != err.Error()) {
+			go r.notify(failureNotification(profile, err))
+		}
+		r.failures[profile] = err
+		r.attempts[profile]++
+		r.retries[profile] = now.Add(daemonBackoff(r.attempts[profile]))
+		if haltsRefresh(err) {
+			r.halted[profile] = true
+		}
+		fmt.Fprint(r.out, i18n.Sprintf("%s failed to refresh %s: %v\n", now.Format(time.RFC3339), profile, err))
+		return err
+	}
+	delete(r.failures, profile)
+	delete(r.attempts, profile)
+	delete(r.retries, profile)
+	r.refreshed[profile] = now
+	info(i18n.T("%s refreshed %s\n"), now.Format(time.RFC3339), profile)
+	return nil
+}
+
+// Credentials returns the cached credentials of the profile
+func (r *refresher) Credentials(profile string) (*sts.Credentials, error) {
+	creds, err := daemonCredentials(r.profiles)(profile)
+	if err == nil && creds == nil {
+		err = errors.Errorf(i18n.T("%s is not refreshed by the daemon"), profile)
+	}
+	return creds, err
+}
+
+// Profiles returns the profiles refreshed by the daemon
+func (r *refresher) Profiles() []string {
+	return r.profiles
+}
+
+// Refresh logs in to the profile now, even if the credentials do not expire soon, the profile backing off or halted
+func (r *refresher) Refresh(profile string) error {
+	if !r.serves(profile) {
+		return errors.Errorf(i18n.T("%s is not refreshed by the daemon"), profile)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Lock()
+	delete(r.halted, profile)
+	r.state.Unlock()
+	err := r.refresh(profile, time.Now())
+	r.session.forgetAssertions()
+	return err
+}
+
+// tokenExpiration returns the expiration of the cached OneLogin access token of the profile, or nil if it is not cached
+func (r *refresher) tokenExpiration(profile string) *time.Time {
+	if r.session.conf == nil || r.session.conf.App[profile] == nil {
+		return nil
+	}
+	service, err := r.session.conf.AppService(*r.session.conf.App[profile])
+	if err != nil {
+		return nil
+	}
+	clientToken, _ := subdomainCredentials(service)
+	value, err := onelogin.FileStore{Dir: oneloginCacheDir(cacheDir)}.Load(clientToken)
+	if err != nil || value == nil || value.AccessExpiresAt.IsZero() {
+		return nil
+	}
+	return &value.AccessExpiresAt
+}
+
+func (r *refresher) serves(profile string) bool {
+	for _, p := range r.profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the expiration, the last refresh and the next refresh of the profiles
+func (r *refresher) Status() *daemon.Status {
+	status := &daemon.Status{PID: os.Getpid(), Started: r.started, Profiles: []daemon.ProfileStatus{}}
+	for _, profile := range r.profiles {
+		p := daemon.ProfileStatus{Profile: profile, TokenExpiration: r.tokenExpiration(profile)}
+		if creds, err := loadCachedCredentials(cacheDir, profile); err == nil && creds != nil {
+			p.Expiration = creds.Expiration
+		}
+		r.state.Lock()
+		if !r.next.IsZero() && !r.halted[profile] {
+			next := r.nextRefresh(profile, p.Expiration)
+			p.NextRefresh = &next
+		}
+		if refreshed, ok := r.refreshed[profile]; ok {
+			p.Refreshed = &refreshed
+		}
+		if err, ok := r.failures[profile]; ok {
+			p.Error = err.Error()
+		}
+		r.state.Unlock()
+		status.Profiles = append(status.Profiles, p)
+	}
+	return status
+}
+
+// serveContainerCredentials serves the credentials of the profiles on the address until the context is done,
+// and prints the URI and the token for the container credentials provider of SDKs.
+// The token is AWS_CONTAINER_AUTHORIZATION_TOKEN if it is set, so containers can be configured before the start
+func serveContainerCredentials(ctx context.Context, listen string, profiles []string) error {
+	address, err := serverAddress(listen)
+	if err != nil {
+		return err
+	}
+	token := os.Getenv(ecs.TokenEnv)
+	if token == "" {
+		if token, err = ecs.NewToken(); err != nil {
+			return err
+		}
+	}
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	h := ecs.New(token, daemonCredentials(profiles))
+	h.Health = credentialsHealth(profiles)
+	srv := &http.Server{Handler: h}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go srv.Serve(l)
+	uri := fmt.Sprintf("http://%s%s%s", l.Addr(), ecs.CredentialsPath, profiles[0])
+	info(i18n.T("daemon is serving credentials on http://%s%s<profile>\n"), l.Addr(), ecs.CredentialsPath)
+	fmt.Printf("%s=%s; export %s;\n", ecs.URIEnv, uri, ecs.URIEnv)
+	fmt.Printf("%s=%s; export %s;\n", ecs.TokenEnv, token, ecs.TokenEnv)
+	return nil
+}
+
+// credentialsHealth returns the health check, which fails if the cached credentials of a profile are expired
+func credentialsHealth(profiles []string) func() error {
+	credentials := daemonCredentials(profiles)
+	return func() error {
+		for _, profile := range profiles {
+			if _, err := credentials(profile); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// daemonCredentials returns the cached credentials of the profiles refreshed by the daemon,
+// the other profiles are not served
+func daemonCredentials(profiles []string) ecs.CredentialsFunc {
+	served := map[string]bool{}
+	for _, profile := range profiles {
+		served[profile] = true
+	}
+	return func(profile string) (*sts.Credentials, error) {
+		if !served[profile] {
+			return nil, nil
+		}
+		creds, err := loadCachedCredentials(cacheDir, profile)
+		if err != nil {
+			return nil, err
+		}
+		if creds == nil || creds.Expiration == nil || !time.Now().Before(*creds.Expiration) {
+			return nil, errors.Errorf(i18n.T("the credentials of %s are not refreshed yet"), profile)
+		}
+		return creds, nil
+	}
+}
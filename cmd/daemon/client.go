@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/ipc"
+)
+
+// timeout of a request, it is long enough for Refresh waiting for the approval of OneLogin Protect
+const timeout = 5 * time.Minute
+
+// Client talks to the daemon over the unix domain socket
+type Client struct {
+	Path string
+}
+
+// NewClient creates a Client of the daemon listening on the path
+func NewClient(path string) *Client {
+	return &Client{Path: path}
+}
+
+// Credentials returns the credentials of the profile refreshed by the daemon
+func (c *Client) Credentials(profile string) (*Credentials, error) {
+	res, err := c.call(&request{Op: opCredentials, Profile: profile})
+	if err != nil {
+		return nil, err
+	}
+	return res.Credentials, nil
+}
+
+// Profiles returns the profiles refreshed by the daemon
+func (c *Client) Profiles() ([]string, error) {
+	res, err := c.call(&request{Op: opProfiles})
+	if err != nil {
+		return nil, err
+	}
+	return res.Profiles, nil
+}
+
+// Refresh makes the daemon log in to the profile now
+func (c *Client) Refresh(profile string) error {
+	_, err := c.call(&request{Op: opRefresh, Profile: profile})
+	return err
+}
+
+// Status returns the state of the daemon
+func (c *Client) Status() (*Status, error) {
+	res, err := c.call(&request{Op: opStatus})
+	if err != nil {
+		return nil, err
+	}
+	return res.Status, nil
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	conn, err := ipc.Dial(c.Path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var res response
+	if err := json.Unmarshal(line, &res); err != nil {
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	return &res, nil
+}
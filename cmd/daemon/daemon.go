@@ -0,0 +1,161 @@
+// Package daemon is the API of the refresh daemon over the unix domain socket,
+// each connection sends a line of JSON request and receives a line of JSON response
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/ipc"
+)
+
+// SocketEnv is the environment variable of the daemon socket path
+const SocketEnv = "ONELOGIN_AWS_DAEMON_SOCK"
+
+// operations of the daemon protocol
+const (
+	opCredentials = "get_credentials"
+	opProfiles    = "list_profiles"
+	opRefresh     = "refresh"
+	opStatus      = "status"
+)
+
+// Backend is the daemon answering the requests
+type Backend interface {
+	// Credentials returns the credentials of the profile refreshed by the daemon
+	Credentials(profile string) (*sts.Credentials, error)
+	// Profiles returns the profiles refreshed by the daemon
+	Profiles() []string
+	// Refresh logs in to the profile now
+	Refresh(profile string) error
+	// Status returns the state of the daemon and the profiles
+	Status() *Status
+}
+
+// Credentials is the credentials of a profile, whose fields are the ones of the credential_process output
+type Credentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// Status represents the state of the daemon
+type Status struct {
+	PID      int             `json:"pid"`
+	Started  time.Time       `json:"started"`
+	Profiles []ProfileStatus `json:"profiles"`
+}
+
+// ProfileStatus represents the state of a profile refreshed by the daemon
+type ProfileStatus struct {
+	Profile    string     `json:"profile"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	Refreshed  *time.Time `json:"refreshed,omitempty"`
+	// NextRefresh is when the daemon logs in to the profile next time
+	NextRefresh *time.Time `json:"next_refresh,omitempty"`
+	// TokenExpiration is the expiration of the cached access token of OneLogin API
+	TokenExpiration *time.Time `json:"token_expiration,omitempty"`
+	// Error is the failure of the last refresh, which is cleared by a successful one
+	Error string `json:"error,omitempty"`
+}
+
+// request is a line of JSON sent to the daemon
+type request struct {
+	Op      string `json:"op"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// response is a line of JSON returned from the daemon
+type response struct {
+	Error       string       `json:"error,omitempty"`
+	Credentials *Credentials `json:"credentials,omitempty"`
+	Profiles    []string     `json:"profiles,omitempty"`
+	Status      *Status      `json:"status,omitempty"`
+}
+
+// Server answers the requests with the backend
+type Server struct {
+	backend Backend
+}
+
+// New creates a Server of the backend
+func New(backend Backend) *Server {
+	return &Server{backend: backend}
+}
+
+// Listen creates the endpoint accessible only by the user, a unix domain socket or a named pipe on Windows
+func Listen(path string) (net.Listener, error) {
+	l, err := ipc.Listen(path)
+	if err == ipc.ErrInUse {
+		return nil, errors.Errorf("daemon is already running on %s", path)
+	}
+	return l, err
+}
+
+// Serve accepts connections until the listener is closed
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		writeResponse(conn, &response{Error: err.Error()})
+		return
+	}
+	writeResponse(conn, s.do(&req))
+}
+
+func (s *Server) do(req *request) *response {
+	switch req.Op {
+	case opCredentials:
+		creds, err := s.backend.Credentials(req.Profile)
+		if err != nil {
+			return &response{Error: err.Error()}
+		}
+		if creds == nil || creds.AccessKeyId == nil || creds.SecretAccessKey == nil || creds.SessionToken == nil || creds.Expiration == nil {
+			return &response{Error: "the credentials of " + req.Profile + " are not available"}
+		}
+		return &response{Credentials: &Credentials{
+			AccessKeyID:     *creds.AccessKeyId,
+			SecretAccessKey: *creds.SecretAccessKey,
+			SessionToken:    *creds.SessionToken,
+			Expiration:      *creds.Expiration,
+		}}
+	case opProfiles:
+		return &response{Profiles: s.backend.Profiles()}
+	case opRefresh:
+		if err := s.backend.Refresh(req.Profile); err != nil {
+			return &response{Error: err.Error()}
+		}
+		return &response{}
+	case opStatus:
+		return &response{Status: s.backend.Status()}
+	}
+	return &response{Error: "unknown operation " + req.Op}
+}
+
+func writeResponse(conn net.Conn, res *response) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		data, _ = json.Marshal(&response{Error: err.Error()})
+	}
+	conn.Write(append(data, '\n'))
+}
@@ -0,0 +1,126 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// backendMock refreshes only "default"
+type backendMock struct {
+	refreshed []string
+}
+
+func (b *backendMock) Credentials(profile string) (*sts.Credentials, error) {
+	switch profile {
+	case "default":
+		return &sts.Credentials{
+			AccessKeyId:     aws.String("AKIAEXAMPLE"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("session"),
+			Expiration:      aws.Time(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		}, nil
+	case "incomplete":
+		return &sts.Credentials{}, nil
+	}
+	return nil, errors.Errorf("%s is not refreshed by the daemon", profile)
+}
+
+func (b *backendMock) Profiles() []string {
+	return []string{"default"}
+}
+
+func (b *backendMock) Refresh(profile string) error {
+	if profile != "default" {
+		return errors.Errorf("%s is not refreshed by the daemon", profile)
+	}
+	b.refreshed = append(b.refreshed, profile)
+	return nil
+}
+
+func (b *backendMock) Status() *Status {
+	return &Status{PID: 1, Started: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Profiles: []ProfileStatus{{Profile: "default", Error: "failed"}}}
+}
+
+func TestDaemon(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "daemon.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	b := &backendMock{}
+	go New(b).Serve(l)
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("%v, %v is unexpected", info, err)
+	}
+	if _, err := Listen(path); err == nil {
+		t.Error("listening twice needs to return error")
+	}
+	c := NewClient(path)
+	creds, err := c.Credentials("default")
+	expected := &Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "session", Expiration: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	if err != nil || !reflect.DeepEqual(creds, expected) {
+		t.Errorf("%+v, %v is not equal %+v", creds, err, expected)
+	}
+	if _, err := c.Credentials("none"); err == nil || err.Error() != "none is not refreshed by the daemon" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if _, err := c.Credentials("incomplete"); err == nil {
+		t.Error("incomplete credentials need to return error")
+	}
+	if profiles, err := c.Profiles(); err != nil || !reflect.DeepEqual(profiles, []string{"default"}) {
+		t.Errorf("%v, %v are not the profiles", profiles, err)
+	}
+	if err := c.Refresh("default"); err != nil || !reflect.DeepEqual(b.refreshed, []string{"default"}) {
+		t.Errorf("%v, %v is not refreshed", b.refreshed, err)
+	}
+	if err := c.Refresh("none"); err == nil {
+		t.Error("refreshing the unknown profile needs to return error")
+	}
+	if status, err := c.Status(); err != nil || !reflect.DeepEqual(status, b.Status()) {
+		t.Errorf("%+v, %v is not equal %+v", status, err, b.Status())
+	}
+	if _, err := c.call(&request{Op: "unknown"}); err == nil || err.Error() != "unknown operation unknown" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestDaemonInvalidRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "daemon.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go New(&backendMock{}).Serve(l)
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("not json\n"))
+	line, err := ioutil.ReadAll(conn)
+	if err != nil || len(line) == 0 || line[0] != '{' {
+		t.Errorf("%s, %v is not the error response", line, err)
+	}
+}
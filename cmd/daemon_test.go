@@ -0,0 +1,472 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/daemon"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// writeCachedExpiration writes the cached credentials of the profile which expire at the time
+func writeCachedExpiration(t *testing.T, cache string, profile string, expiration time.Time) {
+	content := fmt.Sprintf("Expiration = %s\n", expiration.UTC().Format(time.RFC3339))
+	if err := ioutil.WriteFile(awsCacheFile(cache, profile), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemonProfiles(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemonProfiles(c, cache, nil); err == nil {
+		t.Error("no logged in profile is refreshed")
+	}
+	writeCachedExpiration(t, cache, "other", time.Now().Add(-time.Hour))
+	if profiles, err := daemonProfiles(c, cache, nil); err != nil || !reflect.DeepEqual(profiles, []string{"other"}) {
+		t.Errorf("%v, %v is not the logged in profiles", profiles, err)
+	}
+	if profiles, err := daemonProfiles(c, cache, []string{"other", "default"}); err != nil || !reflect.DeepEqual(profiles, []string{"default", "other"}) {
+		t.Errorf("%v, %v is not the given profiles", profiles, err)
+	}
+	if _, err := daemonProfiles(c, cache, []string{"none"}); err == nil || err.Error() != "none profile is not exists" {
+		t.Errorf("%v is not equal none profile is not exists", err)
+	}
+}
+
+func TestRefreshDue(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	now := time.Now()
+	writeCachedExpiration(t, cache, "expiring", now.Add(5*time.Minute))
+	writeCachedExpiration(t, cache, "fresh", now.Add(time.Hour))
+	s := newLoginSession(nil)
+	s.assertions["app"] = secret.Bytes("SAML")
+	refreshed := []string{}
+	out := &bytes.Buffer{}
+	r := newRefresher(out, s, []string{"expiring", "fresh", "failing", "new"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		refreshed = append(refreshed, profile)
+		if profile == "failing" {
+			return nil, errors.New("MFA token is required, but the daemon cannot prompt")
+		}
+		return &sts.Credentials{}, nil
+	})
+	r.refreshDue(now)
+	if !reflect.DeepEqual(refreshed, []string{"expiring", "failing", "new"}) {
+		t.Errorf("%v are refreshed", refreshed)
+	}
+	if !strings.Contains(out.String(), "failed to refresh failing: MFA token is required, but the daemon cannot prompt") {
+		t.Errorf("%q does not tell the failure", out.String())
+	}
+	if len(s.assertions) != 0 {
+		t.Errorf("%v are kept until the next refresh", s.assertions)
+	}
+}
+
+func TestRunDaemon(t *testing.T) {
+	defer func(interval time.Duration) { daemonInterval = interval }(daemonInterval)
+	daemonInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	newRefresher(ioutil.Discard, newLoginSession(nil), []string{"never-logged-in"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		count++
+		if count == 3 {
+			cancel()
+		}
+		return nil, errors.New("failed")
+	}).run(ctx)
+	if count != 3 {
+		t.Errorf("the daemon is stopped after %d refreshes", count)
+	}
+}
+
+func TestRefresherAPI(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	fail := true
+	r := newRefresher(ioutil.Discard, newLoginSession(nil), []string{"default", "other"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		if fail {
+			return nil, errors.New("failed")
+		}
+		writeCachedExpiration(t, cache, profile, expiration)
+		return &sts.Credentials{}, nil
+	})
+	if err := r.Refresh("none"); err == nil || err.Error() != "none is not refreshed by the daemon" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if _, err := r.Credentials("none"); err == nil || err.Error() != "none is not refreshed by the daemon" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if err := r.Refresh("default"); err == nil {
+		t.Error("the failed refresh needs to return error")
+	}
+	status := r.Status()
+	if status.PID != os.Getpid() || len(status.Profiles) != 2 || status.Profiles[0].Error != "failed" || status.Profiles[0].Refreshed != nil {
+		t.Errorf("%+v is not the failure", status)
+	}
+	fail = false
+	if err := r.Refresh("default"); err != nil {
+		t.Fatal(err)
+	}
+	r.next = time.Now()
+	if creds, err := r.Credentials("default"); err != nil || !creds.Expiration.Equal(expiration) {
+		t.Errorf("%v, %v is not the refreshed credentials", creds, err)
+	}
+	p := r.Status().Profiles[0]
+	if p.Error != "" || p.Refreshed == nil || p.Expiration == nil || !p.Expiration.Equal(expiration) {
+		t.Errorf("%+v is not the refreshed profile", p)
+	}
+	if p.NextRefresh == nil || !p.NextRefresh.Equal(expiration.Add(-daemonWithin)) {
+		t.Errorf("%v is not the next refresh before the expiration", p.NextRefresh)
+	}
+	if p := r.Status().Profiles[1]; p.NextRefresh == nil || !p.NextRefresh.Equal(r.next) {
+		t.Errorf("%v is not the next check of the profile not logged in", p.NextRefresh)
+	}
+	if !reflect.DeepEqual(r.Profiles(), []string{"default", "other"}) {
+		t.Errorf("%v are not the profiles", r.Profiles())
+	}
+}
+
+func TestRefreshSchedules(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	now := time.Date(2026, 1, 2, 21, 59, 30, 0, time.Local)
+	writeCachedExpiration(t, cache, "early", now.Add(20*time.Minute))
+	writeCachedExpiration(t, cache, "default", now.Add(20*time.Minute))
+	writeCachedExpiration(t, cache, "night", now.Add(2*time.Hour))
+	writeCachedExpiration(t, cache, "sleeping", now.Add(time.Minute))
+	refreshed := []string{}
+	r := newRefresher(ioutil.Discard, newLoginSession(nil), []string{"early", "default", "night", "sleeping"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		refreshed = append(refreshed, profile)
+		return &sts.Credentials{}, nil
+	})
+	night := &config.QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour}
+	r.schedules = map[string]config.Schedule{
+		"early":    {Within: 30 * time.Minute},
+		"night":    {QuietHours: night},
+		"sleeping": {QuietHours: &config.QuietHours{Start: 21 * time.Hour, End: 23 * time.Hour}},
+	}
+	r.refreshDue(now)
+	if !reflect.DeepEqual(refreshed, []string{"early", "night"}) {
+		t.Errorf("%v are refreshed", refreshed)
+	}
+	r.next = now.Add(time.Minute)
+	if next := r.nextRefresh("night", nil); !next.Equal(time.Date(2026, 1, 3, 7, 0, 0, 0, time.Local)) {
+		t.Errorf("%v is not the end of the quiet hours", next)
+	}
+	expiration := now.Add(time.Hour)
+	if next := r.nextRefresh("early", &expiration); !next.Equal(now.Add(30 * time.Minute)) {
+		t.Errorf("%v is not before refresh_within of the expiration", next)
+	}
+	r.next = now.Add(-time.Hour)
+	expiration = time.Date(2026, 1, 2, 22, 30, 0, 0, time.Local).Add(daemonWithin)
+	if next := r.nextRefresh("night", &expiration); !next.Equal(time.Date(2026, 1, 2, 22, 0, 0, 0, time.Local).Add(-daemonInterval)) {
+		t.Errorf("%v is not the last check before the quiet hours", next)
+	}
+	if deadline, ok := r.deadline("night", time.Date(2026, 1, 2, 22, 0, 0, 0, time.Local).Add(-daemonInterval)); !ok || deadline.Before(expiration) {
+		t.Errorf("%v does not refresh the credentials at the last check", deadline)
+	}
+
+	c := &config.Config{App: map[string]*config.AppConfig{
+		"prod":    {RefreshWithin: "1h", QuietHours: "22:00-07:00", Duration: "2h"},
+		"invalid": {QuietHours: "night"},
+	}}
+	schedules, err := daemonSchedules(c, []string{"prod", "unknown"})
+	if err != nil || schedules["prod"].Within != time.Hour || *schedules["prod"].QuietHours != *night {
+		t.Errorf("%v, %v are not the schedules", schedules, err)
+	}
+	if _, err := daemonSchedules(c, []string{"invalid"}); err == nil || !strings.Contains(err.Error(), "invalid has invalid refresh schedule") {
+		t.Errorf("%v does not tell the invalid profile", err)
+	}
+}
+
+func TestCredentialsHealth(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	writeCachedExpiration(t, cache, "fresh", time.Now().Add(time.Hour))
+	writeCachedExpiration(t, cache, "expired", time.Now().Add(-time.Hour))
+	if err := credentialsHealth([]string{"fresh"})(); err != nil {
+		t.Errorf("%v is unhealthy", err)
+	}
+	if err := credentialsHealth([]string{"fresh", "expired"})(); err == nil || err.Error() != "the credentials of expired are not refreshed yet" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestPrintDaemonStatus(t *testing.T) {
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)
+	fresh := now.Add(time.Hour)
+	expiring := now.Add(5 * time.Minute)
+	next := now.Add(45 * time.Minute)
+	status := &daemon.Status{PID: 42, Started: now, Profiles: []daemon.ProfileStatus{
+		{Profile: "fresh", Expiration: &fresh, NextRefresh: &next, TokenExpiration: &fresh},
+		{Profile: "failing", Expiration: &expiring, Error: "MFA token is required, but the daemon cannot prompt"},
+		{Profile: "new"},
+	}}
+	out := &bytes.Buffer{}
+	if err := printDaemonStatus(out, status, now); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"daemon 42 is running since " + now.Format(time.RFC3339),
+		"fresh    " + fresh.Format(time.RFC3339) + "  " + next.Format(time.RFC3339) + "  " + fresh.Format(time.RFC3339) + "  -",
+		"MFA token is required, but the daemon cannot prompt",
+		"new      not logged in",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("%q does not contain %q", out.String(), expected)
+		}
+	}
+	defer func() { output = outputText }()
+	output = outputJSON
+	out.Reset()
+	if err := printDaemonStatus(out, status, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"next_refresh"`) || !strings.Contains(out.String(), `"pid": 42`) {
+		t.Errorf("%s is not the status", out.String())
+	}
+}
+
+func TestFailureNotification(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&promptRequiredError{name: "MFA token", daemon: true}, "prod failed to refresh: MFA token is required, but the daemon cannot prompt\nrun `onelogin-aws-connector login --aws-profile prod`"},
+		{errors.Wrap(samlassertion.ErrInvalidCredentials, "the username or the password is wrong"), "update the password and restart `onelogin-aws-connector daemon`"},
+		{errors.Wrap(apiresponse.ErrServerError, "[503] Service Unavailable"), "the daemon retries later"},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "the daemon retries later"},
+		{errors.New("role is not assigned"), "run `onelogin-aws-connector login --aws-profile prod` to see the details"},
+	}
+	for _, test := range tests {
+		if actual := failureNotification("prod", test.err); !strings.Contains(actual, test.expected) {
+			t.Errorf("%q does not contain %q", actual, test.expected)
+		}
+	}
+}
+
+func TestRefreshBackoff(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	failures := map[string]error{
+		"flaky":  errors.Wrap(apiresponse.ErrServerError, "[503] Service Unavailable"),
+		"locked": errors.Wrap(samlassertion.ErrUserLocked, "User is Locked"),
+	}
+	refreshed := []string{}
+	r := newRefresher(ioutil.Discard, newLoginSession(nil), []string{"flaky", "locked"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		refreshed = append(refreshed, profile)
+		return nil, failures[profile]
+	})
+	now := time.Now()
+	for _, check := range []time.Duration{0, time.Minute, 2 * time.Minute, 3 * time.Minute, 7 * time.Minute} {
+		r.refreshDue(now.Add(check))
+	}
+	if expected := []string{"flaky", "locked", "flaky", "flaky", "flaky"}; !reflect.DeepEqual(refreshed, expected) {
+		t.Errorf("%v are refreshed, not %v", refreshed, expected)
+	}
+	if retry := r.retries["flaky"]; !retry.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("%v is not the doubled backoff", retry)
+	}
+	r.next = now
+	if p := r.Status().Profiles[1]; p.NextRefresh != nil || p.Error == "" {
+		t.Errorf("%+v is not halted", p)
+	}
+	delete(failures, "locked")
+	if err := r.Refresh("locked"); err != nil || r.halted["locked"] {
+		t.Errorf("the explicit refresh does not resume the halted profile: %v", err)
+	}
+	if len(r.attempts) != 1 || r.attempts["flaky"] != 4 {
+		t.Errorf("%v are not the attempts", r.attempts)
+	}
+	if d := daemonBackoff(100); d != maxDaemonBackoff {
+		t.Errorf("%v is not capped", d)
+	}
+}
+
+func TestRefresherStatusDuringLogin(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := newRefresher(ioutil.Discard, newLoginSession(nil), []string{"prod"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		close(started)
+		<-release
+		return nil, errors.New("MFA is not approved")
+	})
+	done := make(chan error)
+	go func() { done <- r.Refresh("prod") }()
+	<-started
+	status := make(chan *daemon.Status)
+	go func() { status <- r.Status() }()
+	select {
+	case s := <-status:
+		if s.Profiles[0].Error != "" {
+			t.Errorf("%+v has the failure before the login returns", s.Profiles[0])
+		}
+	case <-time.After(time.Second):
+		t.Error("the status waits for the login")
+	}
+	close(release)
+	if err := <-done; err == nil {
+		t.Error("the failed refresh needs to return error")
+	}
+	if p := r.Status().Profiles[0]; p.Error != "MFA is not approved" {
+		t.Errorf("%+v is not the failure", p)
+	}
+}
+
+func TestRefresherNotify(t *testing.T) {
+	notified := make(chan string, 10)
+	failure := "failed"
+	r := newRefresher(ioutil.Discard, newLoginSession(nil), []string{"prod"}, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		if failure != "" {
+			return nil, errors.New(failure)
+		}
+		return &sts.Credentials{}, nil
+	})
+	r.notify = func(message string) { notified <- message }
+	r.refresh("prod", time.Now())
+	r.refresh("prod", time.Now())
+	failure = "changed"
+	r.refresh("prod", time.Now())
+	failure = ""
+	r.refresh("prod", time.Now())
+	failure = "changed"
+	r.refresh("prod", time.Now())
+	messages := []string{}
+	for i := 0; i < 3; i++ {
+		select {
+		case m := <-notified:
+			messages = append(messages, strings.SplitN(m, "\n", 2)[0])
+		case <-time.After(time.Second):
+			t.Fatalf("%v are notified", messages)
+		}
+	}
+	sort.Strings(messages)
+	expected := []string{"prod failed to refresh: changed", "prod failed to refresh: changed", "prod failed to refresh: failed"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("%v is not equal %v", messages, expected)
+	}
+	select {
+	case m := <-notified:
+		t.Errorf("%s is notified again", m)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestUnattendedDevice(t *testing.T) {
+	devices := []samlassertion.GenerateResponseFactorDevice{
+		{DeviceID: 1, DeviceType: "Google Authenticator", RequireOTPToken: true},
+		{DeviceID: 2, DeviceType: "OneLogin Protect", RequireOTPToken: true},
+		{DeviceID: 2, DeviceType: "Notify to OneLogin Protect", RequireOTPToken: false},
+	}
+	if i, ok := unattendedDevice(devices); !ok || i != 2 {
+		t.Errorf("%d is not the push notification", i)
+	}
+	if i, ok := unattendedDevice(devices[:1]); !ok || i != 0 {
+		t.Errorf("%d is not the OTP device", i)
+	}
+	if _, ok := unattendedDevice(nil); ok {
+		t.Error("no device is chosen")
+	}
+}
+
+func TestRequirePromptDaemon(t *testing.T) {
+	defer func() { daemonMode = false }()
+	daemonMode = true
+	err := requirePrompt("MFA token", exitMFATokenRequired)
+	if err == nil || err.Error() != "MFA token is required, but the daemon cannot prompt" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestDaemonCredentials(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	writeCachedExpiration(t, cache, "fresh", time.Now().Add(time.Hour))
+	writeCachedExpiration(t, cache, "expired", time.Now().Add(-time.Hour))
+	writeCachedExpiration(t, cache, "other", time.Now().Add(time.Hour))
+	credentials := daemonCredentials([]string{"fresh", "expired", "new"})
+	if creds, err := credentials("fresh"); err != nil || creds == nil {
+		t.Errorf("%v, %v is not the cached credentials", creds, err)
+	}
+	for _, profile := range []string{"expired", "new"} {
+		if _, err := credentials(profile); err == nil || err.Error() != fmt.Sprintf("the credentials of %s are not refreshed yet", profile) {
+			t.Errorf("%v is not the error of %s", err, profile)
+		}
+	}
+	if creds, err := credentials("other"); err != nil || creds != nil {
+		t.Errorf("%v, %v of the profile out of the daemon is served", creds, err)
+	}
+}
+
+func TestDaemonServiceArgs(t *testing.T) {
+	defer func(file string, listen string, noNotify bool) {
+		configFile, daemonListen, daemonNoNotify = file, listen, noNotify
+	}(configFile, daemonListen, daemonNoNotify)
+	configFile = "/home/user/.onelogin-aws-connector/config.toml"
+	daemonListen = ""
+	daemonNoNotify = false
+	expected := []string{"/usr/local/bin/onelogin-aws-connector", "daemon", "--no-prompt", "--config", configFile, "--interval", daemonInterval.String(), "--within", daemonWithin.String()}
+	if args := daemonServiceArgs("/usr/local/bin/onelogin-aws-connector", nil); !reflect.DeepEqual(args, expected) {
+		t.Errorf("%v is not equal %v", args, expected)
+	}
+	daemonListen = "127.0.0.1:9912"
+	daemonNoNotify = true
+	expected = append(expected, "--listen", "127.0.0.1:9912", "--no-notify", "--", "status", "prod")
+	if args := daemonServiceArgs("/usr/local/bin/onelogin-aws-connector", []string{"status", "prod"}); !reflect.DeepEqual(args, expected) {
+		t.Errorf("%v is not equal %v", args, expected)
+	}
+}
@@ -0,0 +1,135 @@
+// Package ecs serves credentials by the container credentials protocol of ECS,
+// which SDKs use with AWS_CONTAINER_CREDENTIALS_FULL_URI and AWS_CONTAINER_AUTHORIZATION_TOKEN
+package ecs
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// environment variables of the container credentials provider of SDKs
+const (
+	URIEnv   = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	TokenEnv = "AWS_CONTAINER_AUTHORIZATION_TOKEN"
+)
+
+// HealthPath is the health check, which requires no token
+const HealthPath = "/healthz"
+
+// CredentialsPath is the path of the credentials, which is followed by the profile name
+const CredentialsPath = "/credentials/"
+
+// CredentialsFunc returns the credentials of the profile, or nil if the profile is not served
+type CredentialsFunc func(profile string) (*sts.Credentials, error)
+
+// Server is http.Handler serving the credentials of the profiles on CredentialsPath,
+// the requests must have the token in Authorization header
+type Server struct {
+	// Health checks the credentials for /healthz without getting new ones, nil is always healthy
+	Health func() error
+	// Logf logs failures of getting credentials, e.g. log.Printf
+	Logf        func(format string, v ...interface{})
+	token       string
+	credentials CredentialsFunc
+}
+
+// New returns the server of the credentials authorized by the token
+func New(token string, credentials CredentialsFunc) *Server {
+	return &Server{
+		Logf:        log.Printf,
+		token:       token,
+		credentials: credentials,
+	}
+}
+
+// NewToken returns a random token to authorize requests
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// response is the credentials of the container credentials protocol
+type response struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+}
+
+// errorResponse is the error of the container credentials protocol, which SDKs show
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ServeHTTP serves the credentials of the profile in the path
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == HealthPath {
+		s.serveHealth(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "only GET is allowed")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.token)) != 1 || s.token == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "the authorization token is invalid")
+		return
+	}
+	profile := strings.TrimPrefix(r.URL.Path, CredentialsPath)
+	if !strings.HasPrefix(r.URL.Path, CredentialsPath) || profile == "" || strings.Contains(profile, "/") {
+		writeError(w, http.StatusNotFound, "NotFound", "the path must be "+CredentialsPath+"<profile>")
+		return
+	}
+	creds, err := s.credentials(profile)
+	if err != nil {
+		if s.Logf != nil {
+			s.Logf("failed to get the credentials of %s: %v\n", profile, err)
+		}
+		writeError(w, http.StatusServiceUnavailable, "CredentialsUnavailable", "the credentials of "+profile+" are not available")
+		return
+	}
+	if creds == nil {
+		writeError(w, http.StatusNotFound, "NotFound", profile+" is not served")
+		return
+	}
+	if creds.AccessKeyId == nil || creds.SecretAccessKey == nil || creds.SessionToken == nil || creds.Expiration == nil {
+		writeError(w, http.StatusServiceUnavailable, "CredentialsUnavailable", "the credentials of "+profile+" are incomplete")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		Token:           *creds.SessionToken,
+		Expiration:      creds.Expiration.UTC().Format(time.RFC3339),
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Code: code, Message: message})
+}
+
+// serveHealth answers 200 if the credentials are healthy, or 503 with the reason for supervisors
+func (s *Server) serveHealth(w http.ResponseWriter) {
+	if s.Health != nil {
+		if err := s.Health(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Write([]byte("ok\n"))
+}
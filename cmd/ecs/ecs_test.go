@@ -0,0 +1,117 @@
+package ecs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+func newTestServer() *Server {
+	expiration := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := New("token", func(profile string) (*sts.Credentials, error) {
+		switch profile {
+		case "default":
+			return &sts.Credentials{
+				AccessKeyId:     aws.String("AKIAEXAMPLE"),
+				SecretAccessKey: aws.String("secret"),
+				SessionToken:    aws.String("session"),
+				Expiration:      &expiration,
+			}, nil
+		case "expired":
+			return nil, errors.New("the credentials of expired are not refreshed yet")
+		}
+		return nil, nil
+	})
+	s.Logf = nil
+	return s
+}
+
+func serve(s *Server, method string, path string, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := newTestServer()
+	w := serve(s, "GET", CredentialsPath+"default", "token")
+	if w.Code != http.StatusOK {
+		t.Fatalf("%d is not 200", w.Code)
+	}
+	var res response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	expected := response{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret", Token: "session", Expiration: "2026-01-02T03:04:05Z"}
+	if res != expected {
+		t.Errorf("%+v is not equal %+v", res, expected)
+	}
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		code   int
+	}{
+		{"GET", CredentialsPath + "default", "", http.StatusUnauthorized},
+		{"GET", CredentialsPath + "default", "invalid", http.StatusUnauthorized},
+		{"POST", CredentialsPath + "default", "token", http.StatusMethodNotAllowed},
+		{"GET", CredentialsPath + "other", "token", http.StatusNotFound},
+		{"GET", CredentialsPath, "token", http.StatusNotFound},
+		{"GET", CredentialsPath + "default/more", "token", http.StatusNotFound},
+		{"GET", "/other/default", "token", http.StatusNotFound},
+		{"GET", CredentialsPath + "expired", "token", http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		w := serve(s, test.method, test.path, test.token)
+		if w.Code != test.code {
+			t.Errorf("%d of %s %s is not %d", w.Code, test.method, test.path, test.code)
+		}
+		var res errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || res.Code == "" || res.Message == "" {
+			t.Errorf("%s of %s %s is not an error, %v", w.Body.String(), test.method, test.path, err)
+		}
+	}
+}
+
+func TestServeHTTPWithoutToken(t *testing.T) {
+	s := newTestServer()
+	s.token = ""
+	if w := serve(s, "GET", CredentialsPath+"default", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("%d is not 401 without the token of the server", w.Code)
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	a, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 64 || a == b {
+		t.Errorf("%s and %s are not random tokens", a, b)
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	s := newTestServer()
+	if w := serve(s, "GET", HealthPath, ""); w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Errorf("%d %s is not healthy without the token", w.Code, w.Body.String())
+	}
+	s.Health = func() error { return errors.New("the credentials of expired are not refreshed yet") }
+	if w := serve(s, "GET", HealthPath, ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("%d is not unhealthy", w.Code)
+	}
+}
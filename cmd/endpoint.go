@@ -0,0 +1,62 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// endpointEnv overrides the OneLogin API endpoint like --endpoint
+const endpointEnv = "ONELOGIN_ENDPOINT"
+
+// overriddenEndpoint returns the endpoint given by --endpoint or ONELOGIN_ENDPOINT, or empty string if it is not overridden.
+// A region like "us" is resolved to its endpoint, and a value containing "." or ":" is a custom host
+func overriddenEndpoint() (string, error) {
+	override := endpoint
+	if override == "" {
+		override = os.Getenv(endpointEnv)
+	}
+	if override == "" || strings.Contains(override, ".") || strings.Contains(override, ":") {
+		return override, nil
+	}
+	return config.RegionEndpoint(override)
+}
+
+// serviceEndpoint returns the overridden endpoint, or the endpoint of the service
+func serviceEndpoint(service config.ServiceConfig) (string, error) {
+	if endpoint, err := overriddenEndpoint(); endpoint != "" || err != nil {
+		return endpoint, err
+	}
+	return service.APIEndpoint()
+}
+
+// oneloginCacheDir returns the directory of OneLogin token caches.
+// Tokens of an overridden endpoint are cached separately, so tokens of the configured endpoint are never sent to it
+func oneloginCacheDir(cache string) string {
+	endpoint, err := overriddenEndpoint()
+	if err != nil || endpoint == "" {
+		return cache
+	}
+	dir := path.Join(cache, "endpoints", strings.NewReplacer(":", "_", "/", "_").Replace(endpoint))
+	if err := os.MkdirAll(dir, secret.DirMode); err != nil {
+		return cache
+	}
+	return dir
+}
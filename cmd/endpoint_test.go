@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestServiceEndpoint(t *testing.T) {
+	defer func() { endpoint = "" }()
+	defer os.Unsetenv(endpointEnv)
+	service := config.ServiceConfig{Endpoint: "api.us.onelogin.com"}
+	tests := []struct {
+		flag     string
+		env      string
+		expected string
+	}{
+		{"", "", "api.us.onelogin.com"},
+		{"eu", "", "api.eu.onelogin.com"},
+		{"", "localhost:8443", "localhost:8443"},
+		{"staging.example.com", "localhost:8443", "staging.example.com"},
+	}
+	for _, tt := range tests {
+		endpoint = tt.flag
+		os.Setenv(endpointEnv, tt.env)
+		actual, err := serviceEndpoint(service)
+		if err != nil {
+			t.Errorf("%#v", err)
+		}
+		if actual != tt.expected {
+			t.Errorf("%s is not %s", actual, tt.expected)
+		}
+	}
+	endpoint = "mars"
+	if _, err := serviceEndpoint(service); err == nil {
+		t.Error("unknown region must be an error")
+	}
+}
+
+func TestOneloginCacheDir(t *testing.T) {
+	defer func() { endpoint = "" }()
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	if dir := oneloginCacheDir(cache); dir != cache {
+		t.Errorf("%s is not %s", dir, cache)
+	}
+	endpoint = "localhost:8443"
+	expected := path.Join(cache, "endpoints", "localhost_8443")
+	if dir := oneloginCacheDir(cache); dir != expected {
+		t.Errorf("%s is not %s", dir, expected)
+	}
+	if info, err := os.Stat(expected); err != nil || !info.IsDir() {
+		t.Errorf("%s is not created: %v", expected, err)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print AWS credentials as shell exports",
+	Long: `Env is printing export statements of AWS credentials and the region,
+so eval "$(onelogin-aws-connector env)" sets them to the current shell.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		creds, err := loginProfile(s, awsProfile)
+		s.Wipe()
+		if err != nil {
+			errorExit(err)
+		}
+		writeEnvExports(os.Stdout, creds, awsRegion(profileApp(c).Region))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(envCmd)
+	envCmd.Flags().StringVarP(&region, "aws-region", "", "", "AWS Region exported as AWS_REGION and AWS_DEFAULT_REGION")
+}
+
+// writeEnvExports prints the same variables as exec, unsetting the aws profile removed by exec
+func writeEnvExports(out io.Writer, creds *sts.Credentials, region string) {
+	fmt.Fprintln(out, "unset AWS_PROFILE AWS_DEFAULT_PROFILE;")
+	for _, v := range credentialsEnv(nil, creds, region) {
+		kv := strings.SplitN(v, "=", 2)
+		fmt.Fprintf(out, "export %s='%s';\n", kv[0], strings.Replace(kv[1], "'", `'\''`, -1))
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestWriteEnvExports(t *testing.T) {
+	expiration := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+	creds := &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret'access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}
+	var out bytes.Buffer
+	writeEnvExports(&out, creds, "ap-northeast-1")
+	want := `unset AWS_PROFILE AWS_DEFAULT_PROFILE;
+export AWS_ACCESS_KEY_ID='access-key-id';
+export AWS_SECRET_ACCESS_KEY='secret'\''access-key';
+export AWS_SESSION_TOKEN='session-token';
+export AWS_SECURITY_TOKEN='session-token';
+export AWS_SESSION_EXPIRATION='2017-12-01T10:00:00Z';
+export AWS_REGION='ap-northeast-1';
+export AWS_DEFAULT_REGION='ap-northeast-1';
+`
+	if out.String() != want {
+		t.Errorf("writeEnvExports() = %v, want %v", out.String(), want)
+	}
+}
+
+func TestAWSRegion(t *testing.T) {
+	defer func() { region = "" }()
+	region = ""
+	if r := awsRegion("ap-northeast-1"); r != "ap-northeast-1" {
+		t.Errorf("%s is not the configured region", r)
+	}
+	region = "us-west-2"
+	if r := awsRegion("ap-northeast-1"); r != "us-west-2" {
+		t.Errorf("%s is not the region of --aws-region", r)
+	}
+}
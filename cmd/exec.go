@@ -0,0 +1,100 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec -- command [args...]",
+	Short: "Execute a command with AWS credentials",
+	Long: `Exec is executing a command with AWS credentials in environment variables.
+The credentials are created by login, or loaded from the cache.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		creds, err := loginProfile(s, awsProfile)
+		s.Wipe()
+		if err != nil {
+			errorExit(err)
+		}
+		r := awsRegion(profileApp(c).Region)
+		run(args, credentialsEnv(os.Environ(), creds, r))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(execCmd)
+	execCmd.Flags().StringVarP(&region, "aws-region", "", "", "AWS Region")
+}
+
+// run executes the command with the environment variables and exits with its exit code if it fails
+func run(args []string, env []string) {
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	if err := child.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		errorExit(err)
+	}
+}
+
+// credentialsEnv returns environment variables with AWS credentials instead of aws profile
+func credentialsEnv(environ []string, creds *sts.Credentials, region string) []string {
+	env := []string{}
+	for _, v := range environ {
+		switch strings.SplitN(v, "=", 2)[0] {
+		case "AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY",
+			"AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN", "AWS_SESSION_EXPIRATION":
+			continue
+		case "AWS_REGION", "AWS_DEFAULT_REGION":
+			if region != "" {
+				continue
+			}
+		}
+		env = append(env, v)
+	}
+	env = append(env,
+		"AWS_ACCESS_KEY_ID="+*creds.AccessKeyId,
+		"AWS_SECRET_ACCESS_KEY="+*creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN="+*creds.SessionToken,
+		"AWS_SECURITY_TOKEN="+*creds.SessionToken,
+	)
+	if creds.Expiration != nil {
+		env = append(env, "AWS_SESSION_EXPIRATION="+creds.Expiration.UTC().Format(time.RFC3339))
+	}
+	if region != "" {
+		env = append(env, "AWS_REGION="+region, "AWS_DEFAULT_REGION="+region)
+	}
+	return env
+}
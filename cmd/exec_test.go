@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestCredentialsEnv(t *testing.T) {
+	expiration := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+	creds := &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}
+	environ := []string{
+		"HOME=/home/user",
+		"AWS_PROFILE=default",
+		"AWS_ACCESS_KEY_ID=old-access-key-id",
+		"AWS_REGION=us-east-1",
+	}
+	t.Run("without region", func(t *testing.T) {
+		got := credentialsEnv(environ, creds, "")
+		want := []string{
+			"HOME=/home/user",
+			"AWS_REGION=us-east-1",
+			"AWS_ACCESS_KEY_ID=access-key-id",
+			"AWS_SECRET_ACCESS_KEY=secret-access-key",
+			"AWS_SESSION_TOKEN=session-token",
+			"AWS_SECURITY_TOKEN=session-token",
+			"AWS_SESSION_EXPIRATION=2017-12-01T10:00:00Z",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("credentialsEnv() = %v, want %v", got, want)
+		}
+	})
+	t.Run("with region", func(t *testing.T) {
+		got := credentialsEnv(environ, creds, "ap-northeast-1")
+		want := []string{
+			"HOME=/home/user",
+			"AWS_ACCESS_KEY_ID=access-key-id",
+			"AWS_SECRET_ACCESS_KEY=secret-access-key",
+			"AWS_SESSION_TOKEN=session-token",
+			"AWS_SECURITY_TOKEN=session-token",
+			"AWS_SESSION_EXPIRATION=2017-12-01T10:00:00Z",
+			"AWS_REGION=ap-northeast-1",
+			"AWS_DEFAULT_REGION=ap-northeast-1",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("credentialsEnv() = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var manDir string
+
+// genManCmd represents the gen-man command
+var genManCmd = &cobra.Command{
+	Use:    "gen-man",
+	Short:  "Generate man pages",
+	Long:   `Gen-man is generating man pages of all commands into the directory.`,
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := genMan(RootCmd, manDir); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(genManCmd)
+	genManCmd.Flags().StringVarP(&manDir, "dir", "", "man", "Directory to write man pages")
+}
+
+func genMan(root *cobra.Command, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	header := &doc.GenManHeader{
+		Title:   "ONELOGIN-AWS-CONNECTOR",
+		Section: "1",
+		Source:  "OneLogin AWS Connector " + Version,
+		Manual:  "OneLogin AWS Connector Manual",
+	}
+	return doc.GenManTree(root, header, dir)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestGenMan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	root := &cobra.Command{Use: "onelogin-aws-connector", Short: "Generate AWS Credentials with OneLogin SAML"}
+	sub := &cobra.Command{Use: "login", Short: "Login to AWS with OneLogin", Run: func(cmd *cobra.Command, args []string) {}}
+	sub.Flags().StringP("aws-profile", "", "", "aws profile name")
+	hidden := &cobra.Command{Use: "gen-man", Hidden: true, Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(sub, hidden)
+	if err := genMan(root, dir); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, "onelogin-aws-connector-login.1"))
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if !strings.Contains(string(data), "aws\\-profile") {
+		t.Errorf("'%v' does not contain the flag", string(data))
+	}
+	if _, err := os.Stat(path.Join(dir, "onelogin-aws-connector-gen-man.1")); !os.IsNotExist(err) {
+		t.Errorf("man page of hidden command is generated")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+var groupName string
+var groupProfiles []string
+var groupDelete bool
+
+// groupCmd represents the group command
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Define a group of aws profiles",
+	Long:  `Group is defining a group of aws profiles to login them in one invocation.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := initGroup(configFile, groupName); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(groupCmd)
+	groupCmd.Flags().StringVarP(&groupName, "name", "", "", "Group Name")
+	groupCmd.Flags().StringSliceVarP(&groupProfiles, "aws-profiles", "", []string{}, "aws profile names in the group")
+	groupCmd.Flags().BoolVarP(&groupDelete, "delete", "", false, "Delete the group")
+}
+
+func initGroup(file string, name string) error {
+	if name == "" {
+		return errors.Errorf("Group name is required")
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		return err
+	}
+	if groupDelete {
+		delete(c.Group, name)
+	} else {
+		if len(groupProfiles) == 0 {
+			return errors.Errorf("aws profiles are required")
+		}
+		for _, profile := range groupProfiles {
+			if _, ok := c.App[profile]; !ok {
+				return errors.Errorf(i18n.T("%s profile is not exists"), profile)
+			}
+		}
+		c.Group[name] = groupProfiles
+	}
+	if err := c.Save(); err != nil {
+		return err
+	}
+	if debug {
+		log.Printf("Group: %#v\n", c.Group)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGroupCmd(t *testing.T) {
+	source, err := os.Open("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	file := dist.Name()
+	defer os.Remove(file)
+	_, err = io.Copy(dist, source)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	resetGroupFlags()
+	groupProfiles = []string{"default", "other"}
+	if err := initGroup(file, "all"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = "api-server"
+    client_token = "client-token"
+    client_secret = "client-secret"
+    subdomain = "subdomain"
+    username_or_email = "username-or-email"
+
+[app]
+  [app.default]
+    app_id = "app-id"
+    role_arn = "role-arn"
+    principal_arn = "provider-arn"
+    duration_seconds = 0
+  [app.other]
+    app_id = "other-app-id"
+    role_arn = "other-role-arn"
+    principal_arn = "other-provider-arn"
+    duration_seconds = 0
+
+[group]
+  all = ["default", "other"]
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestGroupCmdWithUnknownProfile(t *testing.T) {
+	resetGroupFlags()
+	groupProfiles = []string{"default", "none"}
+	err := initGroup("fixtures/fullfilled.toml", "all")
+	if err == nil || err.Error() != "none profile is not exists" {
+		t.Errorf("%v is not equal %v", err, "none profile is not exists")
+	}
+}
+
+func resetGroupFlags() {
+	groupProfiles = []string{}
+	groupDelete = false
+}
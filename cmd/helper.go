@@ -3,9 +3,20 @@ package cmd
 import (
 	"fmt"
 	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
 )
 
 func errorExit(msg interface{}) {
-	fmt.Println("Error:", msg)
+	fmt.Println(i18n.T("Error:"), secret.Filter(fmt.Sprint(msg)))
+	if err, ok := msg.(error); ok {
+		var coder interface{ ExitCode() int }
+		if errors.As(err, &coder) {
+			os.Exit(coder.ExitCode())
+		}
+	}
 	os.Exit(-1)
 }
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// English is the default language, messages are written in English
+	English = "en"
+	// Japanese language
+	Japanese = "ja"
+)
+
+var catalogs = map[string]map[string]string{
+	Japanese: ja,
+}
+
+var language = detect(os.Getenv)
+
+// SetLanguage sets the language of messages like "ja" or "ja_JP.UTF-8"
+func SetLanguage(lang string) {
+	language = normalize(lang)
+}
+
+// Language returns the current language
+func Language() string {
+	return language
+}
+
+// T returns the translated message
+func T(message string) string {
+	if translated, ok := catalogs[language][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf formats with the translated format
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// detect returns the language from locale environment variables
+func detect(getenv func(string) string) string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := getenv(key); v != "" {
+			return normalize(v)
+		}
+	}
+	return English
+}
+
+func normalize(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_.-@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return English
+}
@@ -0,0 +1,43 @@
+package i18n
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"no locale", map[string]string{}, English},
+		{"LANG", map[string]string{"LANG": "ja_JP.UTF-8"}, Japanese},
+		{"LC_ALL takes precedence", map[string]string{"LC_ALL": "C", "LANG": "ja_JP.UTF-8"}, English},
+		{"LC_MESSAGES", map[string]string{"LC_MESSAGES": "ja", "LANG": "en_US.UTF-8"}, Japanese},
+		{"unsupported", map[string]string{"LANG": "fr_FR.UTF-8"}, English},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detect(func(key string) string { return tt.env[key] })
+			if got != tt.expected {
+				t.Errorf("%v is not equal %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	defer SetLanguage(Language())
+	SetLanguage("ja_JP.UTF-8")
+	if got := Sprintf("Switched to %s\n", "default"); got != "default に切り替えました\n" {
+		t.Errorf("%q is not translated", got)
+	}
+	if got := Sprintf("%s profile in %s group is not exists", "dev", "all"); got != "all グループの dev プロファイルは存在しません" {
+		t.Errorf("%q is not translated", got)
+	}
+	if got := T("untranslated message"); got != "untranslated message" {
+		t.Errorf("%q is not equal the message", got)
+	}
+	SetLanguage("en")
+	if got := Sprintf("Switched to %s\n", "default"); got != "Switched to default\n" {
+		t.Errorf("%q is translated", got)
+	}
+}
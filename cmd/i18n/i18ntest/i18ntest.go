@@ -0,0 +1,15 @@
+// Package i18ntest provides the setup of tests whose expected messages are written in English
+package i18ntest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+// Main runs the tests in English regardless of the locale, it is called by TestMain of each package
+func Main(m *testing.M) {
+	i18n.SetLanguage(i18n.English)
+	os.Exit(m.Run())
+}
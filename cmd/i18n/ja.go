@@ -0,0 +1,130 @@
+package i18n
+
+var ja = map[string]string{
+	// prompts
+	"Select your MFA device: ":               "MFAデバイスを選択してください: ",
+	"Select your role: ":                     "ロールを選択してください: ",
+	"Enter your MFA token: ":                 "MFAトークンを入力してください: ",
+	"Enter your password: ":                  "パスワードを入力してください: ",
+	"Enter your TOTP seed: ":                 "TOTPシードを入力してください: ",
+	"Retype your password: ":                 "パスワードを再入力してください: ",
+	"Select aws profile or type to search: ": "awsプロファイルを選択するか、入力して検索してください: ",
+	"(use arrow keys, or type to search)":    "(矢印キーで選択、入力して検索)",
+	"(%d/%d matched)":                        "(%d/%d件一致)",
+	"No match for %s\n":                      "%s に一致する項目はありません\n",
+	"waiting for OneLogin Protect approval, %ds left, Ctrl-C to cancel": "OneLogin Protectの承認を待っています。残り%d秒、Ctrl-Cでキャンセル",
+
+	// messages
+	"Error:":                              "エラー:",
+	"Refreshed %s\n":                      "%s を更新しました\n",
+	"daemon is refreshing %d profiles\n":  "デーモンが %d 個のプロファイルを更新しています\n",
+	"fetched the shared config from %s\n": "%s から共有設定を取得しました\n",
+	"config_source is not configured, please run `onelogin-aws-connector init --config-source`": "config_source が設定されていません。`onelogin-aws-connector init --config-source` を実行してください",
+	"daemon is serving credentials on http://%s%s<profile>\n":                                   "デーモンが http://%s%s<profile> で認証情報を提供しています\n",
+	"daemon %d is running since %s\n":                                                           "デーモン %d が %s から実行中です\n",
+	"Installed the daemon to %s, the log is %s\n":                                               "デーモンを %s にインストールしました。ログは %s です\n",
+	"metrics are served on http://%s%s\n":                                                       "メトリクスを http://%s%s で提供しています\n",
+	"%s refreshed %s\n":                                                                         "%s %s を更新しました\n",
+	"%s failed to refresh %s: %v\n":                                                             "%s %s の更新に失敗しました: %v\n",
+	"server is serving %s on http://%s/\n":                                                      "サーバーが %s の認証情報を http://%s/ で提供しています\n",
+	"Switched to %s\n":                                                                          "%s に切り替えました\n",
+	"logged in":                                                                                 "ログイン完了",
+	"failed":                                                                                    "失敗",
+	"OneLogin AWS Connector version %v is the latest\n":                                         "OneLogin AWS Connector バージョン %v は最新です\n",
+	"This is the latest version":                                                                "最新バージョンです",
+	"New version %v is available. Run `onelogin-aws-connector self-update` to update.":    "新しいバージョン %v があります。`onelogin-aws-connector self-update` で更新してください。",
+	"Updated OneLogin AWS Connector to version %v\n":                                      "OneLogin AWS Connector をバージョン %v に更新しました\n",
+	"Warning: already in a shell with credentials of %s\n":                                "警告: すでに %s の認証情報を持つシェルの中です\n",
+	"Saved the password of %s in the keychain\n":                                          "%s のパスワードをキーチェーンに保存しました\n",
+	"Saved the proxy password of %s in the keychain\n":                                    "プロキシユーザー %s のパスワードをキーチェーンに保存しました\n",
+	"Deleted the proxy password of %s from the keychain\n":                                "プロキシユーザー %s のパスワードをキーチェーンから削除しました\n",
+	"proxy user is not configured. Please run `onelogin-aws-connector init --proxy-user`": "プロキシのユーザーが設定されていません。`onelogin-aws-connector init --proxy-user` を実行してください",
+	"Warning: TLS certificate verification is disabled by insecure_skip_verify, the password and AWS credentials can be stolen by anyone on the network. Please configure --ca-bundle instead\n": "警告: insecure_skip_verify により TLS 証明書の検証が無効になっているため、パスワードと AWS 認証情報をネットワーク上の誰にでも盗まれる可能性があります。代わりに --ca-bundle を設定してください\n",
+	"Deleted the password of %s from the keychain\n":      "%s のパスワードをキーチェーンから削除しました\n",
+	"agent is listening on %s\n":                          "エージェントは %s で待ち受けています\n",
+	"Unlocked the agent\n":                                "エージェントをアンロックしました\n",
+	"Locked the agent\n":                                  "エージェントをロックしました\n",
+	"agent is locked\n":                                   "エージェントはロックされています\n",
+	"agent is unlocked\n":                                 "エージェントはアンロックされています\n",
+	"Touch ID or Windows Hello is required to use them\n": "使用するには Touch ID または Windows Hello が必要です\n",
+	"TOTP seed is held\n":                                 "TOTPシードを保持しています\n",
+	"it is locked at %s\n":                                "%s にロックされます\n",
+	"Warning: failed to revoke OneLogin token:":           "警告: OneLoginトークンの無効化に失敗しました:",
+
+	// errors
+	"%s is required, but stdin is not a terminal and has no more input": "%s が必要ですが、標準入力が端末ではなく、入力もありません",
+	"%s is required, but the daemon cannot prompt":                      "%s が必要ですが、デーモンは入力を求められません",
+	"%s is required, but prompt is disabled by --no-prompt":             "%s が必要ですが、--no-prompt により入力は無効です",
+	"password":                               "パスワード",
+	"MFA device selection":                   "MFAデバイスの選択",
+	"MFA token":                              "MFAトークン",
+	"role selection":                         "ロールの選択",
+	"aws profile selection":                  "awsプロファイルの選択",
+	"failed to read the password from stdin": "標準入力からパスワードを読み込めませんでした",
+	"Warning: permissions %04o of %s are too open, please run `chmod %o %s`\n":    "警告: %[2]s のパーミッション %04[1]o は緩すぎます。`chmod %[3]o %[4]s` を実行してください\n",
+	"permissions %04o of %s are too open, please run `chmod 600 %s`":              "%[2]s のパーミッション %04[1]o は緩すぎます。`chmod 600 %[3]s` を実行してください",
+	"password_command `%s` failed":                                                "password_command `%s` が失敗しました",
+	"password_command `%s` printed no password":                                   "password_command `%s` がパスワードを出力しませんでした",
+	"passwords do not match":                                                      "パスワードが一致しません",
+	"agent is not running on %s, please run `onelogin-aws-connector agent start`": "エージェントが %s で起動していません。`onelogin-aws-connector agent start` を実行してください",
+	"Warning: the keychain does not require Touch ID or Windows Hello, other commands of the user can read the password. Use `agent start --biometric` to keep it behind the verification\n": "警告: キーチェーンは Touch ID や Windows Hello を要求しないため、ユーザーの他のコマンドはパスワードを読み取れます。認証の後ろに保つには `agent start --biometric` を使用してください\n",
+	"--all logs in to every profile, it cannot be given with --group or --aws-profile":                                                                                                       "--all はすべてのプロファイルにログインするため、--group や --aws-profile と同時に指定できません",
+	"failed to verify with Touch ID or Windows Hello":                                                                                                                                        "Touch ID または Windows Hello による認証に失敗しました",
+	"use the OneLogin password of %s":                            "%s のOneLoginパスワードを使用",
+	"MFA is required, please register an MFA device in OneLogin": "MFAが必要です。OneLoginでMFAデバイスを登録してください",
+	"the SAML assertion is not accepted by the SAML provider, please check the metadata of the provider and the principal ARN": "SAMLアサーションがSAMLプロバイダーに受け入れられません。プロバイダーのメタデータとプリンシパルARNを確認してください",
+	"the SAML assertion is rejected by the SAML provider, please check the role attribute of the OneLogin app":                 "SAMLアサーションがSAMLプロバイダーに拒否されました。OneLoginアプリのロール属性を確認してください",
+	"the SAML assertion is expired, please login again and check the clock of this machine":                                    "SAMLアサーションの有効期限が切れています。再度ログインし、このマシンの時計を確認してください",
+	"the session policy is invalid, please check --policy and --policy-arn":                                                    "セッションポリシーが不正です。--policy と --policy-arn を確認してください",
+	"the session policies are too large, please shorten --policy or reduce --policy-arn":                                       "セッションポリシーが大きすぎます。--policy を短くするか --policy-arn を減らしてください",
+	"%s denied the SAML assertion, please check the trust policy of the role":                                                  "%s がSAMLアサーションを拒否しました。ロールの信頼ポリシーを確認してください",
+	"%s denied the SAML assertion, please check the trust policy of the role. The roles in the SAML assertion are %s":          "%s がSAMLアサーションを拒否しました。ロールの信頼ポリシーを確認してください。SAMLアサーションのロールは %s です",
+	"the username or the password is wrong":                                                                                    "ユーザー名またはパスワードが間違っています",
+	"OneLogin API is busy, please retry later":                                                                                 "OneLogin APIが混雑しています。しばらくしてから再試行してください",
+	"the password is expired, please change it in OneLogin":                                                                    "パスワードの有効期限が切れています。OneLoginで変更してください",
+	"the user is locked, please contact the administrator of OneLogin":                                                         "ユーザーがロックされています。OneLoginの管理者に連絡してください",
+	"Warning: only %d of %d OneLogin API calls remain\n":                                                                       "警告: 呼び出せるOneLogin APIは %[2]d 回中残り %[1]d 回です\n",
+	"Warning: %s is assumed for %ds because %ds exceeds its maximum session duration\n":                                        "警告: %[3]ds が最大セッション期間を超えるため、%[1]s を %[2]ds で引き受けました\n",
+	"Warning: only %d of %d OneLogin API calls remain until %s\n":                                                              "警告: %[3]s までに呼び出せるOneLogin APIは %[2]d 回中残り %[1]d 回です\n",
+	"input is interrupted":                 "入力が中断されました",
+	"selection is interrupted":             "選択が中断されました",
+	"failed to login to %s":                "%s へのログインに失敗しました",
+	"%s group is not exists":               "%s グループは存在しません",
+	"%s profile in %s group is not exists": "%[2]s グループの %[1]s プロファイルは存在しません",
+	"%s matches more than one app, please configure --app-id instead":                                  "%s に一致するアプリが複数あります。代わりに --app-id を設定してください",
+	"%s is not found in apps assigned to %s":                                                           "%[2]s に割り当てられたアプリに %[1]s が見つかりません",
+	"user %s is not found in subdomain %s":                                                             "ユーザー %s はサブドメイン %s に存在しません",
+	"%s is not activated in subdomain %s":                                                              "ユーザー %s はサブドメイン %s で有効化されていません",
+	"%s is suspended in subdomain %s":                                                                  "ユーザー %s はサブドメイン %s で停止されています",
+	"%s is locked in subdomain %s":                                                                     "ユーザー %s はサブドメイン %s でロックされています",
+	"the password of %s is expired in subdomain %s":                                                    "ユーザー %s のパスワードはサブドメイン %s で有効期限が切れています",
+	"%s is awaiting the password reset in subdomain %s":                                                "ユーザー %s はサブドメイン %s でパスワードのリセット待ちです",
+	"Warning: MFA device %s is not found\n":                                                            "警告: MFAデバイス %s が見つかりません\n",
+	"no profile is logged in, please login or give the profiles to refresh":                            "ログイン済みのプロファイルがありません。ログインするか、更新するプロファイルを指定してください",
+	"%s has password_prompt, which cannot be refreshed by the daemon":                                  "%s は password_prompt が有効なため、デーモンでは更新できません",
+	"%s is not a valid address to listen":                                                              "%s は待ち受けられるアドレスではありません",
+	"%s is not a loopback address, the server must not be reachable from other hosts":                  "%s はループバックアドレスではありません。サーバーは他のホストから接続できないようにする必要があります",
+	"the credentials of %s are not refreshed yet":                                                      "%s の認証情報はまだ更新されていません",
+	"%s is not refreshed by the daemon":                                                                "%s はデーモンで更新されていません",
+	"%s has invalid refresh schedule":                                                                  "%s の更新スケジュールが不正です",
+	"daemon is not running on %s, please run `onelogin-aws-connector daemon`":                          "デーモンが %s で起動していません。`onelogin-aws-connector daemon` を実行してください",
+	"%s failed to refresh: %v\n%s":                                                                     "%s の更新に失敗しました: %v\n%s",
+	"run `onelogin-aws-connector login --aws-profile %s`":                                              "`onelogin-aws-connector login --aws-profile %s` を実行してください",
+	"update the password and restart `onelogin-aws-connector daemon`":                                  "パスワードを更新して `onelogin-aws-connector daemon` を再起動してください",
+	"ask the administrator of OneLogin to unlock the user and restart `onelogin-aws-connector daemon`": "OneLogin の管理者にユーザーのロック解除を依頼して `onelogin-aws-connector daemon` を再起動してください",
+	"the daemon retries later, run `onelogin-aws-connector daemon status` to check the next refresh":   "デーモンは後で再試行します。`onelogin-aws-connector daemon status` で次の更新を確認してください",
+	"run `onelogin-aws-connector login --aws-profile %s` to see the details":                           "`onelogin-aws-connector login --aws-profile %s` を実行して詳細を確認してください",
+	"%s profile is not exists":                                                                         "%s プロファイルは存在しません",
+	"aws profile is required, give it by --profile":                                                    "aws プロファイルが必要です。--profile で指定してください",
+	"%s is the source profile, the chained role needs another profile":                                 "%s はソースプロファイルです。チェーンするロールには別のプロファイルが必要です",
+	"%s service is not exists":                                                                         "%s サービスは存在しません",
+	"Endpoint is not exists":                                                                           "Endpoint が設定されていません",
+	"ClientToken is not exists":                                                                        "ClientToken が設定されていません",
+	"ClientSecret is not exists":                                                                       "ClientSecret が設定されていません",
+	"Subdomain is not exists":                                                                          "Subdomain が設定されていません",
+	"MFA verification failed: %v\nTry again?":                                                          "MFAの検証に失敗しました: %v\n再試行しますか?",
+	"%s is not assigned to this user":                                                                  "%s はこのユーザーに割り当てられていません",
+	"Managed session policies require STS of AWS SDK for Go v2":                                        "マネージドセッションポリシーには AWS SDK for Go v2 の STS が必要です",
+	"There is no role in SAML assertion":                                                               "SAMLアサーションにロールがありません",
+	"There is no configured profile. Please run `onelogin-aws-connector configure`":                    "設定されたプロファイルがありません。`onelogin-aws-connector configure` を実行してください",
+}
@@ -0,0 +1,230 @@
+// Package imds serves credentials on the endpoints of EC2 instance metadata service,
+// so SDKs and tools reading the credentials of the instance profile use the credentials of OneLogin
+package imds
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+// paths of the instance metadata service
+const (
+	// HealthPath is the health check, which is not the one of EC2
+	HealthPath      = "/healthz"
+	tokenPath       = "/latest/api/token"
+	credentialsPath = "/latest/meta-data/iam/security-credentials/"
+	regionPath      = "/latest/meta-data/placement/region"
+	documentPath    = "/latest/dynamic/instance-identity/document"
+)
+
+// headers of IMDSv2
+const (
+	tokenHeader    = "X-Aws-Ec2-Metadata-Token"
+	tokenTTLHeader = "X-Aws-Ec2-Metadata-Token-Ttl-Seconds"
+)
+
+// maxTokenTTL is the max lifetime of IMDSv2 tokens, which is 6 hours like EC2
+const maxTokenTTL = 6 * time.Hour
+
+// maxTokens is the number of live IMDSv2 tokens, the one expiring first is evicted by a new token over it,
+// so local processes requesting tokens without limit cannot grow the memory
+const maxTokens = 1024
+
+// CredentialsFunc returns the credentials of the role, which are refreshed by the function before they expire
+type CredentialsFunc func(ctx context.Context) (*sts.Credentials, error)
+
+// Server is http.Handler of the instance metadata service.
+// IMDSv2 tokens are issued and verified, and IMDSv1 requests without tokens are rejected by default
+type Server struct {
+	// Role is the name of the role listed in security-credentials
+	Role string
+	// Region is told by placement/region and the instance identity document if it is set
+	Region string
+	// RequireToken rejects IMDSv1 requests without tokens, it is true by New
+	RequireToken bool
+	// Health checks the credentials for /healthz without getting new ones, nil is always healthy
+	Health func() error
+	// Logf logs failures of getting credentials, e.g. log.Printf
+	Logf        func(format string, v ...interface{})
+	credentials CredentialsFunc
+	mu          sync.Mutex
+	tokens      map[string]time.Time
+	now         func() time.Time
+}
+
+// New returns the server of the credentials of the role
+func New(role string, credentials CredentialsFunc) *Server {
+	return &Server{
+		Role:         role,
+		RequireToken: true,
+		Logf:         log.Printf,
+		credentials:  credentials,
+		tokens:       map[string]time.Time{},
+		now:          time.Now,
+	}
+}
+
+// response is the credentials of security-credentials
+type response struct {
+	Code            string
+	LastUpdated     string
+	Type            string
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+}
+
+// ServeHTTP serves the token, the credentials and the region
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// EC2 rejects forwarded requests, so a proxy or an SSRF cannot read the credentials,
+	// and a web page cannot read them by DNS rebinding to the loopback address with its own host
+	if r.Header.Get("X-Forwarded-For") != "" || !metadataHost(r.Host) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if r.URL.Path == HealthPath {
+		s.serveHealth(w)
+		return
+	}
+	if r.URL.Path == tokenPath {
+		s.serveToken(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch path := r.URL.Path; {
+	case path == credentialsPath || path == strings.TrimSuffix(credentialsPath, "/"):
+		w.Write([]byte(s.Role))
+	case path == credentialsPath+s.Role:
+		s.serveCredentials(w, r)
+	case path == regionPath && s.Region != "":
+		w.Write([]byte(s.Region))
+	case path == documentPath && s.Region != "":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"region": s.Region})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// metadataHost reports whether the Host header is the address of the instance metadata service or a loopback address
+func metadataHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && (ip.IsLoopback() || ip.Equal(net.IPv4(169, 254, 169, 254)))
+}
+
+// serveToken issues the IMDSv2 token of the requested lifetime
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	seconds, err := strconv.Atoi(r.Header.Get(tokenTTLHeader))
+	ttl := time.Duration(seconds) * time.Second
+	if err != nil || ttl <= 0 || ttl > maxTokenTTL {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	token, err := newToken()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	now := s.now()
+	s.mu.Lock()
+	var first string
+	for t, expires := range s.tokens {
+		if !now.Before(expires) {
+			delete(s.tokens, t)
+		} else if first == "" || expires.Before(s.tokens[first]) {
+			first = t
+		}
+	}
+	if len(s.tokens) >= maxTokens {
+		delete(s.tokens, first)
+	}
+	s.tokens[token] = now.Add(ttl)
+	s.mu.Unlock()
+	w.Header().Set(tokenTTLHeader, strconv.Itoa(seconds))
+	w.Write([]byte(token))
+}
+
+// authorized verifies the IMDSv2 token, the request without a token is authorized unless RequireToken
+func (s *Server) authorized(r *http.Request) bool {
+	token := r.Header.Get(tokenHeader)
+	if token == "" {
+		return !s.RequireToken
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.tokens[token]
+	return ok && s.now().Before(expires)
+}
+
+func (s *Server) serveCredentials(w http.ResponseWriter, r *http.Request) {
+	creds, err := s.credentials(r.Context())
+	if err == nil && (creds == nil || creds.AccessKeyId == nil || creds.SecretAccessKey == nil || creds.SessionToken == nil || creds.Expiration == nil) {
+		err = errors.New("the credentials are incomplete")
+	}
+	if err != nil {
+		if s.Logf != nil {
+			s.Logf("failed to get the credentials of %s: %v\n", s.Role, err)
+		}
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response{
+		Code:            "Success",
+		LastUpdated:     s.now().UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		Token:           *creds.SessionToken,
+		Expiration:      creds.Expiration.UTC().Format(time.RFC3339),
+	})
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// serveHealth answers 200 if the credentials are healthy, or 503 with the reason for supervisors
+func (s *Server) serveHealth(w http.ResponseWriter) {
+	if s.Health != nil {
+		if err := s.Health(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Write([]byte("ok\n"))
+}
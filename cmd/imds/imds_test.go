@@ -0,0 +1,197 @@
+package imds
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+)
+
+func newTestServer(err error) *Server {
+	expiration := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := New("admin", func(ctx context.Context) (*sts.Credentials, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &sts.Credentials{
+			AccessKeyId:     aws.String("AKIAEXAMPLE"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      &expiration,
+		}, nil
+	})
+	s.Logf = nil
+	s.RequireToken = false
+	s.now = func() time.Time { return time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func serve(s *Server, method string, path string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.Host = "169.254.169.254"
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeCredentials(t *testing.T) {
+	s := newTestServer(nil)
+	if w := serve(s, "GET", credentialsPath, nil); w.Code != http.StatusOK || w.Body.String() != "admin" {
+		t.Errorf("%d %s is not the role", w.Code, w.Body.String())
+	}
+	w := serve(s, "GET", credentialsPath+"admin", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%d is not 200", w.Code)
+	}
+	var res response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	expected := response{
+		Code:            "Success",
+		LastUpdated:     "2026-01-02T02:00:00Z",
+		Type:            "AWS-HMAC",
+		AccessKeyID:     "AKIAEXAMPLE",
+		SecretAccessKey: "secret",
+		Token:           "token",
+		Expiration:      "2026-01-02T03:04:05Z",
+	}
+	if res != expected {
+		t.Errorf("%+v is not equal %+v", res, expected)
+	}
+	if !strings.Contains(w.Body.String(), `"AccessKeyId":"AKIAEXAMPLE"`) {
+		t.Errorf("%s has no AccessKeyId", w.Body.String())
+	}
+	if w := serve(s, "GET", credentialsPath+"other", nil); w.Code != http.StatusNotFound {
+		t.Errorf("%d is not 404 of another role", w.Code)
+	}
+	if w := serve(s, "GET", regionPath, nil); w.Code != http.StatusNotFound {
+		t.Errorf("%d is not 404 without the region", w.Code)
+	}
+	s.Region = "ap-northeast-1"
+	if w := serve(s, "GET", regionPath, nil); w.Body.String() != "ap-northeast-1" {
+		t.Errorf("%s is not the region", w.Body.String())
+	}
+	if w := serve(s, "GET", documentPath, nil); !strings.Contains(w.Body.String(), `"region":"ap-northeast-1"`) {
+		t.Errorf("%s has no region", w.Body.String())
+	}
+}
+
+func TestServeCredentialsError(t *testing.T) {
+	s := newTestServer(errors.New("MFA token is required, but the daemon cannot prompt"))
+	logged := ""
+	s.Logf = func(format string, v ...interface{}) { logged = format }
+	if w := serve(s, "GET", credentialsPath+"admin", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("%d is not 500", w.Code)
+	}
+	if logged == "" {
+		t.Error("the failure is not logged")
+	}
+}
+
+func TestServeToken(t *testing.T) {
+	if !New("admin", nil).RequireToken {
+		t.Error("IMDSv1 is accepted by default")
+	}
+	s := newTestServer(nil)
+	s.RequireToken = true
+	if w := serve(s, "GET", credentialsPath, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("%d is not 401 without the token", w.Code)
+	}
+	for _, ttl := range []string{"", "0", "21601", "a"} {
+		if w := serve(s, "PUT", tokenPath, map[string]string{tokenTTLHeader: ttl}); w.Code != http.StatusBadRequest {
+			t.Errorf("%d is not 400 of ttl %q", w.Code, ttl)
+		}
+	}
+	if w := serve(s, "GET", tokenPath, map[string]string{tokenTTLHeader: "60"}); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("%d is not 405 of GET", w.Code)
+	}
+	if w := serve(s, "PUT", tokenPath, map[string]string{tokenTTLHeader: "60", "X-Forwarded-For": "192.0.2.1"}); w.Code != http.StatusForbidden {
+		t.Errorf("%d is not 403 of the forwarded request", w.Code)
+	}
+	w := serve(s, "PUT", tokenPath, map[string]string{tokenTTLHeader: "60"})
+	if w.Code != http.StatusOK || w.Header().Get(tokenTTLHeader) != "60" {
+		t.Fatalf("%d %v is not the token", w.Code, w.Header())
+	}
+	token := w.Body.String()
+	if w := serve(s, "GET", credentialsPath, map[string]string{tokenHeader: token}); w.Code != http.StatusOK {
+		t.Errorf("%d is not 200 with the token", w.Code)
+	}
+	if w := serve(s, "GET", credentialsPath, map[string]string{tokenHeader: "invalid"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("%d is not 401 with the invalid token", w.Code)
+	}
+	now := s.now()
+	s.now = func() time.Time { return now.Add(time.Minute) }
+	if w := serve(s, "GET", credentialsPath, map[string]string{tokenHeader: token}); w.Code != http.StatusUnauthorized {
+		t.Errorf("%d is not 401 with the expired token", w.Code)
+	}
+}
+
+func TestServeTokenLimit(t *testing.T) {
+	s := newTestServer(nil)
+	first := serve(s, "PUT", tokenPath, map[string]string{tokenTTLHeader: "60"}).Body.String()
+	for i := 0; i < maxTokens; i++ {
+		serve(s, "PUT", tokenPath, map[string]string{tokenTTLHeader: "120"})
+	}
+	if len(s.tokens) != maxTokens {
+		t.Errorf("%d tokens are kept", len(s.tokens))
+	}
+	if _, ok := s.tokens[first]; ok {
+		t.Error("the token expiring first is not evicted")
+	}
+}
+
+func TestServeHost(t *testing.T) {
+	s := newTestServer(nil)
+	for host, code := range map[string]int{
+		"169.254.169.254":  http.StatusOK,
+		"127.0.0.1:9911":   http.StatusOK,
+		"[::1]:9911":       http.StatusOK,
+		"localhost:9911":   http.StatusOK,
+		"attacker.example": http.StatusForbidden,
+		"192.0.2.1:9911":   http.StatusForbidden,
+		"":                 http.StatusForbidden,
+	} {
+		r := httptest.NewRequest("GET", credentialsPath, nil)
+		r.Host = host
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("%d is not %d of the host %q", w.Code, code, host)
+		}
+	}
+}
+
+func TestServeIMDSv1(t *testing.T) {
+	s := newTestServer(nil)
+	if w := serve(s, "GET", credentialsPath, nil); w.Code != http.StatusOK {
+		t.Errorf("%d is not 200 without the token", w.Code)
+	}
+	if w := serve(s, "GET", credentialsPath, map[string]string{tokenHeader: "invalid"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("%d is not 401 with the invalid token", w.Code)
+	}
+	if w := serve(s, "POST", credentialsPath, nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("%d is not 405 of POST", w.Code)
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	s := newTestServer(nil)
+	if w := serve(s, "GET", HealthPath, nil); w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Errorf("%d %s is not healthy", w.Code, w.Body.String())
+	}
+	s.Health = func() error { return errors.New("the credentials of admin are not refreshed yet") }
+	if w := serve(s, "GET", HealthPath, nil); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "not refreshed yet") {
+		t.Errorf("%d %s is not unhealthy", w.Code, w.Body.String())
+	}
+}
@@ -17,16 +17,50 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"path/filepath"
+	"strings"
 
 	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
 	"github.com/spf13/cobra"
 )
 
+// endpoint is the global --endpoint flag, which is saved by init command
 var endpoint string
+var oneloginRegion string
 var clientToken string
 var clientSecret string
 var subdomain string
 var usernameOrEmail string
+var configSource string
+var vaultPath string
+var checkUser bool
+var mfaDevice string
+var checkUserChanged bool
+var initService string
+var credentialsSubdomain string
+var proxy string
+var noProxy string
+var proxyUser string
+var caBundle string
+var clientCert string
+var clientKey string
+var pins []string
+var requestTimeout string
+var connectTimeout string
+var tlsHandshakeTimeout string
+var verifyFactorTimeout string
+var idleConnTimeout string
+var http2 string
+var maxIdleConns int
+var maxIdleConnsPerHost int
+var preferIPv4 bool
+var preferIPv4Changed bool
+var dnsResolver string
+var dnsCache bool
+var dnsCacheChanged bool
+var insecureSkipVerify bool
+var insecureSkipVerifyChanged bool
 
 // initCmd represents the init command
 var initCmd = &cobra.Command{
@@ -34,10 +68,19 @@ var initCmd = &cobra.Command{
 	Short: "Initialize settings for call to onelogin api ",
 	Long:  `Init is initializing settings for onelogin api.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if endpoint != "" {
+		if endpoint != "" && !strings.Contains(endpoint, ".") && !strings.Contains(endpoint, ":") {
 			endpoint = fmt.Sprintf("api.%s.onelogin.com", endpoint)
 		}
-		if err := initServiceConfig(configFile, "default"); err != nil {
+		if oneloginRegion != "" {
+			if _, err := config.RegionEndpoint(oneloginRegion); err != nil {
+				errorExit(err)
+			}
+		}
+		checkUserChanged = cmd.Flags().Changed("check-user")
+		insecureSkipVerifyChanged = cmd.Flags().Changed("insecure-skip-verify")
+		preferIPv4Changed = cmd.Flags().Changed("prefer-ipv4")
+		dnsCacheChanged = cmd.Flags().Changed("dns-cache")
+		if err := initServiceConfig(configFile, initService); err != nil {
 			errorExit(err)
 		}
 	},
@@ -45,11 +88,36 @@ var initCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(initCmd)
-	initCmd.Flags().StringVarP(&endpoint, "endpoint", "", "", "OneLogin API Server")
+	initCmd.Flags().StringVarP(&initService, "service", "", config.DefaultService, "Name of the service to initialize, e.g. sandbox for a sandbox tenant with separate client credentials")
+	initCmd.Flags().StringVarP(&oneloginRegion, "region", "", "", "OneLogin API region (us or eu), which is resolved to the endpoint at runtime")
 	initCmd.Flags().StringVarP(&clientToken, "client-token", "", "", "OneLogin API Client Token")
 	initCmd.Flags().StringVarP(&clientSecret, "client-secret", "", "", "OneLogin API Client Secret")
 	initCmd.Flags().StringVarP(&subdomain, "subdomain", "", "", "OneLogin Service Subdomain")
+	initCmd.Flags().StringVarP(&credentialsSubdomain, "credentials-subdomain", "", "", "Save --client-token and --client-secret as the credentials of the subdomain, used by profiles configured with configure --subdomain")
 	initCmd.Flags().StringVarP(&usernameOrEmail, "username-or-email", "", "", "OneLogin Login Username or Email")
+	initCmd.Flags().StringVarP(&configSource, "config-source", "", "", "HTTPS URL or s3:// object of shared profile definitions")
+	initCmd.Flags().StringVarP(&vaultPath, "vault", "", "", "Vault KV path to read client_token, client_secret and password from")
+	initCmd.Flags().StringVarP(&proxy, "proxy", "", "", "Proxy URL of OneLogin API and AWS STS, or direct to ignore HTTPS_PROXY")
+	initCmd.Flags().StringVarP(&noProxy, "no-proxy", "", "", "Comma separated hosts connected without the proxy instead of NO_PROXY")
+	initCmd.Flags().StringVarP(&proxyUser, "proxy-user", "", "", "User of the proxy requiring basic auth, the password is read from ONELOGIN_PROXY_PASSWORD or saved by configure set-password --proxy")
+	initCmd.Flags().StringVarP(&caBundle, "ca-bundle", "", "", "PEM file of CA certificates trusted in addition to the system ones, e.g. the private CA of a TLS-intercepting proxy")
+	initCmd.Flags().StringVarP(&clientCert, "client-cert", "", "", "PEM file of the client certificate for mutual TLS with the OneLogin endpoint, used with --client-key")
+	initCmd.Flags().StringVarP(&clientKey, "client-key", "", "", "PEM file of the private key of --client-cert")
+	initCmd.Flags().StringSliceVarP(&pins, "pin", "", []string{}, "SPKI pin like sha256/AAAA= of the certificate chain of the OneLogin endpoint, which can be repeated. none removes the pins")
+	initCmd.Flags().BoolVarP(&insecureSkipVerify, "insecure-skip-verify", "", false, "Disable verification of server certificates, which exposes the password and AWS credentials. Never use it except for debugging")
+	initCmd.Flags().StringVarP(&requestTimeout, "timeout", "", "", "Overall timeout of each request to OneLogin API and AWS STS (default 1m)")
+	initCmd.Flags().StringVarP(&connectTimeout, "connect-timeout", "", "", "Timeout of establishing connections (default 30s)")
+	initCmd.Flags().StringVarP(&tlsHandshakeTimeout, "tls-handshake-timeout", "", "", "Timeout of TLS handshakes (default 10s)")
+	initCmd.Flags().StringVarP(&verifyFactorTimeout, "verify-factor-timeout", "", "", "Time to wait for the push approval of OneLogin Protect (default 1m)")
+	initCmd.Flags().StringVarP(&http2, "http2", "", "", "HTTP/2 of OneLogin API and AWS STS, force, off for proxies breaking HTTP/2, or auto")
+	initCmd.Flags().StringVarP(&idleConnTimeout, "idle-conn-timeout", "", "", "Time to keep idle connections (default 1m30s)")
+	initCmd.Flags().IntVarP(&maxIdleConns, "max-idle-conns", "", 0, "Maximum number of idle connections (default 100)")
+	initCmd.Flags().IntVarP(&maxIdleConnsPerHost, "max-idle-conns-per-host", "", 0, "Maximum number of idle connections to each host (default 2)")
+	initCmd.Flags().BoolVarP(&preferIPv4, "prefer-ipv4", "", false, "Connect to IPv4 addresses before IPv6 ones for networks breaking IPv6")
+	initCmd.Flags().StringVarP(&dnsResolver, "dns-resolver", "", "", "IP address and optional port of the DNS server used instead of the system resolver")
+	initCmd.Flags().BoolVarP(&dnsCache, "dns-cache", "", false, "Resolve each host only once during a command for slow DNS")
+	initCmd.Flags().StringVarP(&mfaDevice, "mfa-device", "", "", "MFA device ID or type used on login without asking, listed by list-mfa-devices")
+	initCmd.Flags().BoolVarP(&checkUser, "check-user", "", false, "Look up the user with OneLogin Users API before login to tell if the user is not found")
 }
 
 func initServiceConfig(file string, profile string) error {
@@ -64,11 +132,35 @@ func initServiceConfig(file string, profile string) error {
 	if endpoint != "" {
 		serviceConfig.Endpoint = endpoint
 	}
-	if clientToken != "" {
-		serviceConfig.ClientToken = clientToken
+	if oneloginRegion != "" {
+		serviceConfig.Region = strings.ToLower(oneloginRegion)
+		if endpoint == "" {
+			// the region is used instead of the previous endpoint
+			serviceConfig.Endpoint = ""
+		}
 	}
-	if clientSecret != "" {
-		serviceConfig.ClientSecret = clientSecret
+	if credentialsSubdomain != "" {
+		if serviceConfig.Credentials == nil {
+			serviceConfig.Credentials = map[string]*config.CredentialConfig{}
+		}
+		pair, ok := serviceConfig.Credentials[credentialsSubdomain]
+		if !ok {
+			pair = &config.CredentialConfig{}
+			serviceConfig.Credentials[credentialsSubdomain] = pair
+		}
+		if clientToken != "" {
+			pair.ClientToken = clientToken
+		}
+		if clientSecret != "" {
+			pair.ClientSecret = clientSecret
+		}
+	} else {
+		if clientToken != "" {
+			serviceConfig.ClientToken = clientToken
+		}
+		if clientSecret != "" {
+			serviceConfig.ClientSecret = clientSecret
+		}
 	}
 	if subdomain != "" {
 		serviceConfig.Subdomain = subdomain
@@ -76,10 +168,106 @@ func initServiceConfig(file string, profile string) error {
 	if usernameOrEmail != "" {
 		serviceConfig.UsernameOrEmail = usernameOrEmail
 	}
-	c.Service["default"] = serviceConfig
+	if vaultPath != "" {
+		serviceConfig.Vault = vaultPath
+	}
+	if mfaDevice != "" {
+		serviceConfig.MFADevice = mfaDevice
+	}
+	if proxy != "" {
+		if _, err := transport.ProxyFunc(transport.Options{Proxy: proxy}); err != nil {
+			return err
+		}
+		serviceConfig.Proxy = proxy
+	}
+	if noProxy != "" {
+		serviceConfig.NoProxy = noProxy
+	}
+	if proxyUser != "" {
+		serviceConfig.ProxyUser = proxyUser
+	}
+	if caBundle != "" {
+		file, err := filepath.Abs(caBundle)
+		if err != nil {
+			return err
+		}
+		if _, err := transport.New(transport.Options{CABundle: file}); err != nil {
+			return err
+		}
+		serviceConfig.CABundle = file
+	}
+	if clientCert != "" || clientKey != "" {
+		if err := initClientCertificate(serviceConfig); err != nil {
+			return err
+		}
+	}
+	if len(pins) > 0 {
+		if err := initPins(serviceConfig); err != nil {
+			return err
+		}
+	}
+	if insecureSkipVerifyChanged {
+		serviceConfig.InsecureSkipVerify = insecureSkipVerify
+		if insecureSkipVerify {
+			warnInsecureSkipVerify()
+		}
+	}
+	for _, timeout := range []struct {
+		value string
+		saved *string
+	}{
+		{requestTimeout, &serviceConfig.Timeout},
+		{connectTimeout, &serviceConfig.ConnectTimeout},
+		{tlsHandshakeTimeout, &serviceConfig.TLSHandshakeTimeout},
+		{verifyFactorTimeout, &serviceConfig.VerifyFactorTimeout},
+		{idleConnTimeout, &serviceConfig.IdleConnTimeout},
+	} {
+		if timeout.value == "" {
+			continue
+		}
+		if _, err := config.ParseTimeout(timeout.value); err != nil {
+			return err
+		}
+		*timeout.saved = timeout.value
+	}
+	if http2 != "" {
+		if err := initHTTP2(serviceConfig); err != nil {
+			return err
+		}
+	}
+	if maxIdleConns > 0 {
+		serviceConfig.MaxIdleConns = maxIdleConns
+	}
+	if maxIdleConnsPerHost > 0 {
+		serviceConfig.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+	if preferIPv4Changed {
+		serviceConfig.PreferIPv4 = preferIPv4
+	}
+	if dnsResolver != "" {
+		if _, err := transport.New(transport.Options{Resolver: dnsResolver}); err != nil {
+			return err
+		}
+		serviceConfig.DNSResolver = dnsResolver
+	}
+	if dnsCacheChanged {
+		serviceConfig.DNSCache = dnsCache
+	}
+	if checkUserChanged {
+		serviceConfig.CheckUser = checkUser
+	}
+	if configSource != "" {
+		c.ConfigSource = configSource
+	}
+	c.Service[profile] = serviceConfig
 	if err := c.Save(); err != nil {
 		return err
 	}
+	if configSource != "" {
+		if err := c.Sync(); err != nil {
+			return err
+		}
+	}
 	if debug {
 		log.Printf("ServiceConfig: %#v\n", serviceConfig)
 	}
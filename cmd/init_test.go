@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"encoding/pem"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
 )
 
 func TestInitCmdWithoutConfigFile(t *testing.T) {
@@ -13,6 +19,7 @@ func TestInitCmdWithoutConfigFile(t *testing.T) {
 	defer os.Remove(file)
 
 	resetInitFlags()
+	defer resetInitFlags()
 	endpoint = "api-server"
 	clientToken = "client-token"
 	clientSecret = "client-secret"
@@ -28,7 +35,9 @@ func TestInitCmdWithoutConfigFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "api-server"
     client_token = "client-token"
@@ -43,6 +52,215 @@ func TestInitCmdWithoutConfigFile(t *testing.T) {
 	}
 }
 
+func TestInitCmdWithRegion(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-region.toml")
+	defer os.Remove(file)
+	if err := ioutil.WriteFile(file, []byte("version = 1\n\n[service]\n  [service.default]\n    endpoint = \"api.us.onelogin.com\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resetInitFlags()
+	defer resetInitFlags()
+	oneloginRegion = "EU"
+
+	if err := initServiceConfig(file, "default"); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := string(data)
+	expected := `version = 1
+
+[service]
+  [service.default]
+    endpoint = ""
+    region = "eu"
+    client_token = ""
+    client_secret = ""
+    subdomain = ""
+    username_or_email = ""
+
+[app]
+`
+	if actual != expected {
+		t.Errorf("'%v' is not equal '%v'", actual, expected)
+	}
+}
+
+func TestInitCmdWithCABundle(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-ca-bundle.toml")
+	defer os.Remove(file)
+	bundle := path.Join(os.TempDir(), "example-ca-bundle.pem")
+	defer os.Remove(bundle)
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	server.Close()
+	if err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resetInitFlags()
+	defer resetInitFlags()
+	caBundle = path.Join(os.TempDir(), "missing.pem")
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("missing CA bundle is accepted")
+	}
+
+	caBundle = bundle
+	insecureSkipVerify = true
+	insecureSkipVerifyChanged = true
+	if err := initServiceConfig(file, "default"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Service["default"]; s.CABundle != bundle || !s.InsecureSkipVerify {
+		t.Errorf("%#v is unexpected", s)
+	}
+}
+
+func TestInitCmdWithClientCertificate(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-client-cert.toml")
+	defer os.Remove(file)
+
+	resetInitFlags()
+	defer resetInitFlags()
+	clientCert = "fixtures/serviceconfig.toml"
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("the client certificate without the key is accepted")
+	}
+	clientKey = "fixtures/serviceconfig.toml"
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("the invalid client certificate is accepted")
+	}
+}
+
+func TestInitCmdWithPins(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-pins.toml")
+	defer os.Remove(file)
+	pin := "sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	resetInitFlags()
+	defer resetInitFlags()
+	pins = []string{"sha256/invalid"}
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("invalid pin is accepted")
+	}
+	for _, tt := range []struct {
+		pins     []string
+		expected int
+	}{
+		{[]string{pin, pin}, 2},
+		{[]string{}, 2},
+		{[]string{noPins}, 0},
+	} {
+		pins = tt.pins
+		if err := initServiceConfig(file, "default"); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Service["default"].Pins) != tt.expected {
+			t.Errorf("%v is unexpected for %v", c.Service["default"].Pins, tt.pins)
+		}
+	}
+}
+
+func TestInitCmdWithTimeouts(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-timeouts.toml")
+	defer os.Remove(file)
+
+	resetInitFlags()
+	defer resetInitFlags()
+	requestTimeout = "forever"
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("invalid timeout is accepted")
+	}
+	requestTimeout = "20s"
+	connectTimeout = "5"
+	verifyFactorTimeout = "3m"
+	if err := initServiceConfig(file, "default"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeouts, err := c.Service["default"].Timeouts()
+	if err != nil || timeouts.Request != 20*time.Second || timeouts.Connect != 5*time.Second || timeouts.TLSHandshake != 0 || timeouts.VerifyFactor != 3*time.Minute {
+		t.Errorf("%#v, %v is unexpected", timeouts, err)
+	}
+	if options := serviceTransport(*c.Service["default"]); options.Timeout != 20*time.Second || options.DialTimeout != 5*time.Second {
+		t.Errorf("%#v is unexpected", options)
+	}
+}
+
+func TestInitCmdWithHTTP2(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-http2.toml")
+	defer os.Remove(file)
+
+	resetInitFlags()
+	defer resetInitFlags()
+	http2 = "on"
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("invalid HTTP/2 setting is accepted")
+	}
+	for _, tt := range []struct {
+		http2    string
+		expected string
+	}{
+		{"OFF", "off"},
+		{"", "off"},
+		{"auto", ""},
+	} {
+		http2 = tt.http2
+		idleConnTimeout = "30s"
+		maxIdleConnsPerHost = 8
+		if err := initServiceConfig(file, "default"); err != nil {
+			t.Fatal(err)
+		}
+		c, err := config.Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := serviceTransport(*c.Service["default"])
+		if options.HTTP2 != tt.expected || options.IdleConnTimeout != 30*time.Second || options.MaxIdleConnsPerHost != 8 || options.MaxIdleConns != 0 {
+			t.Errorf("%#v is unexpected for %q", options, tt.http2)
+		}
+	}
+}
+
+func TestInitCmdWithDNS(t *testing.T) {
+	file := path.Join(os.TempDir(), "example-dns.toml")
+	defer os.Remove(file)
+
+	resetInitFlags()
+	defer resetInitFlags()
+	dnsResolver = "dns.example.com"
+	if err := initServiceConfig(file, "default"); err == nil {
+		t.Error("invalid DNS server is accepted")
+	}
+	dnsResolver = "10.0.0.2"
+	preferIPv4, preferIPv4Changed = true, true
+	dnsCache, dnsCacheChanged = true, true
+	if err := initServiceConfig(file, "default"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options := serviceTransport(*c.Service["default"]); options.Resolver != "10.0.0.2" || !options.PreferIPv4 || !options.DNSCache {
+		t.Errorf("%#v is unexpected", options)
+	}
+}
+
 func TestInitCmdWithConfigFile(t *testing.T) {
 	source, err := os.Open("fixtures/serviceconfig.toml")
 	if err != nil {
@@ -60,6 +278,7 @@ func TestInitCmdWithConfigFile(t *testing.T) {
 	}
 
 	resetInitFlags()
+	defer resetInitFlags()
 	endpoint = "new-api-server"
 	clientToken = "new-client-token"
 	clientSecret = "new-client-secret"
@@ -75,7 +294,9 @@ func TestInitCmdWithConfigFile(t *testing.T) {
 		t.Errorf("%#v", err)
 	}
 	actual := string(data)
-	expected := `[service]
+	expected := `version = 1
+
+[service]
   [service.default]
     endpoint = "new-api-server"
     client_token = "new-client-token"
@@ -96,4 +317,34 @@ func resetInitFlags() {
 	clientSecret = ""
 	subdomain = ""
 	usernameOrEmail = ""
+	configSource = ""
+	vaultPath = ""
+	oneloginRegion = ""
+	checkUser = false
+	mfaDevice = ""
+	checkUserChanged = false
+	initService = config.DefaultService
+	credentialsSubdomain = ""
+	proxy = ""
+	noProxy = ""
+	proxyUser = ""
+	caBundle = ""
+	clientCert = ""
+	clientKey = ""
+	pins = []string{}
+	requestTimeout = ""
+	connectTimeout = ""
+	tlsHandshakeTimeout = ""
+	verifyFactorTimeout = ""
+	idleConnTimeout = ""
+	http2 = ""
+	maxIdleConns = 0
+	maxIdleConnsPerHost = 0
+	preferIPv4 = false
+	preferIPv4Changed = false
+	dnsResolver = ""
+	dnsCache = false
+	dnsCacheChanged = false
+	insecureSkipVerify = false
+	insecureSkipVerifyChanged = false
 }
@@ -0,0 +1,10 @@
+// Package ipc listens and dials the local endpoints of the agent and the daemon, which only the user can connect.
+// They are unix domain sockets checked by the peer credentials on Unix, and named pipes with the DACL of the user on Windows
+package ipc
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrInUse is returned by Listen when another process is listening on the path
+var ErrInUse = errors.New("the endpoint is in use")
@@ -0,0 +1,120 @@
+//go:build !windows
+// +build !windows
+
+package ipc
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Listen creates the unix domain socket accessible only by the user.
+// Connections from other users are closed by the peer credentials where the OS tells them
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, ErrInUse
+	}
+	dir := filepath.Dir(path)
+	if err := checkDir(dir); err != nil {
+		return nil, err
+	}
+	// only the stale socket is replaced, a file at the path may be the one of the user
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket == 0 {
+		return nil, errors.Errorf("%s is not a socket, remove it or use another path", path)
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	// the socket is created in a directory which only the user can enter, so others cannot connect before the chmod,
+	// and it is renamed to the path, which replaces the stale socket
+	tmp, err := os.MkdirTemp(dir, ".ipc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	created := filepath.Join(tmp, "sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: created, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	l.SetUnlinkOnClose(false)
+	if err := os.Chmod(created, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(created, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &listener{UnixListener: l, uid: os.Getuid(), path: path}, nil
+}
+
+// Dial connects to the unix domain socket
+func Dial(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
+
+// checkDir rejects the directory which others can write without the sticky bit, they could replace the socket
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0022 != 0 && info.Mode()&os.ModeSticky == 0 {
+		return errors.Errorf("%s is writable by others, the socket must be in a directory which only the user can write", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() && stat.Uid != 0 {
+		return errors.Errorf("%s is owned by another user, the socket must be in a directory of the user", dir)
+	}
+	return nil
+}
+
+// listener accepts only the connections of the user
+type listener struct {
+	*net.UnixListener
+	uid  int
+	path string
+}
+
+// Close stops listening and removes the socket, which is not the address bound by the listener after the rename
+func (l *listener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// Accept returns the next connection of the user, the others are closed
+func (l *listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+		if l.allowed(conn) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// allowed checks the uid of the peer, which is allowed by the permissions of the socket if the OS does not tell it
+func (l *listener) allowed(conn *net.UnixConn) bool {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var uid int
+	var known bool
+	var peerErr error
+	if err := raw.Control(func(fd uintptr) {
+		uid, known, peerErr = peerUID(int(fd))
+	}); err != nil || peerErr != nil {
+		return false
+	}
+	return !known || uid == l.uid
+}
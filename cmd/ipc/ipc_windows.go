@@ -0,0 +1,224 @@
+package ipc
+
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+var procGetNamedPipeServerProcessID = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetNamedPipeServerProcessId")
+
+// pipeBufferSize is the buffer size of a pipe instance, the requests and the responses are a line of JSON
+const pipeBufferSize = 4096
+
+// pipeName returns the named pipe of the path, which has the path in the name to be unique
+func pipeName(path string) string {
+	return `\\.\pipe\onelogin-aws-connector-` + strings.NewReplacer(`\`, "-", "/", "-", ":", "").Replace(path)
+}
+
+// Listen creates the named pipe whose DACL allows only the user, and which rejects remote clients
+func Listen(path string) (net.Listener, error) {
+	sa, err := userSecurityAttributes()
+	if err != nil {
+		return nil, err
+	}
+	l := &pipeListener{name: pipeName(path), sa: sa}
+	// the first instance fails if another process has the pipe, so the pipe cannot be squatted
+	h, err := l.create(windows.FILE_FLAG_FIRST_PIPE_INSTANCE)
+	if err == windows.ERROR_ACCESS_DENIED {
+		return nil, ErrInUse
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.pending = h
+	return l, nil
+}
+
+// Dial connects to the named pipe, it waits while all the instances are busy until the timeout.
+// The server can only identify the client, and cannot impersonate it.
+// The pipe name is predictable in the namespace of the machine, so the pipe created by another user is refused
+func Dial(path string, timeout time.Duration) (net.Conn, error) {
+	return dialPipe(pipeName(path), timeout)
+}
+
+func dialPipe(name string, timeout time.Duration) (net.Conn, error) {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		h, err := windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING,
+			windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+		if err == nil {
+			if err := checkServer(h, name); err != nil {
+				windows.CloseHandle(h)
+				return nil, err
+			}
+			return newPipeConn(h, name), nil
+		}
+		if err != windows.ERROR_PIPE_BUSY || time.Now().After(deadline) {
+			return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr(name), Err: err}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// checkServer verifies that the server process of the connected pipe runs as the current user,
+// another user could create the pipe before the agent or the daemon to read the requests and return forged credentials
+func checkServer(h windows.Handle, name string) error {
+	var pid uint32
+	if r, _, err := procGetNamedPipeServerProcessID.Call(uintptr(h), uintptr(unsafe.Pointer(&pid))); r == 0 {
+		return errors.Wrapf(err, "failed to get the server of %s", name)
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the server of %s, it may be run by another user", name)
+	}
+	defer windows.CloseHandle(process)
+	var token windows.Token
+	if err := windows.OpenProcessToken(process, windows.TOKEN_QUERY, &token); err != nil {
+		return errors.Wrapf(err, "failed to open the token of the server of %s, it may be run by another user", name)
+	}
+	defer token.Close()
+	server, err := token.GetTokenUser()
+	if err != nil {
+		return err
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+	if !server.User.Sid.Equals(user.User.Sid) {
+		return errors.Errorf("%s is created by another user %s, it is refused", name, server.User.Sid)
+	}
+	return nil
+}
+
+// userSecurityAttributes returns the protected DACL granting the full access only to the current user
+func userSecurityAttributes() (*windows.SecurityAttributes, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + user.User.Sid.String() + ")")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the security descriptor of the named pipe")
+	}
+	sa := &windows.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	return sa, nil
+}
+
+// pipeListener accepts a connection by each instance of the named pipe
+type pipeListener struct {
+	name    string
+	sa      *windows.SecurityAttributes
+	mu      sync.Mutex
+	pending windows.Handle
+	// accepting is true while Accept waits for a client on the pending instance
+	accepting bool
+	closed    bool
+}
+
+func (l *pipeListener) create(flags uint32) (windows.Handle, error) {
+	p, err := windows.UTF16PtrFromString(l.name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+	return windows.CreateNamedPipe(p, windows.PIPE_ACCESS_DUPLEX|flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, pipeBufferSize, pipeBufferSize, 0, l.sa)
+}
+
+// Accept waits for a client on the pending instance, and creates the next instance
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errors.New("use of closed named pipe")
+	}
+	h := l.pending
+	if h == 0 {
+		var err error
+		if h, err = l.create(0); err != nil {
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.pending = h
+	}
+	l.accepting = true
+	l.mu.Unlock()
+	err := windows.ConnectNamedPipe(h, nil)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = 0
+	l.accepting = false
+	if l.closed {
+		windows.CloseHandle(h)
+		return nil, errors.New("use of closed named pipe")
+	}
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	return newPipeConn(h, l.name), nil
+}
+
+// Close closes the pending instance, which is connected to wake up Accept waiting on it
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	if !l.accepting {
+		if l.pending != 0 {
+			windows.CloseHandle(l.pending)
+			l.pending = 0
+		}
+		l.mu.Unlock()
+		return nil
+	}
+	l.mu.Unlock()
+	if conn, err := dialPipe(l.name, time.Second); err == nil {
+		conn.Close()
+	}
+	return nil
+}
+
+// Addr returns the name of the named pipe
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.name)
+}
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn is a connection of a synchronous instance of the named pipe, which has no deadline
+type pipeConn struct {
+	*os.File
+	name string
+}
+
+func newPipeConn(h windows.Handle, name string) *pipeConn {
+	return &pipeConn{File: os.NewFile(uintptr(h), name), name: name}
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return pipeAddr(c.name) }
+func (c *pipeConn) RemoteAddr() net.Addr { return pipeAddr(c.name) }
+
+// SetDeadline, SetReadDeadline and SetWriteDeadline are ignored by the synchronous instance
+func (c *pipeConn) SetDeadline(t time.Time) error      { return nil }
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return nil }
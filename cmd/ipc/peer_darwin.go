@@ -0,0 +1,14 @@
+package ipc
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// peerUID returns the uid of the peer by LOCAL_PEERCRED
+func peerUID(fd int) (int, bool, error) {
+	cred, err := unix.GetsockoptXucred(fd, unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	if err != nil {
+		return 0, false, err
+	}
+	return int(cred.Uid), true, nil
+}
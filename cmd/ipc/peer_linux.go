@@ -0,0 +1,14 @@
+package ipc
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// peerUID returns the uid of the peer by SO_PEERCRED
+func peerUID(fd int) (int, bool, error) {
+	cred, err := unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+	if err != nil {
+		return 0, false, err
+	}
+	return int(cred.Uid), true, nil
+}
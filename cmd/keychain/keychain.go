@@ -0,0 +1,50 @@
+package keychain
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Service is the service name of items stored in the keychain
+const Service = "onelogin-aws-connector"
+
+// ErrNotFound is returned when no password is stored for the account
+var ErrNotFound = errors.New("password is not found in the keychain")
+
+// Command runs an external command with input, and returns its output
+var Command = func(name string, input []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// Set stores the password of the account
+func Set(account, password string) error {
+	return errors.Wrap(set(account, password), "failed to store the password in the keychain")
+}
+
+// Get returns the stored password of the account, or ErrNotFound
+func Get(account string) (string, error) {
+	password, err := get(account)
+	if err != nil && err != ErrNotFound {
+		return "", errors.Wrap(err, "failed to read the password from the keychain")
+	}
+	return password, err
+}
+
+// Delete removes the stored password of the account, it succeeds if no password is stored
+func Delete(account string) error {
+	if err := remove(account); err != nil && err != ErrNotFound {
+		return errors.Wrap(err, "failed to delete the password from the keychain")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package keychain
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// exit status of security command when the item is not found
+const errSecItemNotFound = 44
+
+// set stores the password into the login keychain with security command of macOS.
+// The command is given on stdin of the interactive mode, so the password never appears in the arguments.
+func set(account, password string) error {
+	if strings.ContainsAny(password, "\r\n") {
+		return errors.New("password must not contain a line break")
+	}
+	command := []string{"add-generic-password", "-U", "-s", Service, "-a", account, "-w", password}
+	for i, arg := range command {
+		command[i] = quote(arg)
+	}
+	if _, err := Command("security", []byte(strings.Join(command, " ")+"\n"), "-i"); err != nil {
+		return err
+	}
+	// the interactive mode may exit successfully though the command in it failed
+	if stored, err := get(account); err != nil || stored != password {
+		return errors.Errorf("security command did not store the password: %v", err)
+	}
+	return nil
+}
+
+// quote escapes the argument for a command line of the interactive mode of security command
+func quote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func get(account string) (string, error) {
+	out, err := Command("security", nil, "find-generic-password", "-s", Service, "-a", account, "-w")
+	if err != nil {
+		return "", notFound(err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func remove(account string) error {
+	_, err := Command("security", nil, "delete-generic-password", "-s", Service, "-a", account)
+	return notFound(err)
+}
+
+func notFound(err error) error {
+	if exit, ok := errors.Cause(err).(*exec.ExitError); ok && exit.ExitCode() == errSecItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package keychain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	var calls [][]string
+	var inputs []string
+	defer func(c func(string, []byte, ...string) ([]byte, error)) { Command = c }(Command)
+	Command = func(name string, input []byte, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		inputs = append(inputs, string(input))
+		if args[0] == "find-generic-password" {
+			return []byte(`se"c\ret` + "\n"), nil
+		}
+		return nil, nil
+	}
+	if err := Set("default", `se"c\ret`); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"security", "-i"},
+		{"security", "find-generic-password", "-s", "onelogin-aws-connector", "-a", "default", "-w"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("%v is not equal %v", calls, expected)
+	}
+	input := `"add-generic-password" "-U" "-s" "onelogin-aws-connector" "-a" "default" "-w" "se\"c\\ret"` + "\n"
+	if inputs[0] != input {
+		t.Errorf("%s is not equal %s", inputs[0], input)
+	}
+	for _, call := range calls {
+		if strings.Contains(strings.Join(call, " "), "ret") {
+			t.Errorf("the password is passed to the arguments %v", call)
+		}
+	}
+	if err := Set("default", "sec\nret"); err == nil {
+		t.Error("a password with a line break is stored")
+	}
+}
@@ -0,0 +1,37 @@
+package keychain
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// set stores the password into Secret Service (GNOME Keyring, KWallet) with secret-tool command of libsecret
+func set(account, password string) error {
+	_, err := Command("secret-tool", []byte(password), "store", "--label", Service+" "+account, "service", Service, "account", account)
+	return err
+}
+
+func get(account string) (string, error) {
+	out, err := Command("secret-tool", nil, "lookup", "service", Service, "account", account)
+	if err != nil {
+		return "", notFound(err)
+	}
+	if len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return string(out), nil
+}
+
+func remove(account string) error {
+	_, err := Command("secret-tool", nil, "clear", "service", Service, "account", account)
+	return notFound(err)
+}
+
+// notFound converts the exit status 1 of secret-tool, which means no matching item, to ErrNotFound
+func notFound(err error) error {
+	if exit, ok := errors.Cause(err).(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		return ErrNotFound
+	}
+	return err
+}
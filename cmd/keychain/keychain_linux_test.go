@@ -0,0 +1,59 @@
+package keychain
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSecretTool(t *testing.T) {
+	var calls [][]string
+	var inputs []string
+	defer func(c func(string, []byte, ...string) ([]byte, error)) { Command = c }(Command)
+	Command = func(name string, input []byte, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		inputs = append(inputs, string(input))
+		if args[0] == "lookup" {
+			return []byte("secret"), nil
+		}
+		return nil, nil
+	}
+	if err := Set("default", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	password, err := Get("default")
+	if err != nil || password != "secret" {
+		t.Errorf("%v, %v is unexpected", password, err)
+	}
+	if err := Delete("default"); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"secret-tool", "store", "--label", "onelogin-aws-connector default", "service", "onelogin-aws-connector", "account", "default"},
+		{"secret-tool", "lookup", "service", "onelogin-aws-connector", "account", "default"},
+		{"secret-tool", "clear", "service", "onelogin-aws-connector", "account", "default"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("%v is not equal %v", calls, expected)
+	}
+	if inputs[0] != "secret" {
+		t.Errorf("%s is not passed to stdin", inputs[0])
+	}
+}
+
+func TestSecretToolNotFound(t *testing.T) {
+	defer func(c func(string, []byte, ...string) ([]byte, error)) { Command = c }(Command)
+	Command = func(name string, input []byte, args ...string) ([]byte, error) {
+		err := exec.Command("sh", "-c", "exit 1").Run()
+		return nil, errors.Wrapf(err, "%s: %s", name, strings.Join(args, " "))
+	}
+	if _, err := Get("unknown"); err != ErrNotFound {
+		t.Errorf("%v is not ErrNotFound", err)
+	}
+	if err := Delete("unknown"); err != nil {
+		t.Errorf("%v is unexpected", err)
+	}
+}
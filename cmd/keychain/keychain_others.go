@@ -0,0 +1,22 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package keychain
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func set(account, password string) error {
+	return errors.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
+
+func get(account string) (string, error) {
+	return "", errors.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
+
+func remove(account string) error {
+	return errors.Errorf("keychain is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+var listAllApps bool
+var listAppsConnectorIDs []int
+
+// awsConnectorIDs are the connectors of AWS in the OneLogin app catalog, "Amazon Web Services (AWS) Multi Role"
+var awsConnectorIDs = []int{50534}
+
+// listAppsCmd represents the list-apps command
+var listAppsCmd = &cobra.Command{
+	Use:   "list-apps",
+	Short: "List AWS apps assigned to the OneLogin user",
+	Long: `List-apps is printing a table of AWS apps assigned to the configured OneLogin user,
+to find the app ID for configure command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		service, err := fetchService(c, profileApp(c))
+		if err != nil {
+			errorExit(err)
+		}
+		if err := listApps(os.Stdout, c, newUsersAPI(service), service.UsernameOrEmail); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(listAppsCmd)
+	listAppsCmd.Flags().BoolVarP(&listAllApps, "all", "", false, "List all apps including non-AWS apps")
+	listAppsCmd.Flags().IntSliceVarP(&listAppsConnectorIDs, "connector-id", "", nil, "Connector IDs of AWS apps in addition to the AWS connector of the app catalog, like a custom SAML connector")
+}
+
+// usersAPI is OneLogin Users API used by list-apps and list-mfa-devices
+type usersAPI interface {
+	Find(ctx context.Context, usernameOrEmail string) (*users.User, error)
+	Apps(ctx context.Context, userID int) ([]users.App, error)
+	Devices(ctx context.Context, userID int) ([]users.Device, error)
+}
+
+// newUsersAPI creates OneLogin Users API client with the cached access token
+var newUsersAPI = func(service config.ServiceConfig) usersAPI {
+	return users.NewUsers(newOneloginConfig(service))
+}
+
+type appEntry struct {
+	AppID       string   `json:"app_id"`
+	Name        string   `json:"name"`
+	ConnectorID int      `json:"connector_id"`
+	Profiles    []string `json:"profiles"`
+}
+
+// awsConnectors returns the connector IDs of AWS apps, the AWS connector of the catalog, --connector-id
+// and the connectors of configured apps, which are AWS apps whatever their connectors are
+func awsConnectors(c *config.Config, apps []users.App) map[int]bool {
+	connectors := map[int]bool{}
+	for _, id := range append(append([]int{}, awsConnectorIDs...), listAppsConnectorIDs...) {
+		connectors[id] = true
+	}
+	for _, app := range apps {
+		if len(configuredProfiles(c, app)) > 0 {
+			connectors[app.ConnectorID] = true
+		}
+	}
+	return connectors
+}
+
+// configuredProfiles returns the profiles configured with the app by the app ID or the name
+func configuredProfiles(c *config.Config, app users.App) []string {
+	id := strconv.Itoa(app.ID)
+	profiles := []string{}
+	for name, configured := range c.App {
+		if configured.AppID == id || configured.AppID == "" && strings.EqualFold(configured.AppName, app.Name) {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+func listApps(out io.Writer, c *config.Config, api usersAPI, usernameOrEmail string) error {
+	ctx := context.Background()
+	user, err := api.Find(ctx, usernameOrEmail)
+	if err != nil {
+		return err
+	}
+	apps, err := api.Apps(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	connectors := awsConnectors(c, apps)
+	entries := []appEntry{}
+	for _, app := range apps {
+		if !listAllApps && !connectors[app.ConnectorID] {
+			continue
+		}
+		entries = append(entries, appEntry{
+			AppID:       strconv.Itoa(app.ID),
+			Name:        app.Name,
+			ConnectorID: app.ConnectorID,
+			Profiles:    configuredProfiles(c, app),
+		})
+	}
+	if output == outputJSON {
+		return writeJSON(out, entries)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "APP ID\tNAME\tCONNECTOR ID\tPROFILES")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", e.AppID, e.Name, e.ConnectorID, strings.Join(e.Profiles, ","))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+type usersAPIMock struct {
+	user    *users.User
+	apps    []users.App
+	devices []users.Device
+	err     error
+}
+
+func (m *usersAPIMock) Find(ctx context.Context, usernameOrEmail string) (*users.User, error) {
+	return m.user, m.err
+}
+
+func (m *usersAPIMock) Apps(ctx context.Context, userID int) ([]users.App, error) {
+	return m.apps, nil
+}
+
+func (m *usersAPIMock) Devices(ctx context.Context, userID int) ([]users.Device, error) {
+	return m.devices, nil
+}
+
+func TestListApps(t *testing.T) {
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	c.App["default"].AppID = "3"
+	api := &usersAPIMock{
+		user: &users.User{ID: 1},
+		apps: []users.App{
+			{ID: 1, Name: "AWS Production", ConnectorID: 50534},
+			{ID: 2, Name: "Slack", ConnectorID: 100},
+			{ID: 3, Name: "Sandbox", ConnectorID: 50534},
+			{ID: 4, Name: "AWS Status Page", ConnectorID: 200},
+			{ID: 5, Name: "Legacy Account", ConnectorID: 300},
+		},
+	}
+	var buf bytes.Buffer
+	if err := listApps(&buf, c, api, "user"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `APP ID  NAME            CONNECTOR ID  PROFILES
+1       AWS Production  50534         
+3       Sandbox         50534         default
+`
+	if buf.String() != expected {
+		t.Errorf("'%v' is not equal '%v'", buf.String(), expected)
+	}
+
+	listAllApps = true
+	defer func() { listAllApps = false }()
+	buf.Reset()
+	if err := listApps(&buf, c, api, "user"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Slack")) {
+		t.Errorf("'%v' does not contain Slack", buf.String())
+	}
+
+	listAllApps = false
+	listAppsConnectorIDs = []int{300}
+	defer func() { listAppsConnectorIDs = nil }()
+	buf.Reset()
+	if err := listApps(&buf, c, api, "user"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Legacy Account")) || bytes.Contains(buf.Bytes(), []byte("AWS Status Page")) {
+		t.Errorf("'%v' is not listed by the connector ID", buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+// listMFADevicesCmd represents the list-mfa-devices command
+var listMFADevicesCmd = &cobra.Command{
+	Use:   "list-mfa-devices",
+	Short: "List MFA devices enrolled by the OneLogin user",
+	Long: `List-mfa-devices is printing a table of MFA devices enrolled by the configured OneLogin user,
+to find the device for init --mfa-device.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		service, err := fetchService(c, profileApp(c))
+		if err != nil {
+			errorExit(err)
+		}
+		if err := listMFADevices(os.Stdout, newUsersAPI(service), service); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(listMFADevicesCmd)
+}
+
+type deviceEntry struct {
+	DeviceID string `json:"device_id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Default  bool   `json:"default"`
+	Active   bool   `json:"active"`
+	Pinned   bool   `json:"pinned"`
+}
+
+func listMFADevices(out io.Writer, api usersAPI, service config.ServiceConfig) error {
+	ctx := context.Background()
+	user, err := api.Find(ctx, service.UsernameOrEmail)
+	if err != nil {
+		return err
+	}
+	devices, err := api.Devices(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	entries := []deviceEntry{}
+	for _, device := range devices {
+		id := strconv.Itoa(device.ID)
+		entries = append(entries, deviceEntry{
+			DeviceID: id,
+			Type:     device.TypeDisplayName,
+			Name:     device.UserDisplayName,
+			Default:  device.Default,
+			Active:   device.Active,
+			Pinned:   service.MFADevice != "" && (service.MFADevice == id || strings.EqualFold(service.MFADevice, device.TypeDisplayName)),
+		})
+	}
+	if output == outputJSON {
+		return writeJSON(out, entries)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DEVICE ID\tTYPE\tNAME\tDEFAULT\tACTIVE\tPINNED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.DeviceID, e.Type, e.Name, mark(e.Default), mark(e.Active), mark(e.Pinned))
+	}
+	return w.Flush()
+}
+
+func mark(b bool) string {
+	if b {
+		return "*"
+	}
+	return ""
+}
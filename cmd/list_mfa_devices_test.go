@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/users"
+)
+
+func TestListMFADevices(t *testing.T) {
+	api := &usersAPIMock{
+		user: &users.User{ID: 1},
+		devices: []users.Device{
+			{ID: 100, TypeDisplayName: "OneLogin Protect", UserDisplayName: "iPhone", Active: true, Default: true},
+			{ID: 200, TypeDisplayName: "Google Authenticator", UserDisplayName: "Android", Active: true},
+		},
+	}
+	var buf bytes.Buffer
+	if err := listMFADevices(&buf, api, config.ServiceConfig{MFADevice: "google authenticator"}); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `DEVICE ID  TYPE                  NAME     DEFAULT  ACTIVE  PINNED
+100        OneLogin Protect      iPhone   *        *       
+200        Google Authenticator  Android           *       *
+`
+	if buf.String() != expected {
+		t.Errorf("'%v' is not equal '%v'", buf.String(), expected)
+	}
+}
+
+func TestPinnedDevice(t *testing.T) {
+	devices := []samlassertion.GenerateResponseFactorDevice{
+		{DeviceID: 100, DeviceType: "OneLogin Protect"},
+		{DeviceID: 200, DeviceType: "Google Authenticator"},
+	}
+	tests := []struct {
+		pinned   string
+		expected int
+		ok       bool
+	}{
+		{"200", 1, true},
+		{"onelogin protect", 0, true},
+		{"300", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		event := &LoginEvent{conf: &config.Config{Service: map[string]*config.ServiceConfig{"default": {MFADevice: tt.pinned}}}}
+		i, ok := event.pinnedDevice(devices)
+		if i != tt.expected || ok != tt.ok {
+			t.Errorf("%s: %d, %v is not %d, %v", tt.pinned, i, ok, tt.expected, tt.ok)
+		}
+	}
+}
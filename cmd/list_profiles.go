@@ -0,0 +1,94 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+// listProfilesCmd represents the list-profiles command
+var listProfilesCmd = &cobra.Command{
+	Use:   "list-profiles",
+	Short: "List configured profiles",
+	Long:  `List-profiles is printing a table of configured profiles.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		if err := listProfiles(os.Stdout, c); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(listProfilesCmd)
+}
+
+type profileEntry struct {
+	Profile   string `json:"profile"`
+	AppID     string `json:"app_id"`
+	Subdomain string `json:"subdomain"`
+	RoleArn   string `json:"role_arn"`
+	RoleAlias string `json:"role_alias,omitempty"`
+	Duration  string `json:"duration"`
+}
+
+func listProfiles(out io.Writer, c *config.Config) error {
+	entries := []profileEntry{}
+	profiles, _ := profileItems(c)
+	for _, profile := range profiles {
+		app := c.App[profile]
+		subdomain := app.Subdomain
+		if service, ok := c.Service[app.ServiceName()]; ok && subdomain == "" {
+			subdomain = service.Subdomain
+		}
+		role := c.ResolveRole(app.RoleArn)
+		duration := "invalid"
+		if seconds, err := app.SessionDuration(); err == nil {
+			duration = (time.Duration(seconds) * time.Second).String()
+		}
+		entries = append(entries, profileEntry{
+			Profile:   profile,
+			AppID:     app.AppID,
+			Subdomain: subdomain,
+			RoleArn:   role,
+			RoleAlias: c.RoleAlias(role),
+			Duration:  duration,
+		})
+	}
+	if output == outputJSON {
+		return writeJSON(out, entries)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tAPP ID\tSUBDOMAIN\tROLE ARN\tDURATION")
+	for _, e := range entries {
+		role := e.RoleArn
+		if e.RoleAlias != "" {
+			role = fmt.Sprintf("%s (%s)", role, e.RoleAlias)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Profile, e.AppID, e.Subdomain, role, e.Duration)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestListProfiles(t *testing.T) {
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	c.Alias["admin"] = "role-arn"
+	c.App["other"].Duration = "8h"
+	var buf bytes.Buffer
+	if err := listProfiles(&buf, c); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `PROFILE  APP ID        SUBDOMAIN  ROLE ARN          DURATION
+default  app-id        subdomain  role-arn (admin)  1h0m0s
+other    other-app-id  subdomain  other-role-arn    8h0m0s
+`
+	if buf.String() != expected {
+		t.Errorf("'%v' is not equal '%v'", buf.String(), expected)
+	}
+}
+
+func TestListProfilesSandboxService(t *testing.T) {
+	c, err := config.Load("fixtures/sandbox.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := listProfiles(&buf, c); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `PROFILE  APP ID          SUBDOMAIN          ROLE ARN          DURATION
+default  app-id          subdomain          role-arn          1h0m0s
+missing  missing-app-id                     missing-role-arn  1h0m0s
+sandbox  app-id          sandbox-subdomain  sandbox-role-arn  1h0m0s
+`
+	if buf.String() != expected {
+		t.Errorf("'%v' is not equal '%v'", buf.String(), expected)
+	}
+}
+
+func TestListProfilesJSON(t *testing.T) {
+	output = outputJSON
+	defer func() { output = outputText }()
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	c.Alias["admin"] = "role-arn"
+	var buf bytes.Buffer
+	if err := listProfiles(&buf, c); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `[
+  {
+    "profile": "default",
+    "app_id": "app-id",
+    "subdomain": "subdomain",
+    "role_arn": "role-arn",
+    "role_alias": "admin",
+    "duration": "1h0m0s"
+  },
+  {
+    "profile": "other",
+    "app_id": "other-app-id",
+    "subdomain": "subdomain",
+    "role_arn": "other-role-arn",
+    "duration": "1h0m0s"
+  }
+]
+`
+	if buf.String() != expected {
+		t.Errorf("'%v' is not equal '%v'", buf.String(), expected)
+	}
+}
@@ -16,13 +16,16 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -33,21 +36,41 @@ import (
 
 	"github.com/lifull-dev/onelogin-aws-connector/aws/configuration"
 	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
 	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login/prompt"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
 )
 
 var region string
 var force bool
+var role string
+var group string
+var loginDuration string
+var loginPolicy string
+var loginPolicyArns []string
+var loginAll bool
+var loginJobs int
 
 type LoginEvent struct {
 	reader *bufio.Reader
+	conf   *config.Config
+	// service is the name of the service the profile logs in with
+	service string
+	// approval shows the spinner while waiting for the push approval
+	approval *prompt.Event
+	// prompt serializes the prompts of profiles logged in concurrently if it is not nil
+	prompt *sync.Mutex
 }
 
-func NewLoginEvent(reader *bufio.Reader) *LoginEvent {
+func NewLoginEvent(reader *bufio.Reader, conf *config.Config) *LoginEvent {
 	return &LoginEvent{
-		reader: reader,
+		reader:   reader,
+		conf:     conf,
+		approval: &prompt.Event{Out: infoOut(), TTY: terminal.IsTerminal(int(os.Stdout.Fd()))},
 	}
 }
 
@@ -59,49 +82,307 @@ func (m *LoginEvent) ChooseDeviceIndex(devices []samlassertion.GenerateResponseF
 			log.Printf("  %v:\t\t%v\n", device.DeviceID, device.DeviceType)
 		}
 	}
-	length := len(devices)
-	selected := length
-	for {
-		fmt.Println("--------")
-		for i, device := range devices {
-			fmt.Printf("%d : %s\n", i, device.DeviceType)
-		}
-		fmt.Println("--------")
-		fmt.Print("Select your MFA device: ")
-		tmp, err := m.reader.ReadString('\n')
-		if err != nil {
-			return 0, err
+	if i, ok := m.pinnedDevice(devices); ok {
+		return i, nil
+	}
+	if i, ok := unattendedDevice(devices); ok && daemonMode {
+		return i, nil
+	}
+	if err := requirePrompt("MFA device selection", exitDeviceRequired); err != nil {
+		return 0, err
+	}
+	items := make([]string, len(devices))
+	for i, device := range devices {
+		items[i] = device.DeviceType
+	}
+	selected, err := m.selectIndex(i18n.T("Select your MFA device: "), items)
+	return selected, inputClosed(err, "MFA device selection", exitDeviceRequired)
+}
+
+// pinnedDevice returns the index of the device configured by its ID or type
+func (m *LoginEvent) pinnedDevice(devices []samlassertion.GenerateResponseFactorDevice) (int, bool) {
+	if m.conf == nil {
+		return 0, false
+	}
+	name := m.service
+	if name == "" {
+		name = config.DefaultService
+	}
+	service, ok := m.conf.Service[name]
+	if !ok || service.MFADevice == "" {
+		return 0, false
+	}
+	for i, device := range devices {
+		if strconv.Itoa(device.DeviceID) == service.MFADevice || strings.EqualFold(device.DeviceType, service.MFADevice) {
+			return i, true
 		}
-		tmp = strings.Trim(tmp, "\n")
-		if tmp == "" {
-			continue
+	}
+	fmt.Fprint(os.Stderr, i18n.Sprintf("Warning: MFA device %s is not found\n", service.MFADevice))
+	return 0, false
+}
+
+// unattendedDevice returns the push notification of OneLogin Protect, or the first device of OTP tokens
+// which the agent or ONELOGIN_MFA_TOKEN may give
+func unattendedDevice(devices []samlassertion.GenerateResponseFactorDevice) (int, bool) {
+	for i, device := range devices {
+		if !device.RequireOTPToken {
+			return i, true
 		}
-		selected, err = strconv.Atoi(tmp)
-		if err != nil {
-			return 0, err
+	}
+	return 0, len(devices) > 0
+}
+
+func (m *LoginEvent) ChooseRoleIndex(roles []login.Role) (int, error) {
+	if err := requirePrompt("role selection", exitRoleRequired); err != nil {
+		return 0, err
+	}
+	items := make([]string, len(roles))
+	aliases := loadAccountAliasCache(cacheDir)
+	for i, role := range roles {
+		items[i] = role.RoleArn
+		if alias := m.conf.RoleAlias(role.RoleArn); alias != "" {
+			items[i] = fmt.Sprintf("%s (%s)", alias, role.RoleArn)
 		}
-		if selected < length && selected >= 0 {
-			break
+		if account := accountAlias(m.conf, aliases, role.RoleArn); account != "" {
+			items[i] = fmt.Sprintf("%s [%s]", items[i], account)
 		}
 	}
-	return selected, nil
+	selected, err := m.selectIndex(i18n.T("Select your role: "), items)
+	return selected, inputClosed(err, "role selection", exitRoleRequired)
 }
 
-func (m *LoginEvent) InputMFAToken() (string, error) {
-	var token string
-	var err error
+func (m *LoginEvent) InputMFAToken() (secret.Bytes, error) {
+	if token := os.Getenv(mfaTokenEnv); token != "" {
+		return secret.Bytes(token), nil
+	}
+	if token := agentToken(); len(token) > 0 {
+		return token, nil
+	}
+	if err := requirePrompt("MFA token", exitMFATokenRequired); err != nil {
+		return nil, err
+	}
+	defer m.lockPrompt()()
 	for {
-		fmt.Print("Enter your MFA token: ")
-		token, err = m.reader.ReadString('\n')
+		if interactiveInput {
+			fmt.Print(i18n.T("Enter your MFA token: "))
+		}
+		token, err := readMasked(m.reader)
 		if err != nil {
-			return "", err
+			return nil, inputClosed(err, "MFA token", exitMFATokenRequired)
 		}
-		token = strings.Trim(token, "\n")
-		if token != "" {
-			break
+		if trimmed := bytes.TrimSpace(token); len(trimmed) > 0 {
+			return trimmed, nil
 		}
 	}
-	return token, nil
+}
+
+// lockPrompt waits for the prompts of other profiles, and returns the func to unlock
+func (m *LoginEvent) lockPrompt() func() {
+	if m.prompt == nil {
+		return func() {}
+	}
+	m.prompt.Lock()
+	return m.prompt.Unlock
+}
+
+// WaitApproval shows the spinner of prompt.Event with the remaining time while waiting for OneLogin Protect approval
+func (m *LoginEvent) WaitApproval(remaining time.Duration) {
+	m.approval.WaitApproval(remaining)
+}
+
+// ApprovalDone clears the spinner
+func (m *LoginEvent) ApprovalDone() {
+	m.approval.ApprovalDone()
+}
+
+// loginSession shares the password and SAML assertions between profiles in one invocation
+type loginSession struct {
+	// mu guards the maps of the session and serializes writes to shared files,
+	// it is never held across network calls or prompts so profiles are logged in concurrently
+	mu sync.Mutex
+	// prompt serializes the prompts of profiles logged in concurrently
+	prompt sync.Mutex
+	// keys are held while the password of a tenant or the SAML assertion of an app is obtained, so it is obtained once
+	keys   map[string]*sync.Mutex
+	conf   *config.Config
+	reader *bufio.Reader
+	// passwords are kept for each tenant, a sandbox or another subdomain may have another password
+	passwords  map[string]secret.Bytes
+	assertions map[string]secret.Bytes
+	refresh    bool
+	// rateLimitWarned prints the rate limit warning only once while logging in to multiple profiles
+	rateLimitWarned bool
+	// usersChecked looks up the user of each tenant only once while logging in to multiple profiles
+	usersChecked map[string]bool
+	// registered are the AWS secrets of each profile registered to be masked, they are unregistered when replaced or wiped
+	registered map[string][]string
+}
+
+func newLoginSession(conf *config.Config) *loginSession {
+	return &loginSession{
+		conf:         conf,
+		reader:       bufio.NewReader(os.Stdin),
+		passwords:    map[string]secret.Bytes{},
+		assertions:   map[string]secret.Bytes{},
+		usersChecked: map[string]bool{},
+		keys:         map[string]*sync.Mutex{},
+		registered:   map[string][]string{},
+	}
+}
+
+// register registers the AWS secrets of the profile to be masked instead of the previous ones,
+// so the daemon does not keep the credentials of every refresh
+func (s *loginSession) register(profile string, values ...string) {
+	secret.Register(values...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registered == nil {
+		s.registered = map[string][]string{}
+	}
+	secret.Unregister(s.registered[profile]...)
+	s.registered[profile] = values
+}
+
+// lock locks the key, and returns the func to unlock it
+func (s *loginSession) lock(key string) func() {
+	s.mu.Lock()
+	if s.keys == nil {
+		s.keys = map[string]*sync.Mutex{}
+	}
+	m, ok := s.keys[key]
+	if !ok {
+		m = &sync.Mutex{}
+		s.keys[key] = m
+	}
+	s.mu.Unlock()
+	m.Lock()
+	return m.Unlock
+}
+
+// event returns LoginEvent of the service whose prompts are serialized with other profiles
+func (s *loginSession) event(service string) *LoginEvent {
+	event := NewLoginEvent(s.reader, s.conf)
+	event.service = service
+	event.prompt = &s.prompt
+	return event
+}
+
+// savedPassword returns the copy of the password of the tenant kept in the session, or nil
+func (s *loginSession) savedPassword(name string) secret.Bytes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.passwords[name]) == 0 {
+		return nil
+	}
+	return s.passwords[name].Copy()
+}
+
+// Wipe wipes the password and SAML assertions shared in the session
+func (s *loginSession) Wipe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, password := range s.passwords {
+		password.Wipe()
+		delete(s.passwords, name)
+	}
+	for appID, SAML := range s.assertions {
+		SAML.Wipe()
+		delete(s.assertions, appID)
+	}
+	for profile, values := range s.registered {
+		secret.Unregister(values...)
+		delete(s.registered, profile)
+	}
+}
+
+// forgetAssertions wipes the SAML assertions but keeps the passwords, so the next login generates them again
+func (s *loginSession) forgetAssertions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for appID, SAML := range s.assertions {
+		SAML.Wipe()
+		delete(s.assertions, appID)
+	}
+}
+
+// Password asks the password only once, the returned copy should be wiped after use
+func (s *loginSession) Password() (secret.Bytes, error) {
+	return s.profilePassword("", config.AppConfig{})
+}
+
+// profilePassword returns the password given by flags or environment variables,
+// the output of password_command, the one stored in the keychain, the one held by the agent, or the prompted one.
+// It is asked only once
+func (s *loginSession) profilePassword(profile string, app config.AppConfig) (secret.Bytes, error) {
+	name := tenantName(app)
+	if password := s.savedPassword(name); password != nil {
+		return password, nil
+	}
+	defer s.lock("password/" + name)()
+	if password := s.savedPassword(name); password != nil {
+		return password, nil
+	}
+	password, err := passwordFromSource(s.reader)
+	if err == nil && len(password) == 0 && app.PasswordCommand != "" {
+		password, err = runPasswordCommand(app.PasswordCommand)
+	}
+	if err == nil && len(password) == 0 {
+		password, err = s.vaultPassword(app.ServiceName())
+	}
+	if err == nil && len(password) == 0 && app.Keychain {
+		password, err = keychainPassword(profile, app.Biometric)
+	}
+	if err == nil && len(password) == 0 && name == config.DefaultService {
+		// the agent holds only the password of the default service
+		password = agentPassword()
+	}
+	if err == nil && len(password) == 0 {
+		password, err = s.promptPassword()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.passwords == nil {
+		s.passwords = map[string]secret.Bytes{}
+	}
+	s.passwords[name] = password
+	return password.Copy(), nil
+}
+
+// forgetPassword wipes the password of the tenant, so the wrong one is not reused for other profiles
+func (s *loginSession) forgetPassword(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passwords[name].Wipe()
+	delete(s.passwords, name)
+}
+
+// appPassword returns the password for the profile,
+// it is always prompted and not kept in the session if password_prompt is enabled,
+// even if the password is given by other sources
+func (s *loginSession) appPassword(profile string, app config.AppConfig) (secret.Bytes, error) {
+	if app.PasswordPrompt {
+		return s.promptPassword()
+	}
+	return s.profilePassword(profile, app)
+}
+
+func (s *loginSession) promptPassword() (secret.Bytes, error) {
+	if err := requirePrompt("password", exitPasswordRequired); err != nil {
+		return nil, err
+	}
+	s.prompt.Lock()
+	defer s.prompt.Unlock()
+	if interactiveInput {
+		fmt.Print(i18n.T("Enter your password: "))
+	}
+	password, err := readMasked(s.reader)
+	if err != nil {
+		return nil, inputClosed(err, "password", exitPasswordRequired)
+	}
+	return password, nil
 }
 
 // loginCmd represents the login command
@@ -110,107 +391,317 @@ var loginCmd = &cobra.Command{
 	Short: "Login to AWS with OneLogin",
 	Long:  `Login is CLI Command to Create AWS Credentials with OneLogin`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if awsProfile == "" {
-			awsProfile = "default"
+		if err := checkLoginAll(loginAll, group, cmd.Flags().Changed("aws-profile") || cmd.Flags().Changed("profile")); err != nil {
+			errorExit(err)
+		}
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
 		}
-		err := cached(awsProfile, func() (*sts.Credentials, error) {
-			service, app, err := fetchConfig(configFile, awsProfile)
+		profiles := []string{awsProfile}
+		if group != "" {
+			profiles, err = c.GroupProfiles(group)
 			if err != nil {
-				return nil, err
-			}
-			if debug {
-				log.Println("OneLogin Configuration:")
-				log.Printf("  Endpoint:\t\t%v\n", service.Endpoint)
-				log.Printf("  ClientToken:\t\t%v\n", service.ClientToken)
-				log.Printf("  ClientSecret:\t%v\n", service.ClientSecret)
+				errorExit(err)
 			}
-
-			onelogin.CacheDir = cacheDir
-			config := onelogin.NewConfig(service.Endpoint, service.ClientToken, service.ClientSecret)
-			if force {
-				config.Credentials.Credentials = nil
-			}
-			if err := config.Save(); err != nil {
-				return nil, err
+		}
+		s := newLoginSession(c)
+		defer s.Wipe()
+		if loginAll {
+			profiles, _ = profileItems(c)
+			if err := loginConcurrently(infoOut(), s, profiles, loginJobs, loginProfile); err != nil {
+				errorExit(err)
 			}
-			if debug {
-				creds, _ := config.Credentials.Get()
-				log.Println("OneLogin Credentials:")
-				log.Printf("  AccessToken:\t\t%v\n", creds.AccessToken)
-				log.Printf("  RefreshToken:\t%v\n", creds.RefreshToken)
-				log.Printf("  CreatedAt:\t\t%v\n", creds.CreatedAt)
-				log.Printf("  AccessExpiresAt:\t%v\n", creds.AccessExpiresAt)
-				log.Printf("  RefreshExpiresAt:\t%v\n", creds.RefreshExpiresAt)
+			return
+		}
+		for _, profile := range profiles {
+			if _, err := loginProfile(s, profile); err != nil {
+				errorExit(err)
 			}
+		}
+	},
+}
 
-			fmt.Print("Enter your password: ")
-			tmp, err := terminal.ReadPassword(int(syscall.Stdin))
-			if err != nil {
+func loginProfile(s *loginSession, profile string) (*sts.Credentials, error) {
+	scoped := loginPolicy != "" || len(loginPolicyArns) > 0
+	creds, err := cached(profile, force || role != "" || scoped || s.refresh, func() (*sts.Credentials, error) {
+		service, app, err := fetchConfig(configFile, profile)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			app.RoleArn = s.conf.ResolveRole(role)
+			app.PrincipalArn = ""
+		}
+		if loginPolicy != "" {
+			if app.Policy, err = config.ParsePolicy(loginPolicy); err != nil {
 				return nil, err
 			}
-			password := string(tmp)
-			fmt.Println("")
-			duration := app.DurationSeconds
-			if duration == 0 {
-				duration = 3600
-			}
-			if debug {
-				fmt.Println("")
-				log.Println("Login Parameters:")
-				log.Printf("  Subdomain:\t\t%v\n", service.Subdomain)
-				log.Printf("  AppID:\t\t%v\n", app.AppID)
-				log.Printf("  UsernameOrEmail:\t%v\n", service.UsernameOrEmail)
-				log.Printf("  Password:\t\t%v\n", password)
-				log.Printf("  PrincipalArn:\t%v\n", app.PrincipalArn)
-				log.Printf("  RoleArn:\t\t%v\n", app.RoleArn)
-				log.Printf("  DurationSeconds:\t%v\n", duration)
-			}
-			l := login.New(config, &login.Parameters{
-				UsernameOrEmail: service.UsernameOrEmail,
-				Password:        password,
-				AppID:           app.AppID,
-				Subdomain:       service.Subdomain,
-				PrincipalArn:    app.PrincipalArn,
-				RoleArn:         app.RoleArn,
-				DurationSeconds: duration,
-			})
-			creds, err := l.Login(NewLoginEvent(bufio.NewReader(os.Stdin)))
-
-			if err != nil {
+		}
+		if len(loginPolicyArns) > 0 {
+			if err := config.ValidatePolicyArns(loginPolicyArns); err != nil {
 				return nil, err
 			}
+			app.PolicyArns = loginPolicyArns
+		}
+		duration, err := app.SessionDuration()
+		if loginDuration != "" {
+			duration, err = config.ParseDuration(loginDuration)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if debug {
+			log.Println("OneLogin Configuration:")
+			log.Printf("  Endpoint:\t\t%v\n", service.Endpoint)
+			log.Printf("  ClientToken:\t\t%v\n", service.ClientToken)
+			log.Printf("  ClientSecret:\t%v\n", secret.Redact(service.ClientSecret))
+		}
 
+		if d := roleDuration(cacheDir, app.RoleArn, duration); d != duration {
 			if debug {
-				log.Println("AWS Credentials:")
-				log.Printf("  AccessKeyId:\t%v\n", *creds.AccessKeyId)
-				log.Printf("  SecretAccessKey:\t%v\n", *creds.SecretAccessKey)
-				log.Printf("  SessionToken:\t%v\n", *creds.SessionToken)
-				log.Printf("  Expiration:\t\t%v\n", creds.Expiration)
+				log.Printf("shorten the duration to %ds, the maximum session duration of %s\n", d, app.RoleArn)
 			}
-			options := map[string]string{
-				"aws_access_key_id":     *creds.AccessKeyId,
-				"aws_secret_access_key": *creds.SecretAccessKey,
-				"aws_session_token":     *creds.SessionToken,
+			duration = d
+		}
+		l, SAML, err := s.generateSAML(profile, service, app, duration)
+		if err != nil {
+			return nil, err
+		}
+		creds, err := s.loginWithSAML(l, SAML)
+		if err != nil {
+			return nil, err
+		}
+
+		s.register(profile, *creds.SecretAccessKey, *creds.SessionToken)
+		if debug {
+			log.Println("AWS Credentials:")
+			log.Printf("  AccessKeyId:\t%v\n", *creds.AccessKeyId)
+			log.Printf("  SecretAccessKey:\t%v\n", secret.Redact(*creds.SecretAccessKey))
+			log.Printf("  SessionToken:\t%v\n", secret.Redact(*creds.SessionToken))
+			log.Printf("  Expiration:\t\t%v\n", creds.Expiration)
+		}
+		s.mu.Lock()
+		if err := recordRoleDuration(os.Stderr, cacheDir, l.Params.RoleArn, duration, l.Params.DurationSeconds); err != nil && debug {
+			log.Printf("failed to cache the maximum session duration: %v\n", err)
+		}
+		saveCredentials(profile, creds)
+		s.mu.Unlock()
+		if app.ResolveAccountAlias {
+			assumed := l.Params.RoleArn
+			if l.Params.ChainRoleArn != "" {
+				assumed = l.Params.ChainRoleArn
 			}
-			awsCredentials := configuration.NewCredentials(awsDir, awsProfile)
-			_ = awsCredentials.Save(options)
-			if region != "" {
-				awsConfig := configuration.NewConfig(awsDir, awsProfile)
-				_ = awsConfig.Save(region)
+			if err := resolveAccountAlias(s.conf, cacheDir, assumed, creds, l.HTTPClient, &s.mu); err != nil && debug {
+				log.Printf("failed to cache the account alias: %v\n", err)
 			}
-			return creds, nil
-		})
-		if err != nil {
-			errorExit(err)
 		}
-	},
+		return creds, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if app, ok := s.conf.App[profile]; ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		saveRegion(profile, app.Region)
+	}
+	return creds, nil
+}
+
+// generateSAML returns the SAML assertion of the app, it is generated only once for each AppID
+func (s *loginSession) generateSAML(profile string, service config.ServiceConfig, app config.AppConfig, duration int64) (*login.Login, secret.Bytes, error) {
+	version, negotiate, err := config.ParseAPIVersion(app.APIVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	sdk, err := config.ParseAWSSDK(app.AWSSDK)
+	if err != nil {
+		return nil, nil, err
+	}
+	stsRegion, err := app.AssumeRoleRegion(awsRegion(app.Region))
+	if err != nil {
+		return nil, nil, err
+	}
+	// the timeouts are validated by fetchService
+	timeouts, _ := service.Timeouts()
+	config := newOneloginConfig(service)
+	if force {
+		config.Credentials.Credentials = nil
+	}
+	if err := config.Save(); err != nil {
+		return nil, nil, err
+	}
+	if app.AppID == "" && app.AppName != "" {
+		if app.AppID, err = resolveAppID(newConfigUsersAPI(config), cacheDir, service, app.AppName); err != nil {
+			return nil, nil, err
+		}
+	}
+	if debug {
+		creds, _ := config.Credentials.Get()
+		log.Println("OneLogin Credentials:")
+		log.Printf("  AccessToken:\t\t%v\n", secret.Redact(creds.AccessToken))
+		log.Printf("  RefreshToken:\t%v\n", secret.Redact(creds.RefreshToken))
+		log.Printf("  CreatedAt:\t\t%v\n", creds.CreatedAt)
+		log.Printf("  AccessExpiresAt:\t%v\n", creds.AccessExpiresAt)
+		log.Printf("  RefreshExpiresAt:\t%v\n", creds.RefreshExpiresAt)
+	}
+
+	l := login.New(config, &login.Parameters{
+		UsernameOrEmail:     service.UsernameOrEmail,
+		AppID:               app.AppID,
+		Subdomain:           service.Subdomain,
+		PrincipalArn:        app.PrincipalArn,
+		RoleArn:             app.RoleArn,
+		DurationSeconds:     duration,
+		APIVersion:          version,
+		NegotiateAPIVersion: negotiate,
+		VerifyFactorTimeout: timeouts.VerifyFactor,
+		OnResponse:          logResponse,
+		Policy:              app.Policy,
+		PolicyArns:          app.PolicyArns,
+		ChainRoleArn:        s.conf.ResolveRole(app.ChainRoleArn),
+		ChainExternalID:     app.ChainExternalID,
+		ChainSessionName:    app.ChainSessionName,
+	})
+	l.SDKVersion = sdk
+	l.Region = stsRegion
+	l.STSEndpoint = app.STSEndpoint
+	// the same AppID may exist in another tenant
+	key := tenantName(app) + "/" + app.AppID
+	defer s.lock("assertion/" + key)()
+	s.mu.Lock()
+	SAML, ok := s.assertions[key]
+	s.mu.Unlock()
+	if ok {
+		if debug {
+			log.Printf("use SAML assertion of AppID %v\n", app.AppID)
+		}
+		return l, SAML, nil
+	}
+	if service.CheckUser {
+		if err := s.checkUser(tenantName(app), config, service); err != nil {
+			return nil, nil, err
+		}
+	}
+	l.Params.Password, err = s.appPassword(profile, app)
+	if err != nil {
+		return nil, nil, err
+	}
+	if debug {
+		fmt.Println("")
+		log.Println("Login Parameters:")
+		log.Printf("  Subdomain:\t\t%v\n", service.Subdomain)
+		log.Printf("  AppID:\t\t%v\n", app.AppID)
+		log.Printf("  UsernameOrEmail:\t%v\n", service.UsernameOrEmail)
+		log.Printf("  Password:\t\t%v\n", l.Params.Password)
+		log.Printf("  PrincipalArn:\t%v\n", app.PrincipalArn)
+		log.Printf("  RoleArn:\t\t%v\n", app.RoleArn)
+		log.Printf("  DurationSeconds:\t%v\n", duration)
+	}
+	SAML, err = l.GenerateSAML(s.event(app.ServiceName()))
+	l.Params.Password.Wipe()
+	l.Params.Password = nil
+	s.warnRateLimit(os.Stderr, l.RateLimit())
+	if errors.Is(err, samlassertion.ErrInvalidCredentials) {
+		// the wrong password must not be reused for other profiles
+		s.forgetPassword(tenantName(app))
+	}
+	if err != nil {
+		if app.AppName != "" && staleAppID(err) {
+			// the app may be recreated with another AppID
+			forgetAppID(cacheDir, service, app.AppName)
+		}
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	s.assertions[key] = SAML
+	s.mu.Unlock()
+	return l, SAML, nil
+}
+
+// checkUser looks up the user of the tenant only once while logging in to multiple profiles
+func (s *loginSession) checkUser(tenant string, c *onelogin.Config, service config.ServiceConfig) error {
+	defer s.lock("user/" + tenant)()
+	s.mu.Lock()
+	checked := s.usersChecked[tenant]
+	s.mu.Unlock()
+	if checked {
+		return nil
+	}
+	if err := lookupUser(newConfigUsersAPI(c), service); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.usersChecked[tenant] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// loginWithSAML assumes the role, the role selection prompt is serialized with other profiles
+func (s *loginSession) loginWithSAML(l *login.Login, SAML secret.Bytes) (*sts.Credentials, error) {
+	return l.LoginWithSAML(SAML, s.event(""))
+}
+
+// checkLoginAll rejects --all with the profiles selected by --group or --aws-profile, which would be ignored
+func checkLoginAll(all bool, group string, profileChanged bool) error {
+	if all && (group != "" || profileChanged) {
+		return errors.New(i18n.T("--all logs in to every profile, it cannot be given with --group or --aws-profile"))
+	}
+	return nil
+}
+
+// loginConcurrently logs in to the profiles with a bounded number of workers and prints the progress
+func loginConcurrently(out io.Writer, s *loginSession, profiles []string, jobs int, login func(*loginSession, string) (*sts.Credentials, error)) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	failed := []string{}
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for profile := range queue {
+				_, err := login(s, profile)
+				mu.Lock()
+				done++
+				if err != nil {
+					failed = append(failed, profile)
+					fmt.Fprintf(out, "[%d/%d] %s %s: %v\n", done, len(profiles), colorize(colorRed, i18n.T("failed")), profile, err)
+				} else {
+					fmt.Fprintf(out, "[%d/%d] %s %s\n", done, len(profiles), colorize(colorGreen, i18n.T("logged in")), profile)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, profile := range profiles {
+		queue <- profile
+	}
+	close(queue)
+	wg.Wait()
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf(i18n.T("failed to login to %s"), strings.Join(failed, ", "))
+	}
+	return nil
 }
 
 func init() {
 	RootCmd.AddCommand(loginCmd)
 	loginCmd.Flags().StringVarP(&region, "aws-region", "", "", "AWS Region")
 	loginCmd.Flags().BoolVarP(&force, "force", "", false, "Force refresh AWS credentials if credentials enabled")
-	loginCmd.Flags().StringVarP(&awsProfile, "aws-profile", "", awsProfile, "aws profile name")
+	loginCmd.Flags().StringVarP(&role, "role", "", "", "Login Target AWS Role ARN or alias")
+	loginCmd.Flags().StringVarP(&loginDuration, "duration", "", "", "The session duration to assuming the role instead of the configured one (e.g. 3600, 1h, 45m)")
+	loginCmd.Flags().StringVarP(&loginPolicy, "policy", "", "", "JSON or file://path of the session policy scoping down the role instead of the configured one")
+	loginCmd.Flags().StringSliceVarP(&loginPolicyArns, "policy-arn", "", nil, "ARN of managed session policy scoping down the role instead of the configured ones, repeatable")
+	loginCmd.Flags().StringVarP(&group, "group", "", "", "Login to every aws profile in the group")
+	loginCmd.Flags().BoolVarP(&loginAll, "all", "", false, "Login to every configured aws profile concurrently")
+	loginCmd.Flags().IntVarP(&loginJobs, "jobs", "", 4, "The number of profiles logged in at the same time with --all")
 }
 
 func fetchConfig(file string, profile string) (config.ServiceConfig, config.AppConfig, error) {
@@ -220,66 +711,182 @@ func fetchConfig(file string, profile string) (config.ServiceConfig, config.AppC
 	}
 	app, ok := c.App[profile]
 	if !ok {
-		return emptyConfig(fmt.Sprintf("%s profile is not exists", profile))
+		return emptyConfig(i18n.Sprintf("%s profile is not exists", profile))
 	}
+	service, err := fetchService(c, *app)
+	if err != nil {
+		return config.ServiceConfig{}, config.AppConfig{}, err
+	}
+	resolved := *app
+	resolved.RoleArn = c.ResolveRole(app.RoleArn)
+	return service, resolved, nil
+}
 
-	service := c.Service["default"]
+// fetchService returns the service of the app with the secret from Vault and the resolved endpoint,
+// the subdomain of the app is used instead of the service one
+func fetchService(c *config.Config, app config.AppConfig) (config.ServiceConfig, error) {
+	service, err := c.AppService(app)
+	if err != nil {
+		return config.ServiceConfig{}, err
+	}
+	if err := applyProxyCredentials(&service); err != nil {
+		return config.ServiceConfig{}, err
+	}
+	if err := applyVault(&service); err != nil {
+		return config.ServiceConfig{}, err
+	}
+	applyEnvCredentials(&service)
+	if service.Endpoint, err = serviceEndpoint(service); err != nil {
+		return config.ServiceConfig{}, err
+	}
 	if service.Endpoint == "" {
-		return emptyConfig("Endpoint is not exists")
+		return config.ServiceConfig{}, errors.Errorf(i18n.T("Endpoint is not exists"))
+	}
+	if _, err := service.Timeouts(); err != nil {
+		return config.ServiceConfig{}, err
+	}
+	// the pins are validated for the resolved endpoint
+	if _, err := transport.New(serviceTransport(service)); err != nil {
+		return config.ServiceConfig{}, err
+	}
+	if service.InsecureSkipVerify {
+		warnInsecureSkipVerify()
 	}
 
-	if service.ClientToken == "" {
-		return emptyConfig("ClientToken is not exists")
+	clientToken, clientSecret := subdomainCredentials(service)
+	if clientToken == "" {
+		return config.ServiceConfig{}, errors.Errorf(i18n.T("ClientToken is not exists"))
 	}
 
-	if service.ClientSecret == "" {
-		return emptyConfig("ClientSecret is not exists")
+	if clientSecret == "" {
+		return config.ServiceConfig{}, errors.Errorf(i18n.T("ClientSecret is not exists"))
 	}
 
 	if service.Subdomain == "" {
-		return emptyConfig("Subdomain is not exists")
+		return config.ServiceConfig{}, errors.Errorf(i18n.T("Subdomain is not exists"))
+	}
+	return service, nil
+}
+
+// profileApp returns the app of --aws-profile, or the empty one using the default service if the profile is not configured
+func profileApp(c *config.Config) config.AppConfig {
+	if app, ok := c.App[awsProfile]; ok {
+		return *app
 	}
-	return *service, *app, nil
+	return config.AppConfig{}
 }
 
 func emptyConfig(message string) (config.ServiceConfig, config.AppConfig, error) {
 	return config.ServiceConfig{}, config.AppConfig{}, errors.Errorf(message)
 }
 
-func cached(profile string, block func() (*sts.Credentials, error)) error {
-	file := path.Join(cacheDir, fmt.Sprintf("aws.%s.cache", profile))
-	if !force {
-		var c *sts.Credentials
-		if _, err := toml.DecodeFile(file, &c); err != nil {
-			if err != nil {
-				if !os.IsNotExist(err) {
-					return err
-				}
-			}
-		} else {
-			if c.Expiration != nil {
-				now := time.Now()
-				if now.Before(*c.Expiration) {
-					if debug {
-						log.Println("use aws credentials cache")
-					}
-					return nil
+func saveCredentials(profile string, creds *sts.Credentials) {
+	options := map[string]string{
+		"aws_access_key_id":     *creds.AccessKeyId,
+		"aws_secret_access_key": *creds.SecretAccessKey,
+		"aws_session_token":     *creds.SessionToken,
+	}
+	awsCredentials := configuration.NewCredentials(awsDir, profile)
+	_ = awsCredentials.Save(options)
+}
+
+// awsRegion returns the region of --aws-region, or the configured one
+func awsRegion(configured string) string {
+	if region != "" {
+		return region
+	}
+	return configured
+}
+
+// saveRegion writes the region to ~/.aws/config, --aws-region flag takes precedence over the configured one
+func saveRegion(profile string, configured string) {
+	if r := awsRegion(configured); r != "" {
+		awsConfig := configuration.NewConfig(awsDir, profile)
+		_ = awsConfig.Save(r)
+	}
+}
+
+func awsCacheFile(dir string, profile string) string {
+	return path.Join(dir, fmt.Sprintf("aws.%s.cache", profile))
+}
+
+// loadCachedCredentials returns cached AWS credentials, or nil if there is no cache
+func loadCachedCredentials(dir string, profile string) (*sts.Credentials, error) {
+	var c *sts.Credentials
+	if _, err := toml.DecodeFile(awsCacheFile(dir, profile), &c); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return c, nil
+}
+
+func cached(profile string, refresh bool, block func() (*sts.Credentials, error)) (*sts.Credentials, error) {
+	file := awsCacheFile(cacheDir, profile)
+	if !refresh {
+		c, err := loadCachedCredentials(cacheDir, profile)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil && c.Expiration != nil {
+			now := time.Now()
+			if now.Before(*c.Expiration) {
+				if debug {
+					log.Println("use aws credentials cache")
 				}
+				return c, nil
 			}
 		}
 	}
 	c, err := block()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fd, err := os.Create(file)
-	if err != nil {
-		return err
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
 	}
-	defer fd.Close()
-	encoder := toml.NewEncoder(fd)
-	if err := encoder.Encode(c); err != nil {
-		return err
+	if err := secret.WriteFile(file, buf.Bytes()); err != nil {
+		return nil, err
 	}
-	return nil
+	return c, nil
+}
+
+// observeResponse is given every request to SAML assertion API if it is set, e.g. to count them in the metrics
+var observeResponse func(samlassertion.Metadata)
+
+// logResponse logs every request to SAML assertion API with --verbose, the errors tell only the last one
+func logResponse(m samlassertion.Metadata) {
+	if debug {
+		log.Printf("OneLogin API %s\n", m)
+	}
+	if observeResponse != nil {
+		observeResponse(m)
+	}
+}
+
+// rateLimitWarning is the percent of remaining OneLogin API calls to warn
+const rateLimitWarning = 10
+
+// warnRateLimit warns that few OneLogin API calls remain, before logging in to more profiles fails with 429
+func (s *loginSession) warnRateLimit(out io.Writer, r *samlassertion.RateLimit) {
+	if debug && r != nil {
+		log.Printf("OneLogin API rate limit: %d/%d remaining, reset at %v\n", r.Remaining, r.Limit, r.Reset)
+	}
+	if r == nil || !r.Low(rateLimitWarning) {
+		return
+	}
+	s.mu.Lock()
+	warned := s.rateLimitWarned
+	s.rateLimitWarned = true
+	s.mu.Unlock()
+	if warned {
+		return
+	}
+	if r.Reset.IsZero() {
+		fmt.Fprint(out, i18n.Sprintf("Warning: only %d of %d OneLogin API calls remain\n", r.Remaining, r.Limit))
+		return
+	}
+	fmt.Fprint(out, i18n.Sprintf("Warning: only %d of %d OneLogin API calls remain until %s\n", r.Remaining, r.Limit, r.Reset.Local().Format("15:04:05")))
 }
@@ -0,0 +1,88 @@
+package login
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// DefaultChainSessionName is RoleSessionName of the chained role when the username cannot be used
+const DefaultChainSessionName = "onelogin-aws-connector"
+
+// maxChainDurationSeconds is the maximum session duration of role chaining
+const maxChainDurationSeconds int64 = 3600
+
+// invalidSessionName matches characters not allowed in RoleSessionName
+var invalidSessionName = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// chainSessionName returns RoleSessionName of the chained role, the username is used by default to be traced in CloudTrail
+func (l *Login) chainSessionName() string {
+	name := l.Params.ChainSessionName
+	if name == "" {
+		name = invalidSessionName.ReplaceAllString(l.Params.UsernameOrEmail, "-")
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	if len(name) < 2 {
+		return DefaultChainSessionName
+	}
+	return name
+}
+
+// newChainSTS creates STS of AWS SDK for Go v1 signed with the credentials of the SAML-assumed role
+func (l *Login) newChainSTS(creds *sts.Credentials) (stsiface.STSAPI, error) {
+	awsConfig := aws.NewConfig().WithCredentials(credentials.NewStaticCredentials(*creds.AccessKeyId, *creds.SecretAccessKey, *creds.SessionToken))
+	if l.HTTPClient != nil {
+		awsConfig.HTTPClient = l.HTTPClient
+	}
+	region := l.stsRegion()
+	if region != "" {
+		awsConfig.Region = aws.String(region)
+	}
+	if endpoint := l.stsEndpoint(region); endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+	s, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.Handlers.Build.PushBackNamed(useragent.Handler)
+	return sts.New(s), nil
+}
+
+// chain assumes the chained role with the credentials of the SAML-assumed role, whose duration is up to an hour
+func (l *Login) chain(ctx context.Context, creds *sts.Credentials) (*sts.Credentials, error) {
+	newSTS := l.NewChainSTS
+	if newSTS == nil {
+		newSTS = l.newChainSTS
+	}
+	api, err := newSTS(creds)
+	if err != nil {
+		return nil, err
+	}
+	duration := l.Params.DurationSeconds
+	if duration == 0 || duration > maxChainDurationSeconds {
+		duration = maxChainDurationSeconds
+	}
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(l.Params.ChainRoleArn),
+		RoleSessionName: aws.String(l.chainSessionName()),
+		DurationSeconds: aws.Int64(duration),
+	}
+	if l.Params.ChainExternalID != "" {
+		input.ExternalId = aws.String(l.Params.ChainExternalID)
+	}
+	output, err := api.AssumeRoleWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return output.Credentials, nil
+}
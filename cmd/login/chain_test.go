@@ -0,0 +1,91 @@
+package login
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+type chainSTSMock struct {
+	stsiface.STSAPI
+	input *sts.AssumeRoleInput
+}
+
+func (s *chainSTSMock) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	return s.AssumeRoleWithContext(context.Background(), input)
+}
+
+func (s *chainSTSMock) AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, opts ...request.Option) (*sts.AssumeRoleOutput, error) {
+	s.input = input
+	now := time.Now()
+	return &sts.AssumeRoleOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("chained-access-key-id"),
+			SecretAccessKey: aws.String("chained-secret-access-key"),
+			SessionToken:    aws.String("chained-session-token"),
+			Expiration:      &now,
+		},
+	}, nil
+}
+
+func TestLogin_LoginWithChainRole(t *testing.T) {
+	chainSTS := &chainSTSMock{}
+	params := createDefaultParams()
+	params.DurationSeconds = 43200
+	params.ChainRoleArn = "arn:aws:iam::210987654321:role/Member"
+	params.ChainExternalID = "external-id"
+	l := &Login{
+		SAMLAssertion: createAssertion(t),
+		STS:           createSTS(t),
+		Params:        params,
+		NewChainSTS: func(creds *sts.Credentials) (stsiface.STSAPI, error) {
+			if *creds.AccessKeyId != "access-key-id" {
+				t.Errorf("%s is not the credentials of the SAML-assumed role", *creds.AccessKeyId)
+			}
+			return chainSTS, nil
+		},
+	}
+	creds, err := l.Login(&EventMock{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *creds.AccessKeyId != "chained-access-key-id" {
+		t.Errorf("%s is not the credentials of the chained role", *creds.AccessKeyId)
+	}
+	input := chainSTS.input
+	if *input.RoleArn != params.ChainRoleArn || *input.ExternalId != "external-id" {
+		t.Errorf("%v is not the chained role", input)
+	}
+	if *input.RoleSessionName != "username-or-email" {
+		t.Errorf("%s is not the session name of the user", *input.RoleSessionName)
+	}
+	if *input.DurationSeconds != 3600 {
+		t.Errorf("%d is not the maximum duration of role chaining", *input.DurationSeconds)
+	}
+}
+
+func TestLogin_chainSessionName(t *testing.T) {
+	tests := []struct {
+		sessionName string
+		username    string
+		want        string
+	}{
+		{sessionName: "session", username: "user@example.com", want: "session"},
+		{username: "user@example.com", want: "user@example.com"},
+		{username: "user name", want: "user-name"},
+		{username: "", want: DefaultChainSessionName},
+		{username: "a", want: DefaultChainSessionName},
+		{username: "ユーザー", want: "----"},
+	}
+	for _, tt := range tests {
+		l := &Login{Params: &Parameters{UsernameOrEmail: tt.username, ChainSessionName: tt.sessionName}}
+		if got := l.chainSessionName(); got != tt.want {
+			t.Errorf("chainSessionName() = %v, want %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package login
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// failure kinds of the role selection and AssumeRoleWithSAML, errors returned by Login match them with errors.Is.
+// The errors of OneLogin API match the kinds of samlassertion and apiresponse
+var (
+	// ErrRoleNotAssigned is returned when the configured role is not in the SAML assertion
+	ErrRoleNotAssigned = stderrors.New("role is not assigned")
+	// ErrNoRole is returned when the SAML assertion has no role
+	ErrNoRole = stderrors.New("no role in SAML assertion")
+	// ErrAccessDenied is returned when the trust policy of the role denies the SAML assertion
+	ErrAccessDenied = stderrors.New("access denied")
+	// ErrSAMLRejected is returned when the SAML provider of AWS does not accept the SAML assertion
+	ErrSAMLRejected = stderrors.New("SAML assertion rejected")
+	// ErrSAMLExpired is returned when the SAML assertion is expired before AssumeRoleWithSAML
+	ErrSAMLExpired = stderrors.New("SAML assertion expired")
+	// ErrInvalidPolicy is returned when the session policies are rejected
+	ErrInvalidPolicy = stderrors.New("invalid session policy")
+)
+
+// kindError annotates the error with the failure kind, errors.Is matches both the kind and the wrapped errors
+type kindError struct {
+	error
+	kind error
+}
+
+func withKind(err error, kind error) error {
+	return &kindError{error: err, kind: kind}
+}
+
+// Unwrap returns the annotated error
+func (e *kindError) Unwrap() error {
+	return e.error
+}
+
+// Cause returns the annotated error for errors.Cause of github.com/pkg/errors
+func (e *kindError) Cause() error {
+	return e.error
+}
+
+// Is matches the failure kind
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// hints tell what the user should do for each failure class
+var hints = map[error]string{
+	samlassertion.ErrMFARequired:        "MFA is required, please register an MFA device in OneLogin",
+	samlassertion.ErrInvalidCredentials: "the username or the password is wrong",
+	samlassertion.ErrRateLimited:        "OneLogin API is busy, please retry later",
+	samlassertion.ErrPasswordExpired:    "the password is expired, please change it in OneLogin",
+	samlassertion.ErrUserLocked:         "the user is locked, please contact the administrator of OneLogin",
+}
+
+// describe adds the hint of the failure class to the error, the cause is kept for samlassertion.Class
+func describe(err error) error {
+	if hint, ok := hints[samlassertion.Class(err)]; ok {
+		return errors.Wrap(err, i18n.T(hint))
+	}
+	return err
+}
+
+// stsHints tell what the user should do for each error code of AssumeRoleWithSAML
+var stsHints = map[string]string{
+	"InvalidIdentityToken":    "the SAML assertion is not accepted by the SAML provider, please check the metadata of the provider and the principal ARN",
+	"IDPRejectedClaim":        "the SAML assertion is rejected by the SAML provider, please check the role attribute of the OneLogin app",
+	"ExpiredTokenException":   "the SAML assertion is expired, please login again and check the clock of this machine",
+	"MalformedPolicyDocument": "the session policy is invalid, please check --policy and --policy-arn",
+	"PackedPolicyTooLarge":    "the session policies are too large, please shorten --policy or reduce --policy-arn",
+}
+
+// stsKinds are the failure kinds of the error codes of AssumeRoleWithSAML
+var stsKinds = map[string]error{
+	"AccessDenied":            ErrAccessDenied,
+	"InvalidIdentityToken":    ErrSAMLRejected,
+	"IDPRejectedClaim":        ErrSAMLRejected,
+	"ExpiredTokenException":   ErrSAMLExpired,
+	"MalformedPolicyDocument": ErrInvalidPolicy,
+	"PackedPolicyTooLarge":    ErrInvalidPolicy,
+}
+
+// describeSTS adds the hint and the failure kind of the STS error, AccessDenied tells the roles in the SAML assertion.
+// The awserr.Error is kept as the wrapped error
+func describeSTS(err error, roleArn string, SAML secret.Bytes) error {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return err
+	}
+	if aerr.Code() == "AccessDenied" {
+		roles, perr := ParseRoles(SAML)
+		if perr != nil || len(roles) == 0 {
+			return withKind(errors.Wrap(err, i18n.Sprintf("%s denied the SAML assertion, please check the trust policy of the role", roleArn)), ErrAccessDenied)
+		}
+		arns := make([]string, len(roles))
+		for i, role := range roles {
+			arns[i] = role.RoleArn
+		}
+		return withKind(errors.Wrap(err, i18n.Sprintf("%s denied the SAML assertion, please check the trust policy of the role. The roles in the SAML assertion are %s", roleArn, strings.Join(arns, ", "))), ErrAccessDenied)
+	}
+	if hint, ok := stsHints[aerr.Code()]; ok {
+		return withKind(errors.Wrap(err, i18n.T(hint)), stsKinds[aerr.Code()])
+	}
+	return err
+}
+
+// durationExceeded returns whether STS rejected DurationSeconds longer than MaxSessionDuration of the role
+func durationExceeded(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == "ValidationError" && strings.Contains(aerr.Message(), "MaxSessionDuration")
+}
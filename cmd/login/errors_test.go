@@ -0,0 +1,72 @@
+package login
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestDescribeSTS(t *testing.T) {
+	SAML := encodeSAML(
+		"arn:aws:iam::123456789012:role/admin,arn:aws:iam::123456789012:saml-provider/onelogin",
+		"arn:aws:iam::123456789012:role/readonly,arn:aws:iam::123456789012:saml-provider/onelogin",
+	)
+	tests := []struct {
+		name string
+		err  error
+		SAML []byte
+		want string
+		kind error
+	}{
+		{
+			name: "access denied",
+			err:  awserr.New("AccessDenied", "Not authorized to perform sts:AssumeRoleWithSAML", nil),
+			SAML: SAML,
+			want: "arn:aws:iam::123456789012:role/admin denied the SAML assertion, please check the trust policy of the role. The roles in the SAML assertion are arn:aws:iam::123456789012:role/admin, arn:aws:iam::123456789012:role/readonly: AccessDenied: Not authorized to perform sts:AssumeRoleWithSAML",
+			kind: ErrAccessDenied,
+		},
+		{
+			name: "access denied without roles",
+			err:  awserr.New("AccessDenied", "Not authorized to perform sts:AssumeRoleWithSAML", nil),
+			SAML: []byte("invalid"),
+			want: "arn:aws:iam::123456789012:role/admin denied the SAML assertion, please check the trust policy of the role: AccessDenied: Not authorized to perform sts:AssumeRoleWithSAML",
+			kind: ErrAccessDenied,
+		},
+		{
+			name: "expired",
+			err:  awserr.New("ExpiredTokenException", "Token must be redeemed within 5 minutes of issuance", nil),
+			want: "the SAML assertion is expired, please login again and check the clock of this machine: ExpiredTokenException: Token must be redeemed within 5 minutes of issuance",
+			kind: ErrSAMLExpired,
+		},
+		{
+			name: "unknown code",
+			err:  awserr.New("Throttling", "Rate exceeded", nil),
+			want: "Throttling: Rate exceeded",
+		},
+		{
+			name: "not STS error",
+			err:  errors.New("connection refused"),
+			want: "connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := describeSTS(tt.err, "arn:aws:iam::123456789012:role/admin", tt.SAML)
+			if err.Error() != tt.want {
+				t.Errorf("describeSTS() = %v, want %v", err, tt.want)
+			}
+			if pkgerrors.Cause(err) != tt.err {
+				t.Errorf("%v is not the cause", pkgerrors.Cause(err))
+			}
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("%v is not %v", err, tt.kind)
+			}
+			var aerr awserr.Error
+			if _, ok := tt.err.(awserr.Error); ok && !errors.As(err, &aerr) {
+				t.Errorf("%v does not unwrap to awserr.Error", err)
+			}
+		})
+	}
+}
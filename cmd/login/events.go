@@ -0,0 +1,82 @@
+package login
+
+import (
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+// State is the step of the login told to ProgressEvent
+type State int
+
+const (
+	// StateGeneratingSAML is told before the SAML assertion is requested with the password
+	StateGeneratingSAML State = iota
+	// StateMFARequired is told when OneLogin requires MFA, before the device is chosen
+	StateMFARequired
+	// StateVerifyingToken is told before the OTP token is verified
+	StateVerifyingToken
+	// StateWaitingApproval is told before waiting for the push approval, WaitApproval of ApprovalEvent ticks then
+	StateWaitingApproval
+	// StateApproved is told when MFA is verified and the SAML assertion is issued
+	StateApproved
+	// StateAssumingRole is told before AssumeRoleWithSAML
+	StateAssumingRole
+	// StateChainingRole is told before the chained role is assumed
+	StateChainingRole
+	// StateDone is told when the credentials are returned
+	StateDone
+)
+
+var stateNames = map[State]string{
+	StateGeneratingSAML:  "generating SAML assertion",
+	StateMFARequired:     "MFA required",
+	StateVerifyingToken:  "verifying MFA token",
+	StateWaitingApproval: "waiting for approval",
+	StateApproved:        "approved",
+	StateAssumingRole:    "assuming role",
+	StateChainingRole:    "assuming chained role",
+	StateDone:            "done",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ProgressEvent is optionally implemented by Event to reflect the progress of the login, e.g. on GUI
+type ProgressEvent interface {
+	Progress(state State)
+}
+
+// ConfirmEvent is optionally implemented by Event to ask yes or no, e.g. whether to try MFA again after it fails.
+// Without it, nothing is asked and the failure is returned
+type ConfirmEvent interface {
+	Confirm(message string) (bool, error)
+}
+
+// RetryEvent is optionally implemented by Event to be told of retries of STS after the delay,
+// returning false gives up and returns the error
+type RetryEvent interface {
+	RetrySTS(attempt int, delay time.Duration, err error) bool
+}
+
+// maxMFAAttempts is the number of MFA verifications including the first one while ConfirmEvent confirms retries
+const maxMFAAttempts = 3
+
+func progress(logic Event, state State) {
+	if p, ok := logic.(ProgressEvent); ok {
+		p.Progress(state)
+	}
+}
+
+// confirmMFARetry asks whether to verify MFA again after the failure
+func confirmMFARetry(logic Event, err error) (bool, error) {
+	c, ok := logic.(ConfirmEvent)
+	if !ok {
+		return false, nil
+	}
+	return c.Confirm(i18n.Sprintf("MFA verification failed: %v\nTry again?", err))
+}
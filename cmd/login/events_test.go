@@ -0,0 +1,138 @@
+package login
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+// richEventMock records the progress and answers the confirmations and the retries
+type richEventMock struct {
+	EventMock
+	states   []State
+	confirms []string
+	confirm  bool
+	retries  []int
+	retry    bool
+}
+
+func (m *richEventMock) Progress(state State) {
+	m.states = append(m.states, state)
+}
+
+func (m *richEventMock) Confirm(message string) (bool, error) {
+	m.confirms = append(m.confirms, message)
+	return m.confirm, nil
+}
+
+func (m *richEventMock) RetrySTS(attempt int, delay time.Duration, err error) bool {
+	m.retries = append(m.retries, attempt)
+	return m.retry
+}
+
+func TestLogin_LoginProgress(t *testing.T) {
+	l := &Login{
+		SAMLAssertion: createAssertionForSingleMFA(t),
+		STS:           createSTS(t),
+		Params:        createDefaultParams(),
+	}
+	event := &richEventMock{EventMock: EventMock{MFAToken: "765432"}}
+	if _, err := l.Login(event); err != nil {
+		t.Fatal(err)
+	}
+	expected := []State{StateGeneratingSAML, StateMFARequired, StateVerifyingToken, StateApproved, StateAssumingRole, StateDone}
+	if !reflect.DeepEqual(event.states, expected) {
+		t.Errorf("%v is not %v", event.states, expected)
+	}
+	if len(event.confirms) != 0 {
+		t.Errorf("%v is confirmed", event.confirms)
+	}
+}
+
+func TestLogin_LoginRetryMFA(t *testing.T) {
+	tests := []struct {
+		name         string
+		confirm      bool
+		failures     int
+		wantVerifies int
+		wantConfirms int
+		wantErr      bool
+	}{
+		{name: "retried", confirm: true, failures: 1, wantVerifies: 2, wantConfirms: 1},
+		{name: "declined", confirm: false, failures: 1, wantVerifies: 1, wantConfirms: 1, wantErr: true},
+		{name: "too many attempts", confirm: true, failures: maxMFAAttempts, wantVerifies: maxMFAAttempts, wantConfirms: maxMFAAttempts - 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertion := createAssertionForSingleMFA(t)
+			verifies := 0
+			assertion.VerifyFactorInputVerifier = func(request *samlassertion.VerifyFactorRequest) error {
+				verifies++
+				if verifies <= tt.failures {
+					return errors.New("[401] Unauthorized: Failed authentication with this factor")
+				}
+				return nil
+			}
+			l := &Login{
+				SAMLAssertion: assertion,
+				STS:           createSTS(t),
+				Params:        createDefaultParams(),
+			}
+			event := &richEventMock{EventMock: EventMock{MFAToken: "765432"}, confirm: tt.confirm}
+			_, err := l.Login(event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if verifies != tt.wantVerifies || len(event.confirms) != tt.wantConfirms {
+				t.Errorf("verified %d times and confirmed %v", verifies, event.confirms)
+			}
+			for _, message := range event.confirms {
+				if !strings.Contains(message, "Failed authentication with this factor") {
+					t.Errorf("%s does not tell the failure", message)
+				}
+			}
+		})
+	}
+}
+
+func TestLogin_LoginRetryEvent(t *testing.T) {
+	for _, retry := range []bool{true, false} {
+		stsMock := createSTS(t)
+		calls := 0
+		stsMock.InputVerifier = func(request *sts.AssumeRoleWithSAMLInput) error {
+			calls++
+			if calls == 1 {
+				return awserr.New("Throttling", "Rate exceeded", nil)
+			}
+			return nil
+		}
+		l := &Login{
+			SAMLAssertion: createAssertion(t),
+			STS:           stsMock,
+			Params:        createDefaultParams(),
+			MaxRetries:    2,
+			retryDelay:    time.Millisecond,
+		}
+		event := &richEventMock{retry: retry}
+		_, err := l.Login(event)
+		if (err != nil) == retry {
+			t.Errorf("retry %v: Login() error = %v", retry, err)
+		}
+		if !reflect.DeepEqual(event.retries, []int{1}) {
+			t.Errorf("retry %v: %v is told", retry, event.retries)
+		}
+	}
+}
+
+func TestState_String(t *testing.T) {
+	if StateWaitingApproval.String() != "waiting for approval" || State(100).String() != "unknown" {
+		t.Errorf("%s, %s is unexpected", StateWaitingApproval, State(100))
+	}
+}
@@ -1,122 +1,300 @@
 package login
 
 import (
-	"strconv"
+	"context"
+	"net/http"
+	"time"
 
+	stsv2sdk "github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/pkg/errors"
 
+	"github.com/lifull-dev/onelogin-aws-connector/aws/partition"
+	"github.com/lifull-dev/onelogin-aws-connector/aws/stsv2"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion/samlassertioniface"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
 )
 
 type Event interface {
 	ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error)
-	InputMFAToken() (string, error)
+	// InputMFAToken returns the OTP token, which is wiped after verified
+	InputMFAToken() (secret.Bytes, error)
+	ChooseRoleIndex(roles []Role) (int, error)
 }
 
+// ApprovalEvent is optionally implemented by Event to show the progress while waiting for the push approval
+type ApprovalEvent interface {
+	WaitApproval(remaining time.Duration)
+	ApprovalDone()
+}
+
+// hourSeconds is the unit of MaxSessionDuration of roles, and their minimum
+const hourSeconds int64 = 3600
+
 // Login represents login
 type Login struct {
+	// IdP issues the SAML assertion, nil means OneLogin with SAMLAssertion and Params
+	IdP           IdentityProvider
 	SAMLAssertion samlassertioniface.SAMLAssertionAPI
 	STS           stsiface.STSAPI
 	Params        *Parameters
+	// HTTPClient is used by the AWS session if STS is not given, e.g. the one with the proxy of the profile
+	HTTPClient *http.Client
+	// SDKVersion is the major version of AWS SDK for Go creating STS if it is not given, 1 or 2. Zero means 1
+	SDKVersion int
+	// Region and STSEndpoint are used by STS if it is not given.
+	// The empty region is the one of the partition of the role if it is not aws, like us-gov-west-1.
+	// STS of the region is used instead of the global endpoint unless STSEndpoint is given
+	Region      string
+	STSEndpoint string
+	// NewChainSTS creates STS assuming Params.ChainRoleArn with the credentials of the SAML-assumed role,
+	// nil means STS of AWS SDK for Go v1 with the region and the endpoint above
+	NewChainSTS func(creds *sts.Credentials) (stsiface.STSAPI, error)
+	// MaxRetries is the number of retries of STS throttling, 5xx responses and IDPCommunicationError,
+	// the retries of AWS SDK are disabled for STS created by Login
+	MaxRetries int
+	retryDelay time.Duration
 }
 
 // Parameters represents login parameters
 type Parameters struct {
 	UsernameOrEmail string
-	Password        string
+	Password        secret.Bytes
 	AppID           string
 	Subdomain       string
 	PrincipalArn    string
 	RoleArn         string
+	// DurationSeconds is shortened to MaxSessionDuration of the role if STS rejects it, then it is the effective duration
 	DurationSeconds int64
+	// APIVersion is the version of SAML assertion API, 1 or 2
+	APIVersion int
+	// NegotiateAPIVersion falls back to version 1 if version 2 is not found
+	NegotiateAPIVersion bool
+	// VerifyFactorTimeout is the budget of waiting for the push approval, zero means a minute
+	VerifyFactorTimeout time.Duration
+	// OnResponse is called with the metadata of every request to SAML assertion API, see samlassertion.SAMLAssertion
+	OnResponse func(samlassertion.Metadata)
+	// Policy and PolicyArns scope down the permissions of the session, PolicyArns are passed only by stsv2.STS
+	Policy     string
+	PolicyArns []string
+	// ChainRoleArn is assumed by sts:AssumeRole after AssumeRoleWithSAML, e.g. a role of a member account of the landing zone.
+	// ChainSessionName is the username by default
+	ChainRoleArn     string
+	ChainExternalID  string
+	ChainSessionName string
 }
 
 // New creates a Login instance
 func New(config *onelogin.Config, params *Parameters) *Login {
+	assertion := samlassertion.NewSAMLAssertion(config)
+	assertion.Version = params.APIVersion
+	assertion.Negotiate = params.NegotiateAPIVersion
+	assertion.VerifyFactorTimeout = params.VerifyFactorTimeout
+	assertion.OnResponse = params.OnResponse
 	return &Login{
-		SAMLAssertion: samlassertion.NewSAMLAssertion(config),
+		SAMLAssertion: assertion,
 		Params:        params,
+		HTTPClient:    config.HTTPClient,
+		MaxRetries:    DefaultMaxRetries,
+		retryDelay:    defaultRetryDelay,
 	}
 }
 
+// Login generates SAML assertion and assumes the role with it
 func (l *Login) Login(logic Event) (*sts.Credentials, error) {
-	assertion, err := l.generateAssertion()
+	return l.LoginWithContext(context.Background(), logic)
+}
+
+// LoginWithContext is the same as Login, but the requests are canceled when the context is done
+func (l *Login) LoginWithContext(ctx context.Context, logic Event) (*sts.Credentials, error) {
+	SAML, err := l.GenerateSAMLWithContext(ctx, logic)
 	if err != nil {
 		return nil, err
 	}
-	SAML := assertion.SAML
-	if SAML == "" {
-		factor := assertion.Factors[0]
-		selected := 0
-		length := len(factor.Devices)
-		if length > 1 {
-			selected, err = logic.ChooseDeviceIndex(factor.Devices)
-			if err != nil {
-				return nil, err
-			}
-		}
-		device := factor.Devices[selected]
-		deviceID := device.DeviceID
-		var token string
-		if device.RequireOTPToken {
-			token, err = logic.InputMFAToken()
-			if err != nil {
-				return nil, err
-			}
+	defer SAML.Wipe()
+	return l.LoginWithSAMLWithContext(ctx, SAML, logic)
+}
+
+// GenerateSAML generates SAML assertion with MFA if required, the caller should wipe it after use
+func (l *Login) GenerateSAML(logic Event) (secret.Bytes, error) {
+	return l.GenerateSAMLWithContext(context.Background(), logic)
+}
+
+// GenerateSAMLWithContext is the same as GenerateSAML, but the requests are canceled when the context is done
+func (l *Login) GenerateSAMLWithContext(ctx context.Context, logic Event) (secret.Bytes, error) {
+	progress(logic, StateGeneratingSAML)
+	return l.idp().GenerateSAML(ctx, logic)
+}
+
+// idp returns IdP, or OneLogin with SAMLAssertion and Params if it is not given
+func (l *Login) idp() IdentityProvider {
+	if l.IdP != nil {
+		return l.IdP
+	}
+	return &OneLogin{SAMLAssertion: l.SAMLAssertion, Params: l.Params}
+}
+
+// RateLimit returns the rate limit of OneLogin API told by the last response, or nil if it is unknown
+func (l *Login) RateLimit() *samlassertion.RateLimit {
+	if limited, ok := l.idp().(interface {
+		RateLimit() *samlassertion.RateLimit
+	}); ok {
+		return limited.RateLimit()
+	}
+	return nil
+}
+
+// LoginWithSAML assumes the role with generated SAML assertion, and the chained role if it is given
+func (l *Login) LoginWithSAML(SAML secret.Bytes, logic Event) (*sts.Credentials, error) {
+	return l.LoginWithSAMLWithContext(context.Background(), SAML, logic)
+}
+
+// LoginWithSAMLWithContext is the same as LoginWithSAML, but the requests are canceled when the context is done
+func (l *Login) LoginWithSAMLWithContext(ctx context.Context, SAML secret.Bytes, logic Event) (*sts.Credentials, error) {
+	if l.Params.RoleArn == "" || l.Params.PrincipalArn == "" {
+		if err := l.chooseRole(SAML, logic); err != nil {
+			return nil, err
 		}
-		verified, err := l.generateAssertionWithMFA(deviceID, factor.StateToken, token)
-		if err != nil {
+	}
+	progress(logic, StateAssumingRole)
+	creds, err := l.assumeRole(ctx, SAML, logic)
+	if err != nil {
+		return nil, describeSTS(err, l.Params.RoleArn, SAML)
+	}
+	if l.Params.ChainRoleArn != "" {
+		progress(logic, StateChainingRole)
+		if creds, err = l.chain(ctx, creds); err != nil {
 			return nil, err
 		}
-		SAML = verified.SAML
 	}
-	return l.assumeRole(SAML)
+	progress(logic, StateDone)
+	return creds, nil
 }
 
-// Execute represents login flow
-func (l *Login) generateAssertion() (*samlassertion.GenerateResponse, error) {
-	input := &samlassertion.GenerateRequest{
-		UsernameOrEmail: l.Params.UsernameOrEmail,
-		Password:        l.Params.Password,
-		AppID:           l.Params.AppID,
-		Subdomain:       l.Params.Subdomain,
+// chooseRole fills missing role parameters from roles in the SAML assertion
+func (l *Login) chooseRole(SAML secret.Bytes, logic Event) error {
+	roles, err := ParseRoles(SAML)
+	if err != nil {
+		return err
 	}
-	return l.SAMLAssertion.Generate(input)
-}
-
-func (l *Login) generateAssertionWithMFA(deviceId int, stateToken string, otpToken string) (*samlassertion.VerifyFactorResponse, error) {
-	input := &samlassertion.VerifyFactorRequest{
-		AppID:       l.Params.AppID,
-		DeviceID:    strconv.Itoa(deviceId),
-		StateToken:  stateToken,
-		OtpToken:    otpToken,
-		DoNotNotify: otpToken != "",
+	if l.Params.RoleArn != "" {
+		for _, role := range roles {
+			if role.RoleArn == l.Params.RoleArn {
+				l.Params.PrincipalArn = role.PrincipalArn
+				return nil
+			}
+		}
+		return withKind(errors.Errorf(i18n.T("%s is not assigned to this user"), l.Params.RoleArn), ErrRoleNotAssigned)
+	}
+	selected := 0
+	switch len(roles) {
+	case 0:
+		return withKind(errors.New(i18n.T("There is no role in SAML assertion")), ErrNoRole)
+	case 1:
+	default:
+		selected, err = logic.ChooseRoleIndex(roles)
+		if err != nil {
+			return err
+		}
 	}
-	return l.SAMLAssertion.VerifyFactor(input)
+	l.Params.RoleArn = roles[selected].RoleArn
+	l.Params.PrincipalArn = roles[selected].PrincipalArn
+	return nil
 }
 
 // Execute represents login flow
-func (l *Login) assumeRole(SAML string) (*sts.Credentials, error) {
+func (l *Login) assumeRole(ctx context.Context, SAML secret.Bytes, logic Event) (*sts.Credentials, error) {
+	region := l.stsRegion()
+	endpoint := l.stsEndpoint(region)
+	// the managed session policies are not supported by AWS SDK for Go v1 in use
+	if l.STS == nil && (l.SDKVersion == 2 || len(l.Params.PolicyArns) > 0) {
+		optFns := []func(*stsv2sdk.Options){stsv2.WithoutRetries()}
+		if endpoint != "" {
+			optFns = append(optFns, stsv2.WithEndpoint(endpoint))
+		}
+		l.STS = stsv2.New(l.HTTPClient, region, optFns...)
+	}
 	if l.STS == nil {
-		s, err := session.NewSession()
+		awsConfig := aws.NewConfig()
+		if l.HTTPClient != nil {
+			awsConfig.HTTPClient = l.HTTPClient
+		}
+		awsConfig.MaxRetries = aws.Int(0)
+		if region != "" {
+			awsConfig.Region = aws.String(region)
+		}
+		if endpoint != "" {
+			awsConfig.Endpoint = aws.String(endpoint)
+		}
+		s, err := session.NewSession(awsConfig)
 		if err != nil {
 			return nil, err
 		}
+		s.Handlers.Build.PushBackNamed(useragent.Handler)
 		l.STS = sts.New(s)
 	}
+	// AWS SDK takes the assertion only as string, which cannot be wiped
+	assertion := string(SAML)
 	assumeRoleInput := &sts.AssumeRoleWithSAMLInput{
 		PrincipalArn:    &l.Params.PrincipalArn,
 		RoleArn:         &l.Params.RoleArn,
-		SAMLAssertion:   &SAML,
+		SAMLAssertion:   &assertion,
 		DurationSeconds: &l.Params.DurationSeconds,
 	}
-	assumeRoleOutput, err := l.STS.AssumeRoleWithSAML(assumeRoleInput)
+	if l.Params.Policy != "" {
+		assumeRoleInput.Policy = &l.Params.Policy
+	}
+	if len(l.Params.PolicyArns) > 0 {
+		v2, ok := l.STS.(*stsv2.STS)
+		if !ok {
+			return nil, errors.New(i18n.T("Managed session policies require STS of AWS SDK for Go v2"))
+		}
+		v2.PolicyArns = l.Params.PolicyArns
+	}
+	assumeRoleOutput, err := l.assumeRoleWithSAML(ctx, assumeRoleInput, logic)
+	// STS does not tell MaxSessionDuration of the role, which is whole hours
+	for err != nil && durationExceeded(err) {
+		shorter, ok := shorterDuration(l.Params.DurationSeconds)
+		if !ok {
+			break
+		}
+		l.Params.DurationSeconds = shorter
+		assumeRoleOutput, err = l.assumeRoleWithSAML(ctx, assumeRoleInput, logic)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return assumeRoleOutput.Credentials, nil
 }
+
+// shorterDuration returns the longest whole hours shorter than the duration, and false if it is not longer than an hour
+func shorterDuration(seconds int64) (int64, bool) {
+	shorter := (seconds - 1) / hourSeconds * hourSeconds
+	return shorter, shorter >= hourSeconds
+}
+
+// stsRegion returns the region of STS, STS of aws-us-gov and aws-cn partitions is not reachable by the default region
+func (l *Login) stsRegion() string {
+	if l.Region != "" {
+		return l.Region
+	}
+	if p, ok := partition.FromArn(l.Params.RoleArn); ok && p.ID != partition.AWS {
+		return p.Region
+	}
+	return ""
+}
+
+// stsEndpoint returns the regional endpoint of STS, or empty to use the one of AWS SDK
+func (l *Login) stsEndpoint(region string) string {
+	if l.STSEndpoint != "" || region == "" {
+		return l.STSEndpoint
+	}
+	return partition.STSEndpoint(region)
+}
@@ -1,16 +1,21 @@
 package login
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
 )
 
 type SAMLAssertionMock struct {
@@ -23,6 +28,10 @@ type SAMLAssertionMock struct {
 }
 
 func (s *SAMLAssertionMock) Generate(request *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error) {
+	return s.GenerateWithContext(context.Background(), request)
+}
+
+func (s *SAMLAssertionMock) GenerateWithContext(ctx context.Context, request *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error) {
 	if err := s.GenerateInputVerifier(request); err != nil {
 		return nil, err
 	}
@@ -30,6 +39,10 @@ func (s *SAMLAssertionMock) Generate(request *samlassertion.GenerateRequest) (*s
 }
 
 func (s *SAMLAssertionMock) VerifyFactor(request *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error) {
+	return s.VerifyFactorWithContext(context.Background(), request)
+}
+
+func (s *SAMLAssertionMock) VerifyFactorWithContext(ctx context.Context, request *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error) {
 	if err := s.VerifyFactorInputVerifier(request); err != nil {
 		return nil, err
 	}
@@ -44,6 +57,10 @@ type STSMock struct {
 }
 
 func (s *STSMock) AssumeRoleWithSAML(input *sts.AssumeRoleWithSAMLInput) (*sts.AssumeRoleWithSAMLOutput, error) {
+	return s.AssumeRoleWithSAMLWithContext(context.Background(), input)
+}
+
+func (s *STSMock) AssumeRoleWithSAMLWithContext(ctx aws.Context, input *sts.AssumeRoleWithSAMLInput, opts ...request.Option) (*sts.AssumeRoleWithSAMLOutput, error) {
 	if err := s.InputVerifier(input); err != nil {
 		return nil, err
 	}
@@ -55,25 +72,30 @@ type EventMock struct {
 	ChooseError error
 	MFAToken    string
 	InputError  error
+	RoleIndex   int
+	RoleError   error
 }
 
 func (m *EventMock) ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error) {
 	return m.DeviceIndex, m.ChooseError
 }
-func (m *EventMock) InputMFAToken() (string, error) {
-	return m.MFAToken, m.InputError
+func (m *EventMock) InputMFAToken() (secret.Bytes, error) {
+	return secret.Bytes(m.MFAToken), m.InputError
+}
+func (m *EventMock) ChooseRoleIndex(roles []Role) (int, error) {
+	return m.RoleIndex, m.RoleError
 }
 
 func createAssertion(t *testing.T) *SAMLAssertionMock {
 	return &SAMLAssertionMock{
 		GenerateResponse: &samlassertion.GenerateResponse{
-			SAML: "Base64 encoded SAML Data",
+			SAML: secret.Bytes("Base64 encoded SAML Data"),
 		},
 		GenerateInputVerifier: func(request *samlassertion.GenerateRequest) error {
 			if request.UsernameOrEmail != "username-or-email" {
 				t.Errorf("%s is not equal %s", request.UsernameOrEmail, "username-or-email")
 			}
-			if request.Password != "password" {
+			if string(request.Password) != "password" {
 				t.Errorf("%s is not equal %s", request.Password, "password")
 			}
 			if request.AppID != "app-id" {
@@ -95,8 +117,8 @@ func createAssertionForSingleMFA(t *testing.T) *SAMLAssertionMock {
 					StateToken: "state-token",
 					Devices: []samlassertion.GenerateResponseFactorDevice{
 						{
-							DeviceID:   345678,
-							DeviceType: "device type 1",
+							DeviceID:        345678,
+							DeviceType:      "device type 1",
 							RequireOTPToken: true,
 						},
 					},
@@ -107,7 +129,7 @@ func createAssertionForSingleMFA(t *testing.T) *SAMLAssertionMock {
 			return nil
 		},
 		VerifyFactorResponse: &samlassertion.VerifyFactorResponse{
-			SAML: "Base64 encoded SAML Data",
+			SAML: secret.Bytes("Base64 encoded SAML Data"),
 		},
 		VerifyFactorInputVerifier: func(request *samlassertion.VerifyFactorRequest) error {
 			if request.AppID != "app-id" {
@@ -119,7 +141,7 @@ func createAssertionForSingleMFA(t *testing.T) *SAMLAssertionMock {
 			if request.StateToken != "state-token" {
 				t.Errorf("%s is not equal %s", request.StateToken, "state-token")
 			}
-			if request.OtpToken != "765432" {
+			if string(request.OtpToken) != "765432" {
 				t.Errorf("%s is not equal %s", request.OtpToken, "123456")
 			}
 			if !request.DoNotNotify {
@@ -135,8 +157,8 @@ func createAssertionForMultipleMFA(t *testing.T) *SAMLAssertionMock {
 	assertion.GenerateResponse.Factors[0].Devices = append(
 		assertion.GenerateResponse.Factors[0].Devices,
 		samlassertion.GenerateResponseFactorDevice{
-			DeviceID:   987654,
-			DeviceType: "device type 2",
+			DeviceID:        987654,
+			DeviceType:      "device type 2",
 			RequireOTPToken: true,
 		})
 	assertion.VerifyFactorInputVerifier = func(request *samlassertion.VerifyFactorRequest) error {
@@ -149,7 +171,7 @@ func createAssertionForMultipleMFA(t *testing.T) *SAMLAssertionMock {
 		if request.StateToken != "state-token" {
 			t.Errorf("%s is not equal %s", request.StateToken, "state-token")
 		}
-		if request.OtpToken != "098765" {
+		if string(request.OtpToken) != "098765" {
 			t.Errorf("%s is not equal %s", request.OtpToken, "098765")
 		}
 		if !request.DoNotNotify {
@@ -165,8 +187,8 @@ func createAssertionForNotify(t *testing.T) *SAMLAssertionMock {
 	assertion.GenerateResponse.Factors[0].Devices = append(
 		assertion.GenerateResponse.Factors[0].Devices,
 		samlassertion.GenerateResponseFactorDevice{
-			DeviceID:   987654,
-			DeviceType: "Notify OneLogin Protect",
+			DeviceID:        987654,
+			DeviceType:      "Notify OneLogin Protect",
 			RequireOTPToken: false,
 		})
 	assertion.VerifyFactorInputVerifier = func(request *samlassertion.VerifyFactorRequest) error {
@@ -179,7 +201,7 @@ func createAssertionForNotify(t *testing.T) *SAMLAssertionMock {
 		if request.StateToken != "state-token" {
 			t.Errorf("%s is not equal %s", request.StateToken, "state-token")
 		}
-		if request.OtpToken != "" {
+		if string(request.OtpToken) != "" {
 			t.Errorf("'%s' is not equal '%s'", request.OtpToken, "")
 		}
 		if request.DoNotNotify {
@@ -198,7 +220,7 @@ func createAssertionError(t *testing.T) *SAMLAssertionMock {
 			if request.UsernameOrEmail != "username-or-email" {
 				t.Errorf("%s is not equal %s", request.UsernameOrEmail, "username-or-email")
 			}
-			if request.Password != "password" {
+			if string(request.Password) != "password" {
 				t.Errorf("%s is not equal %s", request.Password, "password")
 			}
 			if request.AppID != "app-id" {
@@ -241,7 +263,7 @@ func createSTS(t *testing.T) *STSMock {
 func createDefaultParams() *Parameters {
 	return &Parameters{
 		UsernameOrEmail: "username-or-email",
-		Password:        "password",
+		Password:        secret.Bytes("password"),
 		AppID:           "app-id",
 		Subdomain:       "subdomain",
 		PrincipalArn:    "principal-arn",
@@ -264,6 +286,76 @@ func TestLogin_LoginWithoutMFA(t *testing.T) {
 	}
 }
 
+func TestLogin_LoginWithSessionPolicy(t *testing.T) {
+	stsMock := createSTS(t)
+	stsMock.InputVerifier = func(request *sts.AssumeRoleWithSAMLInput) error {
+		if request.Policy == nil || *request.Policy != `{"Version":"2012-10-17"}` {
+			t.Errorf("%v is not the session policy", request.Policy)
+		}
+		return nil
+	}
+	params := createDefaultParams()
+	params.Policy = `{"Version":"2012-10-17"}`
+	l := &Login{
+		SAMLAssertion: createAssertion(t),
+		STS:           stsMock,
+		Params:        params,
+	}
+	if _, err := l.Login(&EventMock{}); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	params.PolicyArns = []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}
+	if _, err := l.Login(&EventMock{}); err == nil || err.Error() != "Managed session policies require STS of AWS SDK for Go v2" {
+		t.Errorf("%v is not the error of STS of AWS SDK for Go v1", err)
+	}
+}
+
+func TestLogin_LoginDurationExceeded(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration int64
+		max      int64
+		want     int64
+		wantErr  bool
+	}{
+		{name: "allowed", duration: 3600, max: 3600, want: 3600},
+		{name: "maximum", duration: 43200, max: 14400, want: 14400},
+		{name: "not whole hours", duration: 5400, max: 3600, want: 3600},
+		{name: "an hour", duration: 3600, max: 0, want: 3600, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stsMock := createSTS(t)
+			calls := 0
+			stsMock.InputVerifier = func(request *sts.AssumeRoleWithSAMLInput) error {
+				calls++
+				if *request.DurationSeconds > tt.max {
+					return awserr.New("ValidationError", "The requested DurationSeconds exceeds the MaxSessionDuration set for this role.", nil)
+				}
+				return nil
+			}
+			params := createDefaultParams()
+			params.DurationSeconds = tt.duration
+			l := &Login{
+				SAMLAssertion: createAssertion(t),
+				STS:           stsMock,
+				Params:        params,
+			}
+			_, err := l.Login(&EventMock{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if params.DurationSeconds != tt.want {
+				t.Errorf("%d is not the effective duration %d", params.DurationSeconds, tt.want)
+			}
+			if !tt.wantErr && calls != int((tt.duration-tt.max+3599)/3600)+1 {
+				t.Errorf("STS is called %d times", calls)
+			}
+		})
+	}
+}
+
 func TestLogin_LoginErrorWithoutMFA(t *testing.T) {
 	l := &Login{
 		SAMLAssertion: createAssertionError(t),
@@ -278,6 +370,25 @@ func TestLogin_LoginErrorWithoutMFA(t *testing.T) {
 	}
 }
 
+func TestLogin_LoginInvalidCredentials(t *testing.T) {
+	assertion := createAssertionError(t)
+	assertion.GenerateError = &samlassertion.Error{Code: 401, Type: "Unauthorized", Message: "Authentication Failed: Invalid user credentials", Err: samlassertion.ErrInvalidCredentials}
+	l := &Login{
+		SAMLAssertion: assertion,
+		STS:           createSTS(t),
+		Params:        createDefaultParams(),
+	}
+	_, err := l.Login(&EventMock{
+		ChooseError: errors.New("Don't call choose function"),
+	})
+	if samlassertion.Class(err) != samlassertion.ErrInvalidCredentials {
+		t.Errorf("%v is not classified", err)
+	}
+	if err.Error() != "the username or the password is wrong: [401] Unauthorized: Authentication Failed: Invalid user credentials" {
+		t.Errorf("%s has no hint", err)
+	}
+}
+
 func TestLogin_LoginWithSingleMFA(t *testing.T) {
 	l := &Login{
 		SAMLAssertion: createAssertionForSingleMFA(t),
@@ -351,6 +462,89 @@ func TestLogin_LoginMFAErrorWithMFA(t *testing.T) {
 	}
 }
 
+func TestLogin_LoginChooseRole(t *testing.T) {
+	SAML := encodeSAML(
+		"arn:aws:iam::123456789012:role/admin,principal-arn",
+		"role-arn,arn:aws:iam::123456789012:saml-provider/onelogin",
+	)
+	assertion := createAssertion(t)
+	assertion.GenerateResponse.SAML = SAML
+	stsMock := createSTS(t)
+	stsMock.InputVerifier = func(request *sts.AssumeRoleWithSAMLInput) error {
+		if *request.RoleArn != "role-arn" {
+			t.Errorf("%s is not equal %s", *request.RoleArn, "role-arn")
+		}
+		if *request.PrincipalArn != "arn:aws:iam::123456789012:saml-provider/onelogin" {
+			t.Errorf("%s is not equal %s", *request.PrincipalArn, "arn:aws:iam::123456789012:saml-provider/onelogin")
+		}
+		return nil
+	}
+	params := createDefaultParams()
+	params.RoleArn = ""
+	params.PrincipalArn = ""
+	l := &Login{
+		SAMLAssertion: assertion,
+		STS:           stsMock,
+		Params:        params,
+	}
+	_, err := l.Login(&EventMock{
+		ChooseError: errors.New("Don't call choose function"),
+		RoleIndex:   1,
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestLogin_LoginRoleNotAssigned(t *testing.T) {
+	assertion := createAssertion(t)
+	assertion.GenerateResponse.SAML = encodeSAML("role-arn,principal-arn")
+	params := createDefaultParams()
+	params.RoleArn = "other-role-arn"
+	params.PrincipalArn = ""
+	l := &Login{
+		SAMLAssertion: assertion,
+		STS:           createSTS(t),
+		Params:        params,
+	}
+	_, err := l.Login(&EventMock{})
+	if err == nil || err.Error() != "other-role-arn is not assigned to this user" {
+		t.Errorf("%v is not equal 'other-role-arn is not assigned to this user'", err)
+	}
+	if !errors.Is(err, ErrRoleNotAssigned) {
+		t.Errorf("%v is not ErrRoleNotAssigned", err)
+	}
+}
+
+func TestLogin_stsRegion(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   string
+		roleArn  string
+		endpoint string
+		want     string
+		wantURL  string
+	}{
+		{name: "aws", roleArn: "arn:aws:iam::123456789012:role/Role", want: "", wantURL: ""},
+		{name: "gov cloud", roleArn: "arn:aws-us-gov:iam::123456789012:role/Role", want: "us-gov-west-1", wantURL: "https://sts.us-gov-west-1.amazonaws.com"},
+		{name: "china", roleArn: "arn:aws-cn:iam::123456789012:role/Role", want: "cn-north-1", wantURL: "https://sts.cn-north-1.amazonaws.com.cn"},
+		{name: "region", region: "ap-northeast-1", roleArn: "arn:aws:iam::123456789012:role/Role", want: "ap-northeast-1", wantURL: "https://sts.ap-northeast-1.amazonaws.com"},
+		{name: "endpoint", region: "us-gov-east-1", roleArn: "arn:aws-us-gov:iam::123456789012:role/Role", endpoint: "https://sts.example.com", want: "us-gov-east-1", wantURL: "https://sts.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Login{Params: &Parameters{RoleArn: tt.roleArn}, Region: tt.region, STSEndpoint: tt.endpoint}
+			got := l.stsRegion()
+			if got != tt.want {
+				t.Errorf("stsRegion() = %v, want %v", got, tt.want)
+			}
+			if url := l.stsEndpoint(got); url != tt.wantURL {
+				t.Errorf("stsEndpoint() = %v, want %v", url, tt.wantURL)
+			}
+		})
+	}
+}
+
 func StringRef(v string) *string {
 	return &v
 }
@@ -0,0 +1,397 @@
+// Package logintest provides scripted fakes of SAMLAssertionAPI, STSAPI and login.Event,
+// so programs using the login package can test their integration without OneLogin and AWS
+package logintest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion/samlassertioniface"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+var (
+	_ samlassertioniface.SAMLAssertionAPI = &SAMLAssertion{}
+	_ stsiface.STSAPI                     = &STS{}
+	_ login.ApprovalEvent                 = &Event{}
+	_ login.ProgressEvent                 = &Event{}
+	_ login.ConfirmEvent                  = &Event{}
+	_ login.RetryEvent                    = &Event{}
+)
+
+// EncodeSAML returns the base64 encoded SAML assertion of the roles, which login.ParseRoles reads
+func EncodeSAML(roles ...login.Role) secret.Bytes {
+	values := ""
+	for _, role := range roles {
+		values += fmt.Sprintf("<saml:AttributeValue>%s,%s</saml:AttributeValue>", role.RoleArn, role.PrincipalArn)
+	}
+	xml := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">` +
+		`<saml:Assertion><saml:AttributeStatement>` +
+		`<saml:Attribute Name="https://aws.amazon.com/SAML/Attributes/Role">` + values + `</saml:Attribute>` +
+		`</saml:AttributeStatement></saml:Assertion></samlp:Response>`
+	return secret.Bytes(base64.StdEncoding.EncodeToString([]byte(xml)))
+}
+
+// GenerateResult is the result of Generate
+type GenerateResult struct {
+	Response *samlassertion.GenerateResponse
+	Err      error
+}
+
+// VerifyFactorResult is the result of VerifyFactor, Pending calls OnPending of the request before the response
+type VerifyFactorResult struct {
+	Response *samlassertion.VerifyFactorResponse
+	Err      error
+	Pending  []time.Duration
+}
+
+// SAMLAssertion is the fake of SAMLAssertionAPI returning the scripted results in order, the last one is repeated.
+// The requests are recorded with copies of the password and the OTP token, which login wipes
+type SAMLAssertion struct {
+	Generates     []GenerateResult
+	VerifyFactors []VerifyFactorResult
+
+	mu                   sync.Mutex
+	generateRequests     []samlassertion.GenerateRequest
+	verifyFactorRequests []samlassertion.VerifyFactorRequest
+}
+
+// NewSAMLAssertion returns the fake issuing the SAML assertion without MFA
+func NewSAMLAssertion(SAML secret.Bytes) *SAMLAssertion {
+	return &SAMLAssertion{
+		Generates: []GenerateResult{{Response: &samlassertion.GenerateResponse{SAML: SAML}}},
+	}
+}
+
+// NewSAMLAssertionWithMFA returns the fake requiring MFA of the devices, and issuing the SAML assertion after verified
+func NewSAMLAssertionWithMFA(SAML secret.Bytes, devices ...samlassertion.GenerateResponseFactorDevice) *SAMLAssertion {
+	return &SAMLAssertion{
+		Generates: []GenerateResult{{Response: &samlassertion.GenerateResponse{
+			Factors: []samlassertion.GenerateResponseFactor{{StateToken: "state-token", Devices: devices}},
+		}}},
+		VerifyFactors: []VerifyFactorResult{{Response: &samlassertion.VerifyFactorResponse{SAML: SAML}}},
+	}
+}
+
+// Generate returns the next result of Generates
+func (f *SAMLAssertion) Generate(input *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error) {
+	return f.GenerateWithContext(context.Background(), input)
+}
+
+// GenerateWithContext returns the next result of Generates, or the error of the context
+func (f *SAMLAssertion) GenerateWithContext(ctx context.Context, input *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	recorded := *input
+	recorded.Password = append(secret.Bytes(nil), input.Password...)
+	f.generateRequests = append(f.generateRequests, recorded)
+	if len(f.Generates) == 0 {
+		return nil, errors.New("no result of Generate is scripted")
+	}
+	result := f.Generates[next(len(f.generateRequests), len(f.Generates))]
+	return result.Response, result.Err
+}
+
+// VerifyFactor returns the next result of VerifyFactors
+func (f *SAMLAssertion) VerifyFactor(input *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error) {
+	return f.VerifyFactorWithContext(context.Background(), input)
+}
+
+// VerifyFactorWithContext returns the next result of VerifyFactors, or the error of the context
+func (f *SAMLAssertion) VerifyFactorWithContext(ctx context.Context, input *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error) {
+	f.mu.Lock()
+	recorded := *input
+	recorded.OtpToken = append(secret.Bytes(nil), input.OtpToken...)
+	recorded.OnPending = nil
+	f.verifyFactorRequests = append(f.verifyFactorRequests, recorded)
+	if len(f.VerifyFactors) == 0 {
+		f.mu.Unlock()
+		return nil, errors.New("no result of VerifyFactor is scripted")
+	}
+	result := f.VerifyFactors[next(len(f.verifyFactorRequests), len(f.VerifyFactors))]
+	f.mu.Unlock()
+	for _, remaining := range result.Pending {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if input.OnPending != nil {
+			input.OnPending(remaining)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result.Response, result.Err
+}
+
+// GenerateRequests returns the recorded requests of Generate
+func (f *SAMLAssertion) GenerateRequests() []samlassertion.GenerateRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]samlassertion.GenerateRequest(nil), f.generateRequests...)
+}
+
+// VerifyFactorRequests returns the recorded requests of VerifyFactor without OnPending
+func (f *SAMLAssertion) VerifyFactorRequests() []samlassertion.VerifyFactorRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]samlassertion.VerifyFactorRequest(nil), f.verifyFactorRequests...)
+}
+
+// NewCredentials returns the credentials expiring after the duration
+func NewCredentials(expiresIn time.Duration) *sts.Credentials {
+	return &sts.Credentials{
+		AccessKeyId:     aws.String("ASIAFAKEACCESSKEYID"),
+		SecretAccessKey: aws.String("fake-secret-access-key"),
+		SessionToken:    aws.String("fake-session-token"),
+		Expiration:      aws.Time(time.Now().Add(expiresIn).UTC()),
+	}
+}
+
+// STSResult is the result of AssumeRoleWithSAML or AssumeRole
+type STSResult struct {
+	Credentials *sts.Credentials
+	Err         error
+}
+
+// STS is the fake of STSAPI returning the scripted results in order, the last one is repeated.
+// The credentials of NewCredentials for an hour are returned if no result is scripted.
+// Other methods of STSAPI panic
+type STS struct {
+	stsiface.STSAPI
+	AssumeRoleWithSAMLResults []STSResult
+	AssumeRoleResults         []STSResult
+
+	mu                       sync.Mutex
+	assumeRoleWithSAMLInputs []sts.AssumeRoleWithSAMLInput
+	assumeRoleInputs         []sts.AssumeRoleInput
+}
+
+// AssumeRoleWithSAML returns the next result of AssumeRoleWithSAMLResults
+func (f *STS) AssumeRoleWithSAML(input *sts.AssumeRoleWithSAMLInput) (*sts.AssumeRoleWithSAMLOutput, error) {
+	return f.AssumeRoleWithSAMLWithContext(context.Background(), input)
+}
+
+// AssumeRoleWithSAMLWithContext returns the next result of AssumeRoleWithSAMLResults, or the error of the context
+func (f *STS) AssumeRoleWithSAMLWithContext(ctx aws.Context, input *sts.AssumeRoleWithSAMLInput, _ ...request.Option) (*sts.AssumeRoleWithSAMLOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.assumeRoleWithSAMLInputs = append(f.assumeRoleWithSAMLInputs, *input)
+	creds, err := stsResult(f.AssumeRoleWithSAMLResults, len(f.assumeRoleWithSAMLInputs))
+	if err != nil {
+		return nil, err
+	}
+	return &sts.AssumeRoleWithSAMLOutput{Credentials: creds}, nil
+}
+
+// AssumeRole returns the next result of AssumeRoleResults
+func (f *STS) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	return f.AssumeRoleWithContext(context.Background(), input)
+}
+
+// AssumeRoleWithContext returns the next result of AssumeRoleResults, or the error of the context
+func (f *STS) AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, _ ...request.Option) (*sts.AssumeRoleOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.assumeRoleInputs = append(f.assumeRoleInputs, *input)
+	creds, err := stsResult(f.AssumeRoleResults, len(f.assumeRoleInputs))
+	if err != nil {
+		return nil, err
+	}
+	return &sts.AssumeRoleOutput{Credentials: creds}, nil
+}
+
+// AssumeRoleWithSAMLInputs returns the recorded inputs of AssumeRoleWithSAML
+func (f *STS) AssumeRoleWithSAMLInputs() []sts.AssumeRoleWithSAMLInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sts.AssumeRoleWithSAMLInput(nil), f.assumeRoleWithSAMLInputs...)
+}
+
+// AssumeRoleInputs returns the recorded inputs of AssumeRole
+func (f *STS) AssumeRoleInputs() []sts.AssumeRoleInput {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sts.AssumeRoleInput(nil), f.assumeRoleInputs...)
+}
+
+func stsResult(results []STSResult, calls int) (*sts.Credentials, error) {
+	if len(results) == 0 {
+		return NewCredentials(time.Hour), nil
+	}
+	result := results[next(calls, len(results))]
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if result.Credentials == nil {
+		return NewCredentials(time.Hour), nil
+	}
+	return result.Credentials, nil
+}
+
+// next returns the index of the result of the call counted from 1, the last one is repeated
+func next(calls int, results int) int {
+	if calls > results {
+		return results - 1
+	}
+	return calls - 1
+}
+
+// Event is the fake of login.Event and its optional events answering the scripted values, and recording what is asked
+type Event struct {
+	DeviceIndex int
+	DeviceErr   error
+	RoleIndex   int
+	RoleErr     error
+	MFATokenErr error
+	// MFATokens are answered in order, the last one is repeated
+	MFATokens []string
+	// Confirms are answered in order, the last one is repeated, and no means no retry
+	Confirms []bool
+	// GiveUpSTS returns false to RetrySTS
+	GiveUpSTS bool
+
+	mu        sync.Mutex
+	devices   [][]samlassertion.GenerateResponseFactorDevice
+	roles     [][]login.Role
+	tokens    int
+	messages  []string
+	states    []login.State
+	approvals []time.Duration
+	retries   []int
+}
+
+// ChooseDeviceIndex records the devices and returns DeviceIndex
+func (e *Event) ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.devices = append(e.devices, devices)
+	return e.DeviceIndex, e.DeviceErr
+}
+
+// InputMFAToken returns the next token of MFATokens
+func (e *Event) InputMFAToken() (secret.Bytes, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tokens++
+	if e.MFATokenErr != nil {
+		return nil, e.MFATokenErr
+	}
+	if len(e.MFATokens) == 0 {
+		return nil, errors.New("no MFA token is scripted")
+	}
+	return secret.Bytes(e.MFATokens[next(e.tokens, len(e.MFATokens))]), nil
+}
+
+// ChooseRoleIndex records the roles and returns RoleIndex
+func (e *Event) ChooseRoleIndex(roles []login.Role) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.roles = append(e.roles, roles)
+	return e.RoleIndex, e.RoleErr
+}
+
+// WaitApproval records the remaining time
+func (e *Event) WaitApproval(remaining time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.approvals = append(e.approvals, remaining)
+}
+
+// ApprovalDone does nothing
+func (e *Event) ApprovalDone() {}
+
+// Progress records the state
+func (e *Event) Progress(state login.State) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.states = append(e.states, state)
+}
+
+// Confirm records the message and returns the next answer of Confirms
+func (e *Event) Confirm(message string) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.messages = append(e.messages, message)
+	if len(e.Confirms) == 0 {
+		return false, nil
+	}
+	return e.Confirms[next(len(e.messages), len(e.Confirms))], nil
+}
+
+// RetrySTS records the attempt and returns false if GiveUpSTS is true
+func (e *Event) RetrySTS(attempt int, delay time.Duration, err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.retries = append(e.retries, attempt)
+	return !e.GiveUpSTS
+}
+
+// Devices returns the devices asked by ChooseDeviceIndex
+func (e *Event) Devices() [][]samlassertion.GenerateResponseFactorDevice {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]samlassertion.GenerateResponseFactorDevice(nil), e.devices...)
+}
+
+// Roles returns the roles asked by ChooseRoleIndex
+func (e *Event) Roles() [][]login.Role {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([][]login.Role(nil), e.roles...)
+}
+
+// MFATokenCount returns how many times InputMFAToken is called
+func (e *Event) MFATokenCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tokens
+}
+
+// States returns the states told by Progress
+func (e *Event) States() []login.State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]login.State(nil), e.states...)
+}
+
+// Approvals returns the remaining time told by WaitApproval
+func (e *Event) Approvals() []time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]time.Duration(nil), e.approvals...)
+}
+
+// Messages returns the messages asked by Confirm
+func (e *Event) Messages() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.messages...)
+}
+
+// STSRetries returns the attempts told by RetrySTS
+func (e *Event) STSRetries() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]int(nil), e.retries...)
+}
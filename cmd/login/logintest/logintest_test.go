@@ -0,0 +1,112 @@
+package logintest_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login/logintest"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+var roles = []login.Role{
+	{RoleArn: "arn:aws:iam::123456789012:role/admin", PrincipalArn: "arn:aws:iam::123456789012:saml-provider/onelogin"},
+	{RoleArn: "arn:aws:iam::123456789012:role/readonly", PrincipalArn: "arn:aws:iam::123456789012:saml-provider/onelogin"},
+}
+
+func TestEncodeSAML(t *testing.T) {
+	parsed, err := login.ParseRoles(logintest.EncodeSAML(roles...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, roles) {
+		t.Errorf("%#v is not %#v", parsed, roles)
+	}
+}
+
+func TestLoginWithFakes(t *testing.T) {
+	SAML := logintest.EncodeSAML(roles...)
+	assertion := logintest.NewSAMLAssertionWithMFA(SAML,
+		samlassertion.GenerateResponseFactorDevice{DeviceID: 1, DeviceType: "Google Authenticator", RequireOTPToken: true},
+		samlassertion.GenerateResponseFactorDevice{DeviceID: 2, DeviceType: "Yubico YubiKey", RequireOTPToken: true},
+	)
+	assertion.VerifyFactors = []logintest.VerifyFactorResult{
+		{Err: errors.New("[401] Unauthorized: Failed authentication with this factor")},
+		{Response: &samlassertion.VerifyFactorResponse{SAML: SAML}},
+	}
+	fakeSTS := &logintest.STS{
+		AssumeRoleWithSAMLResults: []logintest.STSResult{
+			{Err: awserr.New("Throttling", "Rate exceeded", nil)},
+			{},
+		},
+	}
+	event := &logintest.Event{DeviceIndex: 1, MFATokens: []string{"111111", "222222"}, RoleIndex: 1, Confirms: []bool{true}}
+	l := &login.Login{
+		SAMLAssertion: assertion,
+		STS:           fakeSTS,
+		Params:        &login.Parameters{UsernameOrEmail: "user", Password: secret.Bytes("password"), AppID: "app-id", DurationSeconds: 3600},
+		MaxRetries:    1,
+	}
+	creds, err := l.Login(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Expiration.Before(time.Now().Add(50 * time.Minute)) {
+		t.Errorf("%v is expired soon", creds.Expiration)
+	}
+
+	if requests := assertion.GenerateRequests(); len(requests) != 1 || string(requests[0].Password) != "password" {
+		t.Errorf("%#v is unexpected", requests)
+	}
+	verifies := assertion.VerifyFactorRequests()
+	if len(verifies) != 2 || string(verifies[0].OtpToken) != "111111" || string(verifies[1].OtpToken) != "222222" || verifies[1].DeviceID != "2" {
+		t.Errorf("%#v is unexpected", verifies)
+	}
+	inputs := fakeSTS.AssumeRoleWithSAMLInputs()
+	if len(inputs) != 2 || *inputs[1].RoleArn != roles[1].RoleArn {
+		t.Errorf("%#v is unexpected", inputs)
+	}
+	if len(event.Devices()) != 2 || len(event.Roles()) != 1 || event.MFATokenCount() != 2 || len(event.Messages()) != 1 {
+		t.Errorf("devices %v, roles %v, tokens %d and confirms %v are unexpected", event.Devices(), event.Roles(), event.MFATokenCount(), event.Messages())
+	}
+	if !reflect.DeepEqual(event.STSRetries(), []int{1}) {
+		t.Errorf("%v is unexpected", event.STSRetries())
+	}
+	states := event.States()
+	if states[0] != login.StateGeneratingSAML || states[len(states)-1] != login.StateDone {
+		t.Errorf("%v is unexpected", states)
+	}
+}
+
+func TestSAMLAssertionPending(t *testing.T) {
+	assertion := logintest.NewSAMLAssertionWithMFA(secret.Bytes("SAML"),
+		samlassertion.GenerateResponseFactorDevice{DeviceID: 1, DeviceType: "Notify to OneLogin Protect"},
+	)
+	assertion.VerifyFactors[0].Pending = []time.Duration{2 * time.Second, time.Second}
+	event := &logintest.Event{}
+	l := &login.Login{
+		SAMLAssertion: assertion,
+		Params:        &login.Parameters{},
+	}
+	SAML, err := l.GenerateSAML(event)
+	if err != nil || string(SAML) != "SAML" {
+		t.Fatalf("%s, %v is unexpected", SAML, err)
+	}
+	if !reflect.DeepEqual(event.Approvals(), []time.Duration{2 * time.Second, time.Second}) {
+		t.Errorf("%v is unexpected", event.Approvals())
+	}
+}
+
+func TestSAMLAssertionNotScripted(t *testing.T) {
+	if _, err := (&logintest.SAMLAssertion{}).Generate(&samlassertion.GenerateRequest{}); err == nil {
+		t.Error("Generate without results must be an error")
+	}
+	if _, err := (&logintest.Event{}).InputMFAToken(); err == nil {
+		t.Error("InputMFAToken without tokens must be an error")
+	}
+}
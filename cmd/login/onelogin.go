@@ -0,0 +1,130 @@
+package login
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion/samlassertioniface"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// IdentityProvider issues the SAML assertion of the user, asking MFA with the event if it is required.
+// The caller wipes the assertion after use
+type IdentityProvider interface {
+	GenerateSAML(ctx context.Context, logic Event) (secret.Bytes, error)
+}
+
+// IdentityProviderFunc is IdentityProvider of the function
+type IdentityProviderFunc func(ctx context.Context, logic Event) (secret.Bytes, error)
+
+// GenerateSAML calls the function
+func (f IdentityProviderFunc) GenerateSAML(ctx context.Context, logic Event) (secret.Bytes, error) {
+	return f(ctx, logic)
+}
+
+// OneLogin is IdentityProvider of OneLogin SAML assertion API,
+// it uses UsernameOrEmail, Password, AppID and Subdomain of Params
+type OneLogin struct {
+	SAMLAssertion samlassertioniface.SAMLAssertionAPI
+	Params        *Parameters
+}
+
+// GenerateSAML generates the SAML assertion, verifying MFA with the device chosen by the event
+func (o *OneLogin) GenerateSAML(ctx context.Context, logic Event) (secret.Bytes, error) {
+	assertion, err := o.generateAssertion(ctx)
+	if err != nil {
+		return nil, describe(err)
+	}
+	if len(assertion.SAML) > 0 {
+		return assertion.SAML, nil
+	}
+	progress(logic, StateMFARequired)
+	factor := assertion.Factors[0]
+	for attempt := 1; ; attempt++ {
+		verified, retryable, err := o.verifyMFA(ctx, factor, logic)
+		if err == nil {
+			progress(logic, StateApproved)
+			return verified.SAML, nil
+		}
+		if !retryable || ctx.Err() != nil || attempt >= maxMFAAttempts {
+			return nil, err
+		}
+		retry, cerr := confirmMFARetry(logic, err)
+		if cerr != nil {
+			return nil, cerr
+		}
+		if !retry {
+			return nil, err
+		}
+	}
+}
+
+// verifyMFA chooses the device, inputs the OTP token if it is required and verifies it.
+// Only the failure of the verification is retryable, not the one of the prompts
+func (o *OneLogin) verifyMFA(ctx context.Context, factor samlassertion.GenerateResponseFactor, logic Event) (*samlassertion.VerifyFactorResponse, bool, error) {
+	selected := 0
+	if len(factor.Devices) > 1 {
+		var err error
+		selected, err = logic.ChooseDeviceIndex(factor.Devices)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	device := factor.Devices[selected]
+	var token secret.Bytes
+	var onPending func(time.Duration)
+	if device.RequireOTPToken {
+		var err error
+		token, err = logic.InputMFAToken()
+		if err != nil {
+			return nil, false, err
+		}
+		defer token.Wipe()
+		progress(logic, StateVerifyingToken)
+	} else {
+		progress(logic, StateWaitingApproval)
+		if approval, ok := logic.(ApprovalEvent); ok {
+			onPending = approval.WaitApproval
+			defer approval.ApprovalDone()
+		}
+	}
+	verified, err := o.generateAssertionWithMFA(ctx, device.DeviceID, factor.StateToken, token, onPending)
+	if err != nil {
+		return nil, true, describe(err)
+	}
+	return verified, false, nil
+}
+
+// RateLimit returns the rate limit of OneLogin API told by the last response, or nil if it is unknown
+func (o *OneLogin) RateLimit() *samlassertion.RateLimit {
+	if limited, ok := o.SAMLAssertion.(interface {
+		RateLimit() *samlassertion.RateLimit
+	}); ok {
+		return limited.RateLimit()
+	}
+	return nil
+}
+
+func (o *OneLogin) generateAssertion(ctx context.Context) (*samlassertion.GenerateResponse, error) {
+	input := &samlassertion.GenerateRequest{
+		UsernameOrEmail: o.Params.UsernameOrEmail,
+		Password:        o.Params.Password,
+		AppID:           o.Params.AppID,
+		Subdomain:       o.Params.Subdomain,
+	}
+	return o.SAMLAssertion.GenerateWithContext(ctx, input)
+}
+
+func (o *OneLogin) generateAssertionWithMFA(ctx context.Context, deviceId int, stateToken string, otpToken secret.Bytes, onPending func(time.Duration)) (*samlassertion.VerifyFactorResponse, error) {
+	input := &samlassertion.VerifyFactorRequest{
+		AppID:       o.Params.AppID,
+		DeviceID:    strconv.Itoa(deviceId),
+		StateToken:  stateToken,
+		OtpToken:    otpToken,
+		DoNotNotify: len(otpToken) > 0,
+		OnPending:   onPending,
+	}
+	return o.SAMLAssertion.VerifyFactorWithContext(ctx, input)
+}
@@ -0,0 +1,49 @@
+package login
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+func TestLogin_LoginWithIdentityProvider(t *testing.T) {
+	stsMock := createSTS(t)
+	stsMock.InputVerifier = func(input *sts.AssumeRoleWithSAMLInput) error {
+		if *input.SAMLAssertion != "SAML of another IdP" {
+			t.Errorf("%s is not the assertion of the IdP", *input.SAMLAssertion)
+		}
+		return nil
+	}
+	called := 0
+	l := &Login{
+		IdP: IdentityProviderFunc(func(ctx context.Context, logic Event) (secret.Bytes, error) {
+			called++
+			return secret.Bytes("SAML of another IdP"), nil
+		}),
+		STS:    stsMock,
+		Params: createDefaultParams(),
+	}
+	if _, err := l.Login(&EventMock{}); err != nil {
+		t.Fatal(err)
+	}
+	if called != 1 {
+		t.Errorf("IdP is called %d times", called)
+	}
+	if l.RateLimit() != nil {
+		t.Errorf("%v is not nil for the IdP without the rate limit", l.RateLimit())
+	}
+}
+
+func TestOneLogin_GenerateSAML(t *testing.T) {
+	o := &OneLogin{SAMLAssertion: createAssertionForSingleMFA(t), Params: createDefaultParams()}
+	SAML, err := o.GenerateSAML(context.Background(), &EventMock{MFAToken: "765432"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(SAML) != "Base64 encoded SAML Data" {
+		t.Errorf("%s is unexpected", SAML)
+	}
+}
@@ -0,0 +1,163 @@
+// Package prompt implements login.Event on the terminal, so programs using the login package
+// do not have to implement prompts to choose the MFA device and the role, and to input the OTP token
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// Event asks the user with numbered lists and reads the OTP token without echo if the input is a terminal.
+// It implements login.ApprovalEvent too, showing a spinner while waiting for the push approval,
+// and login.ConfirmEvent asking y or n
+type Event struct {
+	In  *bufio.Reader
+	Out io.Writer
+	// Fd is the file descriptor of the input to disable echo, -1 means the input is not a terminal
+	Fd int
+	// TTY shows the spinner on the same line instead of printing the message once
+	TTY     bool
+	waiting int
+}
+
+var (
+	_ login.Event         = &Event{}
+	_ login.ApprovalEvent = &Event{}
+	_ login.ConfirmEvent  = &Event{}
+)
+
+// NewEvent returns the event reading stdin and writing stderr, so stdout of the program is kept clean
+func NewEvent() *Event {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		fd = -1
+	}
+	return &Event{
+		In:  bufio.NewReader(os.Stdin),
+		Out: os.Stderr,
+		Fd:  fd,
+		TTY: terminal.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// ChooseDeviceIndex asks the MFA device by its number
+func (e *Event) ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error) {
+	items := make([]string, len(devices))
+	for i, device := range devices {
+		items[i] = device.DeviceType
+	}
+	return e.ChooseIndex(i18n.T("Select your MFA device: "), items)
+}
+
+// ChooseRoleIndex asks the role by its number
+func (e *Event) ChooseRoleIndex(roles []login.Role) (int, error) {
+	items := make([]string, len(roles))
+	for i, role := range roles {
+		items[i] = role.RoleArn
+	}
+	return e.ChooseIndex(i18n.T("Select your role: "), items)
+}
+
+// ChooseIndex prints the numbered items and asks again until a number of them is entered
+func (e *Event) ChooseIndex(prompt string, items []string) (int, error) {
+	for {
+		fmt.Fprintln(e.Out, "--------")
+		for i, item := range items {
+			fmt.Fprintf(e.Out, "%d : %s\n", i, item)
+		}
+		fmt.Fprintln(e.Out, "--------")
+		fmt.Fprint(e.Out, prompt)
+		line, err := e.In.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return 0, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		selected, err := strconv.Atoi(line)
+		if err != nil {
+			return 0, err
+		}
+		if selected >= 0 && selected < len(items) {
+			return selected, nil
+		}
+	}
+}
+
+// Confirm asks yes or no, the empty answer is no
+func (e *Event) Confirm(message string) (bool, error) {
+	fmt.Fprintf(e.Out, "%s [y/N]: ", message)
+	line, err := e.In.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// InputMFAToken asks the OTP token until a non-empty one is entered
+func (e *Event) InputMFAToken() (secret.Bytes, error) {
+	for {
+		fmt.Fprint(e.Out, i18n.T("Enter your MFA token: "))
+		token, err := e.readSecret()
+		if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(token); len(trimmed) > 0 {
+			return trimmed, nil
+		}
+	}
+}
+
+// readSecret reads a line without echo from the terminal, or from the buffered input
+func (e *Event) readSecret() (secret.Bytes, error) {
+	if e.Fd >= 0 {
+		line, err := terminal.ReadPassword(e.Fd)
+		fmt.Fprintln(e.Out, "")
+		return secret.Bytes(line), err
+	}
+	line, err := e.In.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		secret.Wipe(line)
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// WaitApproval shows a spinner with the remaining time while waiting for OneLogin Protect approval
+func (e *Event) WaitApproval(remaining time.Duration) {
+	message := i18n.Sprintf("waiting for OneLogin Protect approval, %ds left, Ctrl-C to cancel", int(remaining.Seconds()))
+	if e.TTY {
+		fmt.Fprintf(e.Out, "\r\x1b[K%s %s", spinnerFrames[e.waiting%len(spinnerFrames)], message)
+	} else if e.waiting == 0 {
+		fmt.Fprintln(e.Out, message)
+	}
+	e.waiting++
+}
+
+// ApprovalDone clears the spinner
+func (e *Event) ApprovalDone() {
+	if e.TTY && e.waiting > 0 {
+		fmt.Fprint(e.Out, "\r\x1b[K")
+	}
+	e.waiting = 0
+}
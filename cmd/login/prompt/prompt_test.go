@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+func newTestEvent(input string, out *bytes.Buffer) *Event {
+	return &Event{In: bufio.NewReader(strings.NewReader(input)), Out: out, Fd: -1}
+}
+
+func TestEvent_ChooseDeviceIndex(t *testing.T) {
+	var out bytes.Buffer
+	devices := []samlassertion.GenerateResponseFactorDevice{
+		{DeviceID: 1, DeviceType: "Google Authenticator"},
+		{DeviceID: 2, DeviceType: "OneLogin Protect"},
+	}
+	// the empty line and the out of range number are asked again
+	selected, err := newTestEvent("\n5\n1\n", &out).ChooseDeviceIndex(devices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected != 1 {
+		t.Errorf("%d is selected", selected)
+	}
+	if !strings.Contains(out.String(), "1 : OneLogin Protect\n") || strings.Count(out.String(), "Select your MFA device: ") != 3 {
+		t.Errorf("%q is unexpected", out.String())
+	}
+}
+
+func TestEvent_ChooseRoleIndex(t *testing.T) {
+	var out bytes.Buffer
+	roles := []login.Role{
+		{RoleArn: "arn:aws:iam::123456789012:role/admin"},
+		{RoleArn: "arn:aws:iam::123456789012:role/readonly"},
+	}
+	selected, err := newTestEvent("0", &out).ChooseRoleIndex(roles)
+	if err != nil || selected != 0 {
+		t.Errorf("%d, %v is unexpected", selected, err)
+	}
+	if _, err := newTestEvent("admin\n", &out).ChooseRoleIndex(roles); err == nil {
+		t.Error("not a number must be an error")
+	}
+	if _, err := newTestEvent("", &out).ChooseRoleIndex(roles); err == nil {
+		t.Error("closed input must be an error")
+	}
+}
+
+func TestEvent_InputMFAToken(t *testing.T) {
+	var out bytes.Buffer
+	token, err := newTestEvent("\n 123456 \r\n", &out).InputMFAToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token) != "123456" {
+		t.Errorf("%s is unexpected", token)
+	}
+	if strings.Count(out.String(), "Enter your MFA token: ") != 2 {
+		t.Errorf("%q is unexpected", out.String())
+	}
+}
+
+func TestEvent_WaitApproval(t *testing.T) {
+	var out bytes.Buffer
+	e := &Event{Out: &out, TTY: true}
+	e.WaitApproval(42 * time.Second)
+	e.WaitApproval(41 * time.Second)
+	e.ApprovalDone()
+	expected := "\r\x1b[K| waiting for OneLogin Protect approval, 42s left, Ctrl-C to cancel" +
+		"\r\x1b[K/ waiting for OneLogin Protect approval, 41s left, Ctrl-C to cancel" +
+		"\r\x1b[K"
+	if out.String() != expected {
+		t.Errorf("%q is not equal %q", out.String(), expected)
+	}
+}
+
+func TestEvent_Confirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{"\n", false},
+		{"n\n", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := newTestEvent(tt.input, &out).Confirm("Try again?")
+		if err != nil || got != tt.want {
+			t.Errorf("%q: %v, %v is unexpected", tt.input, got, err)
+		}
+		if out.String() != "Try again? [y/N]: " {
+			t.Errorf("%q is unexpected", out.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package login
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// DefaultMaxRetries is the number of retries of STS throttling and transient failures
+	DefaultMaxRetries = 4
+	// defaultRetryDelay is the first delay of the exponential backoff
+	defaultRetryDelay = time.Second
+	// maxRetryDelay caps the backoff
+	maxRetryDelay = 20 * time.Second
+)
+
+// retryableCodes are the error codes of STS which succeed later
+var retryableCodes = map[string]bool{
+	"Throttling":            true,
+	"ThrottlingException":   true,
+	"RequestLimitExceeded":  true,
+	"IDPCommunicationError": true,
+	"ServiceUnavailable":    true,
+	"InternalFailure":       true,
+	"RequestTimeout":        true,
+}
+
+// retryableSTS returns true for throttling, 5xx responses and the failure of STS to reach the SAML provider
+func retryableSTS(err error) bool {
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() >= 500 {
+		return true
+	}
+	aerr, ok := err.(awserr.Error)
+	return ok && retryableCodes[aerr.Code()]
+}
+
+// stsRetryDelay returns the exponential backoff of the attempt with jitter,
+// so profiles logging in concurrently do not retry at the same time
+func stsRetryDelay(attempt int, base time.Duration) time.Duration {
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// assumeRoleWithSAML calls AssumeRoleWithSAML, retrying throttling and transient failures up to MaxRetries
+// unless the context is done or RetryEvent gives up
+func (l *Login) assumeRoleWithSAML(ctx context.Context, input *sts.AssumeRoleWithSAMLInput, logic Event) (*sts.AssumeRoleWithSAMLOutput, error) {
+	for attempt := 0; ; attempt++ {
+		output, err := l.STS.AssumeRoleWithSAMLWithContext(ctx, input)
+		if err == nil || attempt >= l.MaxRetries || !retryableSTS(err) {
+			return output, err
+		}
+		var delay time.Duration
+		if l.retryDelay > 0 {
+			delay = stsRetryDelay(attempt, l.retryDelay)
+		}
+		if r, ok := logic.(RetryEvent); ok && !r.RetrySTS(attempt+1, delay, err) {
+			return output, err
+		}
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package login
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestStsRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 500 * time.Millisecond, time.Second},
+		{2, 2 * time.Second, 4 * time.Second},
+		{10, maxRetryDelay / 2, maxRetryDelay},
+		{70, maxRetryDelay / 2, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if delay := stsRetryDelay(tt.attempt, defaultRetryDelay); delay < tt.min || delay > tt.max {
+			t.Errorf("%v of attempt %d is not between %v and %v", delay, tt.attempt, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLogin_LoginRetrySTS(t *testing.T) {
+	throttling := awserr.New("Throttling", "Rate exceeded", nil)
+	unavailable := awserr.NewRequestFailure(awserr.New("Unknown", "Service Unavailable", nil), 503, "request-id")
+	denied := awserr.New("AccessDenied", "Not authorized to perform sts:AssumeRoleWithSAML", nil)
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "throttling", errs: []error{throttling, throttling}, wantCalls: 3},
+		{name: "5xx", errs: []error{unavailable}, wantCalls: 2},
+		{name: "too many retries", errs: []error{throttling, throttling, throttling}, wantCalls: 3, wantErr: true},
+		{name: "not retryable", errs: []error{denied}, wantCalls: 1, wantErr: true},
+		{name: "network error", errs: []error{errors.New("connection refused")}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stsMock := createSTS(t)
+			calls := 0
+			stsMock.InputVerifier = func(request *sts.AssumeRoleWithSAMLInput) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			}
+			l := &Login{
+				SAMLAssertion: createAssertion(t),
+				STS:           stsMock,
+				Params:        createDefaultParams(),
+				MaxRetries:    2,
+				retryDelay:    time.Millisecond,
+			}
+			_, err := l.Login(&EventMock{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("STS is called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package login
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+const roleAttributeName = "https://aws.amazon.com/SAML/Attributes/Role"
+
+// Role represents a role included in a SAML assertion
+type Role struct {
+	RoleArn      string
+	PrincipalArn string
+}
+
+type samlResponse struct {
+	Attributes []samlAttribute `xml:"Assertion>AttributeStatement>Attribute"`
+}
+
+type samlAttribute struct {
+	Name   string   `xml:"Name,attr"`
+	Values []string `xml:"AttributeValue"`
+}
+
+// ParseRoles returns roles in base64 encoded SAML assertion
+func ParseRoles(SAML secret.Bytes) ([]Role, error) {
+	data := make([]byte, base64.StdEncoding.DecodedLen(len(SAML)))
+	defer secret.Wipe(data)
+	n, err := base64.StdEncoding.Decode(data, SAML)
+	if err != nil {
+		return nil, err
+	}
+	data = data[:n]
+	var res samlResponse
+	if err := xml.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	roles := []Role{}
+	for _, attribute := range res.Attributes {
+		if attribute.Name != roleAttributeName {
+			continue
+		}
+		for _, value := range attribute.Values {
+			role := Role{}
+			for _, arn := range strings.Split(value, ",") {
+				arn = strings.TrimSpace(arn)
+				if strings.Contains(arn, ":saml-provider/") {
+					role.PrincipalArn = arn
+				} else {
+					role.RoleArn = arn
+				}
+			}
+			if role.RoleArn != "" && role.PrincipalArn != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles, nil
+}
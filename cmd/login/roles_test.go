@@ -0,0 +1,77 @@
+package login
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+func encodeSAML(roles ...string) secret.Bytes {
+	values := ""
+	for _, role := range roles {
+		values += "<saml:AttributeValue>" + role + "</saml:AttributeValue>"
+	}
+	xml := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">
+  <saml:Assertion>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="https://aws.amazon.com/SAML/Attributes/RoleSessionName">
+        <saml:AttributeValue>username</saml:AttributeValue>
+      </saml:Attribute>
+      <saml:Attribute Name="https://aws.amazon.com/SAML/Attributes/Role">` + values + `</saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`
+	return secret.Bytes(base64.StdEncoding.EncodeToString([]byte(xml)))
+}
+
+func TestParseRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		SAML    secret.Bytes
+		want    []Role
+		wantErr bool
+	}{
+		{
+			name: "single role",
+			SAML: encodeSAML("arn:aws:iam::123456789012:role/admin,arn:aws:iam::123456789012:saml-provider/onelogin"),
+			want: []Role{
+				{RoleArn: "arn:aws:iam::123456789012:role/admin", PrincipalArn: "arn:aws:iam::123456789012:saml-provider/onelogin"},
+			},
+		},
+		{
+			name: "provider first",
+			SAML: encodeSAML(
+				"arn:aws:iam::123456789012:saml-provider/onelogin,arn:aws:iam::123456789012:role/admin",
+				"arn:aws:iam::123456789012:role/readonly,arn:aws:iam::123456789012:saml-provider/onelogin",
+			),
+			want: []Role{
+				{RoleArn: "arn:aws:iam::123456789012:role/admin", PrincipalArn: "arn:aws:iam::123456789012:saml-provider/onelogin"},
+				{RoleArn: "arn:aws:iam::123456789012:role/readonly", PrincipalArn: "arn:aws:iam::123456789012:saml-provider/onelogin"},
+			},
+		},
+		{
+			name: "no role",
+			SAML: encodeSAML(),
+			want: []Role{},
+		},
+		{
+			name:    "invalid base64",
+			SAML:    secret.Bytes("Base64 encoded SAML Data"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoles(tt.SAML)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRoles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
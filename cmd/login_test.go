@@ -1,6 +1,26 @@
 package cmd
 
-import "testing"
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login/prompt"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
 
 func TestLoginCmdFetchConfigConfigVars(t *testing.T) {
 	_, app, err := fetchConfig("fixtures/fullfilled.toml", "other")
@@ -25,3 +45,225 @@ func TestLoginCmdFetchConfigNoProfile(t *testing.T) {
 		t.Error(err.Error())
 	}
 }
+
+func TestLoginConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	running, max := 0, 0
+	called := []string{}
+	login := func(s *loginSession, profile string) (*sts.Credentials, error) {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		called = append(called, profile)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		if profile == "broken" {
+			return nil, errors.Errorf("broken")
+		}
+		return &sts.Credentials{}, nil
+	}
+	profiles := []string{"a", "b", "broken", "c", "d"}
+	var buf bytes.Buffer
+	err := loginConcurrently(&buf, &loginSession{}, profiles, 2, login)
+	if err == nil || err.Error() != "failed to login to broken" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if max > 2 {
+		t.Errorf("%d workers are running at the same time", max)
+	}
+	sort.Strings(called)
+	if strings.Join(called, ",") != strings.Join(profiles, ",") {
+		t.Errorf("%v is not equal %v", called, profiles)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(profiles) || !strings.HasPrefix(lines[4], "[5/5] ") {
+		t.Errorf("'%v' is unexpected progress", buf.String())
+	}
+	if !strings.Contains(buf.String(), "failed broken: broken") {
+		t.Errorf("'%v' does not contain the failure", buf.String())
+	}
+}
+
+func TestLoginEventWaitApproval(t *testing.T) {
+	t.Run("tty", func(t *testing.T) {
+		var buf bytes.Buffer
+		event := &LoginEvent{approval: &prompt.Event{Out: &buf, TTY: true}}
+		event.WaitApproval(42 * time.Second)
+		event.WaitApproval(41 * time.Second)
+		event.ApprovalDone()
+		expected := "\r\x1b[K| waiting for OneLogin Protect approval, 42s left, Ctrl-C to cancel" +
+			"\r\x1b[K/ waiting for OneLogin Protect approval, 41s left, Ctrl-C to cancel" +
+			"\r\x1b[K"
+		if buf.String() != expected {
+			t.Errorf("%q is not equal %q", buf.String(), expected)
+		}
+	})
+	t.Run("not tty", func(t *testing.T) {
+		var buf bytes.Buffer
+		event := &LoginEvent{approval: &prompt.Event{Out: &buf}}
+		event.WaitApproval(42 * time.Second)
+		event.WaitApproval(41 * time.Second)
+		event.ApprovalDone()
+		expected := "waiting for OneLogin Protect approval, 42s left, Ctrl-C to cancel\n"
+		if buf.String() != expected {
+			t.Errorf("%q is not equal %q", buf.String(), expected)
+		}
+	})
+}
+
+func TestLoginCmdFetchConfigSandboxService(t *testing.T) {
+	service, app, err := fetchConfig("fixtures/sandbox.toml", "sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.ClientToken != "sandbox-client-token" || service.Subdomain != "sandbox-subdomain" || app.RoleArn != "sandbox-role-arn" {
+		t.Errorf("%#v and %#v are unexpected", service, app)
+	}
+	service, _, err = fetchConfig("fixtures/sandbox.toml", "default")
+	if err != nil || service.ClientToken != "client-token" {
+		t.Errorf("%#v, %v is unexpected", service, err)
+	}
+	if _, _, err := fetchConfig("fixtures/sandbox.toml", "missing"); err == nil || err.Error() != "missing service is not exists" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestLoginSessionPasswordForEachService(t *testing.T) {
+	s := &loginSession{reader: bufio.NewReader(strings.NewReader(""))}
+	password, err := s.appPassword("default", config.AppConfig{PasswordCommand: "echo production-secret"})
+	if err != nil || string(password) != "production-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	sandbox := config.AppConfig{Service: "sandbox", PasswordCommand: "echo sandbox-secret"}
+	password, err = s.appPassword("sandbox", sandbox)
+	if err != nil || string(password) != "sandbox-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	// the password of each service is kept in the session
+	password, err = s.appPassword("other", config.AppConfig{PasswordCommand: "echo other-secret"})
+	if err != nil || string(password) != "production-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+}
+
+func TestLoginSessionPasswordConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	calls := filepath.Join(dir, "calls")
+	s := newLoginSession(nil)
+	s.reader = bufio.NewReader(strings.NewReader(""))
+	app := config.AppConfig{PasswordCommand: fmt.Sprintf("echo call >> %s; sleep 0.1; echo secret", calls)}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if password, err := s.appPassword("default", app); err != nil || string(password) != "secret" {
+				t.Errorf("%q, %v is unexpected", string(password), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if data, _ := ioutil.ReadFile(calls); string(data) != "call\n" {
+		t.Errorf("password_command is run %d times", strings.Count(string(data), "call"))
+	}
+}
+
+func TestLoginSessionLock(t *testing.T) {
+	s := newLoginSession(nil)
+	unlock := s.lock("assertion/default/app")
+	done := make(chan bool)
+	go func() {
+		// another key is not blocked by the held one
+		s.lock("assertion/default/other")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("another key is blocked")
+	}
+	locked := make(chan bool)
+	go func() {
+		s.lock("assertion/default/app")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Error("the held key is locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
+
+func TestLoginSessionWipe(t *testing.T) {
+	password := secret.Bytes("password")
+	SAML := secret.Bytes("SAML")
+	s := &loginSession{passwords: map[string]secret.Bytes{config.DefaultService: password}, assertions: map[string]secret.Bytes{"default/app-id": SAML}}
+	copied, err := s.Password()
+	if err != nil || string(copied) != "password" {
+		t.Errorf("%q, %v is unexpected", string(copied), err)
+	}
+	s.Wipe()
+	if string(password) != "\x00\x00\x00\x00\x00\x00\x00\x00" || string(SAML) != "\x00\x00\x00\x00" {
+		t.Errorf("%q and %q are not wiped", []byte(password), []byte(SAML))
+	}
+	if string(copied) != "password" || len(s.passwords) != 0 || len(s.assertions) != 0 {
+		t.Errorf("%q, %d and %d are unexpected", string(copied), len(s.passwords), len(s.assertions))
+	}
+}
+
+func TestLoginSessionRegister(t *testing.T) {
+	s := newLoginSession(nil)
+	s.register("default", "first-session-token")
+	s.register("default", "second-session-token")
+	if actual := secret.Filter("first-session-token second-session-token"); actual != "first-session-token "+secret.Redacted {
+		t.Errorf("%s is unexpected", actual)
+	}
+	s.Wipe()
+	if actual := secret.Filter("second-session-token"); actual != "second-session-token" {
+		t.Errorf("%s is registered after wiped", actual)
+	}
+}
+
+func TestWarnRateLimit(t *testing.T) {
+	s := newLoginSession(nil)
+	out := &bytes.Buffer{}
+	s.warnRateLimit(out, nil)
+	s.warnRateLimit(out, &samlassertion.RateLimit{Limit: 5000, Remaining: 4000})
+	if out.Len() != 0 {
+		t.Errorf("%s is warned", out)
+	}
+	s.warnRateLimit(out, &samlassertion.RateLimit{Limit: 5000, Remaining: 100})
+	s.warnRateLimit(out, &samlassertion.RateLimit{Limit: 5000, Remaining: 99})
+	if out.String() != "Warning: only 100 of 5000 OneLogin API calls remain\n" {
+		t.Errorf("%q is unexpected", out)
+	}
+}
+
+func TestCheckLoginAll(t *testing.T) {
+	if err := checkLoginAll(true, "", false); err != nil {
+		t.Errorf("%v rejects --all", err)
+	}
+	if err := checkLoginAll(false, "dev", true); err != nil {
+		t.Errorf("%v rejects --group", err)
+	}
+	for _, tt := range []struct {
+		group          string
+		profileChanged bool
+	}{{"dev", false}, {"", true}} {
+		if err := checkLoginAll(true, tt.group, tt.profileChanged); err == nil || !strings.Contains(err.Error(), "cannot be given with --group or --aws-profile") {
+			t.Errorf("%v does not reject --all with %+v", err, tt)
+		}
+	}
+}
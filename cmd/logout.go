@@ -0,0 +1,91 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/aws/configuration"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Revoke OneLogin token and remove AWS credentials",
+	Long: `Logout is revoking the cached OneLogin access token, removing cached AWS credentials,
+and removing profiles configured with this command from ~/.aws/credentials.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		if err := logout(c, cacheDir, awsDir); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(logoutCmd)
+}
+
+func logout(c *config.Config, cache string, dir string) error {
+	store := onelogin.FileStore{Dir: oneloginCacheDir(cache)}
+	for _, s := range c.Service {
+		service := *s
+		applyEnvCredentials(&service)
+		// the tokens of every subdomain credentials are revoked too
+		pairs := []onelogin.ClientCredentials{{ClientToken: service.ClientToken, ClientSecret: service.ClientSecret}}
+		for _, pair := range service.Credentials {
+			pairs = append(pairs, onelogin.ClientCredentials{ClientToken: pair.ClientToken, ClientSecret: pair.ClientSecret})
+		}
+		endpoint, err := serviceEndpoint(service)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			if pair.ClientToken == "" {
+				continue
+			}
+			oneloginConfig := onelogin.NewConfig(
+				onelogin.WithEndpoint(endpoint),
+				onelogin.WithClientCredentials(pair.ClientToken, pair.ClientSecret),
+				onelogin.WithCredentialsStore(store),
+			)
+			if err := oneloginConfig.Revoke(); err != nil {
+				fmt.Fprintln(os.Stderr, i18n.T("Warning: failed to revoke OneLogin token:"), err)
+			}
+		}
+	}
+	for profile := range c.App {
+		if err := os.Remove(awsCacheFile(cache, profile)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := configuration.NewCredentials(dir, profile).Delete(); err != nil {
+			return err
+		}
+		if debug {
+			log.Printf("logout %s\n", profile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestLogout(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(cache)
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		path.Join(cache, "onelogin.client-token.cache"),
+		awsCacheFile(cache, "default"),
+		awsCacheFile(cache, "other"),
+	}
+	for _, file := range files {
+		if err := ioutil.WriteFile(file, []byte(""), 0600); err != nil {
+			t.Errorf("%#v", err)
+		}
+	}
+	credentials := `[default]
+aws_access_key_id = 12345678
+
+[other]
+aws_access_key_id = 87654321
+
+[static]
+aws_access_key_id = 11111111
+
+`
+	if err := ioutil.WriteFile(path.Join(dir, "credentials"), []byte(credentials), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if err := logout(c, cache, dir); err != nil {
+		t.Errorf("%#v", err)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s is not removed", file)
+		}
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, "credentials"))
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := `[static]
+aws_access_key_id = 11111111
+
+`
+	if string(data) != expected {
+		t.Errorf("'%v' is not equal '%v'", string(data), expected)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+const keyCtrlD = 4
+
+// readMasked reads a secret line from the terminal echoing "*" for each character
+func readMasked(reader *bufio.Reader) (secret.Bytes, error) {
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return nil, err
+		}
+		defer terminal.Restore(fd, state)
+		return readMaskedFrom(reader, os.Stdout)
+	}
+	if mintty() {
+		return readWithoutEcho(reader)
+	}
+	return readLine(reader)
+}
+
+// readLine reads a line from non-terminal stdin
+func readLine(reader *bufio.Reader) (secret.Bytes, error) {
+	line, err := reader.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		secret.Wipe(line)
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// mintty returns whether it runs on mintty of MSYS or Cygwin, which console is a pipe for Windows
+func mintty() bool {
+	return runtime.GOOS == "windows" && (os.Getenv("MSYSTEM") != "" || strings.HasPrefix(os.Getenv("TERM"), "xterm"))
+}
+
+// readWithoutEcho reads a line with disabling echo by stty of MSYS or Cygwin
+func readWithoutEcho(reader *bufio.Reader) (secret.Bytes, error) {
+	if err := stty("-echo"); err != nil {
+		return nil, err
+	}
+	defer stty("echo")
+	line, err := reader.ReadBytes('\n')
+	fmt.Println("")
+	if err != nil {
+		secret.Wipe(line)
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+func stty(args ...string) error {
+	c := exec.Command("stty", args...)
+	c.Stdin = os.Stdin
+	return c.Run()
+}
+
+// readMaskedFrom reads a secret into a buffer, which is wiped when it grows or the input is aborted
+func readMaskedFrom(in *bufio.Reader, out io.Writer) (secret.Bytes, error) {
+	input := make(secret.Bytes, 0, 256)
+	var buf [utf8.UTFMax]byte
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			input.Wipe()
+			return nil, err
+		}
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(out, "\r\n")
+			return input, nil
+		case keyCtrlC:
+			fmt.Fprint(out, "\r\n")
+			input.Wipe()
+			return nil, errors.New(i18n.T("input is interrupted"))
+		case keyCtrlD:
+			if len(input) == 0 {
+				fmt.Fprint(out, "\r\n")
+				return nil, io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if len(input) > 0 {
+				_, size := utf8.DecodeLastRune(input)
+				secret.Wipe(input[len(input)-size:])
+				input = input[:len(input)-size]
+				fmt.Fprint(out, "\b \b")
+			}
+		case keyEsc:
+			// ignore escape sequences like arrow keys
+			if next, _ := in.ReadByte(); next == '[' {
+				in.ReadByte()
+			}
+		default:
+			if unicode.IsPrint(r) {
+				n := utf8.EncodeRune(buf[:], r)
+				if len(input)+n > cap(input) {
+					grown := append(make(secret.Bytes, 0, 2*cap(input)), input...)
+					input.Wipe()
+					input = grown
+				}
+				input = append(input, buf[:n]...)
+				fmt.Fprint(out, "*")
+			}
+		}
+	}
+}
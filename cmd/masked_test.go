@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadMaskedFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		output   string
+	}{
+		{"enter", "pass\r", "pass", "****\r\n"},
+		{"newline", "pass\n", "pass", "****\r\n"},
+		{"backspace", "pasx\x7fs\r", "pass", "****\b \b*\r\n"},
+		{"multibyte", "パス\r", "パス", "**\r\n"},
+		{"escape sequence", "pa\x1b[Dss\r", "pass", "****\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := readMaskedFrom(bufio.NewReader(strings.NewReader(tt.input)), &out)
+			if err != nil {
+				t.Errorf("%#v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("%q is not equal %q", string(got), tt.expected)
+			}
+			if out.String() != tt.output {
+				t.Errorf("%q is not equal %q", out.String(), tt.output)
+			}
+		})
+	}
+	t.Run("interrupted", func(t *testing.T) {
+		if _, err := readMaskedFrom(bufio.NewReader(strings.NewReader("pa\x03")), &bytes.Buffer{}); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+	t.Run("EOF", func(t *testing.T) {
+		if _, err := readMaskedFrom(bufio.NewReader(strings.NewReader("\x04")), &bytes.Buffer{}); err != io.EOF {
+			t.Errorf("%#v is not EOF", err)
+		}
+	})
+}
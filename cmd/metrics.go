@@ -0,0 +1,72 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/metrics"
+)
+
+// metricsListen is the address of the metrics of the daemon and the server, empty disables them
+var metricsListen string
+
+// serveMetrics serves the metrics of the profiles on the address, and observes the requests to OneLogin API.
+// The address must be on a loopback interface like --listen, because the metrics tell the profiles and the failures.
+// The returned function stops serving them
+func serveMetrics(listen string, profiles []string) (*metrics.Registry, func() error, error) {
+	address, err := serverAddress(listen)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	registry := metrics.New()
+	registry.Expirations = cachedExpirations(profiles)
+	observeResponse = registry.Observe
+	srv := &http.Server{Handler: registry}
+	go srv.Serve(l)
+	info(i18n.T("metrics are served on http://%s%s\n"), l.Addr(), metrics.Path)
+	return registry, srv.Close, nil
+}
+
+// countRefreshes returns the login counting the refreshes of the profiles
+func countRefreshes(registry *metrics.Registry, login func(*loginSession, string) (*sts.Credentials, error)) func(*loginSession, string) (*sts.Credentials, error) {
+	return func(s *loginSession, profile string) (*sts.Credentials, error) {
+		creds, err := login(s, profile)
+		registry.Refreshed(profile, err)
+		return creds, err
+	}
+}
+
+// cachedExpirations returns the expirations of the cached credentials of the profiles, which are not logged in are omitted
+func cachedExpirations(profiles []string) func() map[string]time.Time {
+	return func() map[string]time.Time {
+		expirations := map[string]time.Time{}
+		for _, profile := range profiles {
+			if creds, err := loadCachedCredentials(cacheDir, profile); err == nil && creds != nil && creds.Expiration != nil {
+				expirations[profile] = *creds.Expiration
+			}
+		}
+		return expirations
+	}
+}
@@ -0,0 +1,216 @@
+// Package metrics collects the refreshes of the daemon and the requests to OneLogin API,
+// and serves them in the text exposition format of Prometheus
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+// Path is the path of the metrics
+const Path = "/metrics"
+
+// prefix of the metric names
+const prefix = "onelogin_aws_connector_"
+
+// latencyBuckets are the upper bounds in seconds of the histogram of OneLogin API latency
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// results of refreshes
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+type refreshKey struct {
+	profile string
+	result  string
+}
+
+type requestKey struct {
+	path string
+	code string
+}
+
+// histogram is the cumulative counts of the observations in latencyBuckets
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Registry holds the metrics, it is safe for concurrent use
+type Registry struct {
+	// Expirations returns the expirations of the credentials of the profiles, it is called at every scrape
+	Expirations func() map[string]time.Time
+	mu          sync.Mutex
+	refreshes   map[refreshKey]uint64
+	requests    map[requestKey]uint64
+	latencies   map[string]*histogram
+	rateLimit   *samlassertion.RateLimit
+	now         func() time.Time
+}
+
+// New returns the empty registry
+func New() *Registry {
+	return &Registry{
+		refreshes: map[refreshKey]uint64{},
+		requests:  map[requestKey]uint64{},
+		latencies: map[string]*histogram{},
+		now:       time.Now,
+	}
+}
+
+// Refreshed counts the refresh of the profile, which succeeded if err is nil
+func (r *Registry) Refreshed(profile string, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshes[refreshKey{profile, result}]++
+}
+
+// Observe counts the request to OneLogin API, its latency and the rate limit of the response.
+// The status code is "error" if no response is received
+func (r *Registry) Observe(m samlassertion.Metadata) {
+	code := strconv.Itoa(m.StatusCode)
+	if m.Err != nil || m.StatusCode == 0 {
+		code = "error"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests[requestKey{m.Path, code}]++
+	h, ok := r.latencies[m.Path]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		r.latencies[m.Path] = h
+	}
+	seconds := m.Latency.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+	if m.RateLimit != nil {
+		limit := *m.RateLimit
+		r.rateLimit = &limit
+	}
+}
+
+// ServeHTTP writes the metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != Path {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// Write writes the metrics in the text exposition format
+func (r *Registry) Write(w io.Writer) {
+	var expirations map[string]time.Time
+	if r.Expirations != nil {
+		expirations = r.Expirations()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	header(w, "refreshes_total", "counter", "Refreshes of the credentials of the profiles by the result.")
+	refreshes := make([]refreshKey, 0, len(r.refreshes))
+	for k := range r.refreshes {
+		refreshes = append(refreshes, k)
+	}
+	sort.Slice(refreshes, func(i, j int) bool {
+		if refreshes[i].profile != refreshes[j].profile {
+			return refreshes[i].profile < refreshes[j].profile
+		}
+		return refreshes[i].result < refreshes[j].result
+	})
+	for _, k := range refreshes {
+		sample(w, "refreshes_total", labels("profile", k.profile, "result", k.result), float64(r.refreshes[k]))
+	}
+
+	header(w, "credentials_ttl_seconds", "gauge", "Remaining validity of the cached credentials of the profiles, negative if they are expired.")
+	profiles := make([]string, 0, len(expirations))
+	for profile := range expirations {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	now := r.now()
+	for _, profile := range profiles {
+		sample(w, "credentials_ttl_seconds", labels("profile", profile), expirations[profile].Sub(now).Truncate(time.Second).Seconds())
+	}
+
+	header(w, "onelogin_api_requests_total", "counter", "Requests to OneLogin API by the path and the status code.")
+	requests := make([]requestKey, 0, len(r.requests))
+	for k := range r.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].path != requests[j].path {
+			return requests[i].path < requests[j].path
+		}
+		return requests[i].code < requests[j].code
+	})
+	for _, k := range requests {
+		sample(w, "onelogin_api_requests_total", labels("path", k.path, "code", k.code), float64(r.requests[k]))
+	}
+
+	header(w, "onelogin_api_request_duration_seconds", "histogram", "Latency of OneLogin API until the response headers are received.")
+	paths := make([]string, 0, len(r.latencies))
+	for path := range r.latencies {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		h := r.latencies[path]
+		for i, bound := range latencyBuckets {
+			sample(w, "onelogin_api_request_duration_seconds_bucket", labels("path", path, "le", strconv.FormatFloat(bound, 'g', -1, 64)), float64(h.counts[i]))
+		}
+		sample(w, "onelogin_api_request_duration_seconds_bucket", labels("path", path, "le", "+Inf"), float64(h.count))
+		sample(w, "onelogin_api_request_duration_seconds_sum", labels("path", path), h.sum)
+		sample(w, "onelogin_api_request_duration_seconds_count", labels("path", path), float64(h.count))
+	}
+
+	header(w, "onelogin_api_rate_limit_remaining", "gauge", "Remaining calls of OneLogin API in the rate limit window told by the last response.")
+	if r.rateLimit != nil {
+		sample(w, "onelogin_api_rate_limit_remaining", "", float64(r.rateLimit.Remaining))
+	}
+	header(w, "onelogin_api_rate_limit_limit", "gauge", "Calls of OneLogin API allowed in the rate limit window told by the last response.")
+	if r.rateLimit != nil {
+		sample(w, "onelogin_api_rate_limit_limit", "", float64(r.rateLimit.Limit))
+	}
+}
+
+func header(w io.Writer, name string, kind string, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n", prefix, name, help, prefix, name, kind)
+}
+
+func sample(w io.Writer, name string, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s%s %s\n", prefix, name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labelEscaper escapes the label values of the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats the pairs of the names and the values
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
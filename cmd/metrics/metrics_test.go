@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+func TestWrite(t *testing.T) {
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	r := New()
+	r.now = func() time.Time { return now }
+	r.Expirations = func() map[string]time.Time {
+		return map[string]time.Time{"prod": now.Add(time.Hour), "staging": now.Add(-time.Minute)}
+	}
+	r.Refreshed("prod", nil)
+	r.Refreshed("prod", nil)
+	r.Refreshed("staging", errors.New("failed"))
+	r.Observe(samlassertion.Metadata{Path: "/api/2/saml_assertion", StatusCode: 200, Latency: 80 * time.Millisecond, RateLimit: &samlassertion.RateLimit{Limit: 5000, Remaining: 4999}})
+	r.Observe(samlassertion.Metadata{Path: "/api/2/saml_assertion", StatusCode: 200, Latency: 2 * time.Second})
+	r.Observe(samlassertion.Metadata{Path: "/api/2/saml_assertion/verify_factor", Err: errors.New("timeout"), Latency: 20 * time.Second})
+	var b bytes.Buffer
+	r.Write(&b)
+	for _, expected := range []string{
+		"# TYPE onelogin_aws_connector_refreshes_total counter\n",
+		`onelogin_aws_connector_refreshes_total{profile="prod",result="success"} 2` + "\n",
+		`onelogin_aws_connector_refreshes_total{profile="staging",result="failure"} 1` + "\n",
+		`onelogin_aws_connector_credentials_ttl_seconds{profile="prod"} 3600` + "\n",
+		`onelogin_aws_connector_credentials_ttl_seconds{profile="staging"} -60` + "\n",
+		`onelogin_aws_connector_onelogin_api_requests_total{path="/api/2/saml_assertion",code="200"} 2` + "\n",
+		`onelogin_aws_connector_onelogin_api_requests_total{path="/api/2/saml_assertion/verify_factor",code="error"} 1` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_bucket{path="/api/2/saml_assertion",le="0.1"} 1` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_bucket{path="/api/2/saml_assertion",le="2.5"} 2` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_bucket{path="/api/2/saml_assertion/verify_factor",le="10"} 0` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_bucket{path="/api/2/saml_assertion/verify_factor",le="+Inf"} 1` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_sum{path="/api/2/saml_assertion"} 2.08` + "\n",
+		`onelogin_aws_connector_onelogin_api_request_duration_seconds_count{path="/api/2/saml_assertion"} 2` + "\n",
+		"onelogin_aws_connector_onelogin_api_rate_limit_remaining 4999\n",
+		"onelogin_aws_connector_onelogin_api_rate_limit_limit 5000\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("%s does not contain %q", b.String(), expected)
+		}
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var b bytes.Buffer
+	New().Write(&b)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if !strings.HasPrefix(line, "# ") {
+			t.Errorf("%s is written without metrics", line)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	if actual := labels("profile", "a\"b\\c\nd"); actual != `{profile="a\"b\\c\nd"}` {
+		t.Errorf("%s is not escaped", actual)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := New()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", Path, nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("%d %v is unexpected", w.Code, w.Header())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/other", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("%d is not 404", w.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/metrics"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+func TestCountRefreshes(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	writeCachedExpiration(t, cache, "prod", time.Now().Add(time.Hour))
+	registry := metrics.New()
+	registry.Expirations = cachedExpirations([]string{"prod", "staging"})
+	login := countRefreshes(registry, func(s *loginSession, profile string) (*sts.Credentials, error) {
+		if profile == "staging" {
+			return nil, errors.New("failed")
+		}
+		return &sts.Credentials{}, nil
+	})
+	login(nil, "prod")
+	login(nil, "staging")
+	var b bytes.Buffer
+	registry.Write(&b)
+	for _, expected := range []string{
+		`onelogin_aws_connector_refreshes_total{profile="prod",result="success"} 1`,
+		`onelogin_aws_connector_refreshes_total{profile="staging",result="failure"} 1`,
+		`onelogin_aws_connector_credentials_ttl_seconds{profile="prod"} 3`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("%s does not contain %q", b.String(), expected)
+		}
+	}
+	if strings.Contains(b.String(), `credentials_ttl_seconds{profile="staging"}`) {
+		t.Errorf("%s has the TTL of the profile not logged in", b.String())
+	}
+}
+
+func TestServeMetricsLoopback(t *testing.T) {
+	defer func(observe func(samlassertion.Metadata)) { observeResponse = observe }(observeResponse)
+	if _, _, err := serveMetrics("0.0.0.0:0", nil); err == nil || !strings.Contains(err.Error(), "is not a loopback address") {
+		t.Errorf("%v does not reject the address of all the interfaces", err)
+	}
+	_, stop, err := serveMetrics("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+}
@@ -0,0 +1,40 @@
+// Package notify shows desktop notifications with the commands of each OS,
+// notify-send on Linux, osascript on macOS and PowerShell on Windows
+package notify
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Title is the title of the notifications
+const Title = "OneLogin AWS Connector"
+
+// Command runs an external command, it is replaced in tests
+var Command = func(name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Send shows the notification of the message
+func Send(message string) error {
+	return errors.Wrap(send(Title, message), "failed to show the desktop notification")
+}
+
+// appleScriptQuote returns the string literal of AppleScript
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powerShellQuote returns the verbatim string literal of PowerShell
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,6 @@
+package notify
+
+// send shows the notification of Notification Center with osascript
+func send(title, message string) error {
+	return Command("osascript", "-e", "display notification "+appleScriptQuote(message)+" with title "+appleScriptQuote(title))
+}
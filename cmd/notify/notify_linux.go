@@ -0,0 +1,6 @@
+package notify
+
+// send shows the notification with notify-send command of libnotify
+func send(title, message string) error {
+	return Command("notify-send", "--app-name", "onelogin-aws-connector", "--urgency", "critical", title, message)
+}
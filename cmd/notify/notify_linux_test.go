@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestNotifySend(t *testing.T) {
+	var calls [][]string
+	defer func(c func(string, ...string) error) { Command = c }(Command)
+	Command = func(name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+	if err := Send("prod failed to refresh"); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"notify-send", "--app-name", "onelogin-aws-connector", "--urgency", "critical", "OneLogin AWS Connector", "prod failed to refresh"}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("%v is not equal %v", calls, expected)
+	}
+	Command = func(name string, args ...string) error {
+		return errors.New("notify-send: no notification daemon")
+	}
+	if err := Send("prod failed to refresh"); err == nil || !strings.HasPrefix(err.Error(), "failed to show the desktop notification") {
+		t.Errorf("%v is unexpected", err)
+	}
+}
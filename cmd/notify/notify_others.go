@@ -0,0 +1,14 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package notify
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func send(title, message string) error {
+	return errors.Errorf("desktop notification is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,12 @@
+package notify
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	if actual := appleScriptQuote(`say "hi" \ bye`); actual != `"say \"hi\" \\ bye"` {
+		t.Errorf("%s is not the AppleScript string", actual)
+	}
+	if actual := powerShellQuote("it's prod"); actual != "'it''s prod'" {
+		t.Errorf("%s is not the PowerShell string", actual)
+	}
+}
@@ -0,0 +1,14 @@
+package notify
+
+// send shows the balloon tip of the notification area with PowerShell, which needs no module.
+// The icon is kept until the balloon tip is closed
+func send(title, message string) error {
+	script := "Add-Type -AssemblyName System.Windows.Forms; " +
+		"$n = New-Object System.Windows.Forms.NotifyIcon; " +
+		"$n.Icon = [System.Drawing.SystemIcons]::Warning; " +
+		"$n.Visible = $true; " +
+		"$n.ShowBalloonTip(10000, " + powerShellQuote(title) + ", " + powerShellQuote(message) + ", 'Warning'); " +
+		"Start-Sleep -Seconds 10; " +
+		"$n.Dispose()"
+	return Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+}
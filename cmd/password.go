@@ -0,0 +1,99 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+var passwordStdin bool
+var passwordFile string
+
+// passwordFromSource returns the password from --password-file, --password-stdin,
+// and the password environment variable in this order, or nil if none is given
+func passwordFromSource(reader *bufio.Reader) (secret.Bytes, error) {
+	if passwordFile != "" {
+		return readPasswordFile(passwordFile)
+	}
+	if passwordStdin {
+		password, err := readLine(reader)
+		if err != nil {
+			return nil, errors.Wrap(err, i18n.T("failed to read the password from stdin"))
+		}
+		return password, nil
+	}
+	if password := config.EnvPassword(); password != "" {
+		return secret.Bytes(password), nil
+	}
+	return nil, nil
+}
+
+// readPasswordFile reads the first line of the file, which must not be accessible by others
+func readPasswordFile(file string) (secret.Bytes, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0077 != 0 {
+		return nil, errors.Errorf(i18n.T("permissions %04o of %s are too open, please run `chmod 600 %s`"), perm, file, file)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return firstLine(data), nil
+}
+
+// runPasswordCommand runs the command with the shell and returns the first line of its output,
+// stdin and stderr are passed through for secret managers asking to unlock
+func runPasswordCommand(command string) (secret.Bytes, error) {
+	c := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	}
+	c.Stdin = os.Stdin
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	if err != nil {
+		secret.Wipe(out)
+		return nil, errors.Wrapf(err, i18n.T("password_command `%s` failed"), command)
+	}
+	password := firstLine(out)
+	if len(password) == 0 {
+		return nil, errors.Errorf(i18n.T("password_command `%s` printed no password"), command)
+	}
+	return password, nil
+}
+
+// firstLine copies the first line of the data and wipes the data
+func firstLine(data []byte) secret.Bytes {
+	defer secret.Wipe(data)
+	line := data
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return secret.Bytes(bytes.TrimRight(line, "\r")).Copy()
+}
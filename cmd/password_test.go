@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestPasswordFromSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := dir + "/password"
+	if err := ioutil.WriteFile(file, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	open := dir + "/open"
+	if err := ioutil.WriteFile(open, []byte("open-secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(config.PasswordEnvAlias, "env-secret")
+	defer os.Unsetenv(config.PasswordEnvAlias)
+	defer func() {
+		passwordFile = ""
+		passwordStdin = false
+	}()
+	tests := []struct {
+		name     string
+		file     string
+		stdin    bool
+		awsEnv   string
+		expected string
+	}{
+		{"file", file, true, "aws-env-secret", "file-secret"},
+		{"stdin", "", true, "aws-env-secret", "stdin-secret"},
+		{"ONELOGIN_AWS_PASSWORD", "", false, "aws-env-secret", "aws-env-secret"},
+		{"ONELOGIN_PASSWORD", "", false, "", "env-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passwordFile = tt.file
+			passwordStdin = tt.stdin
+			os.Setenv(config.PasswordEnv, tt.awsEnv)
+			defer os.Unsetenv(config.PasswordEnv)
+			password, err := passwordFromSource(bufio.NewReader(strings.NewReader("stdin-secret\n")))
+			if err != nil || string(password) != tt.expected {
+				t.Errorf("%q, %v is unexpected", string(password), err)
+			}
+		})
+	}
+	t.Run("too open file", func(t *testing.T) {
+		passwordFile = open
+		_, err := passwordFromSource(bufio.NewReader(strings.NewReader("")))
+		expected := "permissions 0644 of " + open + " are too open, please run `chmod 600 " + open + "`"
+		if err == nil || err.Error() != expected {
+			t.Errorf("%v is not equal %s", err, expected)
+		}
+	})
+}
+
+func TestRunPasswordCommand(t *testing.T) {
+	password, err := runPasswordCommand("printf 'command-secret\\nignored\\n'")
+	if err != nil || string(password) != "command-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	if _, err := runPasswordCommand("true"); err == nil || err.Error() != "password_command `true` printed no password" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if _, err := runPasswordCommand("exit 1"); err == nil {
+		t.Error("failed command needs to return error")
+	}
+}
+
+func TestCommandPassword(t *testing.T) {
+	s := &loginSession{reader: bufio.NewReader(strings.NewReader(""))}
+	password, err := s.appPassword("default", config.AppConfig{PasswordCommand: "echo command-secret"})
+	if err != nil || string(password) != "command-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	os.Setenv(config.PasswordEnv, "env-secret")
+	defer os.Unsetenv(config.PasswordEnv)
+	s = &loginSession{reader: bufio.NewReader(strings.NewReader(""))}
+	password, err = s.appPassword("default", config.AppConfig{PasswordCommand: "echo command-secret"})
+	if err != nil || string(password) != "env-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+}
+
+func TestConfirmPassword(t *testing.T) {
+	defer func(v bool) { interactiveInput = v }(interactiveInput)
+	interactiveInput = false
+	password, err := confirmPassword(bufio.NewReader(strings.NewReader("secret\nsecret\n")))
+	if err != nil || string(password) != "secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+	if _, err := confirmPassword(bufio.NewReader(strings.NewReader("secret\nsecrets\n"))); err == nil || err.Error() != "passwords do not match" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// secretFiles returns the directories and files holding the client secret, tokens and credentials
+func secretFiles() []string {
+	dir := path.Dir(cacheDir)
+	files := []string{dir, cacheDir, configFile, path.Join(awsDir, "credentials")}
+	if caches, err := ioutil.ReadDir(cacheDir); err == nil {
+		for _, cache := range caches {
+			files = append(files, path.Join(cacheDir, cache.Name()))
+		}
+	}
+	return files
+}
+
+// warnExposedFiles warns the files accessible by the group or others, which are created by older versions or other tools
+func warnExposedFiles() {
+	for _, file := range secretFiles() {
+		perm, err := secret.Exposed(file)
+		if err != nil || perm == 0 {
+			continue
+		}
+		mode := secret.FileMode
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			mode = secret.DirMode
+		}
+		fmt.Fprint(os.Stderr, i18n.Sprintf("Warning: permissions %04o of %s are too open, please run `chmod %o %s`\n", perm, file, mode, file))
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestSecretFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "permissions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	originalCache, originalConfig, originalAWS := cacheDir, configFile, awsDir
+	defer func() { cacheDir, configFile, awsDir = originalCache, originalConfig, originalAWS }()
+	cacheDir = path.Join(dir, "cache")
+	configFile = path.Join(dir, "config.toml")
+	awsDir = path.Join(dir, ".aws")
+	if err := os.Mkdir(cacheDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(cacheDir, "default"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{dir, cacheDir, configFile, path.Join(awsDir, "credentials"), path.Join(cacheDir, "default")}
+	if actual := secretFiles(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("%v is not %v", actual, expected)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+// fuzzyMatch reports whether all characters of pattern appear in s in order
+func fuzzyMatch(pattern string, s string) bool {
+	target := []rune(strings.ToLower(s))
+	i := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for i < len(target) && target[i] != r {
+			i++
+		}
+		if i >= len(target) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// fuzzyFilter returns indexes of items matched with pattern
+func fuzzyFilter(pattern string, items []string) []int {
+	indexes := []int{}
+	for i, item := range items {
+		if fuzzyMatch(pattern, item) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// searchIndex asks an item by number or by searching with typed text
+func (m *LoginEvent) searchIndex(prompt string, items []string) (int, error) {
+	candidates := fuzzyFilter("", items)
+	for {
+		fmt.Println("--------")
+		for _, i := range candidates {
+			fmt.Printf("%d : %s\n", i, items[i])
+		}
+		fmt.Println("--------")
+		fmt.Print(prompt)
+		tmp, err := m.reader.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		tmp = strings.TrimSpace(tmp)
+		if tmp == "" {
+			continue
+		}
+		if selected, err := strconv.Atoi(tmp); err == nil {
+			for _, i := range candidates {
+				if i == selected {
+					return selected, nil
+				}
+			}
+			continue
+		}
+		filtered := fuzzyFilter(tmp, items)
+		switch len(filtered) {
+		case 0:
+			fmt.Print(i18n.Sprintf("No match for %s\n", tmp))
+			candidates = fuzzyFilter("", items)
+		case 1:
+			return filtered[0], nil
+		default:
+			candidates = filtered
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "", s: "prod-admin", want: true},
+		{pattern: "pa", s: "prod-admin", want: true},
+		{pattern: "PRD", s: "prod-admin", want: true},
+		{pattern: "prod admin", s: "prod-admin", want: true},
+		{pattern: "ap", s: "prod-admin", want: false},
+		{pattern: "prod-admins", s: "prod-admin", want: false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("fuzzyMatch(%s, %s) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	items := []string{"dev-admin", "prod-admin", "prod-readonly"}
+	if got := fuzzyFilter("prod", items); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("fuzzyFilter() = %v, want %v", got, []int{1, 2})
+	}
+	if got := fuzzyFilter("dv", items); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("fuzzyFilter() = %v, want %v", got, []int{0})
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	items := []string{"dev-admin", "prod-admin", "prod-readonly"}
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "number", input: "2\n", want: 2},
+		{name: "unique match", input: "dev\n", want: 0},
+		{name: "narrow down", input: "prod\nnothing\nprod\n1\n", want: 1},
+		{name: "number out of candidates", input: "prod\n0\nreadonly\n", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewLoginEvent(bufio.NewReader(strings.NewReader(tt.input)), nil)
+			got, err := e.searchIndex("", items)
+			if err != nil {
+				t.Errorf("%#v", err)
+			}
+			if got != tt.want {
+				t.Errorf("searchIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
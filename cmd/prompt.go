@@ -0,0 +1,87 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+// mfaTokenEnv is the environment variable used instead of the MFA token prompt,
+// the password one is config.PasswordEnv
+const mfaTokenEnv = "ONELOGIN_MFA_TOKEN"
+
+// exit codes when a prompt is required with --no-prompt
+const (
+	exitPasswordRequired = 3
+	exitDeviceRequired   = 4
+	exitMFATokenRequired = 5
+	exitRoleRequired     = 6
+	exitProfileRequired  = 7
+)
+
+var noPrompt bool
+
+// daemonMode fails instead of prompting like --no-prompt, the daemon has nobody to answer prompts
+var daemonMode bool
+
+// interactiveInput represents whether prompts are answered on a terminal
+var interactiveInput = terminal.IsTerminal(int(os.Stdin.Fd())) || mintty()
+
+// promptRequiredError is returned instead of prompting with --no-prompt, or when non-terminal stdin is closed
+type promptRequiredError struct {
+	name       string
+	code       int
+	noTerminal bool
+	daemon     bool
+}
+
+func (e *promptRequiredError) Error() string {
+	if e.daemon {
+		return i18n.Sprintf("%s is required, but the daemon cannot prompt", i18n.T(e.name))
+	}
+	if e.noTerminal {
+		return i18n.Sprintf("%s is required, but stdin is not a terminal and has no more input", i18n.T(e.name))
+	}
+	return i18n.Sprintf("%s is required, but prompt is disabled by --no-prompt", i18n.T(e.name))
+}
+
+// ExitCode returns the exit code telling which prompt is required
+func (e *promptRequiredError) ExitCode() int {
+	return e.code
+}
+
+// requirePrompt returns promptRequiredError if prompting is disabled by --no-prompt or the daemon
+func requirePrompt(name string, code int) error {
+	if daemonMode {
+		return &promptRequiredError{name: name, code: code, daemon: true}
+	}
+	if noPrompt {
+		return &promptRequiredError{name: name, code: code}
+	}
+	return nil
+}
+
+// inputClosed converts EOF of non-terminal stdin to promptRequiredError instead of waiting on an invisible prompt
+func inputClosed(err error, name string, code int) error {
+	if err == io.EOF && !interactiveInput {
+		return &promptRequiredError{name: name, code: code, noTerminal: true}
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+func TestNoPrompt(t *testing.T) {
+	noPrompt = true
+	defer func() { noPrompt = false }()
+	event := NewLoginEvent(bufio.NewReader(strings.NewReader("0\n")), &config.Config{})
+	tests := []struct {
+		name string
+		call func() error
+		code int
+	}{
+		{"password", func() error { _, err := (&loginSession{}).Password(); return err }, exitPasswordRequired},
+		{"device", func() error {
+			_, err := event.ChooseDeviceIndex([]samlassertion.GenerateResponseFactorDevice{{}, {}})
+			return err
+		}, exitDeviceRequired},
+		{"token", func() error { _, err := event.InputMFAToken(); return err }, exitMFATokenRequired},
+		{"role", func() error { _, err := event.ChooseRoleIndex([]login.Role{{}, {}}); return err }, exitRoleRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			coder, ok := errors.Cause(err).(interface{ ExitCode() int })
+			if !ok {
+				t.Fatalf("%#v is not promptRequiredError", err)
+			}
+			if coder.ExitCode() != tt.code {
+				t.Errorf("%d is not equal %d", coder.ExitCode(), tt.code)
+			}
+		})
+	}
+}
+
+func TestNonTerminalInput(t *testing.T) {
+	defer func(v bool) { interactiveInput = v }(interactiveInput)
+	interactiveInput = false
+	t.Run("password from stdin", func(t *testing.T) {
+		s := &loginSession{reader: bufio.NewReader(strings.NewReader("secret\n"))}
+		password, err := s.Password()
+		if err != nil || string(password) != "secret" {
+			t.Errorf("%q, %v is unexpected", string(password), err)
+		}
+	})
+	t.Run("password from env", func(t *testing.T) {
+		os.Setenv(config.PasswordEnvAlias, "env-secret")
+		defer os.Unsetenv(config.PasswordEnvAlias)
+		s := &loginSession{reader: bufio.NewReader(strings.NewReader(""))}
+		password, err := s.Password()
+		if err != nil || string(password) != "env-secret" {
+			t.Errorf("%q, %v is unexpected", string(password), err)
+		}
+	})
+	t.Run("MFA token from env", func(t *testing.T) {
+		os.Setenv(mfaTokenEnv, "123456")
+		defer os.Unsetenv(mfaTokenEnv)
+		token, err := NewLoginEvent(bufio.NewReader(strings.NewReader("")), &config.Config{}).InputMFAToken()
+		if err != nil || string(token) != "123456" {
+			t.Errorf("%q, %v is unexpected", string(token), err)
+		}
+	})
+	t.Run("closed stdin", func(t *testing.T) {
+		event := NewLoginEvent(bufio.NewReader(strings.NewReader("")), &config.Config{})
+		tests := []struct {
+			name string
+			call func() error
+			code int
+		}{
+			{"password", func() error {
+				_, err := (&loginSession{reader: bufio.NewReader(strings.NewReader(""))}).Password()
+				return err
+			}, exitPasswordRequired},
+			{"token", func() error { _, err := event.InputMFAToken(); return err }, exitMFATokenRequired},
+			{"role", func() error { _, err := event.ChooseRoleIndex([]login.Role{{}, {}}); return err }, exitRoleRequired},
+		}
+		for _, tt := range tests {
+			err := tt.call()
+			e, ok := err.(*promptRequiredError)
+			if !ok || e.ExitCode() != tt.code || !e.noTerminal {
+				t.Errorf("%s: %#v is unexpected", tt.name, err)
+			}
+		}
+	})
+}
+
+func TestAppPasswordPrompt(t *testing.T) {
+	defer func(v bool) { interactiveInput = v }(interactiveInput)
+	interactiveInput = false
+	os.Setenv(config.PasswordEnvAlias, "env-secret")
+	defer os.Unsetenv(config.PasswordEnvAlias)
+	s := &loginSession{reader: bufio.NewReader(strings.NewReader("first\nsecond\n"))}
+	app := config.AppConfig{PasswordPrompt: true}
+	for _, expected := range []string{"first", "second"} {
+		password, err := s.appPassword("default", app)
+		if err != nil || string(password) != expected {
+			t.Errorf("%q, %v is unexpected", string(password), err)
+		}
+	}
+	if len(s.passwords) > 0 {
+		t.Errorf("%q is kept in the session", s.passwords)
+	}
+	password, err := s.appPassword("default", config.AppConfig{})
+	if err != nil || string(password) != "env-secret" {
+		t.Errorf("%q, %v is unexpected", string(password), err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/keychain"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// proxyKeychain reads the proxy password saved by set-password --proxy, it is replaced in tests
+var proxyKeychain = keychain.Get
+
+// proxyKeychainAccount returns the keychain account of the proxy password of the user
+func proxyKeychainAccount(user string) string {
+	return "proxy:" + user
+}
+
+// applyProxyCredentials resolves the proxy user and password of the service,
+// the password is read from ONELOGIN_PROXY_PASSWORD or the keychain, and the user info in the proxy URL is used if there is no user
+func applyProxyCredentials(service *config.ServiceConfig) error {
+	if u, err := url.Parse(service.Proxy); err == nil && u.User != nil {
+		// the password written in the proxy URL is never logged
+		if password, ok := u.User.Password(); ok {
+			secret.Register(password)
+		}
+	}
+	if service.ProxyUser == "" {
+		service.ProxyUser = os.Getenv(config.ProxyUserEnv)
+	}
+	if service.ProxyUser == "" || service.ProxyPassword != "" {
+		return nil
+	}
+	if password := os.Getenv(config.ProxyPasswordEnv); password != "" {
+		service.ProxyPassword = password
+		secret.Register(password)
+		return nil
+	}
+	password, err := proxyKeychain(proxyKeychainAccount(service.ProxyUser))
+	if err == keychain.ErrNotFound {
+		if debug {
+			log.Printf("proxy password of %s is not found in the keychain\n", service.ProxyUser)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	service.ProxyPassword = password
+	secret.Register(password)
+	return nil
+}
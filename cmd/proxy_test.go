@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/keychain"
+)
+
+func TestApplyProxyCredentials(t *testing.T) {
+	original := proxyKeychain
+	defer func() { proxyKeychain = original }()
+	accounts := []string{}
+	proxyKeychain = func(account string) (string, error) {
+		accounts = append(accounts, account)
+		if account == "proxy:saved" {
+			return "keychain-password", nil
+		}
+		return "", keychain.ErrNotFound
+	}
+
+	service := &config.ServiceConfig{Proxy: "http://proxy.example.com:8080"}
+	if err := applyProxyCredentials(service); err != nil || service.ProxyUser != "" || len(accounts) != 0 {
+		t.Errorf("%#v, %v and %v are unexpected", service, err, accounts)
+	}
+
+	service = &config.ServiceConfig{ProxyUser: "saved"}
+	if err := applyProxyCredentials(service); err != nil || service.ProxyPassword != "keychain-password" {
+		t.Errorf("%#v, %v is unexpected", service, err)
+	}
+	service = &config.ServiceConfig{ProxyUser: "unsaved"}
+	if err := applyProxyCredentials(service); err != nil || service.ProxyPassword != "" {
+		t.Errorf("%#v, %v is unexpected", service, err)
+	}
+
+	os.Setenv(config.ProxyUserEnv, "env-user")
+	os.Setenv(config.ProxyPasswordEnv, "env-password")
+	defer os.Unsetenv(config.ProxyUserEnv)
+	defer os.Unsetenv(config.ProxyPasswordEnv)
+	accounts = []string{}
+	service = &config.ServiceConfig{}
+	if err := applyProxyCredentials(service); err != nil || service.ProxyUser != "env-user" || service.ProxyPassword != "env-password" || len(accounts) != 0 {
+		t.Errorf("%#v, %v and %v are unexpected", service, err, accounts)
+	}
+	if options := serviceTransport(*service); options.ProxyUser != "env-user" || options.ProxyPassword != "env-password" {
+		t.Errorf("%#v is unexpected", options)
+	}
+}
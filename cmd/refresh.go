@@ -0,0 +1,74 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+var refreshWithin time.Duration
+
+// refreshCmd represents the refresh command
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Login to AWS profiles which credentials are expiring",
+	Long: `Refresh is logging in to only AWS profiles which credentials expire within the duration.
+The password and the MFA are asked once for each OneLogin app.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		profiles, err := expiringProfiles(c, cacheDir, time.Now().Add(refreshWithin))
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		defer s.Wipe()
+		s.refresh = true
+		for _, profile := range profiles {
+			if _, err := loginProfile(s, profile); err != nil {
+				errorExit(err)
+			}
+			info(i18n.T("Refreshed %s\n"), profile)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().DurationVarP(&refreshWithin, "within", "", 15*time.Minute, "Refresh credentials which expire within the duration")
+}
+
+// expiringProfiles returns logged in profiles which credentials expire before the deadline
+func expiringProfiles(c *config.Config, cache string, deadline time.Time) ([]string, error) {
+	profiles, _ := profileItems(c)
+	expiring := []string{}
+	for _, profile := range profiles {
+		creds, err := loadCachedCredentials(cache, profile)
+		if err != nil {
+			return nil, err
+		}
+		if creds != nil && creds.Expiration != nil && creds.Expiration.Before(deadline) {
+			expiring = append(expiring, profile)
+		}
+	}
+	return expiring, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestExpiringProfiles(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(cache)
+	now := time.Now()
+	for profile, expiration := range map[string]time.Time{
+		"default": now.Add(10 * time.Minute),
+		"other":   now.Add(time.Hour),
+	} {
+		content := fmt.Sprintf("Expiration = %s\n", expiration.UTC().Format(time.RFC3339))
+		if err := ioutil.WriteFile(awsCacheFile(cache, profile), []byte(content), 0600); err != nil {
+			t.Errorf("%#v", err)
+		}
+	}
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	c.App["new"] = &config.AppConfig{}
+	tests := []struct {
+		name   string
+		within time.Duration
+		want   []string
+	}{
+		{name: "none", within: time.Minute, want: []string{}},
+		{name: "expiring", within: 15 * time.Minute, want: []string{"default"}},
+		{name: "all", within: 2 * time.Hour, want: []string{"default", "other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expiringProfiles(c, cache, now.Add(tt.within))
+			if err != nil {
+				t.Errorf("%#v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expiringProfiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Name is the binary name of release assets
+	Name = "onelogin-aws-connector"
+	// ChecksumsAsset is the asset name listing sha256 checksums of release assets
+	ChecksumsAsset = "checksums.txt"
+)
+
+// LatestURL is GitHub API URL for the latest release
+var LatestURL = "https://api.github.com/repos/lifull-dev/onelogin-aws-connector/releases/latest"
+
+// HTTPClient is used to fetch releases and assets
+var HTTPClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// Release represents a GitHub release
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset represents a file attached to a GitHub release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Latest fetches the latest release
+func Latest() (*Release, error) {
+	data, err := get(LatestURL)
+	if err != nil {
+		return nil, err
+	}
+	var r Release
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Version returns the release version without "v" prefix
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Asset returns the asset which has the name
+func (r *Release) Asset(name string) (*Asset, error) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], nil
+		}
+	}
+	return nil, errors.Errorf("%s is not found in release %s", name, r.TagName)
+}
+
+// Download fetches the binary for the platform and verifies its checksum
+func (r *Release) Download(goos, goarch string) ([]byte, error) {
+	name := AssetName(goos, goarch)
+	asset, err := r.Asset(name)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := r.Asset(ChecksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := get(checksums.URL)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := Checksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := get(asset.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(data, sum); err != nil {
+		return nil, errors.Wrapf(err, "%s is broken", name)
+	}
+	return data, nil
+}
+
+// AssetName returns the asset name of the binary for the platform
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("%s_%s-%s", Name, goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Checksum finds the sha256 checksum of the name in sha256sum formatted list
+func Checksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Errorf("checksum of %s is not found", name)
+}
+
+// Verify checks the data matches the sha256 checksum
+func Verify(data []byte, sum string) error {
+	actual := sha256.Sum256(data)
+	if hex.EncodeToString(actual[:]) != strings.ToLower(sum) {
+		return errors.Errorf("checksum mismatch")
+	}
+	return nil
+}
+
+// Newer returns true if latest is a newer version than current
+func Newer(current, latest string) bool {
+	c := versionNumbers(current)
+	l := versionNumbers(latest)
+	for i := 0; i < len(c) || i < len(l); i++ {
+		var a, b int
+		if i < len(c) {
+			a = c[i]
+		}
+		if i < len(l) {
+			b = l[i]
+		}
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+func versionNumbers(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	numbers := []int{}
+	for _, s := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+// Replace atomically replaces the file with the data keeping its permission
+func Replace(file string, data []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(file)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		return os.Rename(tmp.Name(), file)
+	}
+	// a running executable cannot be overwritten on Windows, but can be renamed
+	old := file + ".old"
+	os.Remove(old)
+	if err := os.Rename(file, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Rename(old, file)
+		return err
+	}
+	return nil
+}
+
+func get(url string) ([]byte, error) {
+	res, err := HTTPClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("[%d] failed to fetch %s", res.StatusCode, url)
+	}
+	return ioutil.ReadAll(res.Body)
+}
@@ -0,0 +1,126 @@
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current  string
+		latest   string
+		expected bool
+	}{
+		{"0.1.6", "0.1.7", true},
+		{"0.1.6", "v0.2.0", true},
+		{"0.1.6", "0.1.6", false},
+		{"0.1.10", "0.1.9", false},
+		{"Unknown", "0.1.0", true},
+		{"1.0", "1.0.1", true},
+		{"1.0.0-rc1", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current+"-"+tt.latest, func(t *testing.T) {
+			if got := Newer(tt.current, tt.latest); got != tt.expected {
+				t.Errorf("Newer(%v, %v) = %v, want %v", tt.current, tt.latest, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	if got := AssetName("linux", "amd64"); got != "onelogin-aws-connector_linux-amd64" {
+		t.Errorf("%v is unexpected", got)
+	}
+	if got := AssetName("windows", "amd64"); got != "onelogin-aws-connector_windows-amd64.exe" {
+		t.Errorf("%v is unexpected", got)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	binary := "binary"
+	sum := sha256.Sum256([]byte(binary))
+	checksums := fmt.Sprintf("%s  onelogin-aws-connector_linux-amd64\n", hex.EncodeToString(sum[:]))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			fmt.Fprintf(w, `{"tag_name":"v0.2.0","assets":[
+{"name":"onelogin-aws-connector_linux-amd64","browser_download_url":"%[1]s/binary"},
+{"name":"onelogin-aws-connector_darwin-amd64","browser_download_url":"%[1]s/broken"},
+{"name":"checksums.txt","browser_download_url":"%[1]s/checksums.txt"}]}`, server.URL)
+		case "/binary":
+			fmt.Fprint(w, binary)
+		case "/broken":
+			fmt.Fprint(w, "broken")
+		case "/checksums.txt":
+			fmt.Fprint(w, checksums+fmt.Sprintf("%s  onelogin-aws-connector_darwin-amd64\n", hex.EncodeToString(sum[:])))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	LatestURL = server.URL + "/latest"
+	HTTPClient = server.Client()
+
+	r, err := Latest()
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if r.Version() != "0.2.0" {
+		t.Errorf("%v is not equal 0.2.0", r.Version())
+	}
+	t.Run("verified", func(t *testing.T) {
+		data, err := r.Download("linux", "amd64")
+		if err != nil {
+			t.Errorf("%#v", err)
+		}
+		if string(data) != binary {
+			t.Errorf("%v is not equal %v", string(data), binary)
+		}
+	})
+	t.Run("checksum mismatch", func(t *testing.T) {
+		if _, err := r.Download("darwin", "amd64"); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+	t.Run("no asset", func(t *testing.T) {
+		if _, err := r.Download("windows", "amd64"); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+}
+
+func TestReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "onelogin-aws-connector")
+	if err := ioutil.WriteFile(file, []byte("old"), 0755); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if err := Replace(file, []byte("new")); err != nil {
+		t.Errorf("%#v", err)
+	}
+	data, _ := ioutil.ReadFile(file)
+	if string(data) != "new" {
+		t.Errorf("%v is not equal new", string(data))
+	}
+	info, _ := os.Stat(file)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("%v is not equal 0755", info.Mode().Perm())
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("temporary files are left: %v", files)
+	}
+}
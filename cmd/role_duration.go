@@ -0,0 +1,78 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// roleDurationCacheTTL is how long MaxSessionDuration of the role found on login is used,
+// the administrator may extend it later
+const roleDurationCacheTTL = 24 * time.Hour
+
+type roleDurationCache struct {
+	DurationSeconds int64     `json:"duration_seconds"`
+	FoundAt         time.Time `json:"found_at"`
+}
+
+func roleDurationCacheFile(dir string) string {
+	return path.Join(dir, "durations.cache")
+}
+
+func loadRoleDurationCache(dir string) map[string]roleDurationCache {
+	cache := map[string]roleDurationCache{}
+	data, err := ioutil.ReadFile(roleDurationCacheFile(dir))
+	if err != nil {
+		return cache
+	}
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+// roleDuration returns the duration shortened to MaxSessionDuration of the role found on the last login,
+// so STS is not called with the rejected duration again
+func roleDuration(dir string, roleArn string, seconds int64) int64 {
+	if roleArn == "" {
+		return seconds
+	}
+	cached, ok := loadRoleDurationCache(dir)[roleArn]
+	if ok && time.Since(cached.FoundAt) < roleDurationCacheTTL && cached.DurationSeconds < seconds {
+		return cached.DurationSeconds
+	}
+	return seconds
+}
+
+// recordRoleDuration warns that the role is assumed for the shorter duration than requested and caches it
+func recordRoleDuration(out io.Writer, dir string, roleArn string, requested int64, effective int64) error {
+	if effective >= requested {
+		return nil
+	}
+	fmt.Fprint(out, i18n.Sprintf("Warning: %s is assumed for %ds because %ds exceeds its maximum session duration\n", roleArn, effective, requested))
+	cache := loadRoleDurationCache(dir)
+	cache[roleArn] = roleDurationCache{DurationSeconds: effective, FoundAt: time.Now()}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return secret.WriteFile(roleDurationCacheFile(dir), data)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRoleDuration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	role := "arn:aws:iam::123456789012:role/Role"
+	if got := roleDuration(dir, role, 43200); got != 43200 {
+		t.Errorf("%d is not the requested duration", got)
+	}
+
+	var out bytes.Buffer
+	if err := recordRoleDuration(&out, dir, role, 43200, 43200); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("%s is printed for the requested duration", out.String())
+	}
+	if err := recordRoleDuration(&out, dir, role, 43200, 14400); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Warning: "+role+" is assumed for 14400s because 43200s exceeds its maximum session duration\n" {
+		t.Errorf("%s is not the warning", out.String())
+	}
+	if got := roleDuration(dir, role, 43200); got != 14400 {
+		t.Errorf("%d is not the maximum session duration", got)
+	}
+	if got := roleDuration(dir, role, 3600); got != 3600 {
+		t.Errorf("%d is not the requested duration shorter than the maximum", got)
+	}
+	if got := roleDuration(dir, "arn:aws:iam::123456789012:role/Other", 43200); got != 43200 {
+		t.Errorf("%d is the duration of another role", got)
+	}
+}
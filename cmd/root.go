@@ -15,16 +15,25 @@
 package cmd
 
 import (
+	"log"
 	"os"
 	"path"
 
 	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
 )
 
 var (
 	awsProfile string
 	debug      bool
+	output     string
 	configFile string
 	cacheDir   string
 	awsDir     string
@@ -36,6 +45,25 @@ var RootCmd = &cobra.Command{
 	Short: "Generate AWS Credentials with OneLogin SAML",
 	Long: `This is a CLI command to generate AWS credentials with OneLogin SAML
 This command write to credentials to ~/.aws/config and ~/.aws/credentials.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if output != outputText && output != outputJSON {
+			errorExit(errors.Errorf("%s is not supported output format", output))
+		}
+		if language := config.LoadLanguage(configFile); language != "" {
+			i18n.SetLanguage(language)
+		}
+		if Version != "" {
+			useragent.Version = Version
+		}
+		useragent.Suffix = config.LoadUserAgent(configFile)
+		if debug {
+			apiresponse.Logf = log.Printf
+		}
+		if err := openHTTPTrace(); err != nil {
+			errorExit(err)
+		}
+		warnExposedFiles()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -47,6 +75,8 @@ func Execute() {
 }
 
 func init() {
+	// every debug log passes the redaction, so --verbose never prints credentials
+	log.SetOutput(secret.NewWriter(os.Stderr))
 	home, err := homedir.Dir()
 	if err != nil {
 		errorExit(err)
@@ -58,7 +88,7 @@ func init() {
 		}
 	}
 	awsDir = path.Join(home, ".aws")
-	if err := os.Mkdir(dir, 0700); err != nil {
+	if err := os.Mkdir(awsDir, 0700); err != nil {
 		if !os.IsExist(err) {
 			errorExit(err)
 		}
@@ -70,6 +100,24 @@ func init() {
 		}
 	}
 	configFile = path.Join(dir, "config.toml")
+	agentSocket = path.Join(dir, "agent.sock")
+	daemonSocket = path.Join(dir, "daemon.sock")
 	awsProfile = os.Getenv("AWS_PROFILE")
-	RootCmd.PersistentFlags().BoolVarP(&debug, "debug", "", false, "debug mode")
+	if awsProfile == "" {
+		awsProfile = "default"
+	}
+	RootCmd.PersistentFlags().StringVarP(&awsProfile, "aws-profile", "", awsProfile, "aws profile name")
+	RootCmd.PersistentFlags().StringVarP(&awsProfile, "profile", "", awsProfile, "aws profile name, same as --aws-profile")
+	RootCmd.PersistentFlags().StringVarP(&configFile, "config", "", configFile, "config file")
+	RootCmd.PersistentFlags().StringVarP(&endpoint, "endpoint", "", "", "OneLogin API endpoint, us, eu or a custom host like localhost:8443. init saves it, and other commands use it instead of the configured one")
+	RootCmd.PersistentFlags().StringVarP(&output, "output", "", outputText, "Output format of list-profiles, list-apps, list-mfa-devices, status and whoami (text or json)")
+	RootCmd.PersistentFlags().BoolVarP(&debug, "verbose", "", false, "Print verbose logs")
+	RootCmd.PersistentFlags().BoolVarP(&debug, "debug", "", false, "debug mode, same as --verbose")
+	RootCmd.PersistentFlags().StringVarP(&debugHTTP, "debug-http", "", "", "Append requests, responses and timings of OneLogin API and AWS STS to the file with secrets redacted")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "", false, "Print only credentials and errors")
+	RootCmd.PersistentFlags().BoolVarP(&noColor, "no-color", "", false, "Disable colored output")
+	RootCmd.PersistentFlags().BoolVarP(&plain, "plain", "", false, "Choose MFA device and role by number instead of arrow keys")
+	RootCmd.PersistentFlags().BoolVarP(&noPrompt, "no-prompt", "", false, "Fail instead of prompting for password, MFA device, MFA token or role")
+	RootCmd.PersistentFlags().BoolVarP(&passwordStdin, "password-stdin", "", false, "Read the password from the first line of stdin")
+	RootCmd.PersistentFlags().StringVarP(&passwordFile, "password-file", "", "", "Read the password from the file, which must not be accessible by others")
 }
@@ -0,0 +1,153 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+var plain bool
+
+const (
+	keyCtrlC     = 3
+	keyBackspace = 8
+	keyEnter     = '\r'
+	keyEsc       = 27
+	keyDelete    = 127
+)
+
+// selector is an arrow-key driven prompt to choose one of items, typed text filters items fuzzily
+type selector struct {
+	prompt     string
+	items      []string
+	query      string
+	candidates []int
+	cursor     int
+	rendered   int
+	in         *bufio.Reader
+	out        io.Writer
+}
+
+// interactive returns whether the arrow-key selector can be used
+func interactive() bool {
+	if plain || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return terminal.IsTerminal(int(os.Stdin.Fd())) && terminal.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// selectIndex asks an item with the arrow-key selector, or by number or search with --plain or on dumb terminals
+func (m *LoginEvent) selectIndex(prompt string, items []string) (int, error) {
+	defer m.lockPrompt()()
+	if !interactive() {
+		return m.searchIndex(prompt, items)
+	}
+	state, err := terminal.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return m.searchIndex(prompt, items)
+	}
+	defer terminal.Restore(int(os.Stdin.Fd()), state)
+	s := &selector{prompt: prompt, items: items, in: m.reader, out: os.Stdout}
+	return s.run()
+}
+
+func (s *selector) run() (int, error) {
+	s.candidates = fuzzyFilter("", s.items)
+	s.render()
+	for {
+		key, err := s.in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch key {
+		case keyCtrlC:
+			s.clear()
+			return 0, errors.New(i18n.T("selection is interrupted"))
+		case keyEnter, '\n':
+			if len(s.candidates) == 0 {
+				continue
+			}
+			selected := s.candidates[s.cursor]
+			s.clear()
+			fmt.Fprintf(s.out, "%s%s\r\n", s.prompt, s.items[selected])
+			return selected, nil
+		case keyBackspace, keyDelete:
+			if s.query != "" {
+				s.filter(s.query[:len(s.query)-1])
+			}
+		case keyEsc:
+			if next, _ := s.in.ReadByte(); next != '[' {
+				continue
+			}
+			switch arrow, _ := s.in.ReadByte(); arrow {
+			case 'A':
+				s.move(-1)
+			case 'B':
+				s.move(1)
+			}
+		default:
+			if key >= ' ' && key < keyDelete {
+				s.filter(s.query + string(key))
+			}
+		}
+	}
+}
+
+func (s *selector) move(delta int) {
+	if len(s.candidates) == 0 {
+		return
+	}
+	s.cursor = (s.cursor + delta + len(s.candidates)) % len(s.candidates)
+	s.clear()
+	s.render()
+}
+
+func (s *selector) filter(query string) {
+	s.query = query
+	s.candidates = fuzzyFilter(query, s.items)
+	s.cursor = 0
+	s.clear()
+	s.render()
+}
+
+func (s *selector) render() {
+	fmt.Fprintf(s.out, "%s%s\r\n", s.prompt, s.query)
+	if s.query == "" {
+		fmt.Fprint(s.out, i18n.T("(use arrow keys, or type to search)")+"\r\n")
+	} else {
+		fmt.Fprint(s.out, i18n.Sprintf("(%d/%d matched)", len(s.candidates), len(s.items))+"\r\n")
+	}
+	for i, index := range s.candidates {
+		if i == s.cursor {
+			fmt.Fprintf(s.out, "%s\r\n", colorize(colorGreen, "> "+s.items[index]))
+		} else {
+			fmt.Fprintf(s.out, "  %s\r\n", s.items[index])
+		}
+	}
+	s.rendered = len(s.candidates) + 2
+}
+
+// clear erases the rendered prompt and items
+func (s *selector) clear() {
+	fmt.Fprintf(s.out, "\x1b[%dA\x1b[J", s.rendered)
+}
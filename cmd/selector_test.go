@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	items := []string{
+		"admin (arn:aws:iam::123456789012:role/Admin) [production]",
+		"arn:aws:iam::123456789012:role/ReadOnly [production]",
+		"arn:aws:iam::210987654321:role/Developer [staging]",
+	}
+	tests := []struct {
+		name     string
+		keys     string
+		expected int
+	}{
+		{"enter", "\r", 0},
+		{"down", "\x1b[B\x1b[B\r", 2},
+		{"wrap up", "\x1b[A\r", 2},
+		{"wrap down", "\x1b[B\x1b[B\x1b[B\r", 0},
+		{"search account alias", "staging\r", 2},
+		{"search account id", "1234\x1b[B\r", 1},
+		{"search role name", "rdonly\r", 1},
+		{"ignore enter without candidates", "xyz\r\x7f\x7f\x7fdev\r", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := &selector{prompt: "Select: ", items: items, in: bufio.NewReader(strings.NewReader(tt.keys)), out: &out}
+			got, err := s.run()
+			if err != nil {
+				t.Errorf("%#v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("%d is not equal %d", got, tt.expected)
+			}
+			if !strings.HasSuffix(out.String(), "Select: "+items[tt.expected]+"\r\n") {
+				t.Errorf("'%q' does not end with the selected item", out.String())
+			}
+		})
+	}
+	t.Run("interrupted", func(t *testing.T) {
+		s := &selector{prompt: "Select: ", items: items, in: bufio.NewReader(strings.NewReader("a\x03")), out: &bytes.Buffer{}}
+		if _, err := s.run(); err == nil {
+			t.Errorf("error is expected")
+		}
+	})
+}
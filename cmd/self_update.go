@@ -0,0 +1,67 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/release"
+)
+
+// selfUpdateCmd represents the self-update command
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update to the latest release",
+	Long: `Self-update is downloading the latest release for this platform,
+verifying its checksum and replacing the running binary.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		latest, err := release.Latest()
+		if err != nil {
+			errorExit(err)
+		}
+		if !release.Newer(Version, latest.Version()) {
+			info(i18n.T("OneLogin AWS Connector version %v is the latest\n"), Version)
+			return
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			errorExit(err)
+		}
+		if executable, err = filepath.EvalSymlinks(executable); err != nil {
+			errorExit(err)
+		}
+		if debug {
+			log.Printf("Download %s to %s", release.AssetName(runtime.GOOS, runtime.GOARCH), executable)
+		}
+		data, err := latest.Download(runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			errorExit(err)
+		}
+		if err := release.Replace(executable, data); err != nil {
+			errorExit(err)
+		}
+		info(i18n.T("Updated OneLogin AWS Connector to version %v\n"), latest.Version())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,137 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/imds"
+)
+
+var serverListen string
+var serverWithin time.Duration
+var serverRequireToken bool
+
+// serverCmd represents the server command
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Serve credentials of the AWS profile on an EC2 instance metadata compatible endpoint",
+	Long: `Server serves the credentials of --aws-profile on /latest/meta-data/iam/security-credentials/ like the instance metadata service of EC2,
+so SDKs and tools without profile support read them by AWS_EC2_METADATA_SERVICE_ENDPOINT=http://127.0.0.1:9911/.
+It logs in at the start, and logs in again without prompting when the credentials expire within --within.
+It listens only on loopback addresses, and runs until it is stopped by Ctrl-C or SIGTERM.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		app, ok := c.App[awsProfile]
+		if !ok {
+			errorExit(errors.Errorf(i18n.T("%s profile is not exists"), awsProfile))
+		}
+		address, err := serverAddress(serverListen)
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		defer s.Wipe()
+		s.refresh = true
+		login := loginProfile
+		if metricsListen != "" {
+			registry, stop, err := serveMetrics(metricsListen, []string{awsProfile})
+			if err != nil {
+				errorExit(err)
+			}
+			defer stop()
+			login = countRefreshes(registry, login)
+		}
+		credentials := serverCredentials(s, awsProfile, login)
+		if _, err := credentials(context.Background()); err != nil {
+			errorExit(err)
+		}
+		daemonMode = true
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			errorExit(err)
+		}
+		h := imds.New(awsProfile, credentials)
+		h.Region = app.Region
+		h.RequireToken = serverRequireToken
+		h.Health = credentialsHealth([]string{awsProfile})
+		srv := &http.Server{Handler: h}
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			srv.Close()
+		}()
+		info(i18n.T("server is serving %s on http://%s/\n"), awsProfile, l.Addr())
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(serverCmd)
+	serverCmd.Flags().StringVarP(&serverListen, "listen", "", "127.0.0.1:9911", "Listen on the loopback address and port")
+	serverCmd.Flags().DurationVarP(&serverWithin, "within", "", 5*time.Minute, "Refresh credentials which expire within the duration when they are requested")
+	serverCmd.Flags().StringVarP(&metricsListen, "metrics", "", "", "Serve the metrics of Prometheus on the address and port, e.g. 127.0.0.1:9913")
+	serverCmd.Flags().BoolVarP(&serverRequireToken, "imdsv2-only", "", true, "Reject requests without the IMDSv2 session token, --imdsv2-only=false accepts IMDSv1")
+}
+
+// serverAddress returns the address to listen, which must be on a loopback interface,
+// because anyone connecting to the server gets the credentials
+func serverAddress(listen string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", errors.Wrapf(err, i18n.T("%s is not a valid address to listen"), listen)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", errors.Errorf(i18n.T("%s is not a loopback address, the server must not be reachable from other hosts"), listen)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// serverCredentials returns the cached credentials of the profile, or logs in again if they expire within serverWithin.
+// Concurrent requests wait for one login
+func serverCredentials(s *loginSession, profile string, login func(*loginSession, string) (*sts.Credentials, error)) imds.CredentialsFunc {
+	var mu sync.Mutex
+	return func(ctx context.Context) (*sts.Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		creds, err := loadCachedCredentials(cacheDir, profile)
+		if err == nil && creds != nil && creds.Expiration != nil && creds.Expiration.After(time.Now().Add(serverWithin)) {
+			return creds, nil
+		}
+		creds, err = login(s, profile)
+		// SAML assertions expire within minutes, so they are generated again at the next refresh
+		s.forgetAssertions()
+		return creds, err
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestServerAddress(t *testing.T) {
+	for listen, expected := range map[string]string{
+		"127.0.0.1:9911": "127.0.0.1:9911",
+		"[::1]:80":       "[::1]:80",
+		"localhost:0":    "localhost:0",
+	} {
+		if address, err := serverAddress(listen); err != nil || address != expected {
+			t.Errorf("%s, %v is not equal %s", address, err, expected)
+		}
+	}
+	for _, listen := range []string{"0.0.0.0:9911", ":9911", "example.com:80", "127.0.0.1"} {
+		if _, err := serverAddress(listen); err == nil {
+			t.Errorf("%s is listened", listen)
+		}
+	}
+}
+
+func TestServerCredentials(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = cache
+	logins := 0
+	credentials := serverCredentials(newLoginSession(nil), "default", func(s *loginSession, profile string) (*sts.Credentials, error) {
+		logins++
+		expiration := time.Now().Add(time.Hour)
+		writeCachedExpiration(t, cache, profile, expiration)
+		return &sts.Credentials{Expiration: &expiration}, nil
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := credentials(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times with the fresh credentials", logins)
+	}
+	writeCachedExpiration(t, cache, "default", time.Now().Add(serverWithin/2))
+	if _, err := credentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logins != 2 {
+		t.Errorf("logged in %d times with the expiring credentials", logins)
+	}
+}
@@ -0,0 +1,210 @@
+// Package service registers the daemon to the service manager of the user,
+// systemd on Linux, launchd on macOS and Task Scheduler on Windows
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// Name is the name of the systemd unit and the scheduled task
+const Name = "onelogin-aws-connector"
+
+// Label is the label of the launchd agent
+const Label = "com.github.lifull-dev.onelogin-aws-connector"
+
+// restartSeconds is the delay before the failed daemon is started again.
+// Task Scheduler restarts a task at least after a minute
+const restartSeconds = 30
+
+// Definition is the daemon registered to the service manager
+type Definition struct {
+	// Args is the executable and the arguments of the daemon
+	Args []string
+	// Log is the file which stdout and stderr of the daemon are appended to
+	Log string
+}
+
+// Command runs an external command, it is replaced in tests
+var Command = func(name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Render returns the path and the content of the definition for the OS, the path is in the home directory
+func Render(goos string, home string, d Definition) (string, []byte, error) {
+	switch goos {
+	case "linux":
+		return filepath.Join(home, ".config", "systemd", "user", Name+".service"), systemdUnit(d), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", Label+".plist"), launchdPlist(d), nil
+	case "windows":
+		return filepath.Join(home, ".onelogin-aws-connector", Name+".xml"), scheduledTask(d), nil
+	}
+	return "", nil, errors.Errorf("installing the daemon is not supported on %s", goos)
+}
+
+// Install writes the definition for the OS and registers it, the daemon is started at once and at every login.
+// It returns the path of the definition
+func Install(goos string, home string, d Definition) (string, error) {
+	path, content, err := Render(goos, home, d)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		return "", err
+	}
+	for _, args := range registerCommands(goos, path) {
+		if err := Command(args[0], args[1:]...); err != nil {
+			return "", errors.Wrapf(err, "failed to register %s", path)
+		}
+	}
+	return path, nil
+}
+
+// registerCommands returns the commands registering and starting the definition
+func registerCommands(goos string, path string) [][]string {
+	switch goos {
+	case "linux":
+		return [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", Name + ".service"},
+			{"systemctl", "--user", "restart", Name + ".service"},
+		}
+	case "darwin":
+		// unload fails if the agent is not loaded yet, so its error is ignored to replace the loaded agent
+		return [][]string{
+			{"sh", "-c", `launchctl unload "$0" 2>/dev/null; launchctl load -w "$0"`, path},
+		}
+	case "windows":
+		return [][]string{
+			{"schtasks", "/Create", "/F", "/TN", Name, "/XML", path},
+			{"schtasks", "/Run", "/TN", Name},
+		}
+	}
+	return nil
+}
+
+// systemdUnit is the user unit restarted on failure
+func systemdUnit(d Definition) []byte {
+	args := make([]string, len(d.Args))
+	for i, arg := range d.Args {
+		args[i] = systemdQuote(arg)
+	}
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "[Unit]")
+	fmt.Fprintln(&b, "Description=OneLogin AWS Connector daemon refreshing AWS credentials")
+	fmt.Fprintln(&b, "After=network-online.target")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Service]")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	fmt.Fprintln(&b, "Restart=on-failure")
+	fmt.Fprintf(&b, "RestartSec=%d\n", restartSeconds)
+	fmt.Fprintf(&b, "StandardOutput=append:%s\n", systemdEscape(d.Log))
+	fmt.Fprintf(&b, "StandardError=append:%s\n", systemdEscape(d.Log))
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "[Install]")
+	fmt.Fprintln(&b, "WantedBy=default.target")
+	return b.Bytes()
+}
+
+// systemdEscape escapes the specifiers and the variables of systemd
+func systemdEscape(s string) string {
+	return strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+}
+
+func systemdQuote(s string) string {
+	s = systemdEscape(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// launchdPlist is the launch agent started at login, and started again unless it exits successfully
+func launchdPlist(d Definition) []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(&b, `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">`)
+	fmt.Fprintln(&b, `<plist version="1.0">`)
+	fmt.Fprintln(&b, `<dict>`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", Label)
+	fmt.Fprintln(&b, "\t<key>ProgramArguments</key>\n\t<array>")
+	for _, arg := range d.Args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	fmt.Fprintln(&b, "\t</array>")
+	fmt.Fprintln(&b, "\t<key>RunAtLoad</key>\n\t<true/>")
+	fmt.Fprintln(&b, "\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>")
+	fmt.Fprintf(&b, "\t<key>ThrottleInterval</key>\n\t<integer>%d</integer>\n", restartSeconds)
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", xmlEscape(d.Log))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", xmlEscape(d.Log))
+	fmt.Fprintln(&b, `</dict>`)
+	fmt.Fprintln(&b, `</plist>`)
+	return b.Bytes()
+}
+
+// scheduledTask is the task started at logon and restarted on failure in UTF-16, which schtasks requires.
+// The daemon is not a Windows service, it runs as the user to use the keychain and the agent of the user
+func scheduledTask(d Definition) []byte {
+	args := make([]string, len(d.Args))
+	for i, arg := range d.Args {
+		args[i] = windowsQuote(arg)
+	}
+	// cmd /C strips the outer quotes and appends the output to the log
+	command := fmt.Sprintf(`/C "%s >> %s 2>&1"`, strings.Join(args, " "), windowsQuote(d.Log))
+	var b strings.Builder
+	fmt.Fprintln(&b, `<?xml version="1.0" encoding="UTF-16"?>`)
+	fmt.Fprintln(&b, `<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">`)
+	fmt.Fprintln(&b, "  <RegistrationInfo>\n    <Description>OneLogin AWS Connector daemon refreshing AWS credentials</Description>\n  </RegistrationInfo>")
+	fmt.Fprintln(&b, "  <Triggers>\n    <LogonTrigger>\n      <Enabled>true</Enabled>\n    </LogonTrigger>\n  </Triggers>")
+	fmt.Fprintln(&b, "  <Settings>")
+	fmt.Fprintln(&b, "    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>")
+	fmt.Fprintln(&b, "    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>")
+	fmt.Fprintln(&b, "    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>")
+	fmt.Fprintln(&b, "    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>")
+	fmt.Fprintln(&b, "    <RestartOnFailure>\n      <Interval>PT1M</Interval>\n      <Count>999</Count>\n    </RestartOnFailure>")
+	fmt.Fprintln(&b, "    <Hidden>true</Hidden>")
+	fmt.Fprintln(&b, "  </Settings>")
+	fmt.Fprintln(&b, `  <Actions Context="Author">`)
+	fmt.Fprintf(&b, "    <Exec>\n      <Command>cmd.exe</Command>\n      <Arguments>%s</Arguments>\n    </Exec>\n", xmlEscape(command))
+	fmt.Fprintln(&b, "  </Actions>")
+	fmt.Fprintln(&b, "</Task>")
+	return utf16LE(strings.ReplaceAll(b.String(), "\n", "\r\n"))
+}
+
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"&|<>^") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// utf16LE encodes the string in UTF-16 little endian with BOM
+func utf16LE(s string) []byte {
+	b := []byte{0xff, 0xfe}
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+var definition = Definition{
+	Args: []string{"/opt/onelogin aws/bin/onelogin-aws-connector", "daemon", "--no-prompt", "--", "prod"},
+	Log:  "/home/user/.onelogin-aws-connector/daemon.log",
+}
+
+func TestRenderSystemd(t *testing.T) {
+	path, content, err := Render("linux", "/home/user", definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/home/user/.config/systemd/user/onelogin-aws-connector.service" {
+		t.Errorf("%s is not the user unit", path)
+	}
+	for _, expected := range []string{
+		`ExecStart="/opt/onelogin aws/bin/onelogin-aws-connector" daemon --no-prompt -- prod` + "\n",
+		"Restart=on-failure\nRestartSec=30\n",
+		"StandardOutput=append:/home/user/.onelogin-aws-connector/daemon.log\n",
+		"StandardError=append:/home/user/.onelogin-aws-connector/daemon.log\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("%s does not contain %q", content, expected)
+		}
+	}
+	if actual := systemdQuote(`100%"$HOME"`); actual != `"100%%\"$$HOME\""` {
+		t.Errorf("%s is not escaped", actual)
+	}
+}
+
+func TestRenderLaunchd(t *testing.T) {
+	path, content, err := Render("darwin", "/Users/user", definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/Users/user/Library/LaunchAgents/com.github.lifull-dev.onelogin-aws-connector.plist" {
+		t.Errorf("%s is not the launch agent", path)
+	}
+	for _, expected := range []string{
+		"<string>/opt/onelogin aws/bin/onelogin-aws-connector</string>\n\t\t<string>daemon</string>",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+		"<key>ThrottleInterval</key>\n\t<integer>30</integer>",
+		"<key>StandardErrorPath</key>\n\t<string>/home/user/.onelogin-aws-connector/daemon.log</string>",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("%s does not contain %q", content, expected)
+		}
+	}
+}
+
+func TestRenderScheduledTask(t *testing.T) {
+	d := Definition{Args: []string{`C:\Program Files\onelogin-aws-connector.exe`, "daemon"}, Log: `C:\Users\user\daemon.log`}
+	path, content, err := Render("windows", `C:\Users\user`, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "onelogin-aws-connector.xml" {
+		t.Errorf("%s is not the task", path)
+	}
+	if content[0] != 0xff || content[1] != 0xfe {
+		t.Fatalf("%v is not UTF-16LE BOM", content[:2])
+	}
+	units := make([]uint16, 0, len(content)/2-1)
+	for i := 2; i+1 < len(content); i += 2 {
+		units = append(units, uint16(content[i])|uint16(content[i+1])<<8)
+	}
+	xml := string(utf16.Decode(units))
+	for _, expected := range []string{
+		`<?xml version="1.0" encoding="UTF-16"?>` + "\r\n",
+		"<LogonTrigger>",
+		"<RestartOnFailure>\r\n      <Interval>PT1M</Interval>",
+		`<Arguments>/C &quot;&quot;C:\Program Files\onelogin-aws-connector.exe&quot; daemon &gt;&gt; C:\Users\user\daemon.log 2&gt;&amp;1&quot;</Arguments>`,
+	} {
+		if !strings.Contains(xml, expected) {
+			t.Errorf("%s does not contain %q", xml, expected)
+		}
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	if _, _, err := Render("plan9", "/usr/user", definition); err == nil || err.Error() != "installing the daemon is not supported on plan9" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	home, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer func(command func(string, ...string) error) { Command = command }(Command)
+	commands := [][]string{}
+	Command = func(name string, args ...string) error {
+		commands = append(commands, append([]string{name}, args...))
+		return nil
+	}
+	path, err := Install("linux", home, definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("%v, %v is unexpected", info, err)
+	}
+	expected := [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "onelogin-aws-connector.service"},
+		{"systemctl", "--user", "restart", "onelogin-aws-connector.service"},
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("%v is not equal %v", commands, expected)
+	}
+}
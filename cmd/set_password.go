@@ -0,0 +1,201 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/biometric"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/keychain"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+var deletePassword bool
+var setPasswordBiometric bool
+var setProxyPassword bool
+
+// setPasswordCmd represents the configure set-password command
+var setPasswordCmd = &cobra.Command{
+	Use:   "set-password",
+	Short: "Save the OneLogin password in the OS keychain",
+	Long: `Set-password saves the OneLogin password of the aws profile in the OS keychain,
+and login command reads it instead of prompting. The password is never written to the config file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		if setProxyPassword {
+			if err := saveProxyPassword(c); err != nil {
+				errorExit(err)
+			}
+			return
+		}
+		app, ok := c.App[awsProfile]
+		if !ok {
+			errorExit(errors.Errorf(i18n.T("%s profile is not exists"), awsProfile))
+		}
+		if deletePassword {
+			if err := keychain.Delete(awsProfile); err != nil {
+				errorExit(err)
+			}
+			app.Keychain = false
+			app.Biometric = false
+			if err := c.Save(); err != nil {
+				errorExit(err)
+			}
+			info(i18n.T("Deleted the password of %s from the keychain\n"), awsProfile)
+			return
+		}
+		if setPasswordBiometric {
+			if err := verifyBiometric(awsProfile); err != nil {
+				errorExit(err)
+			}
+		}
+		reader := bufio.NewReader(os.Stdin)
+		password, err := passwordFromSource(reader)
+		if err == nil && len(password) == 0 {
+			password, err = confirmPassword(reader)
+		}
+		if err != nil {
+			errorExit(err)
+		}
+		err = keychain.Set(awsProfile, string(password))
+		password.Wipe()
+		if err != nil {
+			errorExit(err)
+		}
+		app.Keychain = true
+		app.Biometric = setPasswordBiometric
+		if err := c.Save(); err != nil {
+			errorExit(err)
+		}
+		info(i18n.T("Saved the password of %s in the keychain\n"), awsProfile)
+		if setPasswordBiometric {
+			// the keychain item has no access control of the OS, so the verification only confirms the use by login
+			fmt.Fprint(os.Stderr, i18n.T("Warning: the keychain does not require Touch ID or Windows Hello, other commands of the user can read the password. Use `agent start --biometric` to keep it behind the verification\n"))
+		}
+	},
+}
+
+func init() {
+	configureCmd.AddCommand(setPasswordCmd)
+	setPasswordCmd.Flags().BoolVarP(&deletePassword, "delete", "", false, "Delete the saved password from the OS keychain")
+	setPasswordCmd.Flags().BoolVarP(&setPasswordBiometric, "biometric", "", false, "Ask Touch ID or Windows Hello before login reads the saved password, which the keychain does not enforce")
+	setPasswordCmd.Flags().BoolVarP(&setProxyPassword, "proxy", "", false, "Save the password of the proxy user of the aws profile instead of the OneLogin password")
+}
+
+// saveProxyPassword saves or deletes the password of the proxy user used by the aws profile
+func saveProxyPassword(c *config.Config) error {
+	app := profileApp(c)
+	user := app.ProxyUser
+	if service, ok := c.Service[app.ServiceName()]; ok && user == "" {
+		user = service.ProxyUser
+	}
+	if user == "" {
+		user = os.Getenv(config.ProxyUserEnv)
+	}
+	if user == "" {
+		return errors.Errorf(i18n.T("proxy user is not configured. Please run `onelogin-aws-connector init --proxy-user`"))
+	}
+	account := proxyKeychainAccount(user)
+	if deletePassword {
+		if err := keychain.Delete(account); err != nil {
+			return err
+		}
+		info(i18n.T("Deleted the proxy password of %s from the keychain\n"), user)
+		return nil
+	}
+	reader := bufio.NewReader(os.Stdin)
+	password, err := passwordFromSource(reader)
+	if err == nil && len(password) == 0 {
+		password, err = confirmPassword(reader)
+	}
+	if err != nil {
+		return err
+	}
+	err = keychain.Set(account, string(password))
+	password.Wipe()
+	if err != nil {
+		return err
+	}
+	info(i18n.T("Saved the proxy password of %s in the keychain\n"), user)
+	return nil
+}
+
+// confirmPassword asks the password twice
+func confirmPassword(reader *bufio.Reader) (secret.Bytes, error) {
+	if err := requirePrompt("password", exitPasswordRequired); err != nil {
+		return nil, err
+	}
+	prompts := []string{i18n.T("Enter your password: "), i18n.T("Retype your password: ")}
+	passwords := make([]secret.Bytes, len(prompts))
+	defer func() { passwords[1].Wipe() }()
+	for i, prompt := range prompts {
+		if interactiveInput {
+			fmt.Print(prompt)
+		}
+		password, err := readMasked(reader)
+		if err != nil {
+			passwords[0].Wipe()
+			return nil, inputClosed(err, "password", exitPasswordRequired)
+		}
+		passwords[i] = password
+	}
+	if !bytes.Equal(passwords[0], passwords[1]) {
+		passwords[0].Wipe()
+		return nil, errors.New(i18n.T("passwords do not match"))
+	}
+	return passwords[0], nil
+}
+
+// keychainPassword returns the password saved by set-password, or nil if it is not saved.
+// The user is verified with Touch ID or Windows Hello before reading it if biometric is enabled,
+// which is a confirmation of login and not access control, the keychain item is readable without it
+func keychainPassword(profile string, biometric bool) (secret.Bytes, error) {
+	if biometric {
+		if err := verifyBiometric(profile); err != nil {
+			return nil, err
+		}
+	}
+	password, err := keychain.Get(profile)
+	if err == keychain.ErrNotFound {
+		if debug {
+			log.Printf("password of %s is not found in the keychain\n", profile)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret.Bytes(password), nil
+}
+
+// verifyBiometric verifies the user before using the saved password of the profile
+func verifyBiometric(profile string) error {
+	if err := biometric.Verify(i18n.Sprintf("use the OneLogin password of %s", profile)); err != nil {
+		return errors.Wrap(err, i18n.T("failed to verify with Touch ID or Windows Hello"))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+// profileMarker is the environment variable telling which aws profile the subshell has credentials of
+const profileMarker = "ONELOGIN_AWS_PROFILE"
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start a subshell with AWS credentials",
+	Long: `Shell is starting $SHELL with AWS credentials in environment variables.
+The credentials are dropped when the shell exits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if current := os.Getenv(profileMarker); current != "" {
+			fmt.Fprint(os.Stderr, i18n.Sprintf("Warning: already in a shell with credentials of %s\n", current))
+		}
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		creds, err := loginProfile(s, awsProfile)
+		s.Wipe()
+		if err != nil {
+			errorExit(err)
+		}
+		r := awsRegion(profileApp(c).Region)
+		run([]string{userShell()}, shellEnv(os.Environ(), creds, r, awsProfile))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(shellCmd)
+	shellCmd.Flags().StringVarP(&region, "aws-region", "", "", "AWS Region")
+}
+
+// userShell returns the login shell of the user
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// shellEnv returns environment variables with AWS credentials and the profile marker
+func shellEnv(environ []string, creds *sts.Credentials, region string, profile string) []string {
+	env := []string{}
+	for _, v := range credentialsEnv(environ, creds, region) {
+		if !strings.HasPrefix(v, profileMarker+"=") {
+			env = append(env, v)
+		}
+	}
+	return append(env, profileMarker+"="+profile)
+}
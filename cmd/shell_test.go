@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestShellEnv(t *testing.T) {
+	creds := &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+	}
+	environ := []string{
+		"HOME=/home/user",
+		"ONELOGIN_AWS_PROFILE=other",
+	}
+	got := shellEnv(environ, creds, "", "default")
+	want := []string{
+		"HOME=/home/user",
+		"AWS_ACCESS_KEY_ID=access-key-id",
+		"AWS_SECRET_ACCESS_KEY=secret-access-key",
+		"AWS_SESSION_TOKEN=session-token",
+		"AWS_SECURITY_TOKEN=session-token",
+		"ONELOGIN_AWS_PROFILE=default",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shellEnv() = %v, want %v", got, want)
+	}
+}
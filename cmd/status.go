@@ -0,0 +1,195 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
+)
+
+// expiringThreshold is remaining validity to show credentials as expiring
+const expiringThreshold = 15 * time.Minute
+
+var statusAPI bool
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print expirations of AWS credentials",
+	Long: `Status is printing expiration and remaining validity of AWS credentials of every configured profile.
+With --api, it prints the remaining OneLogin API rate limit of the client credentials instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		if statusAPI {
+			service, err := fetchService(c, profileApp(c))
+			if err != nil {
+				errorExit(err)
+			}
+			limit, err := getRateLimit(service)
+			if err != nil {
+				errorExit(err)
+			}
+			if err := printAPIStatus(os.Stdout, service, limit); err != nil {
+				errorExit(err)
+			}
+			return
+		}
+		if err := printStatus(os.Stdout, c, cacheDir, time.Now()); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVarP(&statusAPI, "api", "", false, "Print OneLogin API rate limit of the client credentials")
+}
+
+// getRateLimit fetches OneLogin API rate limit with the cached access token
+var getRateLimit = func(service config.ServiceConfig) (*samlassertion.RateLimit, error) {
+	c := newOneloginConfig(service)
+	if err := c.Save(); err != nil {
+		return nil, err
+	}
+	creds, err := c.Credentials.Get()
+	if err != nil {
+		return nil, err
+	}
+	t := tokens.NewTokens()
+	t.Endpoint = service.Endpoint
+	t.HTTPClient = c.HTTPClient
+	return t.GetRateLimit(creds.AccessToken)
+}
+
+type apiStatusEntry struct {
+	Endpoint    string    `json:"endpoint"`
+	ClientToken string    `json:"client_token"`
+	Limit       int       `json:"limit"`
+	Remaining   int       `json:"remaining"`
+	Reset       time.Time `json:"reset"`
+}
+
+func printAPIStatus(out io.Writer, service config.ServiceConfig, limit *samlassertion.RateLimit) error {
+	clientToken, _ := subdomainCredentials(service)
+	e := apiStatusEntry{
+		Endpoint:    service.Endpoint,
+		ClientToken: clientToken,
+		Limit:       limit.Limit,
+		Remaining:   limit.Remaining,
+		Reset:       limit.Reset,
+	}
+	if output == outputJSON {
+		return writeJSON(out, e)
+	}
+	remaining := colorize(colorGreen, fmt.Sprint(e.Remaining))
+	if limit.Low(rateLimitWarning) {
+		remaining = colorize(colorYellow, fmt.Sprint(e.Remaining))
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tCLIENT TOKEN\tLIMIT\tREMAINING\tRESET")
+	fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", e.Endpoint, e.ClientToken, e.Limit, remaining, e.Reset.Local().Format(time.RFC3339))
+	return w.Flush()
+}
+
+// credential states shown in JSON output
+const (
+	stateValid       = "valid"
+	stateExpiring    = "expiring"
+	stateExpired     = "expired"
+	stateNotLoggedIn = "not logged in"
+)
+
+type statusEntry struct {
+	Profile          string     `json:"profile"`
+	RoleArn          string     `json:"role_arn"`
+	Account          string     `json:"account,omitempty"`
+	AccountAlias     string     `json:"account_alias,omitempty"`
+	Expiration       *time.Time `json:"expiration"`
+	RemainingSeconds int64      `json:"remaining_seconds"`
+	State            string     `json:"state"`
+}
+
+func printStatus(out io.Writer, c *config.Config, cache string, now time.Time) error {
+	entries := []statusEntry{}
+	profiles, _ := profileItems(c)
+	aliases := loadAccountAliasCache(cache)
+	for _, profile := range profiles {
+		e := statusEntry{
+			Profile: profile,
+			RoleArn: c.ResolveRole(c.App[profile].RoleArn),
+			State:   stateNotLoggedIn,
+		}
+		e.Account = accountID(e.RoleArn)
+		e.AccountAlias = accountAlias(c, aliases, e.RoleArn)
+		creds, err := loadCachedCredentials(cache, profile)
+		if err != nil {
+			return err
+		}
+		if creds != nil && creds.Expiration != nil {
+			remaining := creds.Expiration.Sub(now).Truncate(time.Second)
+			e.Expiration = creds.Expiration
+			e.State = stateValid
+			switch {
+			case remaining <= 0:
+				e.State = stateExpired
+				remaining = 0
+			case remaining < expiringThreshold:
+				e.State = stateExpiring
+			}
+			e.RemainingSeconds = int64(remaining.Seconds())
+		}
+		entries = append(entries, e)
+	}
+	if output == outputJSON {
+		return writeJSON(out, entries)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tROLE ARN\tACCOUNT\tEXPIRATION\tREMAINING")
+	for _, e := range entries {
+		account := e.AccountAlias
+		if account == "" {
+			account = e.Account
+		}
+		if account == "" {
+			account = "-"
+		}
+		if e.Expiration == nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\t%s\n", e.Profile, e.RoleArn, account, colorize(colorRed, "not logged in"))
+			continue
+		}
+		remaining := (time.Duration(e.RemainingSeconds) * time.Second).String()
+		status := colorize(colorGreen, remaining)
+		switch e.State {
+		case stateExpired:
+			status = colorize(colorRed, "expired")
+		case stateExpiring:
+			status = colorize(colorYellow, remaining)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Profile, e.RoleArn, account, e.Expiration.Local().Format(time.RFC3339), status)
+	}
+	return w.Flush()
+}
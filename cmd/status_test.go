@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
+
+func TestPrintStatus(t *testing.T) {
+	cache, err := ioutil.TempDir("", "onelogin-aws-connector")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	defer os.RemoveAll(cache)
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.Local)
+	expiration := now.Add(10 * time.Minute)
+	content := fmt.Sprintf("Expiration = %s\n", expiration.UTC().Format(time.RFC3339))
+	if err := ioutil.WriteFile(awsCacheFile(cache, "default"), []byte(content), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+	c, err := config.Load("fixtures/fullfilled.toml")
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	useColor = false
+	var buf bytes.Buffer
+	if err := printStatus(&buf, c, cache, now); err != nil {
+		t.Errorf("%#v", err)
+	}
+	expected := [][]string{
+		{"PROFILE", "ROLE", "ARN", "ACCOUNT", "EXPIRATION", "REMAINING"},
+		{"default", "role-arn", "-", expiration.Format(time.RFC3339), "10m0s"},
+		{"other", "other-role-arn", "-", "-", "not", "logged", "in"},
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Errorf("'%v' has %d lines", buf.String(), len(lines))
+		return
+	}
+	for i, line := range lines {
+		if !reflect.DeepEqual(strings.Fields(line), expected[i]) {
+			t.Errorf("%v is not equal %v", strings.Fields(line), expected[i])
+		}
+	}
+	t.Run("json", func(t *testing.T) {
+		output = outputJSON
+		defer func() { output = outputText }()
+		var buf bytes.Buffer
+		if err := printStatus(&buf, c, cache, now); err != nil {
+			t.Errorf("%#v", err)
+		}
+		var entries []statusEntry
+		if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+			t.Fatalf("%#v", err)
+		}
+		if len(entries) != 2 || entries[0].State != stateExpiring || entries[0].RemainingSeconds != 600 ||
+			entries[1].State != stateNotLoggedIn || entries[1].Expiration != nil {
+			t.Errorf("%v is unexpected", buf.String())
+		}
+	})
+}
+
+func TestColorize(t *testing.T) {
+	defer func(use, no bool) { useColor, noColor = use, no }(useColor, noColor)
+	useColor, noColor = true, false
+	if got := colorize(colorRed, "text"); got != "\x1b[31mtext\x1b[0m" {
+		t.Errorf("%q is not colored", got)
+	}
+	noColor = true
+	if got := colorize(colorRed, "text"); got != "text" {
+		t.Errorf("%q is colored with --no-color", got)
+	}
+}
+
+func TestPrintAPIStatus(t *testing.T) {
+	useColor = false
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.Local)
+	service := config.ServiceConfig{Endpoint: "api.us.onelogin.com", ClientToken: "client-token"}
+	var buf bytes.Buffer
+	if err := printAPIStatus(&buf, service, &samlassertion.RateLimit{Limit: 5000, Remaining: 400, Reset: now.Add(10 * time.Minute)}); err != nil {
+		t.Errorf("%#v", err)
+	}
+	actual := strings.Fields(strings.Split(buf.String(), "\n")[1])
+	expected := []string{"api.us.onelogin.com", "client-token", "5000", "400", now.Add(10 * time.Minute).Format(time.RFC3339)}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("%v is not %v", actual, expected)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+)
+
+// subdomainCredentials returns the client credentials used for the subdomain of the service,
+// which are the ones configured for the subdomain or the service ones resolved by ForSubdomain
+func subdomainCredentials(service config.ServiceConfig) (string, string) {
+	c := newOneloginConfig(service)
+	return c.ClientToken, c.ClientSecret
+}
+
+// serviceTransport returns the transport options of the service with --debug-http
+func serviceTransport(service config.ServiceConfig) transport.Options {
+	o := service.TransportOptions()
+	o.Trace = httpTrace
+	return o
+}
+
+// tenantName returns the service name of the app with the subdomain if the app has its own one,
+// the password and SAML assertions are shared between apps of the same tenant
+func tenantName(app config.AppConfig) string {
+	if app.Subdomain == "" {
+		return app.ServiceName()
+	}
+	return app.ServiceName() + "/" + app.Subdomain
+}
+
+// newOneloginConfig creates OneLogin config with the cached access token,
+// it uses the client credentials of the service subdomain
+func newOneloginConfig(service config.ServiceConfig) *onelogin.Config {
+	secret.Register(service.ClientSecret)
+	subdomains := map[string]onelogin.ClientCredentials{}
+	for subdomain, pair := range service.Credentials {
+		secret.Register(pair.ClientSecret)
+		subdomains[subdomain] = onelogin.ClientCredentials{ClientToken: pair.ClientToken, ClientSecret: pair.ClientSecret}
+	}
+	opts := []onelogin.Option{
+		onelogin.WithEndpoint(service.Endpoint),
+		onelogin.WithClientCredentials(service.ClientToken, service.ClientSecret),
+		onelogin.WithCredentialsStore(onelogin.FileStore{Dir: oneloginCacheDir(cacheDir)}),
+		onelogin.WithSubdomains(subdomains),
+	}
+	// the proxy and the TLS files are validated by fetchService
+	if client, err := transport.Shared(serviceTransport(service)); err == nil {
+		opts = append(opts, onelogin.WithHTTPClient(client))
+	}
+	return onelogin.NewConfig(opts...).ForSubdomain(service.Subdomain)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestFetchServiceWithSubdomainCredentials(t *testing.T) {
+	c := &config.Config{Service: map[string]*config.ServiceConfig{
+		"default": {
+			Endpoint:        "api-server",
+			Subdomain:       "consultant",
+			UsernameOrEmail: "user@example.com",
+			Credentials: map[string]*config.CredentialConfig{
+				"customer": {ClientToken: "customer-token", ClientSecret: "customer-secret"},
+			},
+		},
+	}}
+	// the service has no credentials of its own subdomain
+	if _, err := fetchService(c, config.AppConfig{}); err == nil || err.Error() != "ClientToken is not exists" {
+		t.Errorf("%v is unexpected", err)
+	}
+	app := config.AppConfig{Subdomain: "customer"}
+	service, err := fetchService(c, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Subdomain != "customer" {
+		t.Errorf("%s is not equal customer", service.Subdomain)
+	}
+	if token, secret := subdomainCredentials(service); token != "customer-token" || secret != "customer-secret" {
+		t.Errorf("%s and %s are unexpected", token, secret)
+	}
+	if name := tenantName(app); name != "default/customer" {
+		t.Errorf("%s is not equal default/customer", name)
+	}
+	if name := tenantName(config.AppConfig{Service: "sandbox"}); name != "sandbox" {
+		t.Errorf("%s is not equal sandbox", name)
+	}
+}
+
+func TestInitCmdWithCredentialsSubdomain(t *testing.T) {
+	dist, err := ioutil.TempFile("", "onelogin-aws-connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := dist.Name()
+	dist.Close()
+	defer os.Remove(file)
+
+	resetInitFlags()
+	defer resetInitFlags()
+	clientToken = "client-token"
+	clientSecret = "client-secret"
+	subdomain = "consultant"
+	if err := initServiceConfig(file, config.DefaultService); err != nil {
+		t.Fatal(err)
+	}
+	resetInitFlags()
+	credentialsSubdomain = "customer"
+	clientToken = "customer-token"
+	clientSecret = "customer-secret"
+	if err := initServiceConfig(file, config.DefaultService); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := c.Service["default"]
+	if service.ClientToken != "client-token" || service.Subdomain != "consultant" {
+		t.Errorf("%#v is unexpected", service)
+	}
+	if pair := service.Credentials["customer"]; pair == nil || pair.ClientToken != "customer-token" || pair.ClientSecret != "customer-secret" {
+		t.Errorf("%#v is unexpected", pair)
+	}
+}
+
+func TestFetchServiceWithProxy(t *testing.T) {
+	c := &config.Config{Service: map[string]*config.ServiceConfig{
+		"default": {
+			Endpoint:     "api-server",
+			ClientToken:  "client-token",
+			ClientSecret: "client-secret",
+			Subdomain:    "subdomain",
+			Proxy:        "http://proxy.example.com:8080",
+			NoProxy:      "internal.example.com",
+		},
+	}}
+	service, err := fetchService(c, config.AppConfig{})
+	if err != nil || service.Proxy != "http://proxy.example.com:8080" || service.NoProxy != "internal.example.com" {
+		t.Errorf("%#v, %v is unexpected", service, err)
+	}
+	service, err = fetchService(c, config.AppConfig{Proxy: "direct"})
+	if err != nil || service.Proxy != "direct" || service.NoProxy != "internal.example.com" {
+		t.Errorf("%#v, %v is unexpected", service, err)
+	}
+	if _, err := fetchService(c, config.AppConfig{Proxy: "ftp://proxy.example.com"}); err == nil {
+		t.Error("invalid proxy is accepted")
+	}
+}
+
+func TestFetchServiceWithPins(t *testing.T) {
+	c := &config.Config{Service: map[string]*config.ServiceConfig{
+		"default": {
+			Region:       "us",
+			ClientToken:  "client-token",
+			ClientSecret: "client-secret",
+			Subdomain:    "subdomain",
+			Pins:         []string{"sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		},
+	}}
+	service, err := fetchService(c, config.AppConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options := serviceTransport(service); options.PinnedHost != "api.us.onelogin.com" || len(options.Pins) != 1 {
+		t.Errorf("%#v is unexpected", options)
+	}
+	c.Service["default"].Pins = []string{"sha256/invalid"}
+	if _, err := fetchService(c, config.AppConfig{}); err == nil {
+		t.Error("invalid pin is accepted")
+	}
+}
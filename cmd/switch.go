@@ -0,0 +1,88 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+)
+
+var exportProfile string
+
+// switchCmd represents the switch command
+var switchCmd = &cobra.Command{
+	Use:   "switch",
+	Short: "Select aws profile to login and export it as default",
+	Long: `Switch is selecting aws profile from configured profiles with searching,
+then login and export the credentials to the default aws profile.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := config.Load(configFile)
+		if err != nil {
+			errorExit(err)
+		}
+		profiles, items := profileItems(c)
+		if len(profiles) == 0 {
+			errorExit(errors.New(i18n.T("There is no configured profile. Please run `onelogin-aws-connector configure`")))
+		}
+		if err := requirePrompt("aws profile selection", exitProfileRequired); err != nil {
+			errorExit(err)
+		}
+		s := newLoginSession(c)
+		defer s.Wipe()
+		selected, err := NewLoginEvent(s.reader, c).selectIndex(i18n.T("Select aws profile or type to search: "), items)
+		if err := inputClosed(err, "aws profile selection", exitProfileRequired); err != nil {
+			errorExit(err)
+		}
+		profile := profiles[selected]
+		creds, err := loginProfile(s, profile)
+		if err != nil {
+			errorExit(err)
+		}
+		if exportProfile != profile {
+			saveCredentials(exportProfile, creds)
+			saveRegion(exportProfile, c.App[profile].Region)
+		}
+		info(i18n.T("Switched to %s\n"), profile)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(switchCmd)
+	switchCmd.Flags().StringVarP(&exportProfile, "export-profile", "", "default", "aws profile name to export the selected credentials")
+}
+
+// profileItems returns sorted profile names and their descriptions
+func profileItems(c *config.Config) ([]string, []string) {
+	profiles := []string{}
+	for profile := range c.App {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	items := make([]string, len(profiles))
+	for i, profile := range profiles {
+		role := c.App[profile].RoleArn
+		if alias := c.RoleAlias(c.ResolveRole(role)); alias != "" {
+			role = alias
+		}
+		items[i] = fmt.Sprintf("%s\t%s", profile, role)
+	}
+	return profiles, items
+}
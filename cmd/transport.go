@@ -0,0 +1,116 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+)
+
+var insecureSkipVerifyWarning sync.Once
+
+// debugHTTP is the file of --debug-http
+var debugHTTP string
+
+// httpTrace is the opened file of --debug-http, or nil not to trace
+var httpTrace io.Writer
+
+// openHTTPTrace opens the file of --debug-http, which is accessible only by the user
+func openHTTPTrace() error {
+	if debugHTTP == "" {
+		return nil
+	}
+	f, err := os.OpenFile(debugHTTP, os.O_WRONLY|os.O_CREATE|os.O_APPEND, secret.FileMode)
+	if err != nil {
+		return err
+	}
+	httpTrace = f
+	return nil
+}
+
+// warnInsecureSkipVerify warns once a run that server certificates are not verified
+func warnInsecureSkipVerify() {
+	insecureSkipVerifyWarning.Do(func() {
+		fmt.Fprint(os.Stderr, i18n.T("Warning: TLS certificate verification is disabled by insecure_skip_verify, the password and AWS credentials can be stolen by anyone on the network. Please configure --ca-bundle instead\n"))
+	})
+}
+
+// initClientCertificate saves the absolute paths of --client-cert and --client-key after loading the pair
+func initClientCertificate(service *config.ServiceConfig) error {
+	cert, key := service.ClientCert, service.ClientKey
+	var err error
+	if clientCert != "" {
+		if cert, err = filepath.Abs(clientCert); err != nil {
+			return err
+		}
+	}
+	if clientKey != "" {
+		if key, err = filepath.Abs(clientKey); err != nil {
+			return err
+		}
+	}
+	if _, err := transport.New(transport.Options{ClientCert: cert, ClientKey: key}); err != nil {
+		return err
+	}
+	if perm, err := secret.Exposed(key); err == nil && perm != 0 {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("Warning: permissions %04o of %s are too open, please run `chmod %o %s`\n", perm, key, secret.FileMode, key))
+	}
+	service.ClientCert = cert
+	service.ClientKey = key
+	return nil
+}
+
+// noPins is the --pin value removing the pins
+const noPins = "none"
+
+// initPins saves --pin values instead of the previous ones
+func initPins(service *config.ServiceConfig) error {
+	if len(pins) == 1 && pins[0] == noPins {
+		service.Pins = nil
+		return nil
+	}
+	for _, pin := range pins {
+		if err := transport.ValidatePin(pin); err != nil {
+			return err
+		}
+	}
+	service.Pins = pins
+	return nil
+}
+
+// http2Auto is the --http2 value removing the setting
+const http2Auto = "auto"
+
+// initHTTP2 saves --http2 after validating it
+func initHTTP2(service *config.ServiceConfig) error {
+	value := strings.ToLower(http2)
+	if value == http2Auto {
+		value = transport.HTTP2Auto
+	}
+	if _, err := transport.New(transport.Options{HTTP2: value}); err != nil {
+		return err
+	}
+	service.HTTP2 = value
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+)
+
+func TestOpenHTTPTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debug-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() {
+		debugHTTP = ""
+		httpTrace = nil
+	}()
+
+	debugHTTP = path.Join(dir, "trace.log")
+	if err := openHTTPTrace(); err != nil {
+		t.Fatal(err)
+	}
+	defer httpTrace.(*os.File).Close()
+	info, err := os.Stat(debugHTTP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("%v is too open", info.Mode())
+	}
+	if options := serviceTransport(config.ServiceConfig{}); options.Trace != httpTrace {
+		t.Errorf("%#v does not trace", options)
+	}
+
+	debugHTTP = path.Join(dir, "missing", "trace.log")
+	if err := openHTTPTrace(); err == nil {
+		t.Error("the file in the missing directory is opened")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/vault"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// keys of the Vault secret
+const (
+	vaultClientToken  = "client_token"
+	vaultClientSecret = "client_secret"
+	vaultPasswordKey  = "password"
+)
+
+// vaultRead reads the secret from Vault, it is replaced in tests
+var vaultRead = vault.Read
+
+// applyVault replaces the client token and the client secret with the values in Vault if the service has the vault path
+func applyVault(service *config.ServiceConfig) error {
+	if service.Vault == "" {
+		return nil
+	}
+	s, err := vaultRead(service.Vault)
+	if err != nil {
+		return err
+	}
+	defer s.Wipe()
+	if value, ok := s[vaultClientToken]; ok {
+		service.ClientToken = string(value)
+	}
+	if value, ok := s[vaultClientSecret]; ok {
+		service.ClientSecret = string(value)
+		secret.Register(service.ClientSecret)
+	}
+	if debug {
+		log.Printf("OneLogin API credentials are read from Vault %s\n", service.Vault)
+	}
+	return nil
+}
+
+// vaultPassword returns the password in Vault, or nil if the service has no vault path or the secret has no password
+func (s *loginSession) vaultPassword(name string) (secret.Bytes, error) {
+	if s.conf == nil {
+		return nil, nil
+	}
+	service, ok := s.conf.Service[name]
+	if !ok || service.Vault == "" {
+		return nil, nil
+	}
+	values, err := vaultRead(service.Vault)
+	if err != nil {
+		return nil, err
+	}
+	defer values.Wipe()
+	return values[vaultPasswordKey].Copy(), nil
+}
@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// environment variables of the Vault CLI
+const (
+	AddrEnv      = "VAULT_ADDR"
+	TokenEnv     = "VAULT_TOKEN"
+	NamespaceEnv = "VAULT_NAMESPACE"
+)
+
+// HTTPClient is used to read secrets from Vault
+var HTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// Secret is the key-value pairs of a KV secret
+type Secret map[string]secret.Bytes
+
+// Wipe overwrites every value with zeros
+func (s Secret) Wipe() {
+	for _, value := range s {
+		value.Wipe()
+	}
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+// Read reads the KV secret at the path like `secret/data/onelogin` for KV version 2 or `secret/onelogin` for version 1.
+// Vault is found by VAULT_ADDR, and authenticated by VAULT_TOKEN or ~/.vault-token written by `vault login`
+func Read(path string) (Secret, error) {
+	addr := strings.TrimSuffix(os.Getenv(AddrEnv), "/")
+	if addr == "" {
+		return nil, errors.Errorf("%s is required to read %s from Vault", AddrEnv, path)
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv(NamespaceEnv); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	res, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	defer secret.Wipe(body)
+	if err != nil {
+		return nil, err
+	}
+	var r response
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errors.Wrapf(err, "[%d] failed to read %s from Vault", res.StatusCode, path)
+	}
+	defer secret.Wipe(r.Data)
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("[%d] failed to read %s from Vault: %s", res.StatusCode, path, strings.Join(r.Errors, ", "))
+	}
+	return decode(r.Data)
+}
+
+// decode returns `data.data` of KV version 2 if it exists, or `data` of version 1
+func decode(data []byte) (Secret, error) {
+	var v2 struct {
+		Data     Secret          `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &v2); err == nil && v2.Metadata != nil {
+		return v2.Data, nil
+	}
+	v2.Data.Wipe()
+	var v1 Secret
+	if err := json.Unmarshal(data, &v1); err != nil {
+		v1.Wipe()
+		return nil, errors.Wrap(err, "values of the Vault secret must be strings")
+	}
+	return v1, nil
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv(TokenEnv); token != "" {
+		secret.Register(token)
+		return token, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+	if os.IsNotExist(err) {
+		return "", errors.Errorf("%s or ~/.vault-token is required, please run `vault login`", TokenEnv)
+	}
+	if err != nil {
+		return "", err
+	}
+	token := string(bytes.TrimSpace(data))
+	secret.Register(token)
+	return token, nil
+}
@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/onelogin":
+			w.Write([]byte(`{"data":{"data":{"client_token":"token","client_secret":"secret"},"metadata":{"version":1}}}`))
+		case "/v1/kv/onelogin":
+			w.Write([]byte(`{"data":{"client_token":"token","password":"pass\"word"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer ts.Close()
+	defer os.Unsetenv(AddrEnv)
+	defer os.Unsetenv(TokenEnv)
+	defer os.Unsetenv(NamespaceEnv)
+	os.Setenv(AddrEnv, ts.URL+"/")
+	os.Setenv(TokenEnv, "s.token")
+	os.Setenv(NamespaceEnv, "team")
+
+	s, err := Read("secret/data/onelogin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(s["client_token"]) != "token" || string(s["client_secret"]) != "secret" {
+		t.Errorf("%q is unexpected KV version 2 secret", map[string][]byte{"client_token": s["client_token"], "client_secret": s["client_secret"]})
+	}
+	s, err = Read("/kv/onelogin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(s["client_token"]) != "token" || string(s["password"]) != `pass"word` {
+		t.Errorf("%q is unexpected KV version 1 secret", []byte(s["password"]))
+	}
+	if _, err := Read("secret/data/missing"); err == nil || err.Error() != "[404] failed to read secret/data/missing from Vault: " {
+		t.Errorf("%v is unexpected", err)
+	}
+	os.Setenv(TokenEnv, "s.invalid")
+	if _, err := Read("secret/data/onelogin"); err == nil || err.Error() != "[403] failed to read secret/data/onelogin from Vault: permission denied" {
+		t.Errorf("%v is unexpected", err)
+	}
+	os.Unsetenv(AddrEnv)
+	if _, err := Read("secret/data/onelogin"); err == nil {
+		t.Errorf("VAULT_ADDR is required")
+	}
+}
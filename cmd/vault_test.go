@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/vault"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+func TestApplyVault(t *testing.T) {
+	original := vaultRead
+	defer func() { vaultRead = original }()
+	paths := []string{}
+	vaultRead = func(path string) (vault.Secret, error) {
+		paths = append(paths, path)
+		return vault.Secret{vaultClientToken: secret.Bytes("vault-token"), vaultClientSecret: secret.Bytes("vault-secret"), vaultPasswordKey: secret.Bytes("vault-password")}, nil
+	}
+	service := &config.ServiceConfig{ClientToken: "local-token", Subdomain: "example"}
+	if err := applyVault(service); err != nil || len(paths) != 0 || service.ClientToken != "local-token" {
+		t.Errorf("Vault is read without the vault path: %v, %v", err, paths)
+	}
+	service.Vault = "secret/data/onelogin"
+	if err := applyVault(service); err != nil {
+		t.Fatal(err)
+	}
+	if service.ClientToken != "vault-token" || service.ClientSecret != "vault-secret" || service.Subdomain != "example" {
+		t.Errorf("%#v is unexpected", service)
+	}
+	s := newLoginSession(&config.Config{Service: map[string]*config.ServiceConfig{"default": service}})
+	password, err := s.vaultPassword(config.DefaultService)
+	if err != nil || string(password) != "vault-password" {
+		t.Errorf("%v, %v is unexpected", password, err)
+	}
+}
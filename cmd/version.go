@@ -18,11 +18,15 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/i18n"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/release"
 )
 
 var (
 	// Version is release version
 	Version string
+	check   bool
 )
 
 // versionCmd represents the version command
@@ -35,9 +39,22 @@ var versionCmd = &cobra.Command{
 			Version = "Unknown"
 		}
 		fmt.Println(fmt.Sprintf("OneLogin AWS Connector version: %v", Version))
+		if !check {
+			return
+		}
+		latest, err := release.Latest()
+		if err != nil {
+			errorExit(err)
+		}
+		if release.Newer(Version, latest.Version()) {
+			fmt.Println(i18n.Sprintf("New version %v is available. Run `onelogin-aws-connector self-update` to update.", latest.Version()))
+		} else {
+			fmt.Println(i18n.T("This is the latest version"))
+		}
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVarP(&check, "check", "", false, "Check the latest release")
 }
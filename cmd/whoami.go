@@ -0,0 +1,92 @@
+// Copyright © 2017 LIFULL Co., Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Print the identity of AWS credentials",
+	Long:  `Whoami is printing AWS account, ARN and expiration of the credentials created by login.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		creds, err := loadCachedCredentials(cacheDir, awsProfile)
+		if err != nil {
+			errorExit(err)
+		}
+		if creds == nil {
+			errorExit(errors.Errorf("%s profile is not logged in. Please run `onelogin-aws-connector login`", awsProfile))
+		}
+		s, err := session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(*creds.AccessKeyId, *creds.SecretAccessKey, *creds.SessionToken),
+		})
+		if err != nil {
+			errorExit(err)
+		}
+		s.Handlers.Build.PushBackNamed(useragent.Handler)
+		if err := whoami(os.Stdout, sts.New(s), creds); err != nil {
+			errorExit(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(whoamiCmd)
+}
+
+func whoami(out io.Writer, api stsiface.STSAPI, creds *sts.Credentials) error {
+	identity, err := api.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return err
+	}
+	if output == outputJSON {
+		return writeJSON(out, struct {
+			Account    string     `json:"account"`
+			Arn        string     `json:"arn"`
+			UserID     string     `json:"user_id"`
+			Expiration *time.Time `json:"expiration"`
+		}{
+			Account:    aws.StringValue(identity.Account),
+			Arn:        aws.StringValue(identity.Arn),
+			UserID:     aws.StringValue(identity.UserId),
+			Expiration: creds.Expiration,
+		})
+	}
+	fmt.Fprintf(out, "Account:\t%s\n", aws.StringValue(identity.Account))
+	fmt.Fprintf(out, "ARN:\t\t%s\n", aws.StringValue(identity.Arn))
+	if creds.Expiration != nil {
+		remaining := time.Until(*creds.Expiration).Truncate(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		fmt.Fprintf(out, "Expiration:\t%s (%s remaining)\n", creds.Expiration.Local().Format(time.RFC3339), remaining)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+type whoamiSTSMock struct {
+	stsiface.STSAPI
+	Output *sts.GetCallerIdentityOutput
+	Error  error
+}
+
+func (s *whoamiSTSMock) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return s.Output, s.Error
+}
+
+func TestWhoami(t *testing.T) {
+	expiration := time.Now().Add(time.Hour)
+	api := &whoamiSTSMock{
+		Output: &sts.GetCallerIdentityOutput{
+			Account: aws.String("123456789012"),
+			Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/admin/username"),
+		},
+	}
+	var buf bytes.Buffer
+	if err := whoami(&buf, api, &sts.Credentials{Expiration: &expiration}); err != nil {
+		t.Errorf("%#v", err)
+	}
+	for _, expected := range []string{
+		"Account:\t123456789012\n",
+		"ARN:\t\tarn:aws:sts::123456789012:assumed-role/admin/username\n",
+		fmt.Sprintf("Expiration:\t%s (", expiration.Local().Format(time.RFC3339)),
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("'%v' does not contain '%v'", buf.String(), expected)
+		}
+	}
+
+	api.Error = fmt.Errorf("ExpiredToken")
+	if err := whoami(&buf, api, &sts.Credentials{}); err == nil || err.Error() != "ExpiredToken" {
+		t.Errorf("%v is not equal %v", err, "ExpiredToken")
+	}
+}
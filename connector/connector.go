@@ -0,0 +1,295 @@
+// Package connector embeds the login flow of onelogin-aws-connector into other Go tools.
+// It reads the profile of the config file written by the CLI, generates the SAML assertion with OneLogin
+// and assumes the role of the profile, without writing ~/.aws/credentials:
+//
+//	creds, err := connector.Login(ctx, connector.WithProfile("prod"), connector.WithMFAHandler(h))
+//
+// The client secrets and passwords in Vault or the keychain, and app_name of the profile are supported only by the CLI
+package connector
+
+import (
+	"context"
+	"os"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/config"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+)
+
+// profileEnv is the profile used without WithProfile, the other environment variables of the CLI are in the config package
+const profileEnv = "AWS_PROFILE"
+
+// Login generates the SAML assertion of the profile and returns the credentials of the assumed role.
+// OneLogin tokens are cached in the cache directory like the CLI
+func Login(ctx context.Context, opts ...Option) (*sts.Credentials, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c, err := config.Load(o.configFile)
+	if err != nil {
+		return nil, err
+	}
+	app, ok := c.App[o.profile]
+	if !ok {
+		return nil, errors.Errorf("%s profile is not exists", o.profile)
+	}
+	if app.AppID == "" {
+		return nil, errors.Errorf("%s profile has no app_id, app_name is resolved only by the CLI", o.profile)
+	}
+	service, err := c.AppService(*app)
+	if err != nil {
+		return nil, err
+	}
+	applyEnv(&service)
+	if service.Endpoint, err = service.APIEndpoint(); err != nil {
+		return nil, err
+	}
+	if service.Endpoint == "" {
+		return nil, errors.New("Endpoint is not exists")
+	}
+	if service.Subdomain == "" {
+		return nil, errors.New("Subdomain is not exists")
+	}
+	timeouts, err := service.Timeouts()
+	if err != nil {
+		return nil, err
+	}
+	client := o.httpClient
+	if client == nil {
+		if client, err = transport.Shared(service.TransportOptions()); err != nil {
+			return nil, err
+		}
+	}
+
+	secret.Register(service.ClientSecret)
+	subdomains := map[string]onelogin.ClientCredentials{}
+	for subdomain, pair := range service.Credentials {
+		secret.Register(pair.ClientSecret)
+		subdomains[subdomain] = onelogin.ClientCredentials{ClientToken: pair.ClientToken, ClientSecret: pair.ClientSecret}
+	}
+	var store onelogin.CredentialsStore
+	if o.cacheDir != "" {
+		store = onelogin.FileStore{Dir: o.cacheDir}
+	}
+	oneloginConfig := onelogin.NewConfig(
+		onelogin.WithEndpoint(service.Endpoint),
+		onelogin.WithClientCredentials(service.ClientToken, service.ClientSecret),
+		onelogin.WithHTTPClient(client),
+		onelogin.WithCredentialsStore(store),
+		onelogin.WithSubdomains(subdomains),
+	).ForSubdomain(service.Subdomain)
+	if oneloginConfig.ClientToken == "" {
+		return nil, errors.New("ClientToken is not exists")
+	}
+	if oneloginConfig.ClientSecret == "" {
+		return nil, errors.New("ClientSecret is not exists")
+	}
+
+	l, err := newLogin(c, *app, service, oneloginConfig, o)
+	if err != nil {
+		return nil, err
+	}
+	l.Params.VerifyFactorTimeout = timeouts.VerifyFactor
+	// another IdP of WithIdentityProvider authenticates the user by itself
+	if o.idp == nil {
+		if err := oneloginConfig.SaveWithContext(ctx); err != nil {
+			return nil, err
+		}
+		if l.Params.Password, err = o.password(); err != nil {
+			return nil, err
+		}
+		defer l.Params.Password.Wipe()
+		if len(l.Params.Password) == 0 {
+			return nil, errors.Errorf("password is required, give it by WithPassword or %s", config.PasswordEnv)
+		}
+	}
+	creds, err := l.LoginWithContext(ctx, o.event())
+	if err != nil {
+		return nil, err
+	}
+	secret.Register(*creds.SecretAccessKey, *creds.SessionToken)
+	return creds, nil
+}
+
+// newLogin creates Login of the app like the login command, except the role and the duration given by the options
+func newLogin(c *config.Config, app config.AppConfig, service config.ServiceConfig, oneloginConfig *onelogin.Config, o *options) (*login.Login, error) {
+	version, negotiate, err := config.ParseAPIVersion(app.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	sdk, err := config.ParseAWSSDK(app.AWSSDK)
+	if err != nil {
+		return nil, err
+	}
+	stsRegion, err := app.AssumeRoleRegion(app.Region)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := app.SessionDuration()
+	if err != nil {
+		return nil, err
+	}
+	if o.duration != 0 {
+		if duration, err = config.ParseDuration(o.duration.String()); err != nil {
+			return nil, err
+		}
+	}
+	roleArn, principalArn := c.ResolveRole(app.RoleArn), app.PrincipalArn
+	if o.roleArn != "" {
+		roleArn, principalArn = c.ResolveRole(o.roleArn), ""
+	}
+	l := login.New(oneloginConfig, &login.Parameters{
+		UsernameOrEmail:     service.UsernameOrEmail,
+		AppID:               app.AppID,
+		Subdomain:           service.Subdomain,
+		PrincipalArn:        principalArn,
+		RoleArn:             roleArn,
+		DurationSeconds:     duration,
+		APIVersion:          version,
+		NegotiateAPIVersion: negotiate,
+		OnResponse:          o.onResponse,
+		Policy:              app.Policy,
+		PolicyArns:          app.PolicyArns,
+		ChainRoleArn:        c.ResolveRole(app.ChainRoleArn),
+		ChainExternalID:     app.ChainExternalID,
+		ChainSessionName:    app.ChainSessionName,
+	})
+	l.SDKVersion = sdk
+	l.Region = stsRegion
+	l.STSEndpoint = app.STSEndpoint
+	if o.sts != nil {
+		l.STS = o.sts
+	}
+	l.IdP = o.idp
+	return l, nil
+}
+
+// newOptions applies the options to the defaults of the CLI
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		profile:  os.Getenv(profileEnv),
+		password: envPassword,
+	}
+	if o.profile == "" {
+		o.profile = "default"
+	}
+	home, err := homedir.Dir()
+	if err == nil {
+		dir := path.Join(home, ".onelogin-aws-connector")
+		o.configFile = path.Join(dir, "config.toml")
+		o.cacheDir = path.Join(dir, "cache")
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.configFile == "" {
+		// err is nil if the home directory is found but WithConfigFile cleared the path
+		if err == nil {
+			return nil, errors.New("the config file is unknown, give it by WithConfigFile")
+		}
+		return nil, errors.Wrap(err, "the config file is unknown, give it by WithConfigFile")
+	}
+	if o.cacheDir != "" {
+		if err := os.MkdirAll(o.cacheDir, secret.DirMode); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// envPassword returns the password of config.PasswordEnv or its alias
+func envPassword() (secret.Bytes, error) {
+	return secret.Bytes(config.EnvPassword()), nil
+}
+
+// applyEnv fills the missing client credentials and the proxy password from the environment variables
+func applyEnv(service *config.ServiceConfig) {
+	config.EnvCredentials(service)
+	if service.ProxyUser != "" && service.ProxyPassword == "" {
+		service.ProxyPassword = os.Getenv(config.ProxyPasswordEnv)
+		secret.Register(service.ProxyPassword)
+	}
+}
+
+// event answers login.Event with the handlers of the options,
+// the optional events of login are forwarded to MFAHandler if it implements them
+type event struct {
+	o *options
+}
+
+var (
+	_ login.ApprovalEvent = &event{}
+	_ login.ProgressEvent = &event{}
+	_ login.ConfirmEvent  = &event{}
+	_ login.RetryEvent    = &event{}
+)
+
+func (o *options) event() login.Event {
+	return &event{o: o}
+}
+
+func (e *event) ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error) {
+	if e.o.mfa == nil {
+		return 0, errors.New("MFA device is required, but no MFAHandler is given")
+	}
+	return e.o.mfa.ChooseDeviceIndex(devices)
+}
+
+func (e *event) InputMFAToken() (secret.Bytes, error) {
+	if e.o.mfa == nil {
+		return nil, errors.New("MFA token is required, but no MFAHandler is given")
+	}
+	return e.o.mfa.InputMFAToken()
+}
+
+func (e *event) ChooseRoleIndex(roles []login.Role) (int, error) {
+	if e.o.role == nil {
+		return 0, errors.New("role is required, but no RoleHandler is given, configure role_arn or give it by WithRole")
+	}
+	return e.o.role(roles)
+}
+
+func (e *event) WaitApproval(remaining time.Duration) {
+	if approval, ok := e.o.mfa.(login.ApprovalEvent); ok {
+		approval.WaitApproval(remaining)
+	}
+}
+
+func (e *event) ApprovalDone() {
+	if approval, ok := e.o.mfa.(login.ApprovalEvent); ok {
+		approval.ApprovalDone()
+	}
+}
+
+func (e *event) Progress(state login.State) {
+	if p, ok := e.o.mfa.(login.ProgressEvent); ok {
+		p.Progress(state)
+	}
+}
+
+func (e *event) Confirm(message string) (bool, error) {
+	if c, ok := e.o.mfa.(login.ConfirmEvent); ok {
+		return c.Confirm(message)
+	}
+	return false, nil
+}
+
+func (e *event) RetrySTS(attempt int, delay time.Duration, err error) bool {
+	if r, ok := e.o.mfa.(login.RetryEvent); ok {
+		return r.RetrySTS(attempt, delay, err)
+	}
+	return true
+}
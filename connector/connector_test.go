@@ -0,0 +1,184 @@
+package connector
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login/logintest"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// SAML is the assertion of the prod and admin roles
+var SAML = base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">
+  <saml:Assertion>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="https://aws.amazon.com/SAML/Attributes/Role">
+        <saml:AttributeValue>arn:aws:iam::123456789012:role/prod,arn:aws:iam::123456789012:saml-provider/onelogin</saml:AttributeValue>
+        <saml:AttributeValue>arn:aws:iam::123456789012:role/admin,arn:aws:iam::123456789012:saml-provider/onelogin</saml:AttributeValue>
+      </saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`))
+
+// newOneLogin serves OneLogin API, whose SAML assertion API requires MFA if mfa is true
+func newOneLogin(t *testing.T, mfa bool) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/oauth2/v2/token":
+			fmt.Fprintf(w, `{"access_token": "access-token", "created_at": "%s", "expires_in": 3600, "refresh_token": "refresh-token", "token_type": "bearer"}`, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+		case "/api/2/saml_assertion":
+			if mfa {
+				fmt.Fprintln(w, `{"state_token": "state-token", "message": "MFA is required for this user", "devices": [{"device_id": 1, "device_type": "Google Authenticator"}]}`)
+				return
+			}
+			fmt.Fprintf(w, `{"data": "%s", "message": "Success"}`, SAML)
+		case "/api/2/saml_assertion/verify_factor":
+			body, _ := ioutil.ReadAll(r.Body)
+			if !strings.Contains(string(body), `"otp_token":"123456"`) {
+				t.Errorf("%s is unexpected", body)
+			}
+			fmt.Fprintf(w, `{"data": "%s", "message": "Success"}`, SAML)
+		default:
+			t.Errorf("%s is requested", r.URL.Path)
+		}
+	}))
+}
+
+// writeConfig writes the config file of the prod profile using the server
+func writeConfig(t *testing.T, dir string, ts *httptest.Server) string {
+	u, _ := url.Parse(ts.URL)
+	file := path.Join(dir, "config.toml")
+	content := fmt.Sprintf(`version = 1
+
+[service.default]
+endpoint = "%s"
+client_token = "client-token"
+client_secret = "client-secret"
+subdomain = "example"
+username_or_email = "user@example.com"
+
+[app.prod]
+app_id = "123"
+role_arn = "arn:aws:iam::123456789012:role/prod"
+principal_arn = "arn:aws:iam::123456789012:saml-provider/onelogin"
+duration = "2h"
+`, u.Host)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLogin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		name     string
+		mfa      bool
+		opts     []Option
+		role     string
+		duration int64
+		wantErr  string
+	}{
+		{name: "no MFA", role: "arn:aws:iam::123456789012:role/prod", duration: 7200},
+		{name: "MFA", mfa: true, opts: []Option{WithMFAHandler(&logintest.Event{MFATokens: []string{"123456"}})}, role: "arn:aws:iam::123456789012:role/prod", duration: 7200},
+		{name: "no MFAHandler", mfa: true, wantErr: "MFA token is required"},
+		{name: "role and duration", opts: []Option{WithRole("arn:aws:iam::123456789012:role/admin"), WithDuration(time.Hour)}, role: "arn:aws:iam::123456789012:role/admin", duration: 3600},
+		{name: "no profile", opts: []Option{WithProfile("dev")}, wantErr: "dev profile is not exists"},
+		{name: "no password", opts: []Option{WithPassword(nil)}, wantErr: "password is required"},
+		{name: "identity provider", opts: []Option{WithPassword(nil), WithIdentityProvider(login.IdentityProviderFunc(func(ctx context.Context, logic login.Event) (secret.Bytes, error) {
+			return secret.Bytes(SAML), nil
+		}))}, role: "arn:aws:iam::123456789012:role/prod", duration: 7200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newOneLogin(t, tt.mfa)
+			defer ts.Close()
+			fakeSTS := &logintest.STS{}
+			opts := append([]Option{
+				WithConfigFile(writeConfig(t, dir, ts)),
+				WithCacheDir(""),
+				WithProfile("prod"),
+				WithPassword(secret.Bytes("password")),
+				WithHTTPClient(ts.Client()),
+				WithSTS(fakeSTS),
+			}, tt.opts...)
+			creds, err := Login(context.Background(), opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%v is not %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *creds.AccessKeyId != "ASIAFAKEACCESSKEYID" {
+				t.Errorf("%#v is unexpected", creds)
+			}
+			inputs := fakeSTS.AssumeRoleWithSAMLInputs()
+			if len(inputs) != 1 || *inputs[0].RoleArn != tt.role || *inputs[0].DurationSeconds != tt.duration || *inputs[0].SAMLAssertion != SAML {
+				t.Errorf("%#v is unexpected", inputs)
+			}
+		})
+	}
+}
+
+func TestLoginCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Login(ctx, WithConfigFile("config.toml")); err != context.Canceled {
+		t.Errorf("%v is not canceled", err)
+	}
+}
+
+func TestLoginWithoutConfigFile(t *testing.T) {
+	if _, err := Login(context.Background(), WithConfigFile("")); err == nil || err.Error() != "the config file is unknown, give it by WithConfigFile" {
+		t.Errorf("%v does not tell the missing config file", err)
+	}
+}
+
+func TestLoginWithResponseHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "connector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := newOneLogin(t, true)
+	defer ts.Close()
+	paths := []string{}
+	_, err = Login(context.Background(),
+		WithConfigFile(writeConfig(t, dir, ts)),
+		WithCacheDir(""),
+		WithProfile("prod"),
+		WithPassword(secret.Bytes("password")),
+		WithHTTPClient(ts.Client()),
+		WithSTS(&logintest.STS{}),
+		WithMFAHandler(&logintest.Event{MFATokens: []string{"123456"}}),
+		WithResponseHook(func(m samlassertion.Metadata) {
+			paths = append(paths, fmt.Sprintf("%s [%d]", m.Path, m.StatusCode))
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[/api/2/saml_assertion [200] /api/2/saml_assertion/verify_factor [200]]"
+	if fmt.Sprint(paths) != expected {
+		t.Errorf("%v is not %s", paths, expected)
+	}
+}
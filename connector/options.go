@@ -0,0 +1,138 @@
+package connector
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+
+	"github.com/lifull-dev/onelogin-aws-connector/cmd/login"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// MFAHandler chooses the MFA device and inputs the OTP token when OneLogin requires MFA.
+// It may implement login.ApprovalEvent, login.ProgressEvent, login.ConfirmEvent and login.RetryEvent to reflect the login state
+type MFAHandler interface {
+	ChooseDeviceIndex(devices []samlassertion.GenerateResponseFactorDevice) (int, error)
+	// InputMFAToken returns the OTP token, which is wiped after verified
+	InputMFAToken() (secret.Bytes, error)
+}
+
+// RoleHandler chooses the role when the profile has no role_arn and the user has several roles
+type RoleHandler func(roles []login.Role) (int, error)
+
+// PasswordFunc returns the password of the user, which is wiped after the SAML assertion is generated
+type PasswordFunc func() (secret.Bytes, error)
+
+// Option configures Login
+type Option func(*options)
+
+type options struct {
+	configFile string
+	profile    string
+	cacheDir   string
+	password   PasswordFunc
+	mfa        MFAHandler
+	role       RoleHandler
+	roleArn    string
+	duration   time.Duration
+	httpClient *http.Client
+	sts        stsiface.STSAPI
+	idp        login.IdentityProvider
+	onResponse func(samlassertion.Metadata)
+}
+
+// WithConfigFile reads the config file instead of ~/.onelogin-aws-connector/config.toml
+func WithConfigFile(file string) Option {
+	return func(o *options) {
+		o.configFile = file
+	}
+}
+
+// WithProfile logs in to the profile instead of AWS_PROFILE or default
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithCacheDir caches OneLogin tokens in the directory instead of ~/.onelogin-aws-connector/cache,
+// the empty directory disables the cache
+func WithCacheDir(dir string) Option {
+	return func(o *options) {
+		o.cacheDir = dir
+	}
+}
+
+// WithPassword uses the password instead of ONELOGIN_AWS_PASSWORD or its alias ONELOGIN_PASSWORD,
+// it is copied so the caller can wipe its own one
+func WithPassword(password secret.Bytes) Option {
+	copied := append(secret.Bytes(nil), password...)
+	return WithPasswordFunc(func() (secret.Bytes, error) {
+		return append(secret.Bytes(nil), copied...), nil
+	})
+}
+
+// WithPasswordFunc calls the function for the password, e.g. to prompt the user only when it is needed
+func WithPasswordFunc(f PasswordFunc) Option {
+	return func(o *options) {
+		o.password = f
+	}
+}
+
+// WithMFAHandler answers MFA of OneLogin, Login fails with the MFA requirement without the handler
+func WithMFAHandler(h MFAHandler) Option {
+	return func(o *options) {
+		o.mfa = h
+	}
+}
+
+// WithRoleHandler chooses one of the roles, Login fails with several roles without the handler
+func WithRoleHandler(h RoleHandler) Option {
+	return func(o *options) {
+		o.role = h
+	}
+}
+
+// WithRole assumes the role ARN or alias instead of the configured one
+func WithRole(role string) Option {
+	return func(o *options) {
+		o.roleArn = role
+	}
+}
+
+// WithDuration is the session duration instead of the configured one, which must be whole seconds
+func WithDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.duration = d
+	}
+}
+
+// WithHTTPClient sends OneLogin API and STS requests with the client instead of the one of the configured proxy and TLS
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithSTS assumes the role with the STS client instead of the one created for the profile
+func WithSTS(api stsiface.STSAPI) Option {
+	return func(o *options) {
+		o.sts = api
+	}
+}
+
+// WithIdentityProvider generates the SAML assertion with the provider instead of OneLogin of the profile
+func WithIdentityProvider(idp login.IdentityProvider) Option {
+	return func(o *options) {
+		o.idp = idp
+	}
+}
+
+// WithResponseHook calls the hook with the status, the request ID, the rate limit and the latency of every request to SAML assertion API
+func WithResponseHook(hook func(samlassertion.Metadata)) Option {
+	return func(o *options) {
+		o.onResponse = hook
+	}
+}
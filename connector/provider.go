@@ -0,0 +1,126 @@
+package connector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// ProviderName is the provider name of the credentials
+const ProviderName = "OneLoginProvider"
+
+// DefaultExpiryWindow refreshes the credentials before they expire, so in-flight requests are not signed with expired ones
+const DefaultExpiryWindow = 5 * time.Minute
+
+// Provider is credentials.Provider of AWS SDK for Go v1 logging in with the options when the credentials are retrieved first or expire.
+// The password and MFA are required again to refresh them, so the options should give them by functions and handlers
+type Provider struct {
+	// ExpiryWindow is DefaultExpiryWindow by NewProvider
+	ExpiryWindow time.Duration
+
+	opts  []Option
+	login func(ctx context.Context, opts ...Option) (*sts.Credentials, error)
+	mu    sync.Mutex
+	creds *sts.Credentials
+}
+
+// NewProvider returns the provider logging in lazily with the options
+func NewProvider(opts ...Option) *Provider {
+	return &Provider{
+		ExpiryWindow: DefaultExpiryWindow,
+		opts:         opts,
+		login:        Login,
+	}
+}
+
+// NewCredentials returns the credentials of AWS SDK for Go v1 retrieved by the provider
+func NewCredentials(opts ...Option) *credentials.Credentials {
+	return credentials.NewCredentials(NewProvider(opts...))
+}
+
+// Retrieve returns the cached credentials, or logs in if they are expired
+func (p *Provider) Retrieve() (credentials.Value, error) {
+	creds, err := p.retrieve(context.Background())
+	if err != nil {
+		return credentials.Value{ProviderName: ProviderName}, err
+	}
+	return credentials.Value{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		SessionToken:    *creds.SessionToken,
+		ProviderName:    ProviderName,
+	}, nil
+}
+
+// IsExpired returns true if the credentials are not retrieved yet or expire within ExpiryWindow
+func (p *Provider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.expired()
+}
+
+// ExpiresAt returns the expiration of the credentials, or the zero time if they are not retrieved yet
+func (p *Provider) ExpiresAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.creds == nil || p.creds.Expiration == nil {
+		return time.Time{}
+	}
+	return *p.creds.Expiration
+}
+
+// V2 returns aws.CredentialsProvider of AWS SDK for Go v2 sharing the credentials with the provider
+func (p *Provider) V2() awsv2.CredentialsProvider {
+	return providerV2{p}
+}
+
+func (p *Provider) expired() bool {
+	if p.creds == nil || p.creds.Expiration == nil {
+		return true
+	}
+	return !time.Now().Add(p.ExpiryWindow).Before(*p.creds.Expiration)
+}
+
+// retrieve logs in only once at the same time, the concurrent callers share the credentials
+func (p *Provider) retrieve(ctx context.Context) (*sts.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.expired() {
+		return p.creds, nil
+	}
+	creds, err := p.login(ctx, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.creds = creds
+	return creds, nil
+}
+
+// providerV2 is aws.CredentialsProvider of Provider
+type providerV2 struct {
+	p *Provider
+}
+
+// Retrieve returns the cached credentials, or logs in with the context if they are expired
+func (v providerV2) Retrieve(ctx context.Context) (awsv2.Credentials, error) {
+	creds, err := v.p.retrieve(ctx)
+	if err != nil {
+		return awsv2.Credentials{Source: ProviderName}, err
+	}
+	value := awsv2.Credentials{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		SessionToken:    *creds.SessionToken,
+		Source:          ProviderName,
+	}
+	// Expires is ExpiryWindow earlier, so aws.CredentialsCache refreshes them when Provider does
+	if creds.Expiration != nil {
+		value.CanExpire = true
+		value.Expires = creds.Expiration.Add(-v.p.ExpiryWindow)
+	}
+	return value, nil
+}
@@ -0,0 +1,95 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// newTestProvider returns the provider whose login returns the credentials expiring in the duration
+func newTestProvider(expiresIn time.Duration, err error) (*Provider, *int) {
+	count := 0
+	p := NewProvider(WithProfile("prod"))
+	p.login = func(ctx context.Context, opts ...Option) (*sts.Credentials, error) {
+		count++
+		if err != nil {
+			return nil, err
+		}
+		return &sts.Credentials{
+			AccessKeyId:     aws.String("access-key-id"),
+			SecretAccessKey: aws.String("secret-access-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(time.Now().Add(expiresIn)),
+		}, nil
+	}
+	return p, &count
+}
+
+func TestProvider_Retrieve(t *testing.T) {
+	p, count := newTestProvider(time.Hour, nil)
+	if !p.IsExpired() || !p.ExpiresAt().IsZero() {
+		t.Error("credentials must be expired before retrieved")
+	}
+	creds := credentials.NewCredentials(p)
+	for i := 0; i < 2; i++ {
+		value, err := creds.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.AccessKeyID != "access-key-id" || value.ProviderName != ProviderName {
+			t.Errorf("%#v is unexpected", value)
+		}
+	}
+	if *count != 1 {
+		t.Errorf("logged in %d times", *count)
+	}
+	if p.IsExpired() || p.ExpiresAt().Before(time.Now().Add(50*time.Minute)) {
+		t.Errorf("credentials expiring at %v are expired", p.ExpiresAt())
+	}
+}
+
+func TestProvider_RetrieveExpired(t *testing.T) {
+	// the credentials expire within the expiry window
+	p, count := newTestProvider(time.Minute, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := p.Retrieve(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *count != 2 || !p.IsExpired() {
+		t.Errorf("logged in %d times", *count)
+	}
+}
+
+func TestProvider_RetrieveError(t *testing.T) {
+	p, _ := newTestProvider(time.Hour, errors.New("login failed"))
+	if value, err := p.Retrieve(); err == nil || value.ProviderName != ProviderName {
+		t.Errorf("%#v, %v is unexpected", value, err)
+	}
+	if !p.IsExpired() {
+		t.Error("credentials must be expired after failure")
+	}
+}
+
+func TestProvider_V2(t *testing.T) {
+	p, count := newTestProvider(time.Hour, nil)
+	v2 := p.V2()
+	value, err := v2.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.AccessKeyID != "access-key-id" || value.Source != ProviderName || !value.CanExpire {
+		t.Errorf("%#v is unexpected", value)
+	}
+	if !value.Expires.Equal(p.ExpiresAt().Add(-DefaultExpiryWindow)) {
+		t.Errorf("%v is not earlier than %v by the window", value.Expires, p.ExpiresAt())
+	}
+	if _, err := p.Retrieve(); err != nil || *count != 1 {
+		t.Errorf("the credentials are not shared, logged in %d times: %v", *count, err)
+	}
+}
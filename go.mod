@@ -1,20 +1,34 @@
 module github.com/lifull-dev/onelogin-aws-connector
 
-go 1.13
+go 1.17
 
 require (
 	github.com/BurntSushi/toml v0.3.0
 	github.com/aws/aws-sdk-go v1.12.60
+	github.com/aws/aws-sdk-go-v2 v1.17.1
+	github.com/aws/aws-sdk-go-v2/service/sts v1.17.1
+	github.com/aws/smithy-go v1.13.4
 	github.com/go-ini/ini v1.32.0
-	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/jmespath/go-jmespath v0.0.0-20160202185014-0b12d6b521d8 // indirect
 	github.com/mitchellh/go-homedir v0.0.0-20161203194507-b8bc1bf76747
-	github.com/pkg/errors v0.8.0
-	github.com/smartystreets/goconvey v1.6.4 // indirect
+	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v0.0.1
-	github.com/spf13/pflag v1.0.0 // indirect
-	github.com/stretchr/testify v1.4.0 // indirect
+	github.com/spf13/pflag v1.0.0
 	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
-	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553 // indirect
+	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.1.25 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.4.19 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/presigned-url v1.9.19 // indirect
+	github.com/cpuguy83/go-md2man v1.0.10 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/russross/blackfriday v1.5.2 // indirect
+	github.com/smartystreets/goconvey v1.6.4 // indirect
+	github.com/stretchr/testify v1.4.0 // indirect
+	golang.org/x/text v0.3.0 // indirect
 	gopkg.in/ini.v1 v1.51.1 // indirect
+	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
@@ -0,0 +1,158 @@
+package apiresponse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// SnippetLength is the max length of the body shown in Error
+const SnippetLength = 200
+
+// RequestIDHeaders are response headers which may have the ID of the request, in the order of precedence
+var RequestIDHeaders = []string{"X-Request-Id", "Request-Id"}
+
+// Logf logs every decoded response with its request ID if it is set, e.g. log.Printf for debug logs
+var Logf func(format string, v ...interface{})
+
+var (
+	htmlBlock = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTag   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Error is returned when the body of OneLogin API response is not JSON,
+// such as a maintenance page, a block page of WAF or a truncated body
+type Error struct {
+	StatusCode  int
+	ContentType string
+	// Snippet is the beginning of the body without HTML tags and credentials
+	Snippet string
+	// RequestID is the ID of the request given by OneLogin
+	RequestID string
+	// Err is the error of decoding JSON
+	Err error
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("unexpected response from OneLogin API: [%d %s]", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.ContentType != "" {
+		message += " " + e.ContentType
+	}
+	if e.Snippet != "" {
+		message += ": " + e.Snippet
+	}
+	message = fmt.Sprintf("%s (%v)", message, e.Err)
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" (request ID: %s)", e.RequestID)
+	}
+	return message
+}
+
+// Unwrap returns the error of decoding JSON
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrUnexpectedResponse and the failure kind of the status code
+func (e *Error) Is(target error) bool {
+	return target == ErrUnexpectedResponse || target != nil && target == Kind(e.StatusCode)
+}
+
+// RequestError is an error of the request annotated with its request ID,
+// so that support tickets to OneLogin can reference the failing call
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%v (request ID: %s)", e.Err, e.RequestID)
+}
+
+// Unwrap returns the annotated error
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the annotated error for errors.Cause of github.com/pkg/errors
+func (e *RequestError) Cause() error {
+	return e.Err
+}
+
+// RequestID returns the ID of the request in the response headers, or empty if OneLogin does not send it
+func RequestID(res *http.Response) string {
+	if res == nil {
+		return ""
+	}
+	for _, name := range RequestIDHeaders {
+		if id := res.Header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// WithRequestID annotates the error with the request ID of the response, it returns err as is if there is no request ID
+func WithRequestID(res *http.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+	id := RequestID(res)
+	if id == "" {
+		return err
+	}
+	return &RequestError{RequestID: id, Err: err}
+}
+
+// Decode unmarshals the JSON body of the response into v, and returns *Error if the body is not JSON
+func Decode(res *http.Response, body []byte, v interface{}) error {
+	if Logf != nil {
+		logResponse(res)
+	}
+	err := json.Unmarshal(body, v)
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Snippet:     Snippet(body),
+		RequestID:   RequestID(res),
+		Err:         err,
+	}
+}
+
+func logResponse(res *http.Response) {
+	request := ""
+	if res.Request != nil && res.Request.URL != nil {
+		// the query is not logged, it may have the username
+		request = fmt.Sprintf("%s %s ", res.Request.Method, res.Request.URL.Path)
+	}
+	id := RequestID(res)
+	if id == "" {
+		id = "-"
+	}
+	Logf("OneLogin API %s[%d] request ID: %s\n", request, res.StatusCode, id)
+}
+
+// Snippet returns the beginning of the body to be shown in errors, HTML tags are removed and credentials are redacted
+func Snippet(body []byte) string {
+	s := string(body)
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "?")
+	}
+	if strings.HasPrefix(strings.TrimSpace(s), "<") {
+		s = htmlBlock.ReplaceAllString(s, " ")
+		s = htmlTag.ReplaceAllString(s, " ")
+	}
+	s = secret.Filter(strings.Join(strings.Fields(s), " "))
+	if utf8.RuneCountInString(s) > SnippetLength {
+		s = string([]rune(s)[:SnippetLength]) + "..."
+	}
+	return s
+}
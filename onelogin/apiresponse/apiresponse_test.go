@@ -0,0 +1,94 @@
+package apiresponse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	var v struct {
+		Message string `json:"message"`
+	}
+	res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	if err := Decode(res, []byte(`{"message":"Success"}`), &v); err != nil {
+		t.Errorf("%v", err)
+	}
+	if v.Message != "Success" {
+		t.Errorf("%s is not Success", v.Message)
+	}
+	res = &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Content-Type": {"text/html"}}}
+	err := Decode(res, []byte("<html><head><style>body {}</style></head><body><h1>Down for\n maintenance</h1></body></html>"), &v)
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("%v is not *Error", err)
+	}
+	if e.StatusCode != http.StatusServiceUnavailable || e.ContentType != "text/html" || e.Snippet != "Down for maintenance" {
+		t.Errorf("%#v is unexpected", e)
+	}
+	expected := "unexpected response from OneLogin API: [503 Service Unavailable] text/html: Down for maintenance (invalid character '<' looking for beginning of value)"
+	if err.Error() != expected {
+		t.Errorf("%s is not %s", err.Error(), expected)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"", ""},
+		{`{"access_token":"abcdefgh","refresh_tok`, `{"access_token":"********","refresh_tok`},
+		{"<p>Request blocked.</p>\n<p>ID: 1234</p>", "Request blocked. ID: 1234"},
+		{strings.Repeat("a", SnippetLength+1), strings.Repeat("a", SnippetLength) + "..."},
+	}
+	for _, tt := range tests {
+		if actual := Snippet([]byte(tt.body)); actual != tt.expected {
+			t.Errorf("%s is not %s", actual, tt.expected)
+		}
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	base := errors.New("[400] bad request: invalid app")
+	if err := WithRequestID(&http.Response{Header: http.Header{}}, base); err != base {
+		t.Errorf("%v is annotated without the request ID", err)
+	}
+	if err := WithRequestID(nil, base); err != base {
+		t.Errorf("%v is annotated without the response", err)
+	}
+	res := &http.Response{Header: http.Header{"X-Request-Id": {"abc-123"}}}
+	err := WithRequestID(res, base)
+	if err.Error() != "[400] bad request: invalid app (request ID: abc-123)" {
+		t.Errorf("%s is unexpected", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Errorf("%v does not unwrap to %v", err, base)
+	}
+	if err := WithRequestID(res, nil); err != nil {
+		t.Errorf("%v is not nil", err)
+	}
+}
+
+func TestDecodeRequestID(t *testing.T) {
+	defer func() { Logf = nil }()
+	logs := []string{}
+	Logf = func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}
+	req, _ := http.NewRequest("GET", "https://api.us.onelogin.com/api/1/users?email=user@example.com", nil)
+	res := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{"Request-Id": {"abc-123"}}, Request: req}
+	var v struct{}
+	err := Decode(res, []byte("Bad Gateway"), &v)
+	var e *Error
+	if !errors.As(err, &e) || e.RequestID != "abc-123" || !strings.HasSuffix(err.Error(), "(request ID: abc-123)") {
+		t.Errorf("%v is unexpected", err)
+	}
+	expected := []string{"OneLogin API GET /api/1/users [502] request ID: abc-123\n"}
+	if !reflect.DeepEqual(logs, expected) {
+		t.Errorf("%q is not equal %q", logs, expected)
+	}
+}
@@ -0,0 +1,59 @@
+package apiresponse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// failure kinds of OneLogin API, errors returned by API clients match them with errors.Is
+var (
+	// ErrUnauthorized is returned when the client credentials, the access token or the user credentials are rejected
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the API client has no permission of the request
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is returned when the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is returned when the request is rejected by the rate limit
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerError is returned when OneLogin API fails with 5xx status
+	ErrServerError = errors.New("server error")
+	// ErrUnexpectedResponse is returned when the body of the response is not JSON, it is matched by *Error
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+// Kind returns the failure kind of the status code, or nil if the code has no kind
+func Kind(code int) error {
+	switch {
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500:
+		return ErrServerError
+	}
+	return nil
+}
+
+// StatusError is the error status in the JSON body of OneLogin API
+type StatusError struct {
+	Code    int
+	Type    string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("[%d] %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("[%d] %s: %s", e.Code, e.Type, e.Message)
+}
+
+// Unwrap returns the failure kind of the status code for errors.Is
+func (e *StatusError) Unwrap() error {
+	return Kind(e.Code)
+}
@@ -0,0 +1,52 @@
+package apiresponse
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected error
+	}{
+		{401, ErrUnauthorized},
+		{403, ErrForbidden},
+		{404, ErrNotFound},
+		{429, ErrRateLimited},
+		{503, ErrServerError},
+		{400, nil},
+	}
+	for _, tt := range tests {
+		status := &StatusError{Code: tt.code, Type: "type", Message: "message"}
+		err := pkgerrors.Wrap(&RequestError{RequestID: "abc-123", Err: status}, "failed")
+		for _, kind := range []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrServerError} {
+			if errors.Is(err, kind) != (kind == tt.expected) {
+				t.Errorf("[%d] errors.Is(%v, %v) is %v", tt.code, err, kind, !(kind == tt.expected))
+			}
+		}
+		var e *StatusError
+		if !errors.As(err, &e) || e != status {
+			t.Errorf("[%d] %v is not *StatusError", tt.code, err)
+		}
+	}
+	if err := (&StatusError{Code: 401, Type: "Unauthorized", Message: "Invalid Token"}); err.Error() != "[401] Unauthorized: Invalid Token" {
+		t.Errorf("%s is unexpected message", err)
+	}
+	if err := (&StatusError{Code: 400, Message: "Invalid client"}); err.Error() != "[400] Invalid client" {
+		t.Errorf("%s is unexpected message", err)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := pkgerrors.Wrap(&Error{StatusCode: http.StatusServiceUnavailable, Err: errors.New("invalid character")}, "failed")
+	if !errors.Is(err, ErrUnexpectedResponse) || !errors.Is(err, ErrServerError) {
+		t.Errorf("%v is not ErrUnexpectedResponse and ErrServerError", err)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		t.Errorf("%v is ErrRateLimited", err)
+	}
+}
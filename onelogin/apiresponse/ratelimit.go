@@ -0,0 +1,40 @@
+package apiresponse
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the rate limit of OneLogin API, told by X-RateLimit-* headers or Get Rate Limit API
+type RateLimit struct {
+	// Limit is the number of calls allowed in the window
+	Limit int
+	// Remaining is the number of calls left in the window
+	Remaining int
+	// Reset is when the window is reset
+	Reset time.Time
+}
+
+// Low returns true if the remaining calls are less than the percent of the limit
+func (r *RateLimit) Low(percent int) bool {
+	return r.Limit > 0 && r.Remaining*100 < r.Limit*percent
+}
+
+// ParseRateLimit returns nil if the response has no rate limit headers.
+// X-RateLimit-Reset of OneLogin is the number of seconds until the window is reset
+func ParseRateLimit(header http.Header, now time.Time) *RateLimit {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return nil
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+	r := &RateLimit{Limit: limit, Remaining: remaining}
+	if reset, err := strconv.Atoi(header.Get("X-RateLimit-Reset")); err == nil {
+		r.Reset = now.Add(time.Duration(reset) * time.Second)
+	}
+	return r
+}
@@ -0,0 +1,25 @@
+package apiresponse
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	if r := ParseRateLimit(header, now); r != nil {
+		t.Errorf("%#v is parsed without headers", r)
+	}
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "400")
+	header.Set("X-RateLimit-Reset", "60")
+	r := ParseRateLimit(header, now)
+	if r == nil || r.Limit != 5000 || r.Remaining != 400 || !r.Reset.Equal(now.Add(time.Minute)) {
+		t.Fatalf("%#v is unexpected", r)
+	}
+	if !r.Low(10) || r.Low(5) {
+		t.Errorf("%d/%d is not low only below 10%%", r.Remaining, r.Limit)
+	}
+}
@@ -1,68 +1,184 @@
 package onelogin
 
 import (
-	"fmt"
-	"os"
-	"path"
+	"context"
+	"net/http"
 
-	"github.com/BurntSushi/toml"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
 )
 
-// CacheDir is credentials cache dir
+// CacheDir is credentials cache dir used when Config has no Store, nothing is cached if it is empty
 var CacheDir string
 
-// Config provides configuration for API Clients
+// Config provides configuration for API Clients.
+// The credentials are safe for concurrent use, but the fields must be set before the config is shared
 type Config struct {
 	Endpoint     string
 	ClientToken  string
 	ClientSecret string
 	Credentials  *credentials.Credentials
+	// HTTPClient is used by API clients created with the config, which is set by SetHTTPClient.
+	// It is transport.DefaultClient unless another one is injected, so the requests share kept-alive connections
+	HTTPClient *http.Client
+	// Store keeps the tokens between runs, they are cached in CacheDir if it is nil
+	Store CredentialsStore
+	// Subdomains has the client credentials of other OneLogin tenants by their subdomains, which are resolved by ForSubdomain
+	Subdomains map[string]ClientCredentials
 }
 
-// NewConfig returns a new Config pointer
-func NewConfig(endpoint string, clientToken string, clientSecret string) *Config {
-	var c credentials.Value
+// ClientCredentials is a pair of OneLogin API client token and secret
+type ClientCredentials struct {
+	ClientToken  string
+	ClientSecret string
+}
+
+// Option configures Config created by NewConfig
+type Option func(*Config)
+
+// WithEndpoint is the host of OneLogin API like api.us.onelogin.com
+func WithEndpoint(endpoint string) Option {
+	return func(c *Config) {
+		c.Endpoint = endpoint
+	}
+}
+
+// WithClientCredentials is the client token and secret generating the tokens
+func WithClientCredentials(clientToken string, clientSecret string) Option {
+	return func(c *Config) {
+		c.ClientToken = clientToken
+		c.ClientSecret = clientSecret
+	}
+}
+
+// WithHTTPClient generates the tokens and sends requests of API clients with the client instead of transport.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+// WithCredentialsStore keeps the tokens in the store instead of CacheDir, nil stores nothing
+func WithCredentialsStore(store CredentialsStore) Option {
+	return func(c *Config) {
+		if store == nil {
+			store = nopStore{}
+		}
+		c.Store = store
+	}
+}
+
+// WithSubdomains has the client credentials of other OneLogin tenants resolved by ForSubdomain
+func WithSubdomains(subdomains map[string]ClientCredentials) Option {
+	return func(c *Config) {
+		c.Subdomains = subdomains
+	}
+}
+
+// NewConfig returns a new Config pointer with the tokens loaded from the store
+func NewConfig(opts ...Option) *Config {
+	c := &Config{}
+	for _, opt := range opts {
+		opt(c)
+	}
 	var v *credentials.Value
-	if _, err := toml.DecodeFile(cacheFile(clientToken), &c); err == nil {
-		v = &c
+	if store := c.store(); store != nil {
+		v, _ = store.Load(c.ClientToken)
 	}
 	t := tokens.NewTokens()
-	t.Endpoint = endpoint
-	t.ClientToken = clientToken
-	t.ClientSecret = clientSecret
-	return &Config{
-		Endpoint:     endpoint,
-		ClientToken:  clientToken,
-		ClientSecret: clientSecret,
-		Credentials:  credentials.New(t, v),
+	t.Endpoint = c.Endpoint
+	t.ClientToken = c.ClientToken
+	t.ClientSecret = c.ClientSecret
+	if c.HTTPClient != nil {
+		t.HTTPClient = c.HTTPClient
+	}
+	c.HTTPClient = t.HTTPClient
+	c.Credentials = credentials.New(t, v)
+	return c
+}
+
+// SetHTTPClient sets the client used to generate tokens and by API clients created with the config,
+// e.g. the one with the proxy of the profile
+func (c *Config) SetHTTPClient(client *http.Client) {
+	c.HTTPClient = client
+	if t, ok := c.Credentials.Tokens.(*tokens.Tokens); ok {
+		t.HTTPClient = client
 	}
 }
 
+// ForSubdomain returns the config with the client credentials of the subdomain,
+// or the config itself if the subdomain has no credentials in Subdomains.
+// The returned config shares the store and the HTTP client, so the tokens of the subdomain are reused through the store
+func (c *Config) ForSubdomain(subdomain string) *Config {
+	pair, ok := c.Subdomains[subdomain]
+	if !ok || pair.ClientToken == "" || pair.ClientToken == c.ClientToken {
+		return c
+	}
+	config := NewConfig(
+		WithEndpoint(c.Endpoint),
+		WithClientCredentials(pair.ClientToken, pair.ClientSecret),
+		WithHTTPClient(c.HTTPClient),
+		func(config *Config) { config.Store = c.Store },
+	)
+	config.Credentials.ExpiryWindow = c.Credentials.ExpiryWindow
+	return config
+}
+
 // Refresh load new credentials if necessary
 func (c *Config) Refresh() error {
 	return c.Credentials.Refresh()
 }
 
+// RefreshWithContext is the same as Refresh, but the requests are canceled when the context is done
+func (c *Config) RefreshWithContext(ctx context.Context) error {
+	return c.Credentials.RefreshWithContext(ctx)
+}
+
 // Save seves credentials value
 func (c *Config) Save() error {
-	if CacheDir != "" {
-		fd, err := os.Create(cacheFile(c.ClientToken))
-		if err != nil {
-			return err
-		}
-		defer fd.Close()
-		encoder := toml.NewEncoder(fd)
-		creds, err := c.Credentials.Get()
-		if err != nil {
+	return c.SaveWithContext(context.Background())
+}
+
+// SaveWithContext is the same as Save, but generating or refreshing tokens is canceled when the context is done
+func (c *Config) SaveWithContext(ctx context.Context) error {
+	store := c.store()
+	if store == nil {
+		return nil
+	}
+	creds, err := c.Credentials.GetWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Save(c.ClientToken, creds)
+}
+
+// Revoke revokes the tokens, and removes their cache even if revoking fails
+func (c *Config) Revoke() error {
+	return c.RevokeWithContext(context.Background())
+}
+
+// RevokeWithContext is the same as Revoke, but the request is canceled when the context is done
+func (c *Config) RevokeWithContext(ctx context.Context) error {
+	err := c.Credentials.RevokeWithContext(ctx)
+	if store := c.store(); store != nil {
+		if err := store.Remove(c.ClientToken); err != nil {
 			return err
 		}
-		return encoder.Encode(&creds)
 	}
-	return nil
+	return err
 }
 
-func cacheFile(clientToken string) string {
-	return path.Join(CacheDir, fmt.Sprintf("onelogin.%s.cache", clientToken))
+// store returns Store, FileStore of CacheDir without Store, or nil if nothing is stored,
+// so the tokens are not generated only to be discarded
+func (c *Config) store() CredentialsStore {
+	switch c.Store.(type) {
+	case nil:
+		if CacheDir == "" {
+			return nil
+		}
+		return FileStore{Dir: CacheDir}
+	case nopStore:
+		return nil
+	}
+	return c.Store
 }
@@ -1,8 +1,10 @@
 package onelogin
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
 	"testing"
@@ -11,11 +13,12 @@ import (
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens/tokensiface"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
 )
 
 func TestNewConfigFileNotExists(t *testing.T) {
 	CacheDir = os.TempDir()
-	config := NewConfig("endpoint", "client-token", "client-secret")
+	config := NewConfig(WithEndpoint("endpoint"), WithClientCredentials("client-token", "client-secret"))
 	if config.Endpoint != "endpoint" {
 		t.Errorf("%s is not equal %s", config.Endpoint, "endpoint")
 	}
@@ -50,7 +53,7 @@ RefreshExpiresAt = %s`,
 		t.Errorf("%#v", err)
 	}
 	defer os.Remove(file)
-	config := NewConfig("endpoint", "client-token", "client-secret")
+	config := NewConfig(WithEndpoint("endpoint"), WithClientCredentials("client-token", "client-secret"))
 	if config.Endpoint != "endpoint" {
 		t.Errorf("%s is not equal %s", config.Endpoint, "endpoint")
 	}
@@ -82,12 +85,27 @@ type TokensAPIMock struct {
 	tokensiface.TokensAPI
 	GenerateResponse *tokens.GenerateResponse
 	GenerateError    error
+	RevokeRequest    *tokens.RevokeRequest
+	RevokeError      error
 }
 
 func (t *TokensAPIMock) Generate() (*tokens.GenerateResponse, error) {
+	return t.GenerateWithContext(context.Background())
+}
+
+func (t *TokensAPIMock) GenerateWithContext(ctx context.Context) (*tokens.GenerateResponse, error) {
 	return t.GenerateResponse, t.GenerateError
 }
 
+func (t *TokensAPIMock) Revoke(input *tokens.RevokeRequest) error {
+	return t.RevokeWithContext(context.Background(), input)
+}
+
+func (t *TokensAPIMock) RevokeWithContext(ctx context.Context, input *tokens.RevokeRequest) error {
+	t.RevokeRequest = input
+	return t.RevokeError
+}
+
 // There is tested only no credentials.
 // Other patterns are tested in onelogin/credentials package.
 func TestRefresh(t *testing.T) {
@@ -179,6 +197,9 @@ func TestSaveError(t *testing.T) {
 	CacheDir = os.TempDir()
 	var file = path.Join(CacheDir, fmt.Sprintf("onelogin.%s.cache", "client-token"))
 	defer os.Remove(file)
+	if err := ioutil.WriteFile(file, []byte("cached"), 0600); err != nil {
+		t.Fatal(err)
+	}
 	var v *credentials.Value
 	a := &TokensAPIMock{
 		GenerateError: fmt.Errorf("generate error"),
@@ -192,11 +213,158 @@ func TestSaveError(t *testing.T) {
 	if err := c.Save(); err.Error() != "generate error" {
 		t.Errorf("%#v", err)
 	}
-	info, err := os.Stat(file)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("%#v", err)
+	}
+	if string(data) != "cached" {
+		t.Errorf("the cache is overwritten with %q on error", data)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	CacheDir = os.TempDir()
+	var file = path.Join(CacheDir, fmt.Sprintf("onelogin.%s.cache", "client-token"))
+	defer os.Remove(file)
+	if err := ioutil.WriteFile(file, []byte(""), 0600); err != nil {
+		t.Errorf("%#v", err)
+	}
+	now := time.Now()
+	a := &TokensAPIMock{}
+	c := Config{
+		Endpoint:     "endpoint",
+		ClientToken:  "client-token",
+		ClientSecret: "client-secret",
+		Credentials: credentials.New(a, &credentials.Value{
+			AccessToken:      "access-token",
+			RefreshToken:     "refresh-token",
+			CreatedAt:        now,
+			AccessExpiresAt:  now.Add(10 * time.Second),
+			RefreshExpiresAt: now.Add(100 * time.Second),
+		}),
+	}
+	if err := c.Revoke(); err != nil {
+		t.Errorf("%#v", err)
+	}
+	if a.RevokeRequest == nil || a.RevokeRequest.AccessToken != "access-token" {
+		t.Errorf("%#v is not revoked", a.RevokeRequest)
+	}
+	if c.Credentials.Credentials != nil {
+		t.Errorf("%#v is not nil", c.Credentials.Credentials)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("%s is not removed", file)
+	}
+}
+
+func TestConfigForSubdomain(t *testing.T) {
+	CacheDir = ""
+	config := NewConfig(WithEndpoint("endpoint"), WithClientCredentials("client-token", "client-secret"))
+	config.Subdomains = map[string]ClientCredentials{
+		"tenant-a": {ClientToken: "tenant-a-token", ClientSecret: "tenant-a-secret"},
+		"same":     {ClientToken: "client-token", ClientSecret: "client-secret"},
+	}
+	if c := config.ForSubdomain("unknown"); c != config {
+		t.Errorf("%#v is not the config itself", c)
+	}
+	if c := config.ForSubdomain("same"); c != config {
+		t.Errorf("%#v is not the config itself", c)
+	}
+	tenant := config.ForSubdomain("tenant-a")
+	if tenant.Endpoint != "endpoint" || tenant.ClientToken != "tenant-a-token" || tenant.ClientSecret != "tenant-a-secret" {
+		t.Errorf("%#v is unexpected", tenant)
+	}
+	if tenant.Credentials == config.Credentials {
+		t.Error("tokens are shared with the other tenant")
+	}
+}
+
+func TestConfigSetHTTPClient(t *testing.T) {
+	CacheDir = ""
+	config := NewConfig(WithEndpoint("endpoint"), WithClientCredentials("client-token", "client-secret"))
+	config.Subdomains = map[string]ClientCredentials{
+		"tenant-a": {ClientToken: "tenant-a-token", ClientSecret: "tenant-a-secret"},
+	}
+	if tenant := config.ForSubdomain("tenant-a"); config.HTTPClient != transport.DefaultClient || tenant.HTTPClient != transport.DefaultClient {
+		t.Error("the default client is not shared")
+	}
+	client := &http.Client{}
+	config.SetHTTPClient(client)
+	for _, c := range []*Config{config, config.ForSubdomain("tenant-a")} {
+		if c.HTTPClient != client || c.Credentials.Tokens.(*tokens.Tokens).HTTPClient != client {
+			t.Errorf("%s does not use the client", c.ClientToken)
+		}
+	}
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	CacheDir = ""
+	dir, err := ioutil.TempDir("", "onelogin")
 	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileStore{Dir: dir}
+	now := time.Now().UTC().Truncate(time.Second)
+	cached := credentials.Value{
+		AccessToken:      "access-token",
+		RefreshToken:     "refresh-token",
+		CreatedAt:        now,
+		AccessExpiresAt:  now.Add(time.Hour),
+		RefreshExpiresAt: now.Add(2 * time.Hour),
+	}
+	if err := store.Save("client-token", cached); err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{}
+	subdomains := map[string]ClientCredentials{"tenant-a": {ClientToken: "tenant-a-token", ClientSecret: "tenant-a-secret"}}
+	config := NewConfig(
+		WithEndpoint("endpoint"),
+		WithClientCredentials("client-token", "client-secret"),
+		WithHTTPClient(client),
+		WithCredentialsStore(store),
+		WithSubdomains(subdomains),
+	)
+	if config.Endpoint != "endpoint" || config.ClientToken != "client-token" || config.ClientSecret != "client-secret" {
+		t.Errorf("%#v is unexpected", config)
+	}
+	api := config.Credentials.Tokens.(*tokens.Tokens)
+	if api.Endpoint != "endpoint" || api.ClientToken != "client-token" || api.ClientSecret != "client-secret" {
+		t.Errorf("%#v is unexpected", api)
+	}
+	if config.HTTPClient != client || api.HTTPClient != client {
+		t.Error("the client is not used")
+	}
+	if config.Credentials.Credentials == nil || config.Credentials.Credentials.AccessToken != "access-token" {
+		t.Errorf("%#v is not loaded from the store", config.Credentials.Credentials)
+	}
+	tenant := config.ForSubdomain("tenant-a")
+	if tenant.ClientToken != "tenant-a-token" || tenant.Store != store || tenant.HTTPClient != client {
+		t.Errorf("%#v does not share the store and the client", tenant)
+	}
+}
+
+func TestNewConfigWithoutCredentialsStore(t *testing.T) {
+	CacheDir = os.TempDir()
+	var file = path.Join(CacheDir, fmt.Sprintf("onelogin.%s.cache", "client-token"))
+	defer os.Remove(file)
+	config := NewConfig(
+		WithEndpoint("endpoint"),
+		WithClientCredentials("client-token", "client-secret"),
+		WithCredentialsStore(nil),
+	)
+	config.Credentials = credentials.New(&TokensAPIMock{
+		GenerateResponse: &tokens.GenerateResponse{
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			CreatedAt:    time.Now().Format("2006-01-02T15:04:05Z"),
+			ExpiresIn:    10,
+		},
+	}, nil)
+	if err := config.Save(); err != nil {
 		t.Errorf("%#v", err)
 	}
-	if info.Size() > 0 {
-		t.Error("file size is not zero")
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("%s is written without the store", file)
 	}
 }
@@ -1,16 +1,44 @@
 package credentials
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens/tokensiface"
 )
 
-// Credentials provides credentials for API Clients
+// invalidToken is the error of refreshing the revoked or already refreshed token
+const invalidToken = "[401] Unauthorized: Invalid Token"
+
+// isInvalidToken returns true if the error is invalidToken annotated with the request ID or not
+func isInvalidToken(err error) bool {
+	var status *apiresponse.StatusError
+	if errors.As(err, &status) {
+		return status.Error() == invalidToken
+	}
+	return errors.Cause(err).Error() == invalidToken
+}
+
+// DefaultExpiryWindow is how long before the access token expires it is refreshed
+const DefaultExpiryWindow = 5 * time.Minute
+
+// Credentials provides credentials for API Clients.
+// It is safe for concurrent use, and goroutines getting the expired tokens at once share one refresh
 type Credentials struct {
+	// Credentials is the current tokens, which must not be set while the credentials are used by goroutines
 	Credentials *Value
 	Tokens      tokensiface.TokensAPI
+	// ExpiryWindow refreshes the access token before it expires, so it does not expire during requests.
+	// Zero means DefaultExpiryWindow, and a negative value refreshes only after it expires
+	ExpiryWindow time.Duration
+
+	mu sync.Mutex
 }
 
 // Value provides credentials for API Clients
@@ -24,6 +52,9 @@ type Value struct {
 
 // New returns a new Credentials pointer
 func New(t tokensiface.TokensAPI, c *Value) *Credentials {
+	if c != nil {
+		c.register()
+	}
 	return &Credentials{
 		Credentials: c,
 		Tokens:      t,
@@ -32,7 +63,14 @@ func New(t tokensiface.TokensAPI, c *Value) *Credentials {
 
 // Get returns the credentials value, or error
 func (c *Credentials) Get() (Value, error) {
-	if err := c.Refresh(); err != nil {
+	return c.GetWithContext(context.Background())
+}
+
+// GetWithContext is the same as Get, but generating or refreshing tokens is canceled when the context is done
+func (c *Credentials) GetWithContext(ctx context.Context) (Value, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(ctx); err != nil {
 		return Value{}, err
 	}
 	return *c.Credentials, nil
@@ -40,11 +78,23 @@ func (c *Credentials) Get() (Value, error) {
 
 // Refresh load new credentials if necessary
 func (c *Credentials) Refresh() error {
+	return c.RefreshWithContext(context.Background())
+}
+
+// RefreshWithContext is the same as Refresh, but the requests are canceled when the context is done
+func (c *Credentials) RefreshWithContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refresh(ctx)
+}
+
+// refresh is RefreshWithContext while the lock is held
+func (c *Credentials) refresh(ctx context.Context) error {
 	var res *tokens.GenerateResponse
 	var err error
 	if c.Credentials != nil {
 		creds := c.Credentials
-		if creds.availavle() {
+		if creds.availavle(c.expiryWindow()) {
 			return nil
 		}
 		if creds.refreshable() {
@@ -52,24 +102,24 @@ func (c *Credentials) Refresh() error {
 				AccessToken:  c.Credentials.AccessToken,
 				RefreshToken: c.Credentials.RefreshToken,
 			}
-			res, err = c.Tokens.Refresh(input)
+			res, err = c.Tokens.RefreshWithContext(ctx, input)
 			if err != nil {
-				if err.Error() != "[401] Unauthorized: Invalid Token" {
+				if !isInvalidToken(err) {
 					return err
 				}
-				res, err = c.Tokens.Generate()
+				res, err = c.Tokens.GenerateWithContext(ctx)
 				if err != nil {
 					return err
 				}
 			}
 		} else {
-			res, err = c.Tokens.Generate()
+			res, err = c.Tokens.GenerateWithContext(ctx)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
-		res, err = c.Tokens.Generate()
+		res, err = c.Tokens.GenerateWithContext(ctx)
 		if err != nil {
 			return err
 		}
@@ -82,6 +132,7 @@ func (c *Credentials) Refresh() error {
 	accessExpiresAt := createdAt.Add(time.Duration(res.ExpiresIn * 1000000000))
 	refreshExpiresAt := createdAt.Add(45 * 24 * time.Hour)
 
+	old := c.Credentials
 	c.Credentials = &Value{
 		AccessToken:      res.AccessToken,
 		RefreshToken:     res.RefreshToken,
@@ -89,13 +140,94 @@ func (c *Credentials) Refresh() error {
 		AccessExpiresAt:  accessExpiresAt,
 		RefreshExpiresAt: refreshExpiresAt,
 	}
+	c.Credentials.register()
+	if old != nil {
+		old.unregister()
+	}
 	return nil
 }
 
-func (c *Value) availavle() bool {
-	return time.Now().Before(c.AccessExpiresAt)
+// Revoke revokes the tokens so that they cannot be replayed from a stolen cache, and forgets them even if revoking fails
+func (c *Credentials) Revoke() error {
+	return c.RevokeWithContext(context.Background())
+}
+
+// RevokeWithContext is the same as Revoke, but the requests are canceled when the context is done
+func (c *Credentials) RevokeWithContext(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	creds := c.Credentials
+	c.Credentials = nil
+	if creds == nil {
+		return nil
+	}
+	defer creds.unregister()
+	accessToken := creds.AccessToken
+	if !time.Now().Before(creds.AccessExpiresAt) {
+		if !creds.refreshable() {
+			return nil
+		}
+		// the refresh token outlives the expired access token, so it is exchanged for a new pair to revoke
+		res, err := c.Tokens.RefreshWithContext(ctx, &tokens.RefreshRequest{
+			AccessToken:  creds.AccessToken,
+			RefreshToken: creds.RefreshToken,
+		})
+		if err != nil {
+			if isInvalidToken(err) {
+				return nil
+			}
+			return err
+		}
+		secret.Register(res.AccessToken, res.RefreshToken)
+		defer secret.Unregister(res.AccessToken, res.RefreshToken)
+		accessToken = res.AccessToken
+	}
+	return c.Tokens.RevokeWithContext(ctx, &tokens.RevokeRequest{AccessToken: accessToken})
+}
+
+// Expire marks the access token expired, so it is refreshed by the next Get
+// even if it is rejected before the expiration, for example revoked.
+// Nothing is done if the rejected token is already replaced by another goroutine
+func (c *Credentials) Expire(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Credentials != nil && c.Credentials.AccessToken == accessToken {
+		// the value is replaced instead of modified, the old one may be read by goroutines
+		expired := *c.Credentials
+		expired.AccessExpiresAt = time.Time{}
+		c.Credentials = &expired
+	}
+}
+
+func (c *Credentials) expiryWindow() time.Duration {
+	if c.ExpiryWindow == 0 {
+		return DefaultExpiryWindow
+	}
+	if c.ExpiryWindow < 0 {
+		return 0
+	}
+	return c.ExpiryWindow
+}
+
+// availavle returns true if the access token does not expire within the window,
+// which is at most a quarter of its lifetime not to refresh short-lived tokens every time
+func (c *Value) availavle(window time.Duration) bool {
+	if lifetime := c.AccessExpiresAt.Sub(c.CreatedAt) / 4; window > lifetime {
+		window = lifetime
+	}
+	return time.Now().Add(window).Before(c.AccessExpiresAt)
 }
 
 func (c *Value) refreshable() bool {
 	return time.Now().Before(c.RefreshExpiresAt)
 }
+
+// register registers the tokens to be masked in diagnostic output
+func (c *Value) register() {
+	secret.Register(c.AccessToken, c.RefreshToken)
+}
+
+// unregister unregisters the tokens which are replaced or revoked
+func (c *Value) unregister() {
+	secret.Unregister(c.AccessToken, c.RefreshToken)
+}
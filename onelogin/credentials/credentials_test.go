@@ -1,11 +1,14 @@
 package credentials
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
 )
 
@@ -25,20 +28,38 @@ type TokenAPIMock struct {
 	GenerateResponse       *tokens.GenerateResponse
 	RefreshResponse        *tokens.RefreshResponse
 	RefreshRequestVerifier func(*tokens.RefreshRequest) error
+	RevokeRequest          *tokens.RevokeRequest
 	Error                  error
 }
 
 func (t *TokenAPIMock) Generate() (*tokens.GenerateResponse, error) {
+	return t.GenerateWithContext(context.Background())
+}
+
+func (t *TokenAPIMock) GenerateWithContext(ctx context.Context) (*tokens.GenerateResponse, error) {
 	return t.GenerateResponse, t.Error
 }
 
 func (t *TokenAPIMock) Refresh(input *tokens.RefreshRequest) (*tokens.RefreshResponse, error) {
+	return t.RefreshWithContext(context.Background(), input)
+}
+
+func (t *TokenAPIMock) RefreshWithContext(ctx context.Context, input *tokens.RefreshRequest) (*tokens.RefreshResponse, error) {
 	if err := t.RefreshRequestVerifier(input); err != nil {
 		return nil, err
 	}
 	return t.RefreshResponse, t.Error
 }
 
+func (t *TokenAPIMock) Revoke(input *tokens.RevokeRequest) error {
+	return t.RevokeWithContext(context.Background(), input)
+}
+
+func (t *TokenAPIMock) RevokeWithContext(ctx context.Context, input *tokens.RevokeRequest) error {
+	t.RevokeRequest = input
+	return t.Error
+}
+
 func TestCredentialsGet(t *testing.T) {
 	t.Run("when Refresh() success", func(t *testing.T) {
 		n := time.Now().UTC()
@@ -274,34 +295,206 @@ func TestCredentialsRefresh(t *testing.T) {
 			t.Errorf("Credentials.Get() error = %#v", err)
 		}
 	})
-	t.Run("when invalid refresh token", func(t *testing.T) {
-		e := fmt.Errorf("[401] Unauthorized: Invalid Token")
-		n, _ := time.Parse("2006-01-02T15:04:05Z", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
-		a := &TokenAPIMock{
-			GenerateResponse: &tokens.GenerateResponse{
-				AccessToken:  "access-token",
-				RefreshToken: "refresh-token",
-				CreatedAt:    n.Format("2006-01-02T15:04:05Z"),
-				ExpiresIn:    100,
-			},
-			RefreshRequestVerifier: func(t *tokens.RefreshRequest) error {
-				return e
-			},
-		}
-		v := &Value{
-			AccessToken:      "access-token",
-			RefreshToken:     "refresh-token",
-			CreatedAt:        n,
-			AccessExpiresAt:  n.Add(-10 * time.Second),
-			RefreshExpiresAt: n.Add(100 * time.Second),
-		}
-		c := &Credentials{
-			Credentials: v,
-			Tokens:      a,
-		}
-		_, err := c.Get()
-		if err != nil {
-			t.Errorf("Credentials.Get() error = %#v", err)
-		}
+	for name, e := range map[string]error{
+		"when invalid refresh token":                 fmt.Errorf("[401] Unauthorized: Invalid Token"),
+		"when invalid refresh token with request ID": &apiresponse.RequestError{RequestID: "abc-123", Err: fmt.Errorf("[401] Unauthorized: Invalid Token")},
+	} {
+		e := e
+		t.Run(name, func(t *testing.T) {
+			n, _ := time.Parse("2006-01-02T15:04:05Z", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+			a := &TokenAPIMock{
+				GenerateResponse: &tokens.GenerateResponse{
+					AccessToken:  "access-token",
+					RefreshToken: "refresh-token",
+					CreatedAt:    n.Format("2006-01-02T15:04:05Z"),
+					ExpiresIn:    100,
+				},
+				RefreshRequestVerifier: func(t *tokens.RefreshRequest) error {
+					return e
+				},
+			}
+			v := &Value{
+				AccessToken:      "access-token",
+				RefreshToken:     "refresh-token",
+				CreatedAt:        n,
+				AccessExpiresAt:  n.Add(-10 * time.Second),
+				RefreshExpiresAt: n.Add(100 * time.Second),
+			}
+			c := &Credentials{
+				Credentials: v,
+				Tokens:      a,
+			}
+			_, err := c.Get()
+			if err != nil {
+				t.Errorf("Credentials.Get() error = %#v", err)
+			}
+		})
+	}
+}
+
+func TestCredentialsExpiryWindow(t *testing.T) {
+	n := time.Now().UTC()
+	tests := []struct {
+		name      string
+		window    time.Duration
+		expiresIn time.Duration
+		refreshed bool
+	}{
+		{"expiring within the default window", 0, 2 * time.Minute, true},
+		{"not expiring within the default window", 0, time.Hour, false},
+		{"expiring within the configured window", time.Hour, 30 * time.Minute, true},
+		{"window disabled", -1, time.Second * 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshed := false
+			a := &TokenAPIMock{
+				RefreshResponse: &tokens.RefreshResponse{
+					AccessToken: "new-access-token",
+					CreatedAt:   n.Format("2006-01-02T15:04:05Z"),
+					ExpiresIn:   36000,
+				},
+				RefreshRequestVerifier: func(input *tokens.RefreshRequest) error {
+					refreshed = true
+					return nil
+				},
+			}
+			c := &Credentials{
+				Credentials: &Value{
+					AccessToken:      "access-token",
+					RefreshToken:     "refresh-token",
+					CreatedAt:        n.Add(-10 * time.Hour),
+					AccessExpiresAt:  n.Add(tt.expiresIn),
+					RefreshExpiresAt: n.Add(44 * 24 * time.Hour),
+				},
+				Tokens:       a,
+				ExpiryWindow: tt.window,
+			}
+			if _, err := c.Get(); err != nil {
+				t.Fatal(err)
+			}
+			if refreshed != tt.refreshed {
+				t.Errorf("refreshed = %v, want %v", refreshed, tt.refreshed)
+			}
+		})
+	}
+}
+
+func TestCredentialsExpire(t *testing.T) {
+	n := time.Now().UTC()
+	a := &TokenAPIMock{
+		RefreshResponse: &tokens.RefreshResponse{
+			AccessToken: "new-access-token",
+			CreatedAt:   n.Format("2006-01-02T15:04:05Z"),
+			ExpiresIn:   36000,
+		},
+		RefreshRequestVerifier: func(input *tokens.RefreshRequest) error {
+			return nil
+		},
+	}
+	c := New(a, &Value{
+		AccessToken:      "access-token",
+		RefreshToken:     "refresh-token",
+		CreatedAt:        n,
+		AccessExpiresAt:  n.Add(10 * time.Hour),
+		RefreshExpiresAt: n.Add(45 * 24 * time.Hour),
 	})
+	c.Expire("replaced-access-token")
+	if got, err := c.Get(); err != nil || got.AccessToken != "access-token" {
+		t.Errorf("%s, %v is refreshed by the rejection of another token", got.AccessToken, err)
+	}
+	c.Expire("access-token")
+	got, err := c.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.AccessToken != "new-access-token" {
+		t.Errorf("%s is not refreshed", got.AccessToken)
+	}
+}
+
+func TestCredentialsRevoke(t *testing.T) {
+	n := time.Now().UTC()
+	tests := []struct {
+		name     string
+		value    *Value
+		expected string
+	}{
+		{"available access token", &Value{AccessToken: "access-token", AccessExpiresAt: n.Add(time.Hour), RefreshExpiresAt: n.Add(time.Hour)}, "access-token"},
+		{"expired access token", &Value{AccessToken: "access-token", AccessExpiresAt: n.Add(-time.Hour), RefreshExpiresAt: n.Add(time.Hour)}, "new-access-token"},
+		{"expired refresh token", &Value{AccessToken: "access-token", AccessExpiresAt: n.Add(-time.Hour), RefreshExpiresAt: n.Add(-time.Minute)}, ""},
+		{"no credentials", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &TokenAPIMock{
+				RefreshResponse: &tokens.RefreshResponse{
+					AccessToken:  "new-access-token",
+					RefreshToken: "new-refresh-token",
+				},
+				RefreshRequestVerifier: func(input *tokens.RefreshRequest) error {
+					return nil
+				},
+			}
+			c := &Credentials{Credentials: tt.value, Tokens: a}
+			if err := c.Revoke(); err != nil {
+				t.Fatal(err)
+			}
+			if c.Credentials != nil {
+				t.Errorf("%#v is not forgotten", c.Credentials)
+			}
+			revoked := ""
+			if a.RevokeRequest != nil {
+				revoked = a.RevokeRequest.AccessToken
+			}
+			if revoked != tt.expected {
+				t.Errorf("%s is revoked, want %s", revoked, tt.expected)
+			}
+		})
+	}
+}
+
+// countingTokenAPIMock counts the tokens generated by goroutines
+type countingTokenAPIMock struct {
+	TokenAPIMock
+	mu       sync.Mutex
+	generate int
+}
+
+func (t *countingTokenAPIMock) GenerateWithContext(ctx context.Context) (*tokens.GenerateResponse, error) {
+	t.mu.Lock()
+	t.generate++
+	t.mu.Unlock()
+	// the other goroutines wait for this refresh
+	time.Sleep(10 * time.Millisecond)
+	return t.GenerateResponse, nil
+}
+
+func TestCredentialsConcurrentGet(t *testing.T) {
+	createdAt := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	api := &countingTokenAPIMock{TokenAPIMock: TokenAPIMock{
+		GenerateResponse: &tokens.GenerateResponse{AccessToken: "access-token", RefreshToken: "refresh-token", CreatedAt: createdAt, ExpiresIn: 36000},
+		RefreshResponse:  &tokens.RefreshResponse{AccessToken: "refreshed-token", RefreshToken: "refresh-token", CreatedAt: createdAt, ExpiresIn: 36000},
+		RefreshRequestVerifier: func(*tokens.RefreshRequest) error {
+			return nil
+		},
+	}}
+	c := New(api, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := c.Get()
+			if err != nil {
+				t.Error(err)
+			}
+			// the tokens are refreshed by the next goroutines
+			c.Expire(got.AccessToken)
+		}()
+	}
+	wg.Wait()
+	if api.generate != 1 {
+		t.Errorf("tokens are generated %d times by goroutines getting them at once", api.generate)
+	}
 }
@@ -0,0 +1,82 @@
+package samlassertion
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+)
+
+// failure classes of OneLogin SAML assertion API, an *Error unwraps to one of them if it is classified
+var (
+	// ErrMFARequired is returned when MFA is enforced but the user has no device to verify
+	ErrMFARequired = errors.New("MFA is required")
+	// ErrInvalidCredentials is returned when the username or the password is wrong
+	ErrInvalidCredentials = errors.New("invalid user credentials")
+	// ErrRateLimited is returned when the request is rejected by the rate limit after retries,
+	// it is apiresponse.ErrRateLimited so that the rate limit of every API is matched with the same error
+	ErrRateLimited = apiresponse.ErrRateLimited
+	// ErrPasswordExpired is returned when the password must be changed before login
+	ErrPasswordExpired = errors.New("password expired")
+	// ErrUserLocked is returned when the user is locked by failed attempts or the administrator
+	ErrUserLocked = errors.New("user locked")
+	// ErrVerifyTimedOut is returned when the push notification is not approved before VerifyFactorTimeout
+	ErrVerifyTimedOut = errors.New("MFA verification timed out")
+)
+
+// Error is the error status returned by OneLogin SAML assertion API
+type Error struct {
+	Code    int
+	Type    string
+	Message string
+	// Err is one of the failure classes, or nil if it is not classified
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%d] %s: %s", e.Code, e.Type, e.Message)
+}
+
+// Unwrap returns the failure class for errors.Is
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the failure kind of the status code in apiresponse, like apiresponse.ErrUnauthorized
+func (e *Error) Is(target error) bool {
+	return target != nil && target == apiresponse.Kind(e.Code)
+}
+
+// newError classifies the error status by its code and message
+func newError(code int, typ, message string) *Error {
+	return &Error{Code: code, Type: typ, Message: message, Err: classify(code, message)}
+}
+
+func classify(code int, message string) error {
+	m := strings.ToLower(message)
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case strings.Contains(m, "locked"):
+		return ErrUserLocked
+	case strings.Contains(m, "password") && strings.Contains(m, "expired"):
+		return ErrPasswordExpired
+	case strings.Contains(m, "mfa") && strings.Contains(m, "required"):
+		return ErrMFARequired
+	case strings.Contains(m, "invalid user credentials"):
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// Class returns the failure class of the error returned by the API, looking through wrapped errors.
+// It returns nil if the error is not classified
+func Class(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package samlassertion
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		code     int
+		message  string
+		expected error
+	}{
+		{401, "Authentication Failed: Invalid user credentials", ErrInvalidCredentials},
+		{429, "Too Many Requests", ErrRateLimited},
+		{400, "Password expired", ErrPasswordExpired},
+		{401, "User is Locked", ErrUserLocked},
+		{400, "MFA is required for this user", ErrMFARequired},
+		{400, "Bad Request", nil},
+	}
+	for _, tt := range tests {
+		err := newError(tt.code, "type", tt.message)
+		if err.Err != tt.expected {
+			t.Errorf("%s: %v is not %v", tt.message, err.Err, tt.expected)
+		}
+		if tt.expected != nil && !stderrors.Is(err, tt.expected) {
+			t.Errorf("%s: %v is not %v with errors.Is", tt.message, err, tt.expected)
+		}
+	}
+	if err := newError(401, "Unauthorized", "Authentication Failed: Invalid user credentials"); err.Error() != "[401] Unauthorized: Authentication Failed: Invalid user credentials" {
+		t.Errorf("%s is unexpected message", err)
+	}
+}
+
+func TestClass(t *testing.T) {
+	err := errors.Wrap(errors.WithStack(newError(423, "Locked", "User is locked")), "login failed")
+	if class := Class(err); class != ErrUserLocked {
+		t.Errorf("%v is not %v", class, ErrUserLocked)
+	}
+	if class := Class(errors.New("unknown")); class != nil {
+		t.Errorf("%v is classified", class)
+	}
+	if class := Class(nil); class != nil {
+		t.Errorf("%v is classified", class)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := errors.Wrap(&apiresponse.RequestError{RequestID: "abc-123", Err: newError(401, "Unauthorized", "Authentication Failed: Invalid user credentials")}, "login failed")
+	if !stderrors.Is(err, ErrInvalidCredentials) || !stderrors.Is(err, apiresponse.ErrUnauthorized) {
+		t.Errorf("%v is not ErrInvalidCredentials and apiresponse.ErrUnauthorized", err)
+	}
+	if stderrors.Is(err, apiresponse.ErrRateLimited) {
+		t.Errorf("%v is apiresponse.ErrRateLimited", err)
+	}
+	if err := newError(429, "rate limit", "Too Many Requests"); !stderrors.Is(err, apiresponse.ErrRateLimited) {
+		t.Errorf("%v is not apiresponse.ErrRateLimited", err)
+	}
+}
+
+func TestSAMLAssertion_NonJSONResponse(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<html><body><h1>Request blocked</h1></body></html>")
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	_, err := s.Generate(&GenerateRequest{})
+	var e *apiresponse.Error
+	if !stderrors.As(err, &e) {
+		t.Fatalf("%v is not *apiresponse.Error", err)
+	}
+	if e.StatusCode != http.StatusForbidden || e.Snippet != "Request blocked" {
+		t.Errorf("%#v is unexpected", e)
+	}
+	s.Version = 2
+	_, err = s.Generate(&GenerateRequest{})
+	expected := "[403] Forbidden: Request blocked"
+	if err == nil || err.Error() != expected {
+		t.Errorf("%v is not %s", err, expected)
+	}
+}
+
+func TestSAMLAssertion_ErrorRequestID(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc-123")
+		fmt.Fprint(w, `{"status":{"error":true,"code":401,"type":"Unauthorized","message":"Authentication Failed: Invalid user credentials"}}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	_, err := s.Generate(&GenerateRequest{})
+	expected := "[401] Unauthorized: Authentication Failed: Invalid user credentials (request ID: abc-123)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("%v is not %s", err, expected)
+	}
+	if class := Class(err); class != ErrInvalidCredentials {
+		t.Errorf("%v is not %v", class, ErrInvalidCredentials)
+	}
+}
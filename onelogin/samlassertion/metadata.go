@@ -0,0 +1,63 @@
+package samlassertion
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+)
+
+// Metadata describes an HTTP exchange with SAML assertion API, it is given to OnResponse for diagnostics
+type Metadata struct {
+	Method string
+	Path   string
+	// Attempt is 1 for the first request, and counts up for each retry
+	Attempt int
+	// StatusCode is zero if no response is received
+	StatusCode int
+	// RequestID is the ID of the request given by OneLogin, or empty if it is not sent
+	RequestID string
+	// RateLimit is the rate limit told by the response, or nil if it is not told
+	RateLimit *RateLimit
+	// Latency is the time from sending the request to receiving the response headers
+	Latency time.Duration
+	// Err is the error of sending the request, like a connection reset or a timeout
+	Err error
+}
+
+// String formats the metadata for logs, like "POST /api/1/saml_assertion [200] attempt 1, 120ms, request ID: abc-123, rate limit: 4999/5000"
+func (m Metadata) String() string {
+	status := fmt.Sprintf("[%d]", m.StatusCode)
+	if m.Err != nil {
+		status = fmt.Sprintf("(%v)", m.Err)
+	}
+	s := fmt.Sprintf("%s %s %s attempt %d, %s", m.Method, m.Path, status, m.Attempt, m.Latency.Round(time.Millisecond))
+	if m.RequestID != "" {
+		s += ", request ID: " + m.RequestID
+	}
+	if m.RateLimit != nil {
+		s += fmt.Sprintf(", rate limit: %d/%d", m.RateLimit.Remaining, m.RateLimit.Limit)
+	}
+	return s
+}
+
+// observe tells the exchange to OnResponse if it is set
+func (s *SAMLAssertion) observe(req *http.Request, res *http.Response, err error, attempt int, start time.Time) {
+	if s.OnResponse == nil {
+		return
+	}
+	m := Metadata{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Attempt: attempt + 1,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+	if res != nil {
+		m.StatusCode = res.StatusCode
+		m.RequestID = apiresponse.RequestID(res)
+		m.RateLimit = apiresponse.ParseRateLimit(res.Header, start)
+	}
+	s.OnResponse(m)
+}
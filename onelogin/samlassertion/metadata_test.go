@@ -0,0 +1,68 @@
+package samlassertion
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSAMLAssertion_OnResponse(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("X-Request-Id", fmt.Sprintf("request-%d", count))
+		if count == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 1)
+	metadata := []Metadata{}
+	s.OnResponse = func(m Metadata) {
+		metadata = append(metadata, m)
+	}
+	if _, err := s.Generate(&GenerateRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(metadata) != 2 {
+		t.Fatalf("%v is not the metadata of 2 requests", metadata)
+	}
+	first, last := metadata[0], metadata[1]
+	if first.Method != "POST" || first.Path != "/api/1/saml_assertion" || first.Attempt != 1 || first.StatusCode != 503 || first.RequestID != "request-1" || first.RateLimit != nil {
+		t.Errorf("%#v is unexpected", first)
+	}
+	if last.Attempt != 2 || last.StatusCode != 200 || last.RequestID != "request-2" || last.RateLimit == nil || last.RateLimit.Remaining != 4999 {
+		t.Errorf("%#v is unexpected", last)
+	}
+	if first.Latency <= 0 || last.Latency <= 0 {
+		t.Errorf("latencies %v and %v are not measured", first.Latency, last.Latency)
+	}
+}
+
+func TestMetadataString(t *testing.T) {
+	m := Metadata{
+		Method:     "POST",
+		Path:       "/api/1/saml_assertion",
+		Attempt:    1,
+		StatusCode: 200,
+		RequestID:  "abc-123",
+		RateLimit:  &RateLimit{Limit: 5000, Remaining: 4999},
+		Latency:    120 * time.Millisecond,
+	}
+	expected := "POST /api/1/saml_assertion [200] attempt 1, 120ms, request ID: abc-123, rate limit: 4999/5000"
+	if m.String() != expected {
+		t.Errorf("%s is not %s", m, expected)
+	}
+	m = Metadata{Method: "POST", Path: "/api/1/saml_assertion", Attempt: 2, Latency: time.Second, Err: errors.New("connection reset by peer")}
+	expected = "POST /api/1/saml_assertion (connection reset by peer) attempt 2, 1s"
+	if m.String() != expected {
+		t.Errorf("%s is not %s", m, expected)
+	}
+}
@@ -0,0 +1,27 @@
+package samlassertion
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+)
+
+// RateLimit is the rate limit of OneLogin API told by X-RateLimit-* headers of the last response,
+// it is the same type as the rate limit returned by tokens.GetRateLimit
+type RateLimit = apiresponse.RateLimit
+
+// RateLimit returns the rate limit told by the last response, or nil if it is unknown
+func (s *SAMLAssertion) RateLimit() *RateLimit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimit
+}
+
+func (s *SAMLAssertion) setRateLimit(header http.Header) {
+	if r := apiresponse.ParseRateLimit(header, time.Now()); r != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.rateLimit = r
+	}
+}
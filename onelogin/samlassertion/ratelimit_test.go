@@ -0,0 +1,28 @@
+package samlassertion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSAMLAssertion_RateLimit(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", "1200")
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	if r := s.RateLimit(); r != nil {
+		t.Errorf("%#v is known before requests", r)
+	}
+	if _, err := s.Generate(&GenerateRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.RateLimit(); r == nil || r.Remaining != 4999 {
+		t.Errorf("%#v is unexpected", r)
+	}
+}
@@ -0,0 +1,60 @@
+package samlassertion
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultMaxRetries is the number of retries of transient failures
+	defaultMaxRetries = 3
+	// defaultRetryDelay is the first delay of the exponential backoff
+	defaultRetryDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the backoff and Retry-After
+	maxRetryDelay = 30 * time.Second
+)
+
+// retryableStatus returns true for status codes which OneLogin returns without processing the request.
+// 502 and 504 are returned by a gateway which may have passed the request to OneLogin, so they are not retried like written requests
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// tokenRejected returns true if 401 is caused by the access token, not by the user credentials which must not be retried not to lock the user
+func tokenRejected(body []byte) bool {
+	m := strings.ToLower(string(body))
+	return strings.Contains(m, "invalid token") || strings.Contains(m, "access token") || strings.Contains(m, "authorization information")
+}
+
+// retryableError returns true for connection resets and connections closed before the request is written.
+// Once written, the POST may have been processed by OneLogin, which counts a password attempt or sends a push notification,
+// so it is not sent again
+func retryableError(err error, written bool) bool {
+	return !written && (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// retryDelay returns Retry-After of the response if present, or the exponential backoff of the attempt
+func retryDelay(res *http.Response, attempt int, base time.Duration, now time.Time) time.Duration {
+	delay := base << uint(attempt)
+	if res != nil {
+		if after := res.Header.Get("Retry-After"); after != "" {
+			if seconds, err := strconv.Atoi(after); err == nil {
+				delay = time.Duration(seconds) * time.Second
+			} else if date, err := http.ParseTime(after); err == nil {
+				delay = date.Sub(now)
+			}
+		}
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,189 @@
+package samlassertion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := func(after string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{after}}}
+	}
+	tests := []struct {
+		name     string
+		res      *http.Response
+		attempt  int
+		expected time.Duration
+	}{
+		{"first backoff", nil, 0, 500 * time.Millisecond},
+		{"exponential backoff", nil, 2, 2 * time.Second},
+		{"capped backoff", nil, 10, maxRetryDelay},
+		{"Retry-After seconds", header("3"), 2, 3 * time.Second},
+		{"Retry-After date", header("Mon, 01 Jan 2018 00:00:05 GMT"), 0, 5 * time.Second},
+		{"Retry-After in the past", header("Sun, 31 Dec 2017 23:00:00 GMT"), 0, 0},
+		{"invalid Retry-After", header("soon"), 1, time.Second},
+	}
+	for _, tt := range tests {
+		if actual := retryDelay(tt.res, tt.attempt, defaultRetryDelay, now); actual != tt.expected {
+			t.Errorf("%s: %v is not %v", tt.name, actual, tt.expected)
+		}
+	}
+}
+
+func newRetryAssertion(ts *httptest.Server, retries int) *SAMLAssertion {
+	u, _ := url.Parse(ts.URL)
+	return &SAMLAssertion{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC(),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Minute),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Minute),
+			}),
+		},
+		HTTPClient: ts.Client(),
+		MaxRetries: retries,
+		retryDelay: time.Millisecond,
+	}
+}
+
+func TestSAMLAssertion_Retry(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		switch count {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+		}
+	}))
+	defer ts.Close()
+	output, err := newRetryAssertion(ts, 3).Generate(&GenerateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output.SAML) != "SAML" || count != 3 {
+		t.Errorf("%d requests are sent", count)
+	}
+}
+
+func TestSAMLAssertion_RetryNotWritten(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 3)
+	transport := s.HTTPClient.Transport
+	resets := 0
+	s.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if resets == 0 {
+			// the connection is reset before the request is written
+			resets++
+			return nil, syscall.ECONNRESET
+		}
+		return transport.RoundTrip(req)
+	})}
+	output, err := s.Generate(&GenerateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output.SAML) != "SAML" || count != 1 {
+		t.Errorf("%d requests are sent", count)
+	}
+}
+
+func TestSAMLAssertion_NoRetryWritten(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		// reset the connection without a response after the request is received
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer ts.Close()
+	if _, err := newRetryAssertion(ts, 3).Generate(&GenerateRequest{}); err == nil || count != 1 {
+		t.Errorf("%v is returned after %d requests", err, count)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSAMLAssertion_RetryExceeded(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprintln(w, `{"status": {"message": "Too Many Requests", "error": true, "type": "rate limit", "code": 429}}`)
+	}))
+	defer ts.Close()
+	_, err := newRetryAssertion(ts, 2).Generate(&GenerateRequest{})
+	if err == nil || err.Error() != "[429] rate limit: Too Many Requests" {
+		t.Errorf("%v is unexpected", err)
+	}
+	if count != 3 {
+		t.Errorf("%d requests are sent", count)
+	}
+}
+
+func TestSAMLAssertion_NoRetry(t *testing.T) {
+	for _, code := range []int{http.StatusUnauthorized, http.StatusBadGateway, http.StatusGatewayTimeout} {
+		count := 0
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count++
+			w.WriteHeader(code)
+			fmt.Fprintf(w, `{"status": {"message": "%s", "error": true, "type": "error", "code": %d}}`, http.StatusText(code), code)
+		}))
+		if _, err := newRetryAssertion(ts, 3).Generate(&GenerateRequest{}); err == nil || count != 1 {
+			t.Errorf("%v is returned after %d requests of %d", err, count, code)
+		}
+		ts.Close()
+	}
+}
+
+func TestSAMLAssertion_RetryRejectedToken(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/oauth2/v2/token" {
+			fmt.Fprintf(w, `{"access_token": "new-access-token", "refresh_token": "new-refresh-token", "created_at": "%s", "expires_in": 36000}`, time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+			return
+		}
+		count++
+		if r.Header.Get("Authorization") != "bearer:new-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"status": {"message": "Invalid Token", "error": true, "type": "Unauthorized", "code": 401}}`)
+			return
+		}
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.config.Credentials.Tokens = &tokens.Tokens{Endpoint: s.config.Endpoint, HTTPClient: ts.Client()}
+	output, err := s.Generate(&GenerateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output.SAML) != "SAML" || count != 2 {
+		t.Errorf("%d requests are sent", count)
+	}
+}
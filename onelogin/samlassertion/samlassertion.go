@@ -2,47 +2,72 @@ package samlassertion
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/pkg/errors"
-
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
 )
 
-// SAMLAssertion OneLogin Generate SAML Assertion API
+// SAMLAssertion OneLogin Generate SAML Assertion API.
+// It is safe for concurrent use once it is configured, the fields must not be set while requests are sent
 type SAMLAssertion struct {
-	config                   *onelogin.Config
-	HTTPClient               *http.Client
+	config     *onelogin.Config
+	HTTPClient *http.Client
+	// MaxRetries is the number of retries of 429, 503 and connection resets
+	MaxRetries int
+	// Version is the version of SAML assertion API, 1 or 2. Zero means 1.
+	// It is set to 1 by Negotiate, so it must not be read while requests are sent
+	Version int
+	// Negotiate falls back to version 1 when version 2 is not found on the endpoint
+	Negotiate bool
+	// VerifyFactorTimeout is the budget of waiting for the push approval with VerifyFactor,
+	// zero means 60 polls at intervals of a second
+	VerifyFactorTimeout time.Duration
+	// OnResponse is called with the metadata of every request including retries, e.g. to log the request IDs and latencies.
+	// It is called by the goroutines sending the requests
+	OnResponse               func(Metadata)
+	retryDelay               time.Duration
 	verifyFactorLoopMax      int
 	verifyFactorLoopDuration int
+	// mu guards Version negotiated by requests and rateLimit
+	mu        sync.Mutex
+	rateLimit *RateLimit
 }
 
 // https://developers.onelogin.com/api-docs/1/saml-assertions/generate-saml-assertion
 
 // GenerateRequest request for OneLogin Generate Tokens v2 API
 type GenerateRequest struct {
-	UsernameOrEmail string `json:"username_or_email"`
-	Password        string `json:"password"`
-	AppID           string `json:"app_id"`
-	Subdomain       string `json:"subdomain"`
-	IPAddress       string `json:"ip_address"`
+	UsernameOrEmail string       `json:"username_or_email"`
+	Password        secret.Bytes `json:"password"`
+	AppID           string       `json:"app_id"`
+	Subdomain       string       `json:"subdomain"`
+	IPAddress       string       `json:"ip_address"`
 }
 
 // GenerateResponse response
 type GenerateResponse struct {
 	Status  *GenerateResponseStatus `json:"status"`
-	SAML    string
+	SAML    secret.Bytes
 	Factors []GenerateResponseFactor
 }
 
 // GenerateSAMLResponse response of OneLogin Generate Tokens v2 API without mfa
 type GenerateSAMLResponse struct {
 	Status *GenerateResponseStatus `json:"status"`
-	SAML   string                  `json:"data"`
+	SAML   secret.Bytes            `json:"data"`
 }
 
 // GenerateFactorsResponse response of OneLogin Generate Tokens v2 API with mfa
@@ -84,17 +109,19 @@ type GenerateResponseFactorUser struct {
 
 // VerifyFactorRequest request for OneLogin VerifyFactor Tokens v2 API
 type VerifyFactorRequest struct {
-	AppID       string `json:"app_id"`
-	DeviceID    string `json:"device_id"`
-	StateToken  string `json:"state_token"`
-	OtpToken    string `json:"otp_token"`
-	DoNotNotify bool   `json:"do_not_notify"`
+	AppID       string       `json:"app_id"`
+	DeviceID    string       `json:"device_id"`
+	StateToken  string       `json:"state_token"`
+	OtpToken    secret.Bytes `json:"otp_token"`
+	DoNotNotify bool         `json:"do_not_notify"`
+	// OnPending is called with the remaining time while waiting for the push approval
+	OnPending func(remaining time.Duration) `json:"-"`
 }
 
 // VerifyFactorTemporaryResponse response of OneLogin VerifyFactor Tokens v2 API
 type VerifyFactorResponse struct {
 	Status *VerifyFactorResponseStatus `json:"status"`
-	SAML   string                      `json:"data"`
+	SAML   secret.Bytes                `json:"data"`
 }
 
 // VerifyFactorResponseStatus status
@@ -107,9 +134,15 @@ type VerifyFactorResponseStatus struct {
 
 // NewSAMLAssertion creates a SAMLAssertion
 func NewSAMLAssertion(config *onelogin.Config) *SAMLAssertion {
+	client := config.HTTPClient
+	if client == nil {
+		client = transport.DefaultClient
+	}
 	return &SAMLAssertion{
 		config:                   config,
-		HTTPClient:               &http.Client{},
+		HTTPClient:               client,
+		MaxRetries:               defaultMaxRetries,
+		retryDelay:               defaultRetryDelay,
 		verifyFactorLoopMax:      60,
 		verifyFactorLoopDuration: 1000,
 	}
@@ -117,20 +150,33 @@ func NewSAMLAssertion(config *onelogin.Config) *SAMLAssertion {
 
 // Generate call generate tokens v2
 func (s *SAMLAssertion) Generate(input *GenerateRequest) (*GenerateResponse, error) {
+	return s.GenerateWithContext(context.Background(), input)
+}
+
+// GenerateWithContext is the same as Generate, but the request is canceled when the context is done
+func (s *SAMLAssertion) GenerateWithContext(ctx context.Context, input *GenerateRequest) (*GenerateResponse, error) {
 	inputJSON, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
-	body, err := s.post("/api/1/saml_assertion", inputJSON)
+	defer secret.Wipe(inputJSON)
+	if s.version() == 2 {
+		output, err := s.generateV2(ctx, inputJSON)
+		if err != errNotSupported {
+			return output, err
+		}
+	}
+	res, body, err := s.post(ctx, "/api/1/saml_assertion", inputJSON)
 	if err != nil {
 		return nil, err
 	}
+	defer secret.Wipe(body)
 	var output GenerateResponse
-	if err := json.Unmarshal(body, &output); err != nil {
+	if err := apiresponse.Decode(res, body, &output); err != nil {
 		return nil, err
 	}
 	if output.Status.Error {
-		return nil, errors.Errorf("[%d] %s: %s", output.Status.Code, output.Status.Type, output.Status.Message)
+		return nil, apiresponse.WithRequestID(res, newError(output.Status.Code, output.Status.Type, output.Status.Message))
 	}
 	if output.Status.Message == "Success" {
 		var saml GenerateSAMLResponse
@@ -143,75 +189,168 @@ func (s *SAMLAssertion) Generate(input *GenerateRequest) (*GenerateResponse, err
 		if err := json.Unmarshal(body, &factors); err != nil {
 			return nil, err
 		}
-		devices := factors.Factors[0].Devices
-		for i := range devices {
-			devices[i].RequireOTPToken = true
-			device := devices[i]
-			if device.DeviceType == "OneLogin Protect" {
-				devices = append(devices, GenerateResponseFactorDevice{
-					DeviceType:      "Notify to OneLogin Protect",
-					DeviceID:        device.DeviceID,
-					RequireOTPToken: false,
-				})
-			}
+		if len(factors.Factors) == 0 || len(factors.Factors[0].Devices) == 0 {
+			return nil, &Error{Code: output.Status.Code, Type: output.Status.Type, Message: "no MFA device is registered", Err: ErrMFARequired}
 		}
-		factors.Factors[0].Devices = devices
+		factors.Factors[0].Devices = withNotify(factors.Factors[0].Devices)
 		output.Factors = factors.Factors
 	}
 	return &output, nil
 }
 
+// withNotify requires OTP tokens of the devices, and adds the push notification for OneLogin Protect
+func withNotify(devices []GenerateResponseFactorDevice) []GenerateResponseFactorDevice {
+	for i := range devices {
+		devices[i].RequireOTPToken = true
+		device := devices[i]
+		if device.DeviceType == "OneLogin Protect" {
+			devices = append(devices, GenerateResponseFactorDevice{
+				DeviceType:      "Notify to OneLogin Protect",
+				DeviceID:        device.DeviceID,
+				RequireOTPToken: false,
+			})
+		}
+	}
+	return devices
+}
+
 // VerifyFactor call VerifyFactor tokens v2
 func (s *SAMLAssertion) VerifyFactor(input *VerifyFactorRequest) (*VerifyFactorResponse, error) {
-	return s.verifyFactor(input, 0)
+	return s.VerifyFactorWithContext(context.Background(), input)
+}
+
+// VerifyFactorWithContext is the same as VerifyFactor, but the request and waiting for the push approval are canceled when the context is done
+func (s *SAMLAssertion) VerifyFactorWithContext(ctx context.Context, input *VerifyFactorRequest) (*VerifyFactorResponse, error) {
+	return s.verifyFactor(ctx, input, 0)
 }
 
-func (s *SAMLAssertion) verifyFactor(input *VerifyFactorRequest, loopCount int) (*VerifyFactorResponse, error) {
+func (s *SAMLAssertion) verifyFactor(ctx context.Context, input *VerifyFactorRequest, loopCount int) (*VerifyFactorResponse, error) {
 	inputJSON, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
-	body, err := s.post("/api/1/saml_assertion/verify_factor", inputJSON)
-	if err != nil {
-		return nil, err
+	defer secret.Wipe(inputJSON)
+	var output *VerifyFactorResponse
+	if s.version() == 2 {
+		output, err = s.verifyFactorV2(ctx, inputJSON)
 	}
-	var output VerifyFactorResponse
-	if err := json.Unmarshal(body, &output); err != nil {
-		return nil, err
+	if s.version() != 2 || err == errNotSupported {
+		output, err = s.verifyFactorV1(ctx, inputJSON)
 	}
-	if output.Status.Error {
-		return nil, errors.Errorf("[%d] %s: %s", output.Status.Code, output.Status.Type, output.Status.Message)
+	if err != nil {
+		return nil, err
 	}
 	if output.Status.Type == "pending" {
-		if loopCount >= s.verifyFactorLoopMax {
-			return nil, errors.Errorf("[%d] timed out: %s", output.Status.Code, output.Status.Message)
+		interval := time.Duration(s.verifyFactorLoopDuration) * time.Millisecond
+		loopMax := s.verifyFactorLoopMax
+		if s.VerifyFactorTimeout > 0 {
+			loopMax = int(s.VerifyFactorTimeout / interval)
+		}
+		if loopCount >= loopMax {
+			return nil, &Error{Code: output.Status.Code, Type: "timed out", Message: output.Status.Message, Err: ErrVerifyTimedOut}
+		}
+		if input.OnPending != nil {
+			input.OnPending(time.Duration(loopMax-loopCount) * interval)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
 		}
-		time.Sleep(time.Duration(s.verifyFactorLoopDuration))
 		input.DoNotNotify = true
-		return s.verifyFactor(input, loopCount+1)
+		return s.verifyFactor(ctx, input, loopCount+1)
 	}
-	return &output, nil
+	return output, nil
 }
 
-// post OneLogin API Request
-func (s *SAMLAssertion) post(path string, body []byte) ([]byte, error) {
-	url := fmt.Sprintf("https://%s%s", s.config.Endpoint, path)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+func (s *SAMLAssertion) verifyFactorV1(ctx context.Context, inputJSON []byte) (*VerifyFactorResponse, error) {
+	res, body, err := s.post(ctx, "/api/1/saml_assertion/verify_factor", inputJSON)
 	if err != nil {
 		return nil, err
 	}
-	credentials, err := s.config.Credentials.Get()
-	if err != nil {
+	defer secret.Wipe(body)
+	var output VerifyFactorResponse
+	if err := apiresponse.Decode(res, body, &output); err != nil {
 		return nil, err
 	}
-	authorization := fmt.Sprintf("bearer:%s", credentials.AccessToken)
-	req.Header.Set("Authorization", authorization)
-	req.Header.Set("Content-Type", "application/json")
-	client := s.HTTPClient
-	res, err := client.Do(req)
+	if output.Status.Error {
+		return nil, apiresponse.WithRequestID(res, newError(output.Status.Code, output.Status.Type, output.Status.Message))
+	}
+	return &output, nil
+}
+
+func (s *SAMLAssertion) version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Version == 0 {
+		return 1
+	}
+	return s.Version
+}
+
+// post OneLogin API Request and returns the response and the body, transient failures are retried with exponential backoff.
+// The request is retried once with a new access token if the token is rejected before its expiration
+func (s *SAMLAssertion) post(ctx context.Context, path string, body []byte) (*http.Response, []byte, error) {
+	url := fmt.Sprintf("https://%s%s", s.config.Endpoint, path)
+	credentials, err := s.config.Credentials.GetWithContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	accessToken := credentials.AccessToken
+	reauthorized := false
+	client := s.HTTPClient
+	for attempt := 0; ; attempt++ {
+		// written is set by the transport goroutine when the request is written to the connection
+		var written int32
+		trace := &httptrace.ClientTrace{WroteRequest: func(httptrace.WroteRequestInfo) { atomic.StoreInt32(&written, 1) }}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("bearer:%s", accessToken))
+		req.Header.Set("Content-Type", "application/json")
+		useragent.Set(req.Header)
+		start := time.Now()
+		res, err := client.Do(req)
+		s.observe(req, res, err, attempt, start)
+		if err == nil {
+			s.setRateLimit(res.Header)
+		}
+		if err == nil && res.StatusCode == http.StatusUnauthorized && !reauthorized {
+			data, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil || !tokenRejected(data) {
+				return res, data, err
+			}
+			reauthorized = true
+			// another goroutine may have replaced the rejected token already, then it is used without refreshing again
+			s.config.Credentials.Expire(accessToken)
+			credentials, err := s.config.Credentials.GetWithContext(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			accessToken = credentials.AccessToken
+			attempt--
+			continue
+		}
+		retry := attempt < s.MaxRetries && ctx.Err() == nil
+		if err != nil && !(retry && retryableError(err, atomic.LoadInt32(&written) == 1)) {
+			return nil, nil, err
+		}
+		if err == nil && !(retry && retryableStatus(res.StatusCode)) {
+			defer res.Body.Close()
+			data, err := ioutil.ReadAll(res.Body)
+			return res, data, err
+		}
+		delay := retryDelay(res, attempt, s.retryDelay, time.Now())
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(delay):
+		}
 	}
-	defer res.Body.Close()
-	return ioutil.ReadAll(res.Body)
 }
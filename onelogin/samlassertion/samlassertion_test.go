@@ -2,19 +2,23 @@ package samlassertion
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
 	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
 )
 
 func TestSAMLAssertion_Generate(t *testing.T) {
@@ -42,7 +46,7 @@ func TestSAMLAssertion_Generate(t *testing.T) {
 	}
 	request := &GenerateRequest{
 		UsernameOrEmail: "username-or-email",
-		Password:        "password",
+		Password:        secret.Bytes("password"),
 		AppID:           "app-id",
 		Subdomain:       "subdomain",
 		IPAddress:       "ip-address",
@@ -85,7 +89,7 @@ func TestSAMLAssertion_Generate(t *testing.T) {
 					Error:   false,
 					Code:    200,
 				},
-				SAML: "Base64 Encoded SAML Data",
+				SAML: secret.Bytes("Base64 Encoded SAML Data"),
 			},
 			wantErr: false,
 		},
@@ -343,14 +347,14 @@ func TestSAMLAssertion_VerifyFactor(t *testing.T) {
 		AppID:       "app-id",
 		DeviceID:    "device_id",
 		StateToken:  "state_token",
-		OtpToken:    "otp_token",
+		OtpToken:    secret.Bytes("otp_token"),
 		DoNotNotify: false,
 	}
 	notifyRequest := &VerifyFactorRequest{
 		AppID:       "app-id",
 		DeviceID:    "device_id",
 		StateToken:  "state_token",
-		OtpToken:    "",
+		OtpToken:    secret.Bytes{},
 		DoNotNotify: true,
 	}
 
@@ -391,7 +395,7 @@ func TestSAMLAssertion_VerifyFactor(t *testing.T) {
 					Error:   false,
 					Code:    200,
 				},
-				SAML: "Base64 Encoded SAML Data",
+				SAML: secret.Bytes("Base64 Encoded SAML Data"),
 			},
 			wantErr: false,
 		},
@@ -423,7 +427,7 @@ func TestSAMLAssertion_VerifyFactor(t *testing.T) {
 					Error:   false,
 					Code:    200,
 				},
-				SAML: "Base64 Encoded SAML Data",
+				SAML: secret.Bytes("Base64 Encoded SAML Data"),
 			},
 			wantErr: false,
 		},
@@ -535,3 +539,148 @@ func TestSAMLAssertion_VerifyFactor(t *testing.T) {
 		})
 	}
 }
+
+func TestSAMLAssertion_VerifyFactorOnPending(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		status := `{"status": {"message": "Authentication pending on OL Protect", "error": false, "type": "pending", "code": 200}}`
+		if count > 2 {
+			status = `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`
+		}
+		fmt.Fprintln(w, status)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	s := &SAMLAssertion{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC(),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Minute),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Minute),
+			}),
+		},
+		HTTPClient:               ts.Client(),
+		verifyFactorLoopMax:      3,
+		verifyFactorLoopDuration: 10,
+	}
+	remainings := []time.Duration{}
+	_, err := s.VerifyFactor(&VerifyFactorRequest{
+		OnPending: func(remaining time.Duration) {
+			remainings = append(remainings, remaining)
+		},
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	expected := []time.Duration{30 * time.Millisecond, 20 * time.Millisecond}
+	if !reflect.DeepEqual(remainings, expected) {
+		t.Errorf("%v is not equal %v", remainings, expected)
+	}
+}
+
+func TestSAMLAssertion_VerifyFactorPollInterval(t *testing.T) {
+	// verifyFactorLoopDuration is milliseconds, so the push approval is polled every second for a minute by default
+	d := NewSAMLAssertion(&onelogin.Config{})
+	if d.verifyFactorLoopDuration != 1000 || d.verifyFactorLoopMax != 60 {
+		t.Errorf("%dms x %d is not the default poll interval", d.verifyFactorLoopDuration, d.verifyFactorLoopMax)
+	}
+	polls := []time.Time{}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls = append(polls, time.Now())
+		status := `{"status": {"message": "Authentication pending on OL Protect", "error": false, "type": "pending", "code": 200}}`
+		if len(polls) > 2 {
+			status = `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`
+		}
+		fmt.Fprintln(w, status)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	s := &SAMLAssertion{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC(),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Minute),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Minute),
+			}),
+		},
+		HTTPClient:               ts.Client(),
+		verifyFactorLoopMax:      3,
+		verifyFactorLoopDuration: 50,
+	}
+	if _, err := s.VerifyFactor(&VerifyFactorRequest{}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i := 1; i < len(polls); i++ {
+		if interval := polls[i].Sub(polls[i-1]); interval < 50*time.Millisecond {
+			t.Errorf("polled again after %v", interval)
+		}
+	}
+}
+
+func TestSAMLAssertion_VerifyFactorWithContext(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status": {"message": "Authentication pending on OL Protect", "error": false, "type": "pending", "code": 200}}`)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	s := &SAMLAssertion{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC(),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Minute),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Minute),
+			}),
+		},
+		HTTPClient:               ts.Client(),
+		verifyFactorLoopMax:      60,
+		verifyFactorLoopDuration: 1000,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := s.VerifyFactorWithContext(ctx, &VerifyFactorRequest{
+		OnPending: func(remaining time.Duration) {
+			cancel()
+		},
+	})
+	if err != context.Canceled {
+		t.Errorf("%v is not canceled", err)
+	}
+	if _, err := s.GenerateWithContext(ctx, &GenerateRequest{}); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("%v is not canceled", err)
+	}
+}
+
+func TestSAMLAssertion_VerifyFactorTimedOut(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status": {"message": "Authentication pending on OL Protect", "error": false, "type": "pending", "code": 200}}`)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	s := &SAMLAssertion{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC(),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Minute),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Minute),
+			}),
+		},
+		HTTPClient:               ts.Client(),
+		verifyFactorLoopMax:      2,
+		verifyFactorLoopDuration: 10,
+	}
+	_, err := s.VerifyFactor(&VerifyFactorRequest{})
+	if !errors.Is(err, ErrVerifyTimedOut) {
+		t.Errorf("%v is not ErrVerifyTimedOut", err)
+	}
+	if expected := "[200] timed out: Authentication pending on OL Protect"; err == nil || err.Error() != expected {
+		t.Errorf("%v is not %s", err, expected)
+	}
+}
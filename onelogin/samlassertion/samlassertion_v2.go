@@ -0,0 +1,114 @@
+package samlassertion
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// https://developers.onelogin.com/api-docs/2/saml-assertions/generate-saml-assertion
+// https://developers.onelogin.com/api-docs/2/saml-assertions/verify-factor
+
+// errNotSupported is returned when version 2 is not found and Negotiate is enabled
+var errNotSupported = errors.New("SAML assertion API version 2 is not supported")
+
+// generateV2Response is the response of version 2, which has no status object and lists MFA devices at the top level
+type generateV2Response struct {
+	Message     string                         `json:"message"`
+	SAML        secret.Bytes                   `json:"data"`
+	StateToken  string                         `json:"state_token"`
+	Devices     []GenerateResponseFactorDevice `json:"devices"`
+	CallbackURL string                         `json:"callback_url"`
+	User        *GenerateResponseFactorUser    `json:"user"`
+}
+
+// verifyFactorV2Response is the response of version 2 verify factor
+type verifyFactorV2Response struct {
+	Message string       `json:"message"`
+	SAML    secret.Bytes `json:"data"`
+}
+
+// errorV2Response is the error of version 2, which is returned with the HTTP status code
+type errorV2Response struct {
+	StatusCode int    `json:"statusCode"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+}
+
+func (s *SAMLAssertion) generateV2(ctx context.Context, inputJSON []byte) (*GenerateResponse, error) {
+	response, body, err := s.postV2(ctx, "/api/2/saml_assertion", inputJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Wipe(body)
+	var res generateV2Response
+	if err := apiresponse.Decode(response, body, &res); err != nil {
+		return nil, err
+	}
+	output := &GenerateResponse{
+		Status: &GenerateResponseStatus{Type: "success", Message: res.Message, Code: http.StatusOK},
+	}
+	if len(res.SAML) > 0 {
+		output.SAML = res.SAML
+		return output, nil
+	}
+	if len(res.Devices) == 0 {
+		return nil, &Error{Code: http.StatusOK, Type: "success", Message: "no MFA device is registered", Err: ErrMFARequired}
+	}
+	output.Factors = []GenerateResponseFactor{{
+		StateToken:  res.StateToken,
+		Devices:     withNotify(res.Devices),
+		CallbackURL: res.CallbackURL,
+		User:        res.User,
+	}}
+	return output, nil
+}
+
+func (s *SAMLAssertion) verifyFactorV2(ctx context.Context, inputJSON []byte) (*VerifyFactorResponse, error) {
+	response, body, err := s.postV2(ctx, "/api/2/saml_assertion/verify_factor", inputJSON)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Wipe(body)
+	var res verifyFactorV2Response
+	if err := apiresponse.Decode(response, body, &res); err != nil {
+		return nil, err
+	}
+	status := &VerifyFactorResponseStatus{Type: "success", Message: res.Message, Code: http.StatusOK}
+	if len(res.SAML) == 0 && strings.Contains(strings.ToLower(res.Message), "pending") {
+		status.Type = "pending"
+	}
+	return &VerifyFactorResponse{Status: status, SAML: res.SAML}, nil
+}
+
+// postV2 posts to version 2, and converts error status codes to *Error.
+// It returns errNotSupported and falls back to version 1 if version 2 is not found with Negotiate
+func (s *SAMLAssertion) postV2(ctx context.Context, path string, inputJSON []byte) (*http.Response, []byte, error) {
+	response, body, err := s.post(ctx, path, inputJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	code := response.StatusCode
+	if code == http.StatusNotFound && s.Negotiate {
+		secret.Wipe(body)
+		s.mu.Lock()
+		s.Version = 1
+		s.mu.Unlock()
+		return nil, nil, errNotSupported
+	}
+	if code >= http.StatusBadRequest {
+		defer secret.Wipe(body)
+		res := errorV2Response{Name: http.StatusText(code)}
+		if err := apiresponse.Decode(response, body, &res); err != nil {
+			// the error page of a proxy or WAF is shown instead
+			res.Message = err.(*apiresponse.Error).Snippet
+		}
+		return nil, nil, apiresponse.WithRequestID(response, newError(code, res.Name, res.Message))
+	}
+	return response, body, nil
+}
@@ -0,0 +1,178 @@
+package samlassertion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSAMLAssertion_GenerateV2(t *testing.T) {
+	responses := map[string]string{
+		"saml": `{"data": "SAML", "message": "Success"}`,
+		"mfa":  `{"state_token": "state-token", "message": "MFA is required for this user", "devices": [{"device_id": 1, "device_type": "OneLogin Protect"}], "callback_url": "https://api.us.onelogin.com/api/2/saml_assertion/verify_factor", "user": {"id": 2}}`,
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/2/saml_assertion" {
+			t.Errorf("%s is requested", r.URL.Path)
+		}
+		if r.Header.Get("X-Case") == "error" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"statusCode": 401, "name": "Unauthorized", "message": "Authentication Failed: Invalid user credentials"}`)
+			return
+		}
+		fmt.Fprintln(w, responses[r.Header.Get("X-Case")])
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.Version = 2
+	respond := func(c string) {
+		s.HTTPClient = &http.Client{Transport: &caseTransport{c, ts.Client().Transport}}
+	}
+
+	respond("saml")
+	output, err := s.Generate(&GenerateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output.SAML) != "SAML" || output.Status.Message != "Success" {
+		t.Errorf("%#v is unexpected", output)
+	}
+
+	respond("mfa")
+	output, err = s.Generate(&GenerateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Factors) != 1 || output.Factors[0].StateToken != "state-token" || len(output.Factors[0].Devices) != 2 || output.Factors[0].User.ID != 2 {
+		t.Errorf("%#v is unexpected", output.Factors)
+	}
+
+	respond("error")
+	_, err = s.Generate(&GenerateRequest{})
+	if Class(err) != ErrInvalidCredentials || err.Error() != "[401] Unauthorized: Authentication Failed: Invalid user credentials" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestSAMLAssertion_VerifyFactorV2(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/2/saml_assertion/verify_factor" {
+			t.Errorf("%s is requested", r.URL.Path)
+		}
+		count++
+		if count == 1 {
+			fmt.Fprintln(w, `{"message": "Authentication pending on OL Protect"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"data": "SAML", "message": "Success"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.Version = 2
+	s.verifyFactorLoopMax = 3
+	s.verifyFactorLoopDuration = 1
+	output, err := s.VerifyFactor(&VerifyFactorRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output.SAML) != "SAML" || count != 2 {
+		t.Errorf("SAML is returned after %d requests", count)
+	}
+}
+
+func TestSAMLAssertion_VerifyFactorTimeout(t *testing.T) {
+	count := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		fmt.Fprintln(w, `{"message": "Authentication pending on OL Protect"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.Version = 2
+	s.verifyFactorLoopMax = 60
+	s.verifyFactorLoopDuration = 1
+	s.VerifyFactorTimeout = 3 * time.Millisecond
+	remaining := []time.Duration{}
+	_, err := s.VerifyFactor(&VerifyFactorRequest{OnPending: func(d time.Duration) { remaining = append(remaining, d) }})
+	if err == nil || count != 4 {
+		t.Errorf("%v is returned after %d requests", err, count)
+	}
+	if len(remaining) != 3 || remaining[0] != 3*time.Millisecond {
+		t.Errorf("%v is unexpected", remaining)
+	}
+}
+
+func TestSAMLAssertion_Negotiate(t *testing.T) {
+	paths := []string{}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/2/saml_assertion" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.Version = 2
+	s.Negotiate = true
+	for i := 0; i < 2; i++ {
+		output, err := s.Generate(&GenerateRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output.SAML) != "SAML" {
+			t.Errorf("%#v is unexpected", output)
+		}
+	}
+	expected := "[/api/2/saml_assertion /api/1/saml_assertion /api/1/saml_assertion]"
+	if fmt.Sprint(paths) != expected {
+		t.Errorf("%v is not %s", paths, expected)
+	}
+}
+
+func TestSAMLAssertion_ConcurrentNegotiate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/2/saml_assertion" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprintln(w, `{"status": {"message": "Success", "error": false, "type": "success", "code": 200}, "data": "SAML"}`)
+	}))
+	defer ts.Close()
+	s := newRetryAssertion(ts, 0)
+	s.Version = 2
+	s.Negotiate = true
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if output, err := s.Generate(&GenerateRequest{}); err != nil || string(output.SAML) != "SAML" {
+				t.Errorf("%#v, %v is unexpected", output, err)
+			}
+			s.RateLimit()
+		}()
+	}
+	wg.Wait()
+	if s.version() != 1 {
+		t.Errorf("version %d is not negotiated", s.version())
+	}
+}
+
+// caseTransport tells the test server which response is expected
+type caseTransport struct {
+	name      string
+	transport http.RoundTripper
+}
+
+func (c *caseTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.Header.Set("X-Case", c.name)
+	return c.transport.RoundTrip(r)
+}
@@ -1,9 +1,15 @@
 package samlassertioniface
 
-import "github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+import (
+	"context"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/samlassertion"
+)
 
 // SAMLAssertionAPI is SAMLAssertion API Interface
 type SAMLAssertionAPI interface {
 	Generate(input *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error)
+	GenerateWithContext(ctx context.Context, input *samlassertion.GenerateRequest) (*samlassertion.GenerateResponse, error)
 	VerifyFactor(input *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error)
+	VerifyFactorWithContext(ctx context.Context, input *samlassertion.VerifyFactorRequest) (*samlassertion.VerifyFactorResponse, error)
 }
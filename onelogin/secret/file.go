@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// FileMode is the permission of files holding secrets
+	FileMode os.FileMode = 0600
+	// DirMode is the permission of directories holding secret files
+	DirMode os.FileMode = 0700
+)
+
+// WriteFile writes the data to a temporary file in the same directory and renames it to the file,
+// so the file is only accessible by the user and never left partially written
+func WriteFile(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), FileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Exposed returns the permission of the file or directory if it is accessible by the group or others.
+// It returns zero if it is not exposed or does not exist, and always on Windows where permissions are ACLs
+func Exposed(file string) (os.FileMode, error) {
+	if runtime.GOOS == "windows" {
+		return 0, nil
+	}
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return perm, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "credentials")
+	if err := ioutil.WriteFile(file, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(file, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("%s is not new", data)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("temporary file is left: %v", files)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if perm := files[0].Mode().Perm(); perm != FileMode {
+		t.Errorf("%04o is not %04o", perm, FileMode)
+	}
+}
+
+func TestExposed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are ACLs on Windows")
+	}
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.toml")
+	if perm, err := Exposed(file); err != nil || perm != 0 {
+		t.Errorf("%04o, %v is returned for a missing file", perm, err)
+	}
+	if err := ioutil.WriteFile(file, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if perm, err := Exposed(file); err != nil || perm != 0 {
+		t.Errorf("%04o, %v is returned for a private file", perm, err)
+	}
+	if err := os.Chmod(file, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if perm, err := Exposed(file); err != nil || perm != 0640 {
+		t.Errorf("%04o, %v is not 0640", perm, err)
+	}
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// minRegistered is the minimum length of registered secrets, shorter values would mask unrelated text
+const minRegistered = 4
+
+// secretFields matches fields of secrets in JSON, Go structs, form values, HTTP headers and aligned logs,
+// like `"password":"..."`, `ClientSecret:"..."`, `access_token=...` and `SessionToken:	...`
+var secretFields = regexp.MustCompile(`(?i)("?\b(?:password|otp_?token|client_?secret|access_?token|refresh_?token|secret_?access_?key|session_?token|saml_?response|saml_?assertion|authorization)"?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|[^\s,&}\]]+)`)
+
+// registered counts registrations of each secret, so a value registered by two holders is kept until both unregister it
+var registered = struct {
+	sync.RWMutex
+	values map[string]int
+}{values: map[string]int{}}
+
+// Register registers secrets held as string, like the client secret, OAuth tokens and AWS session tokens,
+// which are masked by Filter wherever they appear
+func Register(values ...string) {
+	registered.Lock()
+	defer registered.Unlock()
+	for _, value := range values {
+		if len(value) >= minRegistered {
+			registered.values[value]++
+		}
+	}
+}
+
+// Unregister cancels Register of the secrets which are discarded, like replaced tokens,
+// so long-running processes do not keep every secret they have ever seen
+func Unregister(values ...string) {
+	registered.Lock()
+	defer registered.Unlock()
+	for _, value := range values {
+		if registered.values[value] > 1 {
+			registered.values[value]--
+		} else {
+			delete(registered.values, value)
+		}
+	}
+}
+
+// Filter masks registered secrets and values of secret fields in diagnostic output
+func Filter(s string) string {
+	registered.RLock()
+	for value := range registered.values {
+		s = strings.Replace(s, value, Redacted, -1)
+	}
+	registered.RUnlock()
+	return secretFields.ReplaceAllStringFunc(s, func(field string) string {
+		m := secretFields.FindStringSubmatch(field)
+		if m[2] == `""` {
+			return field
+		}
+		if strings.HasPrefix(m[2], `"`) {
+			return m[1] + `"` + Redacted + `"`
+		}
+		return m[1] + Redacted
+	})
+}
+
+// NewWriter returns a writer which masks secrets with Filter before writing to w.
+// Each write is filtered independently, so it suits writers of whole lines like log.Logger
+func NewWriter(w io.Writer) io.Writer {
+	return &filterWriter{w: w}
+}
+
+type filterWriter struct {
+	w io.Writer
+}
+
+func (f *filterWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(f.w, Filter(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
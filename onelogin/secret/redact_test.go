@@ -0,0 +1,61 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"username_or_email":"user","password":"p@ss\"word","otp_token":"123456"}`, `{"username_or_email":"user","password":"********","otp_token":"********"}`},
+		{`config.ServiceConfig{Endpoint:"us", ClientToken:"token", ClientSecret:"secret"}`, `config.ServiceConfig{Endpoint:"us", ClientToken:"token", ClientSecret:"********"}`},
+		{"  SessionToken:\tFQoDYXdzEJr//////////", "  SessionToken:\t********"},
+		{"  SecretAccessKey:\twJalrXUtnFEMI/K7MDENG", "  SecretAccessKey:\t********"},
+		{"grant_type=refresh_token&refresh_token=abcd&access_token=efgh", "grant_type=refresh_token&refresh_token=********&access_token=********"},
+		{"Authorization: bearer:0123456789abcdef", "Authorization: ********"},
+		{`{"password":""}`, `{"password":""}`},
+		{"AccessKeyId: ASIAEXAMPLE", "AccessKeyId: ASIAEXAMPLE"},
+	}
+	for _, tt := range tests {
+		if actual := Filter(tt.input); actual != tt.expected {
+			t.Errorf("%s is not %s", actual, tt.expected)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("registered-secret", "abc")
+	if actual := Filter("error: registered-secret is invalid, abc"); actual != "error: "+Redacted+" is invalid, abc" {
+		t.Errorf("%s is not redacted", actual)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	Register("discarded-token", "discarded-token")
+	Unregister("discarded-token")
+	if actual := Filter("discarded-token"); actual != Redacted {
+		t.Errorf("%s is unregistered while registered twice", actual)
+	}
+	Unregister("discarded-token")
+	if actual := Filter("discarded-token"); actual != "discarded-token" {
+		t.Errorf("%s is not unregistered", actual)
+	}
+	if _, ok := registered.values["discarded-token"]; ok {
+		t.Error("discarded-token is kept in the registered values")
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.New(NewWriter(buf), "", 0)
+	logger.Printf("AppConfig: %#v\n", struct{ Password, RoleArn string }{"password", "arn"})
+	expected := fmt.Sprintf("AppConfig: struct { Password string; RoleArn string }{Password:\"%s\", RoleArn:\"arn\"}\n", Redacted)
+	if buf.String() != expected {
+		t.Errorf("%s is not %s", buf.String(), expected)
+	}
+}
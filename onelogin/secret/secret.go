@@ -0,0 +1,95 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Redacted is printed instead of secrets
+const Redacted = "********"
+
+// Bytes holds a secret like a password, an OTP token or a SAML assertion.
+// It is printed as Redacted by fmt and log, and should be wiped after use.
+type Bytes []byte
+
+// Format prints Redacted instead of the secret for any verb
+func (b Bytes) Format(f fmt.State, verb rune) {
+	if len(b) > 0 {
+		io.WriteString(f, Redacted)
+	}
+}
+
+// Copy returns a copy of the secret, which can be wiped independently
+func (b Bytes) Copy() Bytes {
+	if b == nil {
+		return nil
+	}
+	return append(Bytes{}, b...)
+}
+
+// Wipe overwrites the secret with zeros
+func (b Bytes) Wipe() {
+	Wipe(b)
+}
+
+// MarshalJSON encodes the secret as a JSON string without converting it to string
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf("secret is not valid UTF-8")
+	}
+	out := make([]byte, 0, len(b)+2)
+	out = append(out, '"')
+	for _, c := range b {
+		switch {
+		case c == '"' || c == '\\':
+			out = append(out, '\\', c)
+		case c < 0x20:
+			out = append(out, fmt.Sprintf(`\u%04x`, c)...)
+		default:
+			out = append(out, c)
+		}
+	}
+	return append(out, '"'), nil
+}
+
+// UnmarshalJSON decodes a JSON string, wiping the previous secret
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	b.Wipe()
+	if bytes.Equal(data, []byte("null")) {
+		*b = nil
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("secret is not a JSON string")
+	}
+	value := data[1 : len(data)-1]
+	if bytes.IndexByte(value, '\\') < 0 {
+		*b = append(Bytes{}, value...)
+		return nil
+	}
+	// escaped strings are rare in secrets, decode them with encoding/json
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b = Bytes(s)
+	return nil
+}
+
+// Wipe overwrites the buffer with zeros, e.g. a request body including secrets
+func Wipe(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// Redact returns Redacted for a secret which is held as string, or empty string if it is empty
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
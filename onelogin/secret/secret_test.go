@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	s := Bytes("password")
+	for _, format := range []string{"%v", "%s", "%q", "%#v", "%x", "%+v"} {
+		if actual := fmt.Sprintf(format, s); actual != Redacted {
+			t.Errorf("%s: %s is not redacted", format, actual)
+		}
+	}
+	if actual := fmt.Sprintf("%v", Bytes{}); actual != "" {
+		t.Errorf("%s is not empty", actual)
+	}
+	if actual := fmt.Sprintf("%v", struct{ Password Bytes }{s}); actual != "{"+Redacted+"}" {
+		t.Errorf("%s is not redacted", actual)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	s := Bytes("password")
+	c := s.Copy()
+	s.Wipe()
+	if string(s) != "\x00\x00\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("%q is not wiped", []byte(s))
+	}
+	if string(c) != "password" {
+		t.Errorf("%q is wiped with the original", []byte(c))
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []string{"password", `pass"word\`, "パスワード\n", ""}
+	for _, tt := range tests {
+		data, err := json.Marshal(struct {
+			Password Bytes `json:"password"`
+		}{Bytes(tt)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plain struct {
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal(data, &plain); err != nil || plain.Password != tt {
+			t.Errorf("%s is decoded as %q, %v", data, plain.Password, err)
+		}
+		var decoded struct {
+			Password Bytes `json:"password"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil || string(decoded.Password) != tt {
+			t.Errorf("%s is decoded as %q, %v", data, []byte(decoded.Password), err)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if actual := Redact("secret"); actual != Redacted {
+		t.Errorf("%s is not redacted", actual)
+	}
+	if actual := Redact(""); actual != "" {
+		t.Errorf("%s is not empty", actual)
+	}
+}
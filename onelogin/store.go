@@ -0,0 +1,73 @@
+package onelogin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// CredentialsStore keeps the tokens of OneLogin API clients between runs
+type CredentialsStore interface {
+	// Load returns the stored tokens of the client, or nil if they are not stored
+	Load(clientToken string) (*credentials.Value, error)
+	Save(clientToken string, value credentials.Value) error
+	// Remove removes the tokens of the client, it is not an error if they are not stored
+	Remove(clientToken string) error
+}
+
+// FileStore stores the tokens in a TOML file of each client in Dir, which only the user can read
+type FileStore struct {
+	Dir string
+}
+
+// Load reads the tokens, the unreadable file is regarded as not stored
+func (s FileStore) Load(clientToken string) (*credentials.Value, error) {
+	var v credentials.Value
+	if _, err := toml.DecodeFile(s.file(clientToken), &v); err != nil {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+// Save writes the tokens
+func (s FileStore) Save(clientToken string, value credentials.Value) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(&value); err != nil {
+		return err
+	}
+	defer secret.Wipe(buf.Bytes())
+	return secret.WriteFile(s.file(clientToken), buf.Bytes())
+}
+
+// Remove removes the file of the tokens
+func (s FileStore) Remove(clientToken string) error {
+	if err := os.Remove(s.file(clientToken)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s FileStore) file(clientToken string) string {
+	return path.Join(s.Dir, fmt.Sprintf("onelogin.%s.cache", clientToken))
+}
+
+// nopStore stores nothing, it is set by WithCredentialsStore(nil)
+type nopStore struct{}
+
+func (nopStore) Load(string) (*credentials.Value, error) {
+	return nil, nil
+}
+
+func (nopStore) Save(string, credentials.Value) error {
+	return nil
+}
+
+func (nopStore) Remove(string) error {
+	return nil
+}
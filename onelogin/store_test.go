@@ -0,0 +1,55 @@
+package onelogin
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onelogin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileStore{Dir: dir}
+	if v, err := store.Load("client-token"); v != nil || err != nil {
+		t.Errorf("%#v, %#v is loaded before saving", v, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	value := credentials.Value{
+		AccessToken:      "access-token",
+		RefreshToken:     "refresh-token",
+		CreatedAt:        now,
+		AccessExpiresAt:  now.Add(time.Hour),
+		RefreshExpiresAt: now.Add(2 * time.Hour),
+	}
+	if err := store.Save("client-token", value); err != nil {
+		t.Fatal(err)
+	}
+	file := path.Join(dir, "onelogin.client-token.cache")
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("%s is readable by others: %v", file, info.Mode())
+	}
+	v, err := store.Load("client-token")
+	if err != nil || v == nil || *v != value {
+		t.Errorf("%#v, %#v is not equal %#v", v, err, value)
+	}
+	if err := store.Remove("client-token"); err != nil {
+		t.Errorf("%#v", err)
+	}
+	if err := store.Remove("client-token"); err != nil {
+		t.Errorf("removing the removed tokens fails: %#v", err)
+	}
+	if v, err := store.Load("client-token"); v != nil || err != nil {
+		t.Errorf("%#v, %#v is loaded after removing", v, err)
+	}
+}
@@ -2,12 +2,15 @@ package tokens
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/pkg/errors"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
 )
 
 // https://developers.onelogin.com/api-docs/1/oauth20-tokens/generate-tokens-2
@@ -57,12 +60,17 @@ type Tokens struct {
 // NewTokens creates a Tokens
 func NewTokens() *Tokens {
 	return &Tokens{
-		HTTPClient: &http.Client{},
+		HTTPClient: transport.DefaultClient,
 	}
 }
 
 // Generate retrive access_token and other
 func (g *Tokens) Generate() (*GenerateResponse, error) {
+	return g.GenerateWithContext(context.Background())
+}
+
+// GenerateWithContext is the same as Generate, but the request is canceled when the context is done
+func (g *Tokens) GenerateWithContext(ctx context.Context) (*GenerateResponse, error) {
 	input := &GenerateRequest{
 		GrantType: "client_credentials",
 	}
@@ -71,13 +79,15 @@ func (g *Tokens) Generate() (*GenerateResponse, error) {
 		return nil, err
 	}
 	url := fmt.Sprintf("https://%s/auth/oauth2/v2/token", g.Endpoint)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(inputJSON)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer([]byte(inputJSON)))
 	if err != nil {
 		return nil, err
 	}
+	// the client secret is not a wiped secret.Bytes, because the header of net/http is a string anyway
 	creds := fmt.Sprintf("client_id:%s, client_secret:%s", g.ClientToken, g.ClientSecret)
 	req.Header.Set("Authorization", creds)
 	req.Header.Set("Content-Type", "application/json")
+	useragent.Set(req.Header)
 	client := g.HTTPClient
 	res, err := client.Do(req)
 	if err != nil {
@@ -89,28 +99,34 @@ func (g *Tokens) Generate() (*GenerateResponse, error) {
 		return nil, err
 	}
 	var output GenerateResponse
-	if err := json.Unmarshal(body, &output); err != nil {
+	if err := apiresponse.Decode(res, body, &output); err != nil {
 		return nil, err
 	}
 	if output.Status != nil && output.Status.Error {
-		return nil, errors.Errorf("(%d) %s", output.Status.Code, output.Status.Message)
+		return nil, apiresponse.WithRequestID(res, &apiresponse.StatusError{Code: output.Status.Code, Message: output.Status.Message})
 	}
 	return &output, nil
 }
 
 // Refresh retrive access_token and other by refresh_token
 func (g *Tokens) Refresh(input *RefreshRequest) (*RefreshResponse, error) {
+	return g.RefreshWithContext(context.Background(), input)
+}
+
+// RefreshWithContext is the same as Refresh, but the request is canceled when the context is done
+func (g *Tokens) RefreshWithContext(ctx context.Context, input *RefreshRequest) (*RefreshResponse, error) {
 	input.GrantType = "refresh_token"
 	inputJSON, err := json.Marshal(input)
 	if err != nil {
 		return nil, err
 	}
 	url := fmt.Sprintf("https://%s/auth/oauth2/v2/token", g.Endpoint)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(inputJSON)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer([]byte(inputJSON)))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	useragent.Set(req.Header)
 	client := g.HTTPClient
 	res, err := client.Do(req)
 	if err != nil {
@@ -122,11 +138,11 @@ func (g *Tokens) Refresh(input *RefreshRequest) (*RefreshResponse, error) {
 		return nil, err
 	}
 	var output RefreshResponse
-	if err := json.Unmarshal(body, &output); err != nil {
+	if err := apiresponse.Decode(res, body, &output); err != nil {
 		return nil, err
 	}
 	if output.Status != nil && output.Status.Error {
-		return nil, errors.Errorf("[%d] %s: %s", output.Status.Code, output.Status.Type, output.Status.Message)
+		return nil, apiresponse.WithRequestID(res, &apiresponse.StatusError{Code: output.Status.Code, Type: output.Status.Type, Message: output.Status.Message})
 	}
 	return &output, nil
 }
@@ -12,6 +12,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
 )
 
 func TestTokens_Generate(t *testing.T) {
@@ -106,6 +108,9 @@ func TestTokens_Generate(t *testing.T) {
 				if !reflect.DeepEqual(&input, tt.req) {
 					t.Errorf("Tokens.Generate() = %#v, want %#v", &input, tt.req)
 				}
+				if ua := r.Header.Get("User-Agent"); ua != useragent.String() {
+					t.Errorf("User-Agent = %s, want %s", ua, useragent.String())
+				}
 				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 				w.Header().Set("X-Content-Type-Options", "nosniff")
 				w.WriteHeader(tt.res.code)
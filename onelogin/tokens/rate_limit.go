@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// https://developers.onelogin.com/api-docs/1/oauth20-tokens/get-rate-limit
+
+// RateLimitResponse response of OneLogin Get Rate Limit API
+type RateLimitResponse struct {
+	Status *Status `json:"status"`
+	Data   *struct {
+		Limit     int `json:"X-RateLimit-Limit"`
+		Remaining int `json:"X-RateLimit-Remaining"`
+		// Reset is the seconds until the rate limit is reset
+		Reset int `json:"X-RateLimit-Reset"`
+	} `json:"data"`
+}
+
+// GetRateLimit returns the rate limit of the client credentials which issued the access token
+func (g *Tokens) GetRateLimit(accessToken string) (*apiresponse.RateLimit, error) {
+	return g.GetRateLimitWithContext(context.Background(), accessToken)
+}
+
+// GetRateLimitWithContext is the same as GetRateLimit, but the request is canceled when the context is done
+func (g *Tokens) GetRateLimitWithContext(ctx context.Context, accessToken string) (*apiresponse.RateLimit, error) {
+	url := fmt.Sprintf("https://%s/auth/rate_limit", g.Endpoint)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("bearer:%s", accessToken))
+	useragent.Set(req.Header)
+	now := time.Now()
+	res, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var output RateLimitResponse
+	if err := apiresponse.Decode(res, body, &output); err != nil {
+		return nil, err
+	}
+	if output.Status != nil && output.Status.Error {
+		return nil, apiresponse.WithRequestID(res, &apiresponse.StatusError{Code: output.Status.Code, Type: output.Status.Type, Message: output.Status.Message})
+	}
+	if output.Data == nil {
+		return nil, errors.Errorf("rate limit is not returned")
+	}
+	return &apiresponse.RateLimit{
+		Limit:     output.Data.Limit,
+		Remaining: output.Data.Remaining,
+		Reset:     now.Add(time.Duration(output.Data.Reset) * time.Second),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package tokens
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+)
+
+func TestTokens_GetRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		want    *apiresponse.RateLimit
+		wantErr bool
+	}{
+		{
+			name: "success",
+			code: 200,
+			body: `{"status": {"error": false, "code": 200, "type": "success", "message": "Success"}, "data": {"X-RateLimit-Limit": 5000, "X-RateLimit-Remaining": 4990, "X-RateLimit-Reset": 1200}}`,
+			want: &apiresponse.RateLimit{Limit: 5000, Remaining: 4990, Reset: time.Now().Add(1200 * time.Second)},
+		},
+		{
+			name:    "unauthorized",
+			code:    401,
+			body:    `{"status": {"error": true, "code": 401, "type": "Unauthorized", "message": "Authorization Information is incorrect"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/auth/rate_limit" || r.Header.Get("Authorization") != "bearer:access-token" {
+					t.Errorf("%s is requested with %s", r.URL, r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tt.code)
+				fmt.Fprintln(w, tt.body)
+			}))
+			defer ts.Close()
+			u, _ := url.Parse(ts.URL)
+			g := &Tokens{
+				Endpoint:   fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+				HTTPClient: ts.Client(),
+			}
+			got, err := g.GetRateLimit("access-token")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Tokens.GetRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want == nil {
+				return
+			}
+			// the reset is the seconds after the request
+			if got.Limit != tt.want.Limit || got.Remaining != tt.want.Remaining || got.Reset.Before(tt.want.Reset.Add(-time.Second)) || got.Reset.After(tt.want.Reset.Add(time.Second)) {
+				t.Errorf("Tokens.GetRateLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
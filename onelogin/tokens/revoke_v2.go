@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// https://developers.onelogin.com/api-docs/1/oauth20-tokens/revoke-tokens-2
+
+// RevokeRequest request for OneLogin Revoke Tokens v2 API
+type RevokeRequest struct {
+	AccessToken string `json:"access_token"`
+}
+
+// RevokeResponse response of OneLogin Revoke Tokens v2 API
+type RevokeResponse struct {
+	Status *Status `json:"status"`
+}
+
+// Revoke revokes access_token
+func (g *Tokens) Revoke(input *RevokeRequest) error {
+	return g.RevokeWithContext(context.Background(), input)
+}
+
+// RevokeWithContext is the same as Revoke, but the request is canceled when the context is done
+func (g *Tokens) RevokeWithContext(ctx context.Context, input *RevokeRequest) error {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%s/auth/oauth2/revoke", g.Endpoint)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer([]byte(inputJSON)))
+	if err != nil {
+		return err
+	}
+	creds := fmt.Sprintf("client_id:%s, client_secret:%s", g.ClientToken, g.ClientSecret)
+	req.Header.Set("Authorization", creds)
+	req.Header.Set("Content-Type", "application/json")
+	useragent.Set(req.Header)
+	client := g.HTTPClient
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var output RevokeResponse
+	if err := apiresponse.Decode(res, body, &output); err != nil {
+		return err
+	}
+	if output.Status != nil && output.Status.Error {
+		return apiresponse.WithRequestID(res, &apiresponse.StatusError{Code: output.Status.Code, Type: output.Status.Type, Message: output.Status.Message})
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package tokens
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestTokens_Revoke(t *testing.T) {
+	type response struct {
+		code int
+		body string
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	tests := []struct {
+		name    string
+		req     *RevokeRequest
+		res     response
+		wantErr bool
+	}{
+		{
+			name: "success",
+			req: &RevokeRequest{
+				AccessToken: "access-token",
+			},
+			res: response{
+				code: 200,
+				body: `{
+					"status": {
+						"error": false,
+						"code": 200,
+						"type": "success",
+						"message": "Success"
+					}
+				}`,
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty body",
+			req: &RevokeRequest{
+				AccessToken: "access-token",
+			},
+			res: response{
+				code: 200,
+				body: "",
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed",
+			req: &RevokeRequest{
+				AccessToken: "access-token",
+			},
+			res: response{
+				code: 401,
+				body: `{
+					"status": {
+						"error": true,
+						"code": 401,
+						"type": "Unauthorized",
+						"message": "Authentication Failure"
+					}
+				}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				if r.URL.Path != "/auth/oauth2/revoke" {
+					t.Errorf("%s is not equal %s", r.URL.Path, "/auth/oauth2/revoke")
+				}
+				if r.Header.Get("Authorization") != "client_id:client-token, client_secret:client-secret" {
+					t.Errorf("%s is invalid Authorization header", r.Header.Get("Authorization"))
+				}
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+				var input RevokeRequest
+				if err := json.Unmarshal(body, &input); err != nil {
+					t.Errorf("%v", err)
+				}
+				if !reflect.DeepEqual(&input, tt.req) {
+					t.Errorf("Tokens.Revoke() = %#v, want %#v", &input, tt.req)
+				}
+				w.WriteHeader(tt.res.code)
+				fmt.Fprint(w, tt.res.body)
+			}))
+			defer ts.Close()
+			u, _ := url.Parse(ts.URL)
+			g := &Tokens{
+				Endpoint:     fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+				ClientToken:  "client-token",
+				ClientSecret: "client-secret",
+				HTTPClient:   httpClient,
+			}
+			err := g.Revoke(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Tokens.Revoke() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,17 @@
 package tokensiface
 
-import "github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
+import (
+	"context"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/tokens"
+)
 
 // TokensAPI is Tokens API Interface
 type TokensAPI interface {
 	Generate() (*tokens.GenerateResponse, error)
+	GenerateWithContext(ctx context.Context) (*tokens.GenerateResponse, error)
 	Refresh(input *tokens.RefreshRequest) (*tokens.RefreshResponse, error)
+	RefreshWithContext(ctx context.Context, input *tokens.RefreshRequest) (*tokens.RefreshResponse, error)
+	Revoke(input *tokens.RevokeRequest) error
+	RevokeWithContext(ctx context.Context, input *tokens.RevokeRequest) error
 }
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultDialTimeout and defaultKeepAlive are the ones of http.DefaultTransport
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// dnsCacheTTL is how long the resolved addresses are kept, shared clients live as long as the daemon
+const dnsCacheTTL = 5 * time.Minute
+
+// dialer resolves hosts with the resolver and the cache, and dials their addresses in the preferred order
+type dialer struct {
+	net.Dialer
+	resolver   *net.Resolver
+	preferIPv4 bool
+	cache      bool
+	mu         sync.Mutex
+	addrs      map[string]cachedAddrs
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// cachedAddrs are the addresses of a host resolved before the expiration
+type cachedAddrs struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// newDialer returns the dialer of the options, or nil if the dialer of http.DefaultTransport can be used
+func newDialer(o Options) (*dialer, error) {
+	if o.DialTimeout == 0 && !o.PreferIPv4 && o.Resolver == "" && !o.DNSCache {
+		return nil, nil
+	}
+	d := &dialer{
+		Dialer:     net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive},
+		resolver:   net.DefaultResolver,
+		preferIPv4: o.PreferIPv4,
+		cache:      o.DNSCache,
+		addrs:      map[string]cachedAddrs{},
+		now:        time.Now,
+	}
+	if o.DialTimeout > 0 {
+		d.Timeout = o.DialTimeout
+	}
+	if o.Resolver != "" {
+		server, err := resolverAddress(o.Resolver)
+		if err != nil {
+			return nil, err
+		}
+		upstream := net.Dialer{Timeout: d.Timeout}
+		d.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return upstream.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return d, nil
+}
+
+// resolverAddress returns the address of the DNS server, whose port is 53 if it is omitted
+func resolverAddress(resolver string) (string, error) {
+	host, port, err := net.SplitHostPort(resolver)
+	if err != nil {
+		host, port = strings.Trim(resolver, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", errors.Errorf("%s is not a valid DNS server, it must be an IP address with an optional port", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// DialContext dials the addresses of the host one by one until a connection is established
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.Dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var first error
+	for _, addr := range addrs {
+		conn, err := d.Dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if first == nil {
+			first = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if first == nil {
+		first = errors.Errorf("no address of %s is found", host)
+	}
+	// the host may have moved, so it is resolved again by the next dial
+	d.forget(host)
+	return nil, first
+}
+
+// lookup resolves the host, the addresses are cached for dnsCacheTTL if the cache is enabled
+func (d *dialer) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if d.cache {
+		d.mu.Lock()
+		cached, ok := d.addrs[host]
+		d.mu.Unlock()
+		if ok && d.now().Before(cached.expires) {
+			return cached.addrs, nil
+		}
+	}
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if d.preferIPv4 {
+		sort.SliceStable(addrs, func(i, j int) bool {
+			return addrs[i].IP.To4() != nil && addrs[j].IP.To4() == nil
+		})
+	}
+	if d.cache {
+		d.mu.Lock()
+		d.addrs[host] = cachedAddrs{addrs: addrs, expires: d.now().Add(dnsCacheTTL)}
+		d.mu.Unlock()
+	}
+	return addrs, nil
+}
+
+// forget drops the cached addresses of the host
+func (d *dialer) forget(host string) {
+	if d.cache {
+		d.mu.Lock()
+		delete(d.addrs, host)
+		d.mu.Unlock()
+	}
+}
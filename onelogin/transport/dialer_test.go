@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers every A query with 127.0.0.1 and AAAA query with ::1, and counts the queries
+func serveDNS(t *testing.T) (string, *int32, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries int32
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if err := request.Unpack(buf[:n]); err != nil || len(request.Questions) == 0 {
+				continue
+			}
+			atomic.AddInt32(&queries, 1)
+			question := request.Questions[0]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: request.ID, Response: true, Authoritative: true},
+				Questions: request.Questions,
+			}
+			header := dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: 60}
+			switch question.Type {
+			case dnsmessage.TypeA:
+				response.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}}}
+			case dnsmessage.TypeAAAA:
+				response.Answers = []dnsmessage.Resource{{Header: header, Body: &dnsmessage.AAAAResource{AAAA: [16]byte{15: 1}}}}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String(), &queries, func() { conn.Close() }
+}
+
+func TestClientWithResolver(t *testing.T) {
+	server, queries, closeDNS := serveDNS(t)
+	defer closeDNS()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host)
+	}))
+	defer backend.Close()
+	_, port, _ := net.SplitHostPort(backend.Listener.Addr().String())
+
+	client, err := Client(Options{Proxy: Direct, Resolver: server, PreferIPv4: true, DNSCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+	for i := 0; i < 2; i++ {
+		res, err := client.Get("http://onelogin.test:" + port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != "onelogin.test:"+port {
+			t.Errorf("%s is unexpected", body)
+		}
+	}
+	// A and AAAA are queried only once
+	if n := atomic.LoadInt32(queries); n != 2 {
+		t.Errorf("%d queries are sent", n)
+	}
+}
+
+func TestDialerCacheExpires(t *testing.T) {
+	server, queries, closeDNS := serveDNS(t)
+	defer closeDNS()
+	d, err := newDialer(Options{Resolver: server, DNSCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+	d.lookup(ctx, "onelogin.test")
+	d.lookup(ctx, "onelogin.test")
+	if n := atomic.LoadInt32(queries); n != 2 {
+		t.Errorf("%d queries are sent within the TTL", n)
+	}
+	now = now.Add(dnsCacheTTL)
+	d.lookup(ctx, "onelogin.test")
+	if n := atomic.LoadInt32(queries); n != 4 {
+		t.Errorf("%d queries are sent after the TTL", n)
+	}
+}
+
+func TestDialerForgetsFailedHost(t *testing.T) {
+	server, queries, closeDNS := serveDNS(t)
+	defer closeDNS()
+	d, err := newDialer(Options{Resolver: server, DNSCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing listens on the port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+	if _, err := d.DialContext(context.Background(), "tcp", "onelogin.test:"+port); err == nil {
+		t.Fatal("closed port is connected")
+	}
+	d.lookup(context.Background(), "onelogin.test")
+	if n := atomic.LoadInt32(queries); n != 4 {
+		t.Errorf("%d queries are sent, the host is not resolved again after the dial failed", n)
+	}
+}
+
+func TestDialerPreferIPv4(t *testing.T) {
+	server, _, closeDNS := serveDNS(t)
+	defer closeDNS()
+	for _, prefer := range []bool{true, false} {
+		d, err := newDialer(Options{Resolver: server, PreferIPv4: prefer})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs, err := d.lookup(context.Background(), "onelogin.test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(addrs) != 2 {
+			t.Fatalf("%v is unexpected", addrs)
+		}
+		if prefer && addrs[0].IP.To4() == nil {
+			t.Errorf("%v does not start with IPv4", addrs)
+		}
+	}
+}
+
+func TestResolverAddress(t *testing.T) {
+	for _, tt := range []struct {
+		resolver string
+		expected string
+	}{
+		{"10.0.0.2", "10.0.0.2:53"},
+		{"10.0.0.2:5353", "10.0.0.2:5353"},
+		{"[2001:db8::1]:53", "[2001:db8::1]:53"},
+		{"2001:db8::1", "[2001:db8::1]:53"},
+		{"dns.example.com", ""},
+	} {
+		actual, err := resolverAddress(tt.resolver)
+		if actual != tt.expected || (err == nil) != (tt.expected != "") {
+			t.Errorf("%s, %v is unexpected for %s", actual, err, tt.resolver)
+		}
+	}
+	if _, err := Client(Options{Resolver: "dns.example.com"}); err == nil {
+		t.Error("invalid resolver is accepted")
+	}
+}
@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/secret"
+)
+
+// redactedHeaders are always masked, because their values like "Basic AAAA" are not matched by secret.Filter
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// traceFields matches secrets in bodies which secret.Filter does not know,
+// like the SAML assertion in "data" of OneLogin API and the credentials in XML of AWS STS
+var traceFields = regexp.MustCompile(`("(?:data|state_token)"\s*:\s*)"(?:[^"\\]|\\.)*"|(<(SecretAccessKey|SessionToken)>)[^<]*(</(?:SecretAccessKey|SessionToken)>)`)
+
+// tracer is http.RoundTripper writing requests and responses with their timings
+type tracer struct {
+	base http.RoundTripper
+	mu   sync.Mutex
+	out  io.Writer
+}
+
+// newTracer returns the round tripper writing the trace of each exchange at once, even if requests run concurrently
+func newTracer(base http.RoundTripper, out io.Writer) *tracer {
+	return &tracer{base: base, out: out}
+}
+
+// timings records the phases of a request by httptrace
+type timings struct {
+	start, dnsStart, dnsDone, connectStart, connectDone, tlsStart, tlsDone, firstByte time.Time
+	reused                                                                            bool
+}
+
+func (t *timings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn:              func(info httptrace.GotConnInfo) { t.reused = info.Reused },
+		DNSStart:             func(httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { t.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.tlsDone = time.Now() },
+		GotFirstResponseByte: func() { t.firstByte = time.Now() },
+	}
+}
+
+func (t *timings) String(end time.Time) string {
+	phases := []string{}
+	add := func(name string, from, to time.Time) {
+		if !from.IsZero() && !to.IsZero() {
+			phases = append(phases, fmt.Sprintf("%s %s", name, to.Sub(from).Round(time.Microsecond)))
+		}
+	}
+	if t.reused {
+		phases = append(phases, "reused connection")
+	}
+	add("dns", t.dnsStart, t.dnsDone)
+	add("connect", t.connectStart, t.connectDone)
+	add("tls", t.tlsStart, t.tlsDone)
+	add("first byte", t.start, t.firstByte)
+	add("total", t.start, end)
+	return strings.Join(phases, ", ")
+}
+
+// RoundTrip sends the request with the base round tripper, buffering the bodies to write them
+func (t *tracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = data
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+	timing := &timings{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timing.clientTrace()))
+	res, err := t.base.RoundTrip(req)
+	var responseBody []byte
+	if err == nil {
+		responseBody, err = ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	}
+	end := time.Now()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s %s %s\n", timing.start.Format(time.RFC3339Nano), req.Method, req.URL.Redacted())
+	fmt.Fprintf(&b, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	fmt.Fprintf(&b, "> Host: %s\n", req.URL.Host)
+	writeHeader(&b, "> ", req.Header)
+	writeBody(&b, "> ", requestBody)
+	if err != nil {
+		fmt.Fprintf(&b, "! %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "< %s %s\n", res.Proto, res.Status)
+		writeHeader(&b, "< ", res.Header)
+		writeBody(&b, "< ", responseBody)
+	}
+	fmt.Fprintf(&b, "* %s\n\n", timing.String(end))
+	t.mu.Lock()
+	io.WriteString(t.out, redactTrace(b.String()))
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func writeHeader(b *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redactedHeaders[http.CanonicalHeaderKey(name)] {
+				value = secret.Redacted
+			}
+			fmt.Fprintf(b, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+func writeBody(b *strings.Builder, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s\n", prefix)
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		fmt.Fprintf(b, "%s%s\n", prefix, line)
+	}
+}
+
+// redactTrace masks secrets in the trace
+func redactTrace(s string) string {
+	s = traceFields.ReplaceAllStringFunc(s, func(field string) string {
+		m := traceFields.FindStringSubmatch(field)
+		if m[1] != "" {
+			return m[1] + `"` + secret.Redacted + `"`
+		}
+		return m[2] + secret.Redacted + m[4]
+	})
+	return secret.Filter(s)
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientWithTrace(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(body), "p@ssw0rd") {
+			t.Errorf("%s is sent", body)
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "cookie-value"})
+		if r.URL.Path == "/sts" {
+			fmt.Fprint(w, `<Credentials><SecretAccessKey>secret-access-key</SecretAccessKey><SessionToken>session-token</SessionToken></Credentials>`)
+			return
+		}
+		fmt.Fprintln(w, `{"status": {"type": "success"}, "data": "PHNhbWxwOlJlc3BvbnNl", "access_token": "access-token-value"}`)
+	}))
+	defer backend.Close()
+	var trace bytes.Buffer
+	client, err := Client(Options{Proxy: Direct, Trace: &trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", backend.URL+"/api/2/saml_assertion?debug=1", strings.NewReader(`{"username_or_email": "user", "password": "p@ssw0rd"}`))
+	req.Header.Set("Authorization", "bearer:access-token-value")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(body), "PHNhbWxwOlJlc3BvbnNl") {
+		t.Errorf("%s is not the response", body)
+	}
+	res, err = client.Post(backend.URL+"/sts", "application/x-www-form-urlencoded", strings.NewReader("Action=AssumeRoleWithSAML&SAMLAssertion=PHNhbWxwOlJlc3BvbnNl&password=p@ssw0rd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	actual := trace.String()
+	for _, expected := range []string{
+		"> POST /api/2/saml_assertion?debug=1 HTTP/1.1\n",
+		"> Authorization: ********\n",
+		`"password": "********"`,
+		"< HTTP/1.1 200 OK\n",
+		"< Set-Cookie: ********\n",
+		`"data": "********"`,
+		"<SecretAccessKey>********</SecretAccessKey>",
+		"* connect ",
+		"* reused connection, ",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("%s is not traced in %s", expected, actual)
+		}
+	}
+	for _, leaked := range []string{"p@ssw0rd", "access-token-value", "PHNhbWxwOlJlc3BvbnNl", "cookie-value", "secret-access-key", "session-token"} {
+		if strings.Contains(actual, leaked) {
+			t.Errorf("%s is leaked in %s", leaked, actual)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultTimeout is the overall timeout of each request used instead of waiting forever
+const DefaultTimeout = time.Minute
+
+// DefaultClient is used by OneLogin API clients created without a client,
+// so they share the kept-alive connections of http.DefaultTransport
+var DefaultClient = &http.Client{Timeout: DefaultTimeout}
+
+var (
+	sharedMu      sync.Mutex
+	sharedClients = map[string]*http.Client{}
+)
+
+// HTTP/2 settings of Options
+const (
+	// HTTP2Auto attempts HTTP/2 only with the stock TLS config and dialer, like http.Transport without ForceAttemptHTTP2
+	HTTP2Auto = ""
+	// HTTP2Force attempts HTTP/2 even with the custom TLS config and dialer
+	HTTP2Force = "force"
+	// HTTP2Off uses only HTTP/1.1 for proxies breaking HTTP/2
+	HTTP2Off = "off"
+)
+
+// Direct is the proxy setting to connect without proxies even if HTTP_PROXY or HTTPS_PROXY is set
+const Direct = "direct"
+
+// Options configures the transport of OneLogin API clients and the AWS session
+type Options struct {
+	// Proxy is the URL of the proxy, Direct, or empty to use HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	Proxy string
+	// NoProxy is the comma separated hosts connected without the proxy, NO_PROXY is used if it is empty
+	NoProxy string
+	// ProxyUser and ProxyPassword authenticate to the proxy with basic auth including CONNECT tunnels,
+	// they are used instead of the user info in the proxy URL
+	ProxyUser     string
+	ProxyPassword string
+	// CABundle is the PEM file of CA certificates trusted in addition to the system ones,
+	// e.g. the private CA of a TLS-intercepting proxy
+	CABundle string
+	// ClientCert and ClientKey are the PEM files of the client certificate and its key for mutual TLS,
+	// which is sent only to servers requesting it
+	ClientCert string
+	ClientKey  string
+	// Pins are base64 encoded SHA-256 hashes of the SubjectPublicKeyInfo like sha256/AAAA=,
+	// one of which must be in the certificate chain of PinnedHost to detect TLS interception
+	Pins       []string
+	PinnedHost string
+	// InsecureSkipVerify disables verification of server certificates, which must not be used except for debugging
+	InsecureSkipVerify bool
+	// Timeout is the overall timeout of each request of Client, DefaultTimeout is used if it is zero
+	Timeout time.Duration
+	// DialTimeout and TLSHandshakeTimeout are used instead of the ones of http.DefaultTransport if they are not zero
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	// HTTP2 is HTTP2Auto, HTTP2Force or HTTP2Off
+	HTTP2 string
+	// IdleConnTimeout, MaxIdleConns and MaxIdleConnsPerHost tune the kept-alive connections,
+	// the ones of http.DefaultTransport are used if they are zero
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// PreferIPv4 dials IPv4 addresses of hosts before IPv6 ones for networks breaking IPv6
+	PreferIPv4 bool
+	// Resolver is the DNS server like 10.0.0.2:53 used instead of the system resolver
+	Resolver string
+	// DNSCache keeps the resolved addresses for 5 minutes, or until no address of the host is connected
+	DNSCache bool
+	// Trace is written requests, responses and timings of Client with secrets redacted, which is nil not to trace
+	Trace io.Writer
+}
+
+// New returns a transport cloned from http.DefaultTransport with the options
+func New(o Options) (*http.Transport, error) {
+	proxy, err := ProxyFunc(o)
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	d, err := newDialer(o)
+	if err != nil {
+		return nil, err
+	}
+	if d != nil {
+		t.DialContext = d.DialContext
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	switch o.HTTP2 {
+	case HTTP2Auto:
+		// http.DefaultTransport forces HTTP/2, which would make HTTP2Force the same as the default
+		t.ForceAttemptHTTP2 = false
+	case HTTP2Force:
+		t.ForceAttemptHTTP2 = true
+	case HTTP2Off:
+		// a non-nil empty map disables HTTP/2
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	default:
+		return nil, errors.Errorf("%s is not a supported HTTP/2 setting, it must be force or off", o.HTTP2)
+	}
+	if o.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = o.IdleConnTimeout
+	}
+	if o.MaxIdleConns > 0 {
+		t.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.CABundle != "" || o.ClientCert != "" || o.ClientKey != "" || len(o.Pins) > 0 || o.InsecureSkipVerify {
+		if t.TLSClientConfig, err = tlsConfig(o); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// tlsConfig returns the TLS config trusting the CA bundle with the client certificate and the pins
+func tlsConfig(o Options) (*tls.Config, error) {
+	c := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if len(o.Pins) > 0 {
+		verify, err := verifyPins(o.PinnedHost, o.Pins)
+		if err != nil {
+			return nil, err
+		}
+		c.VerifyConnection = verify
+	}
+	if o.ClientCert != "" || o.ClientKey != "" {
+		if o.ClientCert == "" || o.ClientKey == "" {
+			return nil, errors.Errorf("both of the client certificate and the client key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the client certificate")
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	if o.CABundle == "" {
+		return c, nil
+	}
+	pem, err := ioutil.ReadFile(o.CABundle)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the CA bundle")
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// the system roots are not available on some platforms such as Windows before Go 1.18
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("%s has no PEM encoded certificates", o.CABundle)
+	}
+	c.RootCAs = pool
+	return c, nil
+}
+
+// Client returns a new http.Client with the transport of the options
+func Client(o Options) (*http.Client, error) {
+	t, err := New(o)
+	if err != nil {
+		return nil, err
+	}
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if o.Trace != nil {
+		return &http.Client{Transport: newTracer(t, o.Trace), Timeout: timeout}, nil
+	}
+	return &http.Client{Transport: t, Timeout: timeout}, nil
+}
+
+// Shared returns the client of the options, which is created only once and shared by every caller with the same options.
+// Token generation, SAML assertion and VerifyFactor requests reuse its kept-alive connections without TLS handshakes
+func Shared(o Options) (*http.Client, error) {
+	key := sharedKey(o)
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if client, ok := sharedClients[key]; ok {
+		return client, nil
+	}
+	client, err := Client(o)
+	if err != nil {
+		return nil, err
+	}
+	sharedClients[key] = client
+	return client, nil
+}
+
+// sharedKey returns the key of the shared client, the proxy credentials are hashed not to keep the password in the key
+func sharedKey(o Options) string {
+	credentials := sha256.Sum256([]byte(o.ProxyUser + "\x00" + o.ProxyPassword))
+	o.ProxyUser = ""
+	o.ProxyPassword = ""
+	return fmt.Sprintf("%#v/%x", o, credentials)
+}
+
+// ProxyFunc returns the proxy function of http.Transport, which is nil for Direct
+func ProxyFunc(o Options) (func(*http.Request) (*url.URL, error), error) {
+	var c *httpproxy.Config
+	switch o.Proxy {
+	case Direct:
+		return nil, nil
+	case "":
+		c = httpproxy.FromEnvironment()
+	default:
+		if err := validate(o.Proxy); err != nil {
+			return nil, err
+		}
+		c = &httpproxy.Config{HTTPProxy: o.Proxy, HTTPSProxy: o.Proxy}
+	}
+	if o.NoProxy != "" {
+		c.NoProxy = o.NoProxy
+	}
+	f := c.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := f(req.URL)
+		if err != nil || u == nil || o.ProxyUser == "" {
+			return u, err
+		}
+		// http.Transport sends the user info as Proxy-Authorization of requests and CONNECT
+		authenticated := *u
+		authenticated.User = url.UserPassword(o.ProxyUser, o.ProxyPassword)
+		return &authenticated, nil
+	}, nil
+}
+
+// Pin returns the pin of the certificate
+func Pin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ValidatePin checks the pin is a base64 encoded SHA-256 hash
+func ValidatePin(pin string) error {
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+	if err != nil || len(sum) != sha256.Size {
+		return errors.Errorf("%s is not a valid pin, it must be sha256/ and a base64 encoded SHA-256 hash", pin)
+	}
+	return nil
+}
+
+// verifyPins returns the function verifying that the chain of the host has one of the pins,
+// connections to other hosts like AWS STS are not pinned
+func verifyPins(host string, pins []string) (func(tls.ConnectionState) error, error) {
+	if host == "" {
+		return nil, errors.Errorf("the pinned host is required")
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	allowed := map[string]bool{}
+	for _, pin := range pins {
+		if err := ValidatePin(pin); err != nil {
+			return nil, err
+		}
+		allowed["sha256/"+strings.TrimPrefix(pin, "sha256/")] = true
+	}
+	return func(cs tls.ConnectionState) error {
+		// SNI, and so ServerName, is empty for IP addresses
+		if !strings.EqualFold(cs.ServerName, host) && !(cs.ServerName == "" && net.ParseIP(host) != nil) {
+			return nil
+		}
+		// any verified chain may have the pin, e.g. a backup pin of a cross-signed chain.
+		// Without the verification, an interceptor can append the public intermediate of the host to its chain,
+		// so only the leaf is pinned
+		chains := cs.VerifiedChains
+		if len(chains) == 0 && len(cs.PeerCertificates) > 0 {
+			chains = [][]*x509.Certificate{cs.PeerCertificates[:1]}
+		}
+		actual := []string{}
+		seen := map[string]bool{}
+		for _, chain := range chains {
+			for _, cert := range chain {
+				pin := Pin(cert)
+				if allowed[pin] {
+					return nil
+				}
+				if !seen[pin] {
+					seen[pin] = true
+					actual = append(actual, pin)
+				}
+			}
+		}
+		return errors.Errorf("the certificate of %s does not match the pins, the connection may be intercepted. The certificate chain has %s", host, strings.Join(actual, ", "))
+	}, nil
+}
+
+// validate checks the proxy URL, a URL without scheme like proxy.example.com:8080 is an HTTP proxy
+func validate(proxy string) error {
+	raw := proxy
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.Errorf("%s is not a valid proxy URL", proxy)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return errors.Errorf("%s is not a supported proxy scheme", u.Scheme)
+}
@@ -0,0 +1,436 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyFunc(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.us.onelogin.com/api/1/saml_assertion", nil)
+	internal, _ := http.NewRequest("POST", "https://onelogin.internal.example.com/api/1/saml_assertion", nil)
+
+	proxy, err := ProxyFunc(Options{Proxy: Direct})
+	if err != nil || proxy != nil {
+		t.Errorf("%v is unexpected for direct", err)
+	}
+
+	proxy, err = ProxyFunc(Options{Proxy: "proxy.example.com:8080", NoProxy: "internal.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := proxy(req); err != nil || u == nil || u.String() != "http://proxy.example.com:8080" {
+		t.Errorf("%v, %v is unexpected", u, err)
+	}
+	if u, err := proxy(internal); err != nil || u != nil {
+		t.Errorf("%v, %v is proxied", u, err)
+	}
+
+	os.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+	defer os.Unsetenv("HTTPS_PROXY")
+	proxy, err = ProxyFunc(Options{NoProxy: "internal.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := proxy(req); err != nil || u == nil || u.Host != "env-proxy.example.com:3128" {
+		t.Errorf("%v, %v is unexpected", u, err)
+	}
+	if u, err := proxy(internal); err != nil || u != nil {
+		t.Errorf("%v, %v is proxied", u, err)
+	}
+
+	for _, invalid := range []string{"ftp://proxy.example.com", "http://"} {
+		if _, err := ProxyFunc(Options{Proxy: invalid}); err == nil {
+			t.Errorf("%s is accepted", invalid)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	client, err := Client(Options{Proxy: "https://proxy.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Transport.(*http.Transport).Proxy == nil {
+		t.Error("proxy is not set")
+	}
+	if _, err := Client(Options{Proxy: "ftp://proxy.example.com"}); err == nil {
+		t.Error("invalid proxy is accepted")
+	}
+}
+
+func TestClientWithTimeouts(t *testing.T) {
+	client, err := Client(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Timeout != DefaultTimeout {
+		t.Errorf("%v is not the default timeout", client.Timeout)
+	}
+
+	client, err = Client(Options{Proxy: Direct, Timeout: 50 * time.Millisecond, DialTimeout: time.Second, TLSHandshakeTimeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := client.Transport.(*http.Transport); tr.TLSHandshakeTimeout != 2*time.Second || tr.DialContext == nil {
+		t.Errorf("%#v is unexpected", tr)
+	}
+	done := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer backend.Close()
+	defer close(done)
+	if res, err := client.Get(backend.URL); err == nil {
+		res.Body.Close()
+		t.Error("the request is not timed out")
+	}
+}
+
+func TestClientWithHTTP2(t *testing.T) {
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	backend.EnableHTTP2 = true
+	backend.StartTLS()
+	defer backend.Close()
+
+	for _, tt := range []struct {
+		http2    string
+		expected string
+	}{
+		// InsecureSkipVerify is a custom TLS config, which is not attempted HTTP/2 by auto
+		{HTTP2Auto, "HTTP/1.1"},
+		{HTTP2Force, "HTTP/2.0"},
+		{HTTP2Off, "HTTP/1.1"},
+	} {
+		client, err := Client(Options{Proxy: Direct, InsecureSkipVerify: true, HTTP2: tt.http2, IdleConnTimeout: time.Second, MaxIdleConns: 4, MaxIdleConnsPerHost: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tr := client.Transport.(*http.Transport); tr.IdleConnTimeout != time.Second || tr.MaxIdleConns != 4 || tr.MaxIdleConnsPerHost != 4 {
+			t.Errorf("%#v is unexpected", tr)
+		}
+		res, err := client.Get(backend.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != tt.expected {
+			t.Errorf("%s is unexpected for %q", body, tt.http2)
+		}
+	}
+	if _, err := Client(Options{HTTP2: "on"}); err == nil {
+		t.Error("invalid HTTP/2 setting is accepted")
+	}
+}
+
+func TestShared(t *testing.T) {
+	var connections int32
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	backend.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	backend.StartTLS()
+	defer backend.Close()
+
+	options := Options{Proxy: Direct, InsecureSkipVerify: true}
+	for i := 0; i < 3; i++ {
+		client, err := Shared(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := client.Post(backend.URL, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+	if connections != 1 {
+		t.Errorf("%d connections are established", connections)
+	}
+	a, _ := Shared(options)
+	b, _ := Shared(Options{Proxy: Direct, InsecureSkipVerify: true, Timeout: time.Second})
+	if a == b {
+		t.Error("the client of other options is shared")
+	}
+	if _, err := Shared(Options{Proxy: "ftp://proxy.example.com"}); err == nil {
+		t.Error("invalid proxy is accepted")
+	}
+}
+
+func TestSharedKey(t *testing.T) {
+	a := sharedKey(Options{Proxy: "http://proxy.example.com:8080", ProxyUser: "user", ProxyPassword: "proxy-secret"})
+	if strings.Contains(a, "proxy-secret") || strings.Contains(a, "user") {
+		t.Errorf("%s has the proxy credentials", a)
+	}
+	if b := sharedKey(Options{Proxy: "http://proxy.example.com:8080", ProxyUser: "user", ProxyPassword: "another-secret"}); a == b {
+		t.Error("the client of another proxy password is shared")
+	}
+	if b := sharedKey(Options{Proxy: "http://proxy.example.com:8080", ProxyUser: "user", ProxyPassword: "proxy-secret"}); a != b {
+		t.Errorf("%s is not equal %s", b, a)
+	}
+}
+
+func TestClientWithCABundle(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer backend.Close()
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundle := dir + "/ca.pem"
+	ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw}), 0600)
+	broken := dir + "/broken.pem"
+	ioutil.WriteFile(broken, []byte("not a certificate"), 0600)
+
+	for _, tt := range []struct {
+		options Options
+		ok      bool
+	}{
+		{Options{Proxy: Direct}, false},
+		{Options{Proxy: Direct, CABundle: bundle}, true},
+		{Options{Proxy: Direct, InsecureSkipVerify: true}, true},
+	} {
+		client, err := Client(tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := client.Get(backend.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%v is unexpected for %#v", err, tt.options)
+		}
+	}
+	for _, file := range []string{dir + "/missing.pem", broken} {
+		if _, err := Client(Options{CABundle: file}); err == nil {
+			t.Errorf("%s is accepted", file)
+		}
+	}
+}
+
+func TestClientWithClientCertificate(t *testing.T) {
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	backend.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	backend.StartTLS()
+	defer backend.Close()
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert, key := dir+"/client.pem", dir+"/client-key.pem"
+	writeClientCertificate(t, cert, key, "client")
+
+	client, err := Client(Options{Proxy: Direct, ClientCert: cert, ClientKey: key, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Get(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "client" {
+		t.Errorf("%s is unexpected", body)
+	}
+
+	client, _ = Client(Options{Proxy: Direct, InsecureSkipVerify: true})
+	if res, err := client.Get(backend.URL); err == nil {
+		res.Body.Close()
+		t.Error("the request without the client certificate is accepted")
+	}
+	for _, o := range []Options{{ClientCert: cert}, {ClientKey: key}, {ClientCert: key, ClientKey: cert}} {
+		if _, err := Client(o); err == nil {
+			t.Errorf("%#v is accepted", o)
+		}
+	}
+}
+
+func writeClientCertificate(t *testing.T, cert, key, name string) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &private.PublicKey, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func TestClientWithPins(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer backend.Close()
+	host := backend.Listener.Addr().String()
+	pin := Pin(backend.Certificate())
+	other := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	for _, tt := range []struct {
+		options Options
+		ok      bool
+	}{
+		{Options{Pins: []string{other, pin}, PinnedHost: host}, true},
+		{Options{Pins: []string{strings.TrimPrefix(pin, "sha256/")}, PinnedHost: host}, true},
+		{Options{Pins: []string{other}, PinnedHost: host}, false},
+		{Options{Pins: []string{other}, PinnedHost: "api.us.onelogin.com"}, true},
+	} {
+		tt.options.Proxy = Direct
+		tt.options.InsecureSkipVerify = true
+		client, err := Client(tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := client.Get(backend.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%v is unexpected for %#v", err, tt.options)
+		}
+		if err != nil && !strings.Contains(err.Error(), pin) {
+			t.Errorf("%v does not tell the actual pin", err)
+		}
+	}
+	for _, o := range []Options{{Pins: []string{"sha256/invalid"}, PinnedHost: host}, {Pins: []string{pin}}} {
+		if _, err := Client(o); err == nil {
+			t.Errorf("%#v is accepted", o)
+		}
+	}
+}
+
+func TestVerifyPins(t *testing.T) {
+	leaf := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("leaf")}
+	intermediate := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("intermediate")}
+	cross := &x509.Certificate{RawSubjectPublicKeyInfo: []byte("cross-signed root")}
+	for _, tt := range []struct {
+		pin string
+		cs  tls.ConnectionState
+		ok  bool
+	}{
+		{Pin(leaf), tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf, intermediate}}, true},
+		// the unverified chain may have the intermediate appended by an interceptor
+		{Pin(intermediate), tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf, intermediate}}, false},
+		{Pin(cross), tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf, intermediate}, {leaf, cross}}}, true},
+	} {
+		verify, err := verifyPins("api.us.onelogin.com", []string{tt.pin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.cs.ServerName = "api.us.onelogin.com"
+		if err := verify(tt.cs); (err == nil) != tt.ok {
+			t.Errorf("%v is unexpected for %s", err, tt.pin)
+		}
+	}
+}
+
+func TestClientWithAuthenticatedProxy(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer backend.Close()
+	authorizations := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Proxy-Authorization"))
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT is required", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:p@ss")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		// the backend is connected as example.com, because requests to localhost are never proxied
+		upstream, err := net.Dial("tcp", backend.Listener.Addr().String())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		go func() {
+			io.Copy(upstream, buf)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	defer proxy.Close()
+
+	for _, tt := range []struct {
+		password string
+		ok       bool
+	}{
+		{"p@ss", true},
+		{"wrong", false},
+	} {
+		client, err := Client(Options{Proxy: proxy.URL, ProxyUser: "user", ProxyPassword: tt.password})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Transport.(*http.Transport).TLSClientConfig = backend.Client().Transport.(*http.Transport).TLSClientConfig
+		_, port, _ := net.SplitHostPort(backend.Listener.Addr().String())
+		res, err := client.Get("https://example.com:" + port)
+		if tt.ok {
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if string(body) != "ok" {
+				t.Errorf("%s is not ok", body)
+			}
+		} else if err == nil {
+			res.Body.Close()
+			t.Errorf("the wrong password is authenticated")
+		}
+	}
+	if len(authorizations) != 2 {
+		t.Errorf("%v is unexpected", authorizations)
+	}
+}
@@ -0,0 +1,42 @@
+package useragent
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Name is the product name in User-Agent
+const Name = "onelogin-aws-connector"
+
+var (
+	// Version is the release version in User-Agent
+	Version = "Unknown"
+	// Suffix is appended to User-Agent to identify the organization or the environment
+	Suffix string
+)
+
+// String returns User-Agent such as "onelogin-aws-connector/1.0.0 (linux/amd64)"
+func String() string {
+	ua := fmt.Sprintf("%s/%s (%s/%s)", Name, Version, runtime.GOOS, runtime.GOARCH)
+	if suffix := strings.TrimSpace(Suffix); suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// Set sets User-Agent to the header of OneLogin API requests
+func Set(header http.Header) {
+	header.Set("User-Agent", String())
+}
+
+// Handler appends User-Agent to AWS SDK's one, to be pushed to Build handlers of the session
+var Handler = request.NamedHandler{
+	Name: "onelogin-aws-connector.UserAgentHandler",
+	Fn: func(r *request.Request) {
+		request.AddToUserAgent(r, String())
+	},
+}
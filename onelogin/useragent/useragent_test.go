@@ -0,0 +1,36 @@
+package useragent
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	defer func(version, suffix string) {
+		Version = version
+		Suffix = suffix
+	}(Version, Suffix)
+	platform := fmt.Sprintf("(%s/%s)", runtime.GOOS, runtime.GOARCH)
+	tests := []struct {
+		version  string
+		suffix   string
+		expected string
+	}{
+		{"1.2.3", "", "onelogin-aws-connector/1.2.3 " + platform},
+		{"1.2.3", "  acme-ci ", "onelogin-aws-connector/1.2.3 " + platform + " acme-ci"},
+	}
+	for _, tt := range tests {
+		Version = tt.version
+		Suffix = tt.suffix
+		if actual := String(); actual != tt.expected {
+			t.Errorf("%s is not %s", actual, tt.expected)
+		}
+		header := http.Header{}
+		Set(header)
+		if actual := header.Get("User-Agent"); actual != tt.expected {
+			t.Errorf("%s is not %s", actual, tt.expected)
+		}
+	}
+}
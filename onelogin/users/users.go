@@ -0,0 +1,178 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/apiresponse"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/transport"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/useragent"
+)
+
+// ErrNotFound is returned when the user is not found
+var ErrNotFound = stderrors.New("user is not found")
+
+// user statuses which cannot log in
+// https://developers.onelogin.com/api-docs/1/users/user-resource
+const (
+	StatusUnactivated     = 0
+	StatusActive          = 1
+	StatusSuspended       = 2
+	StatusLocked          = 3
+	StatusPasswordExpired = 4
+	StatusAwaitingReset   = 5
+)
+
+// Users OneLogin Users API
+type Users struct {
+	config     *onelogin.Config
+	HTTPClient *http.Client
+}
+
+// https://developers.onelogin.com/api-docs/1/users/get-users
+
+// User is a user of OneLogin
+type User struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+	Status    int    `json:"status"`
+}
+
+// https://developers.onelogin.com/api-docs/1/users/get-apps-for-user
+
+// App is an app assigned to a user
+type App struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	ConnectorID int    `json:"connector_id"`
+	Provisioned bool   `json:"provisioned"`
+}
+
+// https://developers.onelogin.com/api-docs/1/multi-factor-authentication/get-enrolled-factors
+
+// Device is an MFA device enrolled by a user
+type Device struct {
+	ID              int    `json:"id"`
+	TypeDisplayName string `json:"type_display_name"`
+	AuthFactorName  string `json:"auth_factor_name"`
+	UserDisplayName string `json:"user_display_name"`
+	Active          bool   `json:"active"`
+	Default         bool   `json:"default"`
+	NeedsTrigger    bool   `json:"needs_trigger"`
+}
+
+// Status is the status of OneLogin API version 1 response
+type Status struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Error   bool   `json:"error"`
+	Code    int    `json:"code"`
+}
+
+type response struct {
+	Status *Status          `json:"status"`
+	Data   *json.RawMessage `json:"data"`
+}
+
+// NewUsers creates a Users
+func NewUsers(config *onelogin.Config) *Users {
+	client := config.HTTPClient
+	if client == nil {
+		client = transport.DefaultClient
+	}
+	return &Users{
+		config:     config,
+		HTTPClient: client,
+	}
+}
+
+// Find finds the user by the email if it contains @, or the username
+func (u *Users) Find(ctx context.Context, usernameOrEmail string) (*User, error) {
+	fields := []string{"username"}
+	if strings.Contains(usernameOrEmail, "@") {
+		// a username can also be an email address
+		fields = []string{"email", "username"}
+	}
+	for _, field := range fields {
+		var users []User
+		if err := u.get(ctx, "/api/1/users", url.Values{field: {usernameOrEmail}}, &users); err != nil {
+			return nil, err
+		}
+		if len(users) > 0 {
+			return &users[0], nil
+		}
+	}
+	return nil, errors.WithMessage(ErrNotFound, usernameOrEmail)
+}
+
+// Apps returns the apps assigned to the user
+func (u *Users) Apps(ctx context.Context, userID int) ([]App, error) {
+	apps := []App{}
+	if err := u.get(ctx, fmt.Sprintf("/api/1/users/%d/apps", userID), nil, &apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// Devices returns the MFA devices enrolled by the user
+func (u *Users) Devices(ctx context.Context, userID int) ([]Device, error) {
+	var data struct {
+		Devices []Device `json:"otp_devices"`
+	}
+	if err := u.get(ctx, fmt.Sprintf("/api/1/users/%d/otp_devices", userID), nil, &data); err != nil {
+		return nil, err
+	}
+	if data.Devices == nil {
+		return []Device{}, nil
+	}
+	return data.Devices, nil
+}
+
+func (u *Users) get(ctx context.Context, path string, query url.Values, data interface{}) error {
+	endpoint := fmt.Sprintf("https://%s%s", u.config.Endpoint, path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	credentials, err := u.config.Credentials.GetWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("bearer:%s", credentials.AccessToken))
+	useragent.Set(req.Header)
+	res, err := u.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	var output response
+	if err := apiresponse.Decode(res, body, &output); err != nil {
+		return err
+	}
+	if output.Status != nil && output.Status.Error {
+		return apiresponse.WithRequestID(res, &apiresponse.StatusError{Code: output.Status.Code, Type: output.Status.Type, Message: output.Status.Message})
+	}
+	if output.Data == nil {
+		return nil
+	}
+	return json.Unmarshal(*output.Data, data)
+}
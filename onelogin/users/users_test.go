@@ -0,0 +1,120 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin"
+	"github.com/lifull-dev/onelogin-aws-connector/onelogin/credentials"
+)
+
+func newTestUsers(ts *httptest.Server) *Users {
+	u, _ := url.Parse(ts.URL)
+	return &Users{
+		config: &onelogin.Config{
+			Endpoint: fmt.Sprintf("%s:%s", u.Hostname(), u.Port()),
+			Credentials: credentials.New(nil, &credentials.Value{
+				AccessToken:      "access-token",
+				CreatedAt:        time.Now().UTC().Add(-time.Hour),
+				AccessExpiresAt:  time.Now().UTC().Add(time.Hour),
+				RefreshExpiresAt: time.Now().UTC().Add(time.Hour),
+			}),
+		},
+		HTTPClient: ts.Client(),
+	}
+}
+
+func TestUsers_Find(t *testing.T) {
+	tests := []struct {
+		usernameOrEmail string
+		query           string
+	}{
+		{"user@example.com", "email=user%40example.com"},
+		{"user", "username=user"},
+	}
+	for _, tt := range tests {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/1/users" || r.URL.RawQuery != tt.query {
+				t.Errorf("%s is requested", r.URL)
+			}
+			if r.Header.Get("Authorization") != "bearer:access-token" {
+				t.Errorf("%s is not authorized", r.Header.Get("Authorization"))
+			}
+			fmt.Fprintln(w, `{"status": {"error": false, "code": 200, "type": "success", "message": "Success"}, "data": [{"id": 1, "username": "user", "email": "user@example.com"}]}`)
+		}))
+		user, err := newTestUsers(ts).Find(context.Background(), tt.usernameOrEmail)
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &User{ID: 1, Username: "user", Email: "user@example.com"}
+		if !reflect.DeepEqual(user, expected) {
+			t.Errorf("%#v is not %#v", user, expected)
+		}
+	}
+}
+
+func TestUsers_FindNotFound(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status": {"error": false, "code": 200, "type": "success", "message": "Success"}, "data": []}`)
+	}))
+	defer ts.Close()
+	if _, err := newTestUsers(ts).Find(context.Background(), "user"); err == nil || errors.Cause(err) != ErrNotFound {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestUsers_Apps(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/users/1/apps" {
+			t.Errorf("%s is requested", r.URL)
+		}
+		fmt.Fprintln(w, `{"status": {"error": false, "code": 200, "type": "success", "message": "Success"}, "data": [{"id": 10, "name": "AWS", "connector_id": 50534, "provisioned": true}]}`)
+	}))
+	defer ts.Close()
+	apps, err := newTestUsers(ts).Apps(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []App{{ID: 10, Name: "AWS", ConnectorID: 50534, Provisioned: true}}
+	if !reflect.DeepEqual(apps, expected) {
+		t.Errorf("%#v is not %#v", apps, expected)
+	}
+}
+
+func TestUsers_Error(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, `{"status": {"error": true, "code": 401, "type": "Unauthorized", "message": "Authorization Information is incorrect"}}`)
+	}))
+	defer ts.Close()
+	if _, err := newTestUsers(ts).Apps(context.Background(), 1); err == nil || err.Error() != "[401] Unauthorized: Authorization Information is incorrect" {
+		t.Errorf("%v is unexpected", err)
+	}
+}
+
+func TestUsers_Devices(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/users/1/otp_devices" {
+			t.Errorf("%s is requested", r.URL)
+		}
+		fmt.Fprintln(w, `{"status": {"error": false, "code": 200, "type": "success", "message": "Success"}, "data": {"otp_devices": [{"id": 100, "type_display_name": "OneLogin Protect", "auth_factor_name": "OneLogin", "user_display_name": "iPhone", "active": true, "default": true, "needs_trigger": true}]}}`)
+	}))
+	defer ts.Close()
+	devices, err := newTestUsers(ts).Devices(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Device{{ID: 100, TypeDisplayName: "OneLogin Protect", AuthFactorName: "OneLogin", UserDisplayName: "iPhone", Active: true, Default: true, NeedsTrigger: true}}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Errorf("%#v is not %#v", devices, expected)
+	}
+}